@@ -0,0 +1,121 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateStorageFrom string
+	migrateStorageTo   string
+	checkFix           bool
+)
+
+func init() {
+	filesMigrateStorageCmd.Flags().StringVar(&migrateStorageFrom, "from", files.StorageTypeLocal, "The storage to copy the files from.")
+	filesMigrateStorageCmd.Flags().StringVar(&migrateStorageTo, "to", files.StorageTypeS3, "The storage to copy the files to.")
+
+	filesCheckCmd.Flags().BoolVar(&checkFix, "fix", false, "Clean up all inconsistencies found.")
+
+	filesCmd.AddCommand(filesMigrateStorageCmd)
+	filesCmd.AddCommand(filesDeduplicateCmd)
+	filesCmd.AddCommand(filesCheckCmd)
+	rootCmd.AddCommand(filesCmd)
+}
+
+var filesCmd = &cobra.Command{
+	Use:   "files",
+	Short: "Manage the files stored by Vikunja.",
+}
+
+var filesMigrateStorageCmd = &cobra.Command{
+	Use:   "migrate-storage",
+	Short: "Copies all files from one storage to another. Change the configured storage afterwards to start using the new one.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Infof("Copying all files from the %s storage to the %s storage...", migrateStorageFrom, migrateStorageTo)
+		migrated, err := files.MigrateStorage(migrateStorageFrom, migrateStorageTo)
+		if err != nil {
+			log.Fatalf("Could not migrate the files: %s", err)
+		}
+		log.Infof("Done. Copied %d files. Set files.storage to %s to use the new storage.", migrated, migrateStorageTo)
+	},
+}
+
+var filesDeduplicateCmd = &cobra.Command{
+	Use:   "deduplicate",
+	Short: "Stores the content of files which were uploaded multiple times only once.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Infof("Deduplicating files...")
+		deduplicated, removed, err := files.Deduplicate()
+		if err != nil {
+			log.Fatalf("Could not deduplicate the files: %s", err)
+		}
+		log.Infof("Done. %d files now share their content with another file, removed %d unused blobs from the storage.", deduplicated, removed)
+	},
+}
+
+var filesCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Finds stored files which are not used anymore and records using files which don't exist.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		result, err := models.CheckFiles(s, checkFix)
+		if err != nil {
+			log.Fatalf("Could not check the files: %s", err)
+		}
+
+		for _, blobID := range result.OrphanedBlobs {
+			log.Infof("Blob %d in the file storage is not used by any file", blobID)
+		}
+		for _, fileID := range result.FilesWithoutBlob {
+			log.Infof("File %d has no content in the file storage", fileID)
+		}
+		for _, fileID := range result.UnusedFiles {
+			log.Infof("File %d is not used anywhere", fileID)
+		}
+		for _, ref := range result.MissingFiles {
+			log.Infof("The %s %d uses file %d which does not exist", ref.Kind, ref.ID, ref.FileID)
+		}
+
+		if !result.HasIssues() {
+			log.Infof("Done. Everything is consistent.")
+			return
+		}
+		if checkFix {
+			log.Infof("Done. Cleaned up all inconsistencies.")
+			return
+		}
+		log.Infof("Done. Run this again with --fix to clean up the inconsistencies.")
+	},
+}
@@ -28,6 +28,7 @@ import (
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/initialize"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/modules/search"
 	"code.vikunja.io/api/pkg/routes"
 	"code.vikunja.io/api/pkg/swagger"
 	"code.vikunja.io/api/pkg/utils"
@@ -70,6 +71,7 @@ var webCmd = &cobra.Command{
 	Short: "Starts the rest api web server",
 	PreRun: func(cmd *cobra.Command, args []string) {
 		initialize.FullInit()
+		search.InitIndex()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -107,5 +109,8 @@ var webCmd = &cobra.Command{
 			e.Logger.Fatal(err)
 		}
 		cron.Stop()
+		if err := search.Close(); err != nil {
+			log.Errorf("Could not close the search index: %s", err)
+		}
 	},
 }
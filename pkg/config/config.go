@@ -50,19 +50,22 @@ const (
 	ServiceStaticpath      Key = `service.staticpath`
 	ServiceMaxItemsPerPage Key = `service.maxitemsperpage`
 	// Deprecated: Use metrics.enabled
-	ServiceEnableMetrics         Key = `service.enablemetrics`
-	ServiceMotd                  Key = `service.motd`
-	ServiceEnableLinkSharing     Key = `service.enablelinksharing`
-	ServiceEnableRegistration    Key = `service.enableregistration`
-	ServiceEnableTaskAttachments Key = `service.enabletaskattachments`
-	ServiceTimeZone              Key = `service.timezone`
-	ServiceEnableTaskComments    Key = `service.enabletaskcomments`
-	ServiceEnableTotp            Key = `service.enabletotp`
-	ServiceSentryDsn             Key = `service.sentrydsn`
-	ServiceTestingtoken          Key = `service.testingtoken`
-	ServiceEnableEmailReminders  Key = `service.enableemailreminders`
-	ServiceEnableUserDeletion    Key = `service.enableuserdeletion`
-	ServiceMaxAvatarSize         Key = `service.maxavatarsize`
+	ServiceEnableMetrics               Key = `service.enablemetrics`
+	ServiceMotd                        Key = `service.motd`
+	ServiceEnableLinkSharing           Key = `service.enablelinksharing`
+	ServiceEnableRegistration          Key = `service.enableregistration`
+	ServiceEnableTaskAttachments       Key = `service.enabletaskattachments`
+	ServiceTimeZone                    Key = `service.timezone`
+	ServiceEnableTaskComments          Key = `service.enabletaskcomments`
+	ServiceEnableTotp                  Key = `service.enabletotp`
+	ServiceSentryDsn                   Key = `service.sentrydsn`
+	ServiceTestingtoken                Key = `service.testingtoken`
+	ServiceEnableEmailReminders        Key = `service.enableemailreminders`
+	ServiceEnableUserDeletion          Key = `service.enableuserdeletion`
+	ServiceMaxAvatarSize               Key = `service.maxavatarsize`
+	ServiceEnableTimeTracking          Key = `service.enabletimetracking`
+	ServiceTrashRetentionDays          Key = `service.trashretentiondays`
+	ServiceEnableReactionNotifications Key = `service.enablereactionnotifications`
 
 	AuthLocalEnabled      Key = `auth.local.enabled`
 	AuthOpenIDEnabled     Key = `auth.openid.enabled`
@@ -125,8 +128,19 @@ const (
 	RateLimitLimit   Key = `ratelimit.limit`
 	RateLimitStore   Key = `ratelimit.store`
 
-	FilesBasePath Key = `files.basepath`
-	FilesMaxSize  Key = `files.maxsize`
+	FilesBasePath       Key = `files.basepath`
+	FilesMaxSize        Key = `files.maxsize`
+	FilesStorage        Key = `files.storage`
+	FilesS3Endpoint     Key = `files.s3.endpoint`
+	FilesS3Bucket       Key = `files.s3.bucket`
+	FilesS3Prefix       Key = `files.s3.prefix`
+	FilesS3Region       Key = `files.s3.region`
+	FilesS3AccessKey    Key = `files.s3.accesskey`
+	FilesS3SecretKey    Key = `files.s3.secretkey`
+	FilesS3UsePathStyle Key = `files.s3.usepathstyle`
+	FilesS3Timeout      Key = `files.s3.timeout`
+	FilesCheckSchedule  Key = `files.check.schedule`
+	FilesCheckFix       Key = `files.check.fix`
 
 	MigrationWunderlistEnable          Key = `migration.wunderlist.enable`
 	MigrationWunderlistClientID        Key = `migration.wunderlist.clientid`
@@ -158,6 +172,9 @@ const (
 
 	KeyvalueType Key = `keyvalue.type`
 
+	SearchType Key = `search.type`
+	SearchPath Key = `search.path`
+
 	MetricsEnabled  Key = `metrics.enabled`
 	MetricsUsername Key = `metrics.username`
 	MetricsPassword Key = `metrics.password`
@@ -290,6 +307,9 @@ func InitDefaultConfig() {
 	ServiceEnableEmailReminders.setDefault(true)
 	ServiceEnableUserDeletion.setDefault(true)
 	ServiceMaxAvatarSize.setDefault(1024)
+	ServiceEnableTimeTracking.setDefault(true)
+	ServiceTrashRetentionDays.setDefault(30)
+	ServiceEnableReactionNotifications.setDefault(true)
 
 	// Auth
 	AuthLocalEnabled.setDefault(true)
@@ -352,6 +372,12 @@ func InitDefaultConfig() {
 	// Files
 	FilesBasePath.setDefault("files")
 	FilesMaxSize.setDefault("20MB")
+	FilesStorage.setDefault("local")
+	FilesS3Region.setDefault("us-east-1")
+	FilesS3UsePathStyle.setDefault(true)
+	FilesS3Timeout.setDefault(30)
+	FilesCheckSchedule.setDefault("")
+	FilesCheckFix.setDefault(false)
 	// Cors
 	CorsEnable.setDefault(true)
 	CorsOrigins.setDefault([]string{"*"})
@@ -369,6 +395,9 @@ func InitDefaultConfig() {
 	BackgroundsUnsplashEnabled.setDefault(false)
 	// Key Value
 	KeyvalueType.setDefault("memory")
+	// Search
+	SearchType.setDefault("embedded")
+	SearchPath.setDefault(ServiceRootpath.GetString() + "/search")
 	// Metrics
 	MetricsEnabled.setDefault(false)
 }
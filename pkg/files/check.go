@@ -0,0 +1,120 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+)
+
+// CheckBlobs compares the blobs in the storage with the files in the database.
+// It returns the ids of all blobs which are not used by any file and all files whose blob does not exist.
+// Blobs modified after modifiedBefore are never reported as unused, the file using them might not be saved yet.
+func CheckBlobs(modifiedBefore time.Time) (orphanedBlobs []int64, filesWithoutBlob []*File, err error) {
+	names, err := store.list()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored := make(map[int64]bool, len(names))
+	for _, name := range names {
+		id, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			log.Debugf("Ignoring %s in the file storage because it is not a file", name)
+			continue
+		}
+		stored[id] = true
+	}
+
+	all := []*File{}
+	err = x.OrderBy("id asc").Find(&all)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	used := make(map[int64]bool, len(all))
+	filesWithoutBlob = []*File{}
+	for _, f := range all {
+		used[f.blobID()] = true
+		if !stored[f.blobID()] {
+			filesWithoutBlob = append(filesWithoutBlob, f)
+		}
+	}
+
+	orphanedBlobs = []int64{}
+	for id := range stored {
+		if used[id] {
+			continue
+		}
+
+		recent, err := isRecentBlob(id, modifiedBefore)
+		// The blob was removed since listing it
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !recent {
+			orphanedBlobs = append(orphanedBlobs, id)
+		}
+	}
+	sort.Slice(orphanedBlobs, func(i, j int) bool {
+		return orphanedBlobs[i] < orphanedBlobs[j]
+	})
+
+	return orphanedBlobs, filesWithoutBlob, nil
+}
+
+// RemoveOrphanedBlob removes a blob from the storage. It fails if the blob is still used by a file
+// or was modified after modifiedBefore, because the file using it might not be saved yet.
+func RemoveOrphanedBlob(blobID int64, modifiedBefore time.Time) error {
+	references, err := x.
+		Where("blob_id = ? OR (blob_id = 0 AND id = ?)", blobID, blobID).
+		Count(&File{})
+	if err != nil {
+		return err
+	}
+	if references > 0 {
+		return ErrBlobIsInUse{BlobID: blobID}
+	}
+
+	recent, err := isRecentBlob(blobID, modifiedBefore)
+	if err != nil {
+		return err
+	}
+	if recent {
+		return ErrBlobIsInUse{BlobID: blobID}
+	}
+
+	f := &File{ID: blobID, BlobID: blobID}
+	return store.remove(f.getFileName())
+}
+
+func isRecentBlob(blobID int64, modifiedBefore time.Time) (bool, error) {
+	f := &File{ID: blobID, BlobID: blobID}
+	info, err := store.stat(f.getFileName())
+	if err != nil {
+		return false, err
+	}
+	return info.ModTime().After(modifiedBefore), nil
+}
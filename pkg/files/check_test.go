@@ -0,0 +1,92 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBlobs(t *testing.T) {
+	// Start with an empty storage, other tests leave their files behind
+	InitTestFileHandler()
+	initFixtures(t)
+	err := afero.WriteFile(afs, config.FilesBasePath.GetString()+"/99", []byte("orphaned"), 0644)
+	assert.NoError(t, err)
+	err = afs.Chtimes(config.FilesBasePath.GetString()+"/99", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	err = afero.WriteFile(afs, config.FilesBasePath.GetString()+"/README", []byte("not a file"), 0644)
+	assert.NoError(t, err)
+	// A file using this blob might still be uploading
+	err = afero.WriteFile(afs, config.FilesBasePath.GetString()+"/100", []byte("fresh"), 0644)
+	assert.NoError(t, err)
+
+	orphanedBlobs, filesWithoutBlob, err := CheckBlobs(time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{99}, orphanedBlobs)
+	assert.Len(t, filesWithoutBlob, 1)
+	assert.Equal(t, int64(2), filesWithoutBlob[0].ID)
+}
+
+func TestRemoveOrphanedBlob(t *testing.T) {
+	t.Run("orphaned", func(t *testing.T) {
+		initFixtures(t)
+		err := afero.WriteFile(afs, config.FilesBasePath.GetString()+"/99", []byte("orphaned"), 0644)
+		assert.NoError(t, err)
+
+		err = RemoveOrphanedBlob(99, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/99")
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("recently modified", func(t *testing.T) {
+		initFixtures(t)
+		err := afero.WriteFile(afs, config.FilesBasePath.GetString()+"/99", []byte("orphaned"), 0644)
+		assert.NoError(t, err)
+
+		err = RemoveOrphanedBlob(99, time.Now().Add(-time.Minute))
+		assert.Error(t, err)
+		assert.True(t, IsErrBlobIsInUse(err))
+		_, err = FileStat(config.FilesBasePath.GetString() + "/99")
+		assert.NoError(t, err)
+	})
+	t.Run("in use", func(t *testing.T) {
+		initFixtures(t)
+
+		err := RemoveOrphanedBlob(1, time.Now().Add(time.Minute))
+		assert.Error(t, err)
+		assert.True(t, IsErrBlobIsInUse(err))
+		_, err = FileStat(config.FilesBasePath.GetString() + "/1")
+		assert.NoError(t, err)
+	})
+}
+
+func TestCheckBlobsWithoutStorage(t *testing.T) {
+	// The files directory does not exist, for example because it is not mounted
+	InitTestFileHandler()
+	initFixtures(t)
+	err := afs.RemoveAll(config.FilesBasePath.GetString())
+	assert.NoError(t, err)
+
+	_, _, err = CheckBlobs(time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+)
+
+// Deduplicate calculates the hash of all files which don't have one yet and makes all files with the
+// same content share one blob. Blobs which are not used by any file afterwards are removed from the storage.
+// It returns the number of files which now share a blob and the number of removed blobs.
+func Deduplicate() (deduplicated int, removed int, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	// Files created before hashes were stored need one first
+	unhashed := []*File{}
+	err = s.
+		Where("hash IS NULL OR hash = ''").
+		OrderBy("id asc").
+		Find(&unhashed)
+	if err != nil {
+		return
+	}
+
+	blobHashes := make(map[int64]string)
+	for _, f := range unhashed {
+		hash, has := blobHashes[f.blobID()]
+		if !has {
+			hash, err = hashBlob(f.getFileName())
+			if errors.Is(err, os.ErrNotExist) {
+				log.Warningf("The content of file %d does not exist, not deduplicating it", f.ID)
+				err = nil
+				continue
+			}
+			if err != nil {
+				return
+			}
+			blobHashes[f.blobID()] = hash
+		}
+
+		f.Hash = hash
+		_, err = s.
+			Where("id = ?", f.ID).
+			Cols("hash").
+			NoAutoCondition().
+			Update(f)
+		if err != nil {
+			return
+		}
+	}
+
+	// The oldest file with a hash keeps its blob, all others with the same hash use that one
+	hashed := []*File{}
+	err = s.
+		Where("hash IS NOT NULL AND hash != ''").
+		OrderBy("id asc").
+		Find(&hashed)
+	if err != nil {
+		return
+	}
+
+	blobsByHash := make(map[string]int64)
+	replacedBlobs := make(map[int64]bool)
+	for _, f := range hashed {
+		blobID, has := blobsByHash[f.Hash]
+		if !has {
+			blobsByHash[f.Hash] = f.blobID()
+			continue
+		}
+		if f.blobID() == blobID {
+			continue
+		}
+
+		replacedBlobs[f.blobID()] = true
+		f.BlobID = blobID
+		_, err = s.
+			Where("id = ?", f.ID).
+			Cols("blob_id").
+			NoAutoCondition().
+			Update(f)
+		if err != nil {
+			return
+		}
+		deduplicated++
+	}
+
+	for blobID := range replacedBlobs {
+		var references int64
+		references, err = s.Where("blob_id = ?", blobID).Count(&File{})
+		if err != nil {
+			return
+		}
+		if references > 0 {
+			continue
+		}
+
+		f := &File{ID: blobID, BlobID: blobID}
+		err = store.remove(f.getFileName())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		err = nil
+		removed++
+	}
+
+	return
+}
+
+func hashBlob(name string) (string, error) {
+	content, err := store.open(name)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,62 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplicate(t *testing.T) {
+	initFixtures(t)
+
+	// Files stored before deduplication existed, without a hash and with their own blob
+	createLegacyFile := func(t *testing.T, content string) *File {
+		f := &File{Name: "legacy", Size: uint64(len(content)), CreatedByID: 1}
+		_, err := x.Insert(f)
+		assert.NoError(t, err)
+		err = afero.WriteFile(afs, config.FilesBasePath.GetString()+"/"+strconv.FormatInt(f.ID, 10), []byte(content), 0644)
+		assert.NoError(t, err)
+		return f
+	}
+	same := createLegacyFile(t, "testfile1")
+	other := createLegacyFile(t, "something else")
+
+	deduplicated, removed, err := Deduplicate()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deduplicated)
+	assert.Equal(t, 1, removed)
+
+	err = same.LoadFileMetaByID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), same.BlobID)
+	assert.Equal(t, "4081c7eb093957750e20d84bcc1d5826a313624c45fdabed784e45af72b43f8d", same.Hash)
+	_, err = FileStat(config.FilesBasePath.GetString() + "/" + strconv.FormatInt(same.ID, 10))
+	assert.True(t, os.IsNotExist(err))
+
+	err = other.LoadFileMetaByID()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), other.BlobID)
+	assert.NotEmpty(t, other.Hash)
+	_, err = FileStat(config.FilesBasePath.GetString() + "/" + strconv.FormatInt(other.ID, 10))
+	assert.NoError(t, err)
+}
@@ -21,21 +21,32 @@ import (
 )
 
 // Dump dumps all saved files
-// This only includes the raw files, no db entries.
+// This only includes the raw files, no db entries. Files which share their content are only included once.
 func Dump() (allFiles map[int64]io.ReadCloser, err error) {
-	files := []*File{}
-	err = x.Find(&files)
+	blobIDs, err := getBlobIDs()
 	if err != nil {
 		return
 	}
 
-	allFiles = make(map[int64]io.ReadCloser, len(files))
-	for _, file := range files {
+	allFiles = make(map[int64]io.ReadCloser, len(blobIDs))
+	for _, blobID := range blobIDs {
+		file := &File{ID: blobID, BlobID: blobID}
 		if err := file.LoadFileByID(); err != nil {
 			return nil, err
 		}
-		allFiles[file.ID] = file.File
+		allFiles[blobID] = file.File
 	}
 
 	return
 }
+
+// Returns the ids of all blobs used by files
+func getBlobIDs() (blobIDs []int64, err error) {
+	blobIDs = []int64{}
+	err = x.
+		Table("files").
+		Distinct("blob_id").
+		OrderBy("blob_id asc").
+		Find(&blobIDs)
+	return
+}
@@ -66,3 +66,54 @@ func IsErrFileIsNotUnsplashFile(err error) bool {
 	_, ok := err.(ErrFileIsNotUnsplashFile)
 	return ok
 }
+
+// ErrInvalidStorageType defines an error where the configured storage type does not exist
+type ErrInvalidStorageType struct {
+	Type string
+}
+
+// Error is the error implementation of ErrInvalidStorageType
+func (err ErrInvalidStorageType) Error() string {
+	return fmt.Sprintf("storage type %q does not exist, use one of local or s3", err.Type)
+}
+
+// IsErrInvalidStorageType checks if an error is ErrInvalidStorageType
+func IsErrInvalidStorageType(err error) bool {
+	_, ok := err.(ErrInvalidStorageType)
+	return ok
+}
+
+// ErrBlobIsInUse defines an error where a blob should be removed while a file still uses it
+// or it was modified so recently that a file might be about to use it
+type ErrBlobIsInUse struct {
+	BlobID int64
+}
+
+// Error is the error implementation of ErrBlobIsInUse
+func (err ErrBlobIsInUse) Error() string {
+	return fmt.Sprintf("blob %d is still in use", err.BlobID)
+}
+
+// IsErrBlobIsInUse checks if an error is ErrBlobIsInUse
+func IsErrBlobIsInUse(err error) bool {
+	_, ok := err.(ErrBlobIsInUse)
+	return ok
+}
+
+// ErrTooManyFilesWithoutBlob defines an error where so many files have no content in the storage that the
+// storage is more likely misconfigured than the content actually lost
+type ErrTooManyFilesWithoutBlob struct {
+	Files            int
+	FilesWithoutBlob int
+}
+
+// Error is the error implementation of ErrTooManyFilesWithoutBlob
+func (err ErrTooManyFilesWithoutBlob) Error() string {
+	return fmt.Sprintf("%d of %d files have no content in the storage, check the storage configuration", err.FilesWithoutBlob, err.Files)
+}
+
+// IsErrTooManyFilesWithoutBlob checks if an error is ErrTooManyFilesWithoutBlob
+func IsErrTooManyFilesWithoutBlob(err error) bool {
+	_, ok := err.(ErrTooManyFilesWithoutBlob)
+	return ok
+}
@@ -17,6 +17,8 @@
 package files
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
 	"os"
@@ -30,7 +32,6 @@ import (
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/web"
 	"github.com/c2h5oh/datasize"
-	"github.com/spf13/afero"
 )
 
 // File holds all information about a file
@@ -40,10 +41,16 @@ type File struct {
 	Mime string `xorm:"text null" json:"mime"`
 	Size uint64 `xorm:"bigint not null" json:"size"`
 
+	// The sha256 hash of the content, used to find files with the same content.
+	Hash string `xorm:"varchar(64) null INDEX" json:"-"`
+	// The id of the blob in the storage which holds the content. Files with the same content share one blob,
+	// it is only removed once the last file referencing it is deleted.
+	BlobID int64 `xorm:"bigint not null default 0 INDEX" json:"-"`
+
 	Created     time.Time `xorm:"created" json:"created"`
 	CreatedByID int64     `xorm:"bigint not null" json:"-"`
 
-	File afero.File `xorm:"-" json:"-"`
+	File Content `xorm:"-" json:"-"`
 	// This ReadCloser is only used for migration purposes. Use with care!
 	// There is currentlc no better way of doing this.
 	FileContent []byte `xorm:"-" json:"-"`
@@ -54,13 +61,28 @@ func (File) TableName() string {
 	return "files"
 }
 
+// Files created before blobs were shared don't have a blob id and use their own id instead.
+func (f *File) blobID() int64 {
+	if f.BlobID == 0 {
+		return f.ID
+	}
+	return f.BlobID
+}
+
 func (f *File) getFileName() string {
-	return config.FilesBasePath.GetString() + "/" + strconv.FormatInt(f.ID, 10)
+	return strconv.FormatInt(f.blobID(), 10)
 }
 
 // LoadFileByID returns a file by its ID
 func (f *File) LoadFileByID() (err error) {
-	f.File, err = afs.Open(f.getFileName())
+	if f.BlobID == 0 {
+		_, err = x.Table("files").Where("id = ?", f.ID).Cols("blob_id").Get(&f.BlobID)
+		if err != nil {
+			return
+		}
+	}
+
+	f.File, err = store.open(f.getFileName())
 	return
 }
 
@@ -115,36 +137,152 @@ func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, rea
 		return
 	}
 
-	// Save the file to storage with its new ID as path
-	err = file.Save(f)
+	// Save the file to storage with its new ID as path and calculate its hash while doing so
+	file.BlobID = file.ID
+	hash := sha256.New()
+	err = file.Save(io.TeeReader(f, hash))
+	if err != nil {
+		return
+	}
+	file.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	err = file.deduplicate(s)
+	return
+}
+
+// Makes a file use the blob of an existing file with the same content and removes its own blob.
+func (f *File) deduplicate(s *xorm.Session) error {
+	existing := &File{}
+	exists, err := s.
+		Where("hash = ? AND id != ?", f.Hash, f.ID).
+		OrderBy("id asc").
+		Get(existing)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		// The existing blob might be removed in the meantime if the files using it are deleted.
+		// Holding the lock until the transaction ends makes sure that does not happen after checking.
+		references, err := lockBlobReferences(s, existing.blobID())
+		if err != nil {
+			return err
+		}
+		exists = len(references) > 0
+	}
+
+	ownBlob := f.getFileName()
+	if exists {
+		f.BlobID = existing.blobID()
+	}
+
+	_, err = s.
+		Where("id = ?", f.ID).
+		Cols("hash", "blob_id").
+		NoAutoCondition().
+		Update(f)
+	if err != nil || !exists {
+		return err
+	}
+
+	if err := store.remove(ownBlob); err != nil {
+		log.Errorf("Could not remove blob %s of file %d after deduplicating it: %s", ownBlob, f.ID, err)
+	}
+	return nil
+}
+
+// Locks all files using a blob until the transaction of the session ends and returns them.
+// Files only start or stop using an existing blob while holding that lock, so a blob is never
+// removed while another file starts using it.
+func lockBlobReferences(s *xorm.Session, blobID int64) (files []*File, err error) {
+	files = []*File{}
+	err = s.
+		Where("blob_id = ?", blobID).
+		ForUpdate().
+		Find(&files)
+	return
+}
+
+// Duplicate creates a new file with the same content as an existing one. Both files share the same blob,
+// the content is not copied. The metadata of the file must be loaded.
+func (f *File) Duplicate(s *xorm.Session, a web.Auth) (file *File, err error) {
+	references, err := lockBlobReferences(s, f.blobID())
+	if err != nil {
+		return nil, err
+	}
+	if len(references) == 0 {
+		return nil, ErrFileDoesNotExist{FileID: f.ID}
+	}
+
+	file = &File{
+		Name:        f.Name,
+		Mime:        f.Mime,
+		Size:        f.Size,
+		Hash:        f.Hash,
+		BlobID:      f.blobID(),
+		CreatedByID: a.GetID(),
+	}
+
+	_, err = s.Insert(file)
 	return
 }
 
-// Delete removes a file from the DB and the file system
+// Delete removes a file from the DB. Its content is removed from the storage if no other file uses it.
 func (f *File) Delete() (err error) {
 	s := db.NewSession()
 	defer s.Close()
 
-	deleted, err := s.Where("id = ?", f.ID).Delete(f)
+	err = s.Begin()
+	if err != nil {
+		return err
+	}
+
+	stored := &File{}
+	exists, err := s.Where("id = ?", f.ID).Get(stored)
 	if err != nil {
 		_ = s.Rollback()
 		return err
 	}
-	if deleted == 0 {
+	if !exists {
 		_ = s.Rollback()
 		return ErrFileDoesNotExist{FileID: f.ID}
 	}
 
-	err = afs.Remove(f.getFileName())
+	_, err = lockBlobReferences(s, stored.blobID())
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	_, err = s.Where("id = ?", f.ID).Delete(&File{})
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	// Files which started using the blob while waiting for the lock are not part of the result of
+	// the locking query itself, only of queries after it.
+	references, err := lockBlobReferences(s, stored.blobID())
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	err = s.Commit()
+	if err != nil || len(references) > 0 {
+		return err
+	}
+
+	// The blob is only removed once the file is gone for good. Nothing can start using it after that.
+	err = store.remove(stored.getFileName())
 	if err != nil {
 		var perr *os.PathError
 		if errors.As(err, &perr) {
 			// Don't fail when removing the file failed
-			log.Errorf("Error deleting file %d: %w", err)
-			return s.Commit()
+			log.Errorf("Error deleting file %d: %s", f.ID, err)
+			return nil
 		}
 
-		_ = s.Rollback()
 		return err
 	}
 
@@ -153,5 +291,5 @@ func (f *File) Delete() (err error) {
 
 // Save saves a file to storage
 func (f *File) Save(fcontent io.Reader) error {
-	return afs.WriteReader(f.getFileName(), fcontent)
+	return store.save(f.getFileName(), fcontent)
 }
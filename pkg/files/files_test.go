@@ -19,8 +19,11 @@ package files
 import (
 	"io"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,6 +72,25 @@ func TestCreate(t *testing.T) {
 		assert.Equal(t, uint64(100), file.Size)
 
 	})
+	t.Run("Same content as an existing file", func(t *testing.T) {
+		initFixtures(t)
+		ta := &testauth{id: 1}
+		createdFile, err := Create(strings.NewReader("testfile1"), "copy", 9, ta)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), createdFile.BlobID)
+		assert.Equal(t, "4081c7eb093957750e20d84bcc1d5826a313624c45fdabed784e45af72b43f8d", createdFile.Hash)
+
+		// The content is not stored twice
+		_, err = FileStat(config.FilesBasePath.GetString() + "/" + strconv.FormatInt(createdFile.ID, 10))
+		assert.True(t, os.IsNotExist(err))
+
+		file := &File{ID: createdFile.ID}
+		err = file.LoadFileByID()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(file.File)
+		assert.NoError(t, err)
+		assert.Equal(t, "testfile1", string(content))
+	})
 	t.Run("Too Large", func(t *testing.T) {
 		initFixtures(t)
 		tf := &testfile{
@@ -95,6 +117,59 @@ func TestFile_Delete(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, IsErrFileDoesNotExist(err))
 	})
+	t.Run("Shared content", func(t *testing.T) {
+		initFixtures(t)
+		f := &File{ID: 1}
+		err := f.LoadFileMetaByID()
+		assert.NoError(t, err)
+		duplicate, err := f.Duplicate(x.NewSession(), &testauth{id: 1})
+		assert.NoError(t, err)
+
+		// The content is kept as long as another file uses it
+		err = f.Delete()
+		assert.NoError(t, err)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/1")
+		assert.NoError(t, err)
+
+		err = duplicate.Delete()
+		assert.NoError(t, err)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/1")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestFile_Duplicate(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		initFixtures(t)
+		f := &File{ID: 1}
+		err := f.LoadFileMetaByID()
+		assert.NoError(t, err)
+
+		duplicate, err := f.Duplicate(x.NewSession(), &testauth{id: 2})
+		assert.NoError(t, err)
+		assert.NotEqual(t, f.ID, duplicate.ID)
+		assert.Equal(t, int64(1), duplicate.BlobID)
+		assert.Equal(t, int64(2), duplicate.CreatedByID)
+		assert.Equal(t, f.Name, duplicate.Name)
+
+		err = duplicate.LoadFileByID()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(duplicate.File)
+		assert.NoError(t, err)
+		assert.Equal(t, "testfile1", string(content))
+	})
+	t.Run("Deleted in the meantime", func(t *testing.T) {
+		initFixtures(t)
+		f := &File{ID: 1}
+		err := f.LoadFileMetaByID()
+		assert.NoError(t, err)
+		err = f.Delete()
+		assert.NoError(t, err)
+
+		_, err = f.Duplicate(x.NewSession(), &testauth{id: 2})
+		assert.Error(t, err)
+		assert.True(t, IsErrFileDoesNotExist(err))
+	})
 }
 
 func TestFile_LoadFileByID(t *testing.T) {
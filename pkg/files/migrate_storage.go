@@ -0,0 +1,76 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/log"
+)
+
+// MigrateStorage copies the content of all files from one storage backend to another.
+// Files which don't exist in the source storage are skipped with a warning.
+// The source storage is not modified, the configured storage has to be changed afterwards.
+func MigrateStorage(from, to string) (migrated int, err error) {
+	if from == to {
+		return 0, errors.New("the source and target storage must be different")
+	}
+
+	source, err := newStorage(from)
+	if err != nil {
+		return 0, err
+	}
+	target, err := newStorage(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return migrateStorage(source, target)
+}
+
+func migrateStorage(source, target storage) (migrated int, err error) {
+	blobIDs, err := getBlobIDs()
+	if err != nil {
+		return
+	}
+
+	for _, blobID := range blobIDs {
+		name := strconv.FormatInt(blobID, 10)
+
+		content, err := source.open(name)
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warningf("File %d does not exist in the source storage, skipping it", blobID)
+			continue
+		}
+		if err != nil {
+			return migrated, err
+		}
+
+		err = target.save(name, content)
+		_ = content.Close()
+		if err != nil {
+			return migrated, err
+		}
+
+		migrated++
+		log.Debugf("Migrated file %d", blobID)
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,99 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The dispositions a file can be served with
+const (
+	// DispositionInline lets the browser display the file if it can
+	DispositionInline = "inline"
+	// DispositionAttachment makes the browser download the file
+	DispositionAttachment = "attachment"
+)
+
+// ETag returns a strong validator for the content of a file.
+// The content of a file never changes once it is saved, which makes the id, size and creation time enough to identify it.
+func (f *File) ETag() string {
+	return `"` + strconv.FormatInt(f.ID, 10) + "-" + strconv.FormatUint(f.Size, 10) + "-" + strconv.FormatInt(f.Created.Unix(), 10) + `"`
+}
+
+// ServeContent sends the content of a file to the client. It handles range requests as well as
+// conditional requests with If-None-Match and If-Modified-Since.
+// Both the file content and its metadata need to be loaded before calling this.
+func (f *File) ServeContent(w http.ResponseWriter, r *http.Request, disposition string) {
+	SetCacheHeaders(w, f.ETag())
+	if f.Mime != "" {
+		w.Header().Set("Content-Type", f.Mime)
+	}
+	w.Header().Set("Content-Disposition", ContentDisposition(disposition, f.Name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, f.Name, f.Created, f.File)
+}
+
+// SetCacheHeaders sets the headers which make clients revalidate the content they cached with the given etag.
+// Downloads need authentication and the content behind a url can change, so clients must not reuse it without asking.
+func SetCacheHeaders(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+}
+
+// ContentDisposition returns the value of a Content-Disposition header for a file name.
+// Names with characters which are not safe in a quoted string get an ascii fallback and
+// the full name encoded as described in RFC 6266 and RFC 5987.
+func ContentDisposition(disposition, filename string) string {
+	if filename == "" {
+		return disposition
+	}
+
+	fallback := &strings.Builder{}
+	safe := true
+	for _, r := range filename {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' || r == '%' {
+			fallback.WriteByte('_')
+			safe = false
+			continue
+		}
+		fallback.WriteRune(r)
+	}
+
+	header := disposition + `; filename="` + fallback.String() + `"`
+	if !safe {
+		header += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return header
+}
+
+func encodeRFC5987(s string) string {
+	b := &strings.Builder{}
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentDisposition(t *testing.T) {
+	t.Run("ascii", func(t *testing.T) {
+		assert.Equal(t, `inline; filename="test.pdf"`, ContentDisposition(DispositionInline, "test.pdf"))
+	})
+	t.Run("non-ascii", func(t *testing.T) {
+		assert.Equal(t, `attachment; filename="_bersicht 100_.pdf"; filename*=UTF-8''%C3%9Cbersicht%20100%25.pdf`, ContentDisposition(DispositionAttachment, "Übersicht 100%.pdf"))
+	})
+	t.Run("quotes and line breaks", func(t *testing.T) {
+		assert.Equal(t, `inline; filename="a_b_c.txt"; filename*=UTF-8''a%22b%0Ac.txt`, ContentDisposition(DispositionInline, "a\"b\nc.txt"))
+	})
+	t.Run("no name", func(t *testing.T) {
+		assert.Equal(t, "inline", ContentDisposition(DispositionInline, ""))
+	})
+}
+
+func TestFile_ServeContent(t *testing.T) {
+	serve := func(t *testing.T, header http.Header) *httptest.ResponseRecorder {
+		f := &File{ID: 1}
+		err := f.LoadFileMetaByID()
+		assert.NoError(t, err)
+		err = f.LoadFileByID()
+		assert.NoError(t, err)
+		defer f.File.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header = header
+		rec := httptest.NewRecorder()
+		f.ServeContent(rec, req, DispositionInline)
+		return rec
+	}
+
+	t.Run("full", func(t *testing.T) {
+		initFixtures(t)
+		rec := serve(t, http.Header{})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "testfile1", rec.Body.String())
+		assert.Regexp(t, `^"1-100-\d+"$`, rec.Header().Get("ETag"))
+		assert.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+		assert.Equal(t, `inline; filename="test"`, rec.Header().Get("Content-Disposition"))
+	})
+	t.Run("range", func(t *testing.T) {
+		initFixtures(t)
+		rec := serve(t, http.Header{"Range": []string{"bytes=4-7"}})
+		assert.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, "file", rec.Body.String())
+		assert.Equal(t, "bytes 4-7/9", rec.Header().Get("Content-Range"))
+	})
+	t.Run("not modified", func(t *testing.T) {
+		initFixtures(t)
+		etag := serve(t, http.Header{}).Header().Get("ETag")
+		rec := serve(t, http.Header{"If-None-Match": []string{etag}})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+	t.Run("modified", func(t *testing.T) {
+		initFixtures(t)
+		rec := serve(t, http.Header{"If-None-Match": []string{`"1-100-1"`}})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "testfile1", rec.Body.String())
+	})
+}
@@ -0,0 +1,104 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"io"
+	"os"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/spf13/afero"
+)
+
+// The storage types which can be used to store files
+const (
+	StorageTypeLocal = "local"
+	StorageTypeS3    = "s3"
+)
+
+// storage is a backend which holds the content of files. Files are stored with their id as name.
+type storage interface {
+	open(name string) (Content, error)
+	save(name string, content io.Reader) error
+	remove(name string) error
+	stat(name string) (os.FileInfo, error)
+	// list returns the names of all stored files
+	list() ([]string, error)
+}
+
+// Content is the content of a stored file. It can be read from any position.
+type Content interface {
+	io.ReadSeekCloser
+	Name() string
+}
+
+// The storage all files are saved in
+var store storage
+
+func newStorage(storageType string) (storage, error) {
+	switch storageType {
+	case StorageTypeLocal:
+		return &localStorage{fs: afs}, nil
+	case StorageTypeS3:
+		return newS3StorageFromConfig()
+	default:
+		return nil, ErrInvalidStorageType{Type: storageType}
+	}
+}
+
+// localStorage saves files on the file system in the configured files.basepath
+type localStorage struct {
+	fs *afero.Afero
+}
+
+func (l *localStorage) path(name string) string {
+	return config.FilesBasePath.GetString() + "/" + name
+}
+
+func (l *localStorage) open(name string) (Content, error) {
+	return l.fs.Open(l.path(name))
+}
+
+func (l *localStorage) save(name string, content io.Reader) error {
+	return l.fs.WriteReader(l.path(name), content)
+}
+
+func (l *localStorage) remove(name string) error {
+	return l.fs.Remove(l.path(name))
+}
+
+func (l *localStorage) stat(name string) (os.FileInfo, error) {
+	return l.fs.Stat(l.path(name))
+}
+
+func (l *localStorage) list() ([]string, error) {
+	// A missing directory most likely means the storage is not mounted, listing it as empty would make
+	// all files look like their content is missing.
+	infos, err := l.fs.ReadDir(config.FilesBasePath.GetString())
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		names = append(names, info.Name())
+	}
+	return names, nil
+}
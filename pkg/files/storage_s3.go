@@ -0,0 +1,511 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+// The payload hash used for requests with an empty body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Storage saves files in a bucket of an S3 compatible object storage.
+// Requests are signed with AWS Signature Version 4, which is supported by all major implementations.
+// The names of all objects start with the configured prefix, which allows sharing a bucket.
+type s3Storage struct {
+	endpoint     *url.URL
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	usePathStyle bool
+	client       *http.Client
+	now          func() time.Time
+}
+
+func newS3StorageFromConfig() (*s3Storage, error) {
+	return newS3Storage(
+		config.FilesS3Endpoint.GetString(),
+		config.FilesS3Bucket.GetString(),
+		config.FilesS3Prefix.GetString(),
+		config.FilesS3Region.GetString(),
+		config.FilesS3AccessKey.GetString(),
+		config.FilesS3SecretKey.GetString(),
+		config.FilesS3UsePathStyle.GetBool(),
+		config.FilesS3Timeout.GetDuration()*time.Second,
+	)
+}
+
+func newS3Storage(endpoint, bucket, prefix, region, accessKey, secretKey string, usePathStyle bool, timeout time.Duration) (*s3Storage, error) {
+	if endpoint == "" || bucket == "" {
+		return nil, fmt.Errorf("the s3 storage needs an endpoint and a bucket")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %w", endpoint, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: must include the scheme and host", endpoint)
+	}
+
+	return &s3Storage{
+		endpoint:     u,
+		bucket:       bucket,
+		prefix:       prefix,
+		region:       region,
+		accessKey:    accessKey,
+		secretKey:    secretKey,
+		usePathStyle: usePathStyle,
+		client:       &http.Client{Transport: newS3Transport(timeout)},
+		now:          time.Now,
+	}, nil
+}
+
+// Returns a transport which gives up if connecting to the storage or waiting for it to respond takes too long.
+// There is no timeout for the whole request because downloads are streamed to the client for as long as it takes.
+func newS3Transport(timeout time.Duration) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	return transport
+}
+
+func (s *s3Storage) objectURL(name string, query url.Values) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.usePathStyle {
+		path += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	if name != "" {
+		path += "/" + s.prefix + name
+	}
+	if path == "" {
+		path = "/"
+	}
+	u.Path = path
+	u.RawPath = awsURIEncode(path, false)
+	u.RawQuery = canonicalQueryString(query)
+	return &u
+}
+
+func (s *s3Storage) newRequest(method, name string, query url.Values, body io.Reader) (*http.Request, error) {
+	return http.NewRequest(method, s.objectURL(name, query).String(), body)
+}
+
+func (s *s3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash)
+	return s.client.Do(req)
+}
+
+// Returns an error for every response which is not successful. The body of the response is closed.
+func checkS3Response(resp *http.Response, name string, op string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	// The body contains an xml error message from the storage, which is helpful for debugging
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s failed with status %d: %s", op, name, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+func (s *s3Storage) open(name string) (Content, error) {
+	info, err := s.stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Object{
+		storage: s,
+		name:    name,
+		size:    info.Size(),
+	}, nil
+}
+
+func (s *s3Storage) save(name string, content io.Reader) error {
+	// S3 needs to know the size of an object before uploading it.
+	body, size, cleanup, err := sizedReader(content)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	req, err := s.newRequest(http.MethodPut, name, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	resp, err := s.do(req, "UNSIGNED-PAYLOAD")
+	if err != nil {
+		return err
+	}
+	if err := checkS3Response(resp, name, "save"); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3Storage) remove(name string) error {
+	req, err := s.newRequest(http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	if err := checkS3Response(resp, name, "remove"); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3Storage) stat(name string) (os.FileInfo, error) {
+	req, err := s.newRequest(http.MethodHead, name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkS3Response(resp, name, "stat"); err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	info := &s3FileInfo{name: name, size: resp.ContentLength}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		info.modTime, _ = http.ParseTime(lastModified)
+	}
+	return info, nil
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Storage) list() (names []string, err error) {
+	names = []string{}
+	continuationToken := ""
+	for {
+		query := url.Values{"list-type": []string{"2"}}
+		if s.prefix != "" {
+			query.Set("prefix", s.prefix)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		req, err := s.newRequest(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkS3Response(resp, s.bucket, "list"); err != nil {
+			return nil, err
+		}
+
+		result := &s3ListBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			names = append(names, strings.TrimPrefix(object.Key, s.prefix))
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// Returns a reader with a known size. Readers which can seek are used directly,
+// everything else is buffered in a temporary file first.
+func sizedReader(content io.Reader) (body io.Reader, size int64, cleanup func(), err error) {
+	cleanup = func() {}
+
+	if seeker, is := content.(io.ReadSeeker); is {
+		current, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0, cleanup, err
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, 0, cleanup, err
+		}
+		_, err = seeker.Seek(current, io.SeekStart)
+		return seeker, end - current, cleanup, err
+	}
+
+	tmp, err := os.CreateTemp("", "vikunja-upload-")
+	if err != nil {
+		return nil, 0, cleanup, err
+	}
+	cleanup = func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+
+	size, err = io.Copy(tmp, content)
+	if err != nil {
+		return nil, 0, cleanup, err
+	}
+	_, err = tmp.Seek(0, io.SeekStart)
+	return tmp, size, cleanup, err
+}
+
+// s3Object is the content of an object in an s3 storage. The content is only downloaded when reading
+// and seeking starts a new download from the new position, which makes it possible to serve ranges of large files.
+type s3Object struct {
+	storage *s3Storage
+	name    string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Name() string {
+	return o.name
+}
+
+func (o *s3Object) Read(p []byte) (n int, err error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		req, err := o.storage.newRequest(http.MethodGet, o.name, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+
+		resp, err := o.storage.do(req, emptyPayloadHash)
+		if err != nil {
+			return 0, err
+		}
+		if err := checkS3Response(resp, o.name, "read"); err != nil {
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+
+	n, err = o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = o.offset + offset
+	case io.SeekEnd:
+		newOffset = o.size + offset
+	default:
+		return 0, errors.New("s3 object: invalid whence")
+	}
+	if newOffset < 0 {
+		return 0, errors.New("s3 object: negative position")
+	}
+
+	if newOffset != o.offset {
+		if err := o.closeBody(); err != nil {
+			return 0, err
+		}
+		o.offset = newOffset
+	}
+	return o.offset, nil
+}
+
+func (o *s3Object) closeBody() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
+
+func (o *s3Object) Close() error {
+	return o.closeBody()
+}
+
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i *s3FileInfo) Name() string       { return i.name }
+func (i *s3FileInfo) Size() int64        { return i.size }
+func (i *s3FileInfo) Mode() os.FileMode  { return 0644 }
+func (i *s3FileInfo) ModTime() time.Time { return i.modTime }
+func (i *s3FileInfo) IsDir() bool        { return false }
+func (i *s3FileInfo) Sys() interface{}   { return nil }
+
+///////
+// Request signing, see https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+
+func (s *s3Storage) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaderNames := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Range") != "" {
+		signedHeaderNames = append(signedHeaderNames, "range")
+	}
+	sort.Strings(signedHeaderNames)
+
+	canonicalHeaders := &strings.Builder{}
+	for _, h := range signedHeaderNames {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hashSHA256(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, content string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(content))
+	return h.Sum(nil)
+}
+
+func canonicalQueryString(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// Encodes a string the way aws expects it: Everything except unreserved characters is percent encoded.
+func awsURIEncode(s string, encodeSlash bool) string {
+	b := &strings.Builder{}
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
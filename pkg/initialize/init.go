@@ -98,6 +98,8 @@ func FullInit() {
 	user.RegisterDeletionNotificationCron()
 	models.RegisterUserDeletionCron()
 	models.RegisterOldExportCleanupCron()
+	models.RegisterTrashPurgeCron()
+	models.RegisterFileCheckCron()
 
 	// Start processing events
 	go func() {
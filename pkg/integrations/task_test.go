@@ -113,19 +113,24 @@ func TestTask(t *testing.T) {
 				assert.Contains(t, rec.Body.String(), `"reminder_dates":null`)
 				assert.NotContains(t, rec.Body.String(), `"reminder_dates":[1543626724,1543626824]`)
 			})
-			t.Run("Repeat after", func(t *testing.T) {
-				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"repeat_after":3600}`)
+			t.Run("Repeat rule", func(t *testing.T) {
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"repeat_rule":"FREQ=WEEKLY;BYDAY=MO,FR"}`)
 				assert.NoError(t, err)
-				assert.Contains(t, rec.Body.String(), `"repeat_after":3600`)
-				assert.NotContains(t, rec.Body.String(), `"repeat_after":0`)
+				assert.Contains(t, rec.Body.String(), `"repeat_rule":"FREQ=WEEKLY;BYDAY=MO,FR"`)
+				assert.NotContains(t, rec.Body.String(), `"repeat_rule":""`)
 			})
-			t.Run("Repeat after unset", func(t *testing.T) {
-				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "28"}, `{"repeat_after":0}`)
+			t.Run("Repeat rule invalid", func(t *testing.T) {
+				_, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"repeat_rule":"FREQ=SOMETIMES"}`)
+				assert.Error(t, err)
+				assertHandlerErrorCode(t, err, models.ErrCodeInvalidRepeatRule)
+			})
+			t.Run("Repeat rule unset", func(t *testing.T) {
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "28"}, `{"repeat_rule":""}`)
 				assert.NoError(t, err)
-				assert.Contains(t, rec.Body.String(), `"repeat_after":0`)
-				assert.NotContains(t, rec.Body.String(), `"repeat_after":3600`)
+				assert.Contains(t, rec.Body.String(), `"repeat_rule":""`)
+				assert.NotContains(t, rec.Body.String(), `"repeat_rule":"FREQ=HOURLY"`)
 			})
-			t.Run("Repeat after update done", func(t *testing.T) {
+			t.Run("Repeat rule update done", func(t *testing.T) {
 				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "28"}, `{"done":true}`)
 				assert.NoError(t, err)
 				assert.Contains(t, rec.Body.String(), `"done":false`)
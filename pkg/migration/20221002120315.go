@@ -0,0 +1,188 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"strconv"
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type tasks20221002120315 struct {
+	RepeatRule            string      `xorm:"text null" json:"repeat_rule"`
+	RepeatExdates         []time.Time `xorm:"json null" json:"repeat_exdates"`
+	RepeatFromCurrentDate bool        `xorm:"null" json:"repeat_from_current_date"`
+	RepeatStart           time.Time   `xorm:"DATETIME null 'repeat_start'" json:"-"`
+}
+
+func (tasks20221002120315) TableName() string {
+	return "tasks"
+}
+
+type repeatingTasks20221002120315 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	RepeatAfter int64     `xorm:"bigint INDEX null"`
+	RepeatMode  int       `xorm:"not null default 0"`
+	DueDate     time.Time `xorm:"DATETIME INDEX null 'due_date'"`
+	StartDate   time.Time `xorm:"DATETIME INDEX null 'start_date'"`
+	EndDate     time.Time `xorm:"DATETIME INDEX null 'end_date'"`
+}
+
+func (repeatingTasks20221002120315) TableName() string {
+	return "tasks"
+}
+
+type taskReminders20221002120315 struct {
+	TaskID   int64     `xorm:"bigint not null INDEX"`
+	Reminder time.Time `xorm:"DATETIME not null INDEX 'reminder'"`
+}
+
+func (taskReminders20221002120315) TableName() string {
+	return "task_reminders"
+}
+
+const (
+	repeatModeDefault20221002120315         = 0
+	repeatModeMonth20221002120315           = 1
+	repeatModeFromCurrentDate20221002120315 = 2
+)
+
+// Converts an amount of seconds to the biggest frequency it can be expressed in
+func repeatAfterToRule20221002120315(repeatAfter int64) string {
+	freqs := []struct {
+		freq    string
+		seconds int64
+	}{
+		{freq: "WEEKLY", seconds: 60 * 60 * 24 * 7},
+		{freq: "DAILY", seconds: 60 * 60 * 24},
+		{freq: "HOURLY", seconds: 60 * 60},
+	}
+
+	for _, f := range freqs {
+		if repeatAfter%f.seconds != 0 {
+			continue
+		}
+
+		return intervalRule20221002120315(f.freq, repeatAfter/f.seconds)
+	}
+
+	// Tasks can repeat at most every hour, everything else is rounded up to full hours
+	return intervalRule20221002120315("HOURLY", (repeatAfter+60*60-1)/(60*60))
+}
+
+func intervalRule20221002120315(freq string, interval int64) string {
+	rule := "FREQ=" + freq
+	if interval > 1 {
+		rule += ";INTERVAL=" + strconv.FormatInt(interval, 10)
+	}
+	return rule
+}
+
+// The old monthly repeat mode added one month to the dates without ever skipping one. A plain monthly rule skips all
+// months which don't have the day of the date, so for days after the 28th the last day of the month is used instead.
+func monthlyRule20221002120315(date time.Time) string {
+	if date.Day() <= 28 {
+		return "FREQ=MONTHLY"
+	}
+
+	return "FREQ=MONTHLY;BYMONTHDAY=" + strconv.Itoa(date.Day()) + ",-1;BYSETPOS=1"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221002120315",
+		Description: "Migrate task repeat_after and repeat_mode to RFC 5545 repeat rules",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(tasks20221002120315{})
+			if err != nil {
+				return err
+			}
+
+			// The repeat_from_current_date column was not used anymore since it was replaced by repeat_mode
+			// and may contain stale values.
+			_, err = tx.Exec("UPDATE tasks SET repeat_from_current_date = ?", false)
+			if err != nil {
+				return err
+			}
+
+			tasks := []*repeatingTasks20221002120315{}
+			err = tx.
+				Where("repeat_after > 0 OR repeat_mode = ?", repeatModeMonth20221002120315).
+				Find(&tasks)
+			if err != nil {
+				return err
+			}
+
+			for _, task := range tasks {
+				newTask := &tasks20221002120315{
+					RepeatFromCurrentDate: task.RepeatMode == repeatModeFromCurrentDate20221002120315,
+				}
+
+				switch {
+				case !task.DueDate.IsZero():
+					newTask.RepeatStart = task.DueDate
+				case !task.StartDate.IsZero():
+					newTask.RepeatStart = task.StartDate
+				case !task.EndDate.IsZero():
+					newTask.RepeatStart = task.EndDate
+				default:
+					reminder := &taskReminders20221002120315{}
+					_, err = tx.
+						Where("task_id = ?", task.ID).
+						OrderBy("reminder asc").
+						Get(reminder)
+					if err != nil {
+						return err
+					}
+					newTask.RepeatStart = reminder.Reminder
+				}
+
+				switch task.RepeatMode {
+				case repeatModeMonth20221002120315:
+					newTask.RepeatRule = monthlyRule20221002120315(newTask.RepeatStart)
+				case repeatModeDefault20221002120315, repeatModeFromCurrentDate20221002120315:
+					newTask.RepeatRule = repeatAfterToRule20221002120315(task.RepeatAfter)
+				}
+
+				if newTask.RepeatRule == "" {
+					continue
+				}
+
+				_, err = tx.
+					Where("id = ?", task.ID).
+					Cols("repeat_rule", "repeat_from_current_date", "repeat_start").
+					NoAutoCondition().
+					Update(newTask)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = dropTableColum(tx, "tasks", "repeat_after")
+			if err != nil {
+				return err
+			}
+
+			return dropTableColum(tx, "tasks", "repeat_mode")
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
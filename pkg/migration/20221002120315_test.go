@@ -0,0 +1,71 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teambition/rrule-go"
+)
+
+func TestRepeatAfterToRule20221002120315(t *testing.T) {
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2", repeatAfterToRule20221002120315(60*60*24*14))
+	assert.Equal(t, "FREQ=DAILY", repeatAfterToRule20221002120315(60*60*24))
+	assert.Equal(t, "FREQ=HOURLY;INTERVAL=3", repeatAfterToRule20221002120315(60*60*3))
+	// Less than an hour is not supported anymore
+	assert.Equal(t, "FREQ=HOURLY", repeatAfterToRule20221002120315(60*30))
+	assert.Equal(t, "FREQ=HOURLY;INTERVAL=2", repeatAfterToRule20221002120315(60*90))
+}
+
+func TestMonthlyRule20221002120315(t *testing.T) {
+	occurrences := func(t *testing.T, dtstart time.Time) []time.Time {
+		opts, err := rrule.StrToROption(monthlyRule20221002120315(dtstart))
+		assert.NoError(t, err)
+		opts.Dtstart = dtstart
+		opts.Count = 5
+		r, err := rrule.NewRRule(*opts)
+		assert.NoError(t, err)
+		return r.All()
+	}
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2022, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("start of month", func(t *testing.T) {
+		assert.Equal(t, "FREQ=MONTHLY", monthlyRule20221002120315(date(time.January, 15)))
+	})
+	t.Run("31st", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(time.January, 31),
+			date(time.February, 28),
+			date(time.March, 31),
+			date(time.April, 30),
+			date(time.May, 31),
+		}, occurrences(t, date(time.January, 31)))
+	})
+	t.Run("30th", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(time.January, 30),
+			date(time.February, 28),
+			date(time.March, 30),
+			date(time.April, 30),
+			date(time.May, 30),
+		}, occurrences(t, date(time.January, 30)))
+	})
+}
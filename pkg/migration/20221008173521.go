@@ -0,0 +1,53 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskTimeEntries20221008173521 struct {
+	ID       int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	TaskID   int64     `xorm:"bigint INDEX not null" json:"task_id"`
+	UserID   int64     `xorm:"bigint INDEX not null" json:"-"`
+	Start    time.Time `xorm:"DATETIME INDEX not null 'start_time'" json:"start"`
+	End      time.Time `xorm:"DATETIME INDEX null 'end_time'" json:"end"`
+	Duration int64     `xorm:"bigint not null default 0" json:"duration"`
+	Note     string    `xorm:"text null" json:"note"`
+	Created  time.Time `xorm:"created not null" json:"created"`
+	Updated  time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (taskTimeEntries20221008173521) TableName() string {
+	return "task_time_entries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221008173521",
+		Description: "Add task time entries table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskTimeEntries20221008173521{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,69 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type tasks20221015161203 struct {
+	Deleted time.Time `xorm:"deleted null INDEX" json:"-"`
+}
+
+func (tasks20221015161203) TableName() string {
+	return "tasks"
+}
+
+type lists20221015161203 struct {
+	Deleted time.Time `xorm:"deleted null INDEX" json:"-"`
+}
+
+func (lists20221015161203) TableName() string {
+	return "lists"
+}
+
+type namespaces20221015161203 struct {
+	Deleted time.Time `xorm:"deleted null INDEX" json:"-"`
+}
+
+func (namespaces20221015161203) TableName() string {
+	return "namespaces"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221015161203",
+		Description: "Add deleted timestamp to tasks, lists and namespaces for the trash",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(tasks20221015161203{})
+			if err != nil {
+				return err
+			}
+			err = tx.Sync2(lists20221015161203{})
+			if err != nil {
+				return err
+			}
+			return tx.Sync2(namespaces20221015161203{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,55 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskChecklistItems20221019102846 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	TaskID      int64     `xorm:"bigint INDEX not null" json:"task_id"`
+	Title       string    `xorm:"varchar(250) not null" json:"title"`
+	Done        bool      `xorm:"INDEX not null default false" json:"done"`
+	DoneAt      time.Time `xorm:"INDEX null 'done_at'" json:"done_at"`
+	AssigneeID  int64     `xorm:"bigint INDEX null" json:"assignee_id"`
+	DueDate     time.Time `xorm:"DATETIME INDEX null 'due_date'" json:"due_date"`
+	Position    float64   `xorm:"double null" json:"position"`
+	CreatedByID int64     `xorm:"bigint not null" json:"-"`
+	Created     time.Time `xorm:"created not null" json:"created"`
+	Updated     time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (taskChecklistItems20221019102846) TableName() string {
+	return "task_checklist_items"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221019102846",
+		Description: "Add task checklist items table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskChecklistItems20221019102846{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,89 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskTemplates20221024091736 struct {
+	ID              int64     `xorm:"bigint autoincr not null unique pk"`
+	Title           string    `xorm:"varchar(250) not null"`
+	Description     string    `xorm:"longtext null"`
+	Priority        int64     `xorm:"bigint null"`
+	HexColor        string    `xorm:"varchar(6) null"`
+	LabelIDs        []int64   `xorm:"JSON null 'label_ids'"`
+	AssigneeIDs     []int64   `xorm:"JSON null 'assignee_ids'"`
+	Checklist       []string  `xorm:"JSON null 'checklist'"`
+	ReminderOffsets []int64   `xorm:"JSON null 'reminder_offsets'"`
+	DueDateOffset   *int64    `xorm:"bigint null"`
+	StartDateOffset *int64    `xorm:"bigint null"`
+	EndDateOffset   *int64    `xorm:"bigint null"`
+	OwnerID         int64     `xorm:"bigint not null INDEX"`
+	Created         time.Time `xorm:"created not null"`
+	Updated         time.Time `xorm:"updated not null"`
+}
+
+func (taskTemplates20221024091736) TableName() string {
+	return "task_templates"
+}
+
+type taskTemplateUsers20221024091736 struct {
+	ID         int64     `xorm:"bigint autoincr not null unique pk"`
+	UserID     int64     `xorm:"bigint not null INDEX"`
+	TemplateID int64     `xorm:"bigint not null INDEX"`
+	Right      int64     `xorm:"bigint INDEX not null default 0"`
+	Created    time.Time `xorm:"created not null"`
+	Updated    time.Time `xorm:"updated not null"`
+}
+
+func (taskTemplateUsers20221024091736) TableName() string {
+	return "task_template_users"
+}
+
+type taskTemplateTeams20221024091736 struct {
+	ID         int64     `xorm:"bigint autoincr not null unique pk"`
+	TeamID     int64     `xorm:"bigint not null INDEX"`
+	TemplateID int64     `xorm:"bigint not null INDEX"`
+	Right      int64     `xorm:"bigint INDEX not null default 0"`
+	Created    time.Time `xorm:"created not null"`
+	Updated    time.Time `xorm:"updated not null"`
+}
+
+func (taskTemplateTeams20221024091736) TableName() string {
+	return "task_template_teams"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221024091736",
+		Description: "Add task templates",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(
+				taskTemplates20221024091736{},
+				taskTemplateUsers20221024091736{},
+				taskTemplateTeams20221024091736{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,49 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskPreviousIdentifiers20221102090814 struct {
+	ID      int64     `xorm:"bigint autoincr not null unique pk"`
+	TaskID  int64     `xorm:"bigint not null INDEX"`
+	ListID  int64     `xorm:"bigint not null INDEX"`
+	Index   int64     `xorm:"bigint not null"`
+	Created time.Time `xorm:"created not null"`
+}
+
+func (taskPreviousIdentifiers20221102090814) TableName() string {
+	return "task_previous_identifiers"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221102090814",
+		Description: "Add previous identifiers of tasks moved to another list",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskPreviousIdentifiers20221102090814{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,109 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"math"
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type savedFilterTaskBuckets20221107101524 struct {
+	ID       int64     `xorm:"bigint autoincr not null unique pk"`
+	FilterID int64     `xorm:"bigint not null INDEX"`
+	BucketID int64     `xorm:"bigint not null INDEX"`
+	TaskID   int64     `xorm:"bigint not null INDEX"`
+	Position float64   `xorm:"double null"`
+	Created  time.Time `xorm:"created not null"`
+	Updated  time.Time `xorm:"updated not null"`
+}
+
+func (savedFilterTaskBuckets20221107101524) TableName() string {
+	return "saved_filter_task_buckets"
+}
+
+type savedFilters20221107101524 struct {
+	ID      int64 `xorm:"autoincr not null unique pk"`
+	OwnerID int64 `xorm:"bigint not null INDEX"`
+}
+
+func (savedFilters20221107101524) TableName() string {
+	return "saved_filters"
+}
+
+type buckets20221107101524 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	Title       string    `xorm:"text not null"`
+	ListID      int64     `xorm:"bigint not null"`
+	Position    float64   `xorm:"double null"`
+	CreatedByID int64     `xorm:"bigint not null"`
+	Created     time.Time `xorm:"created not null"`
+	Updated     time.Time `xorm:"updated not null"`
+}
+
+func (buckets20221107101524) TableName() string {
+	return "buckets"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221107101524",
+		Description: "Add kanban buckets for saved filters",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(savedFilterTaskBuckets20221107101524{})
+			if err != nil {
+				return err
+			}
+
+			filters := []*savedFilters20221107101524{}
+			err = tx.Find(&filters)
+			if err != nil {
+				return err
+			}
+
+			// Every saved filter gets a first bucket, the same way new filters do
+			for _, filter := range filters {
+				bucket := &buckets20221107101524{
+					Title:       "Backlog",
+					ListID:      filter.ID*-1 - 1,
+					CreatedByID: filter.OwnerID,
+				}
+				_, err = tx.Insert(bucket)
+				if err != nil {
+					return err
+				}
+
+				bucket.Position = float64(bucket.ID) * math.Pow(2, 16)
+				_, err = tx.
+					Where("id = ?", bucket.ID).
+					Cols("position").
+					NoAutoCondition().
+					Update(bucket)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
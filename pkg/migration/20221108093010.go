@@ -0,0 +1,63 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskComments20221108093010 struct {
+	ParentID  int64 `xorm:"bigint not null default 0 INDEX"`
+	ThreadID  int64 `xorm:"bigint not null default 0 INDEX"`
+	IsEdited  bool  `xorm:"bool default false"`
+	IsDeleted bool  `xorm:"bool default false"`
+}
+
+func (taskComments20221108093010) TableName() string {
+	return "task_comments"
+}
+
+type taskCommentEdits20221108093010 struct {
+	ID        int64     `xorm:"bigint autoincr not null unique pk"`
+	CommentID int64     `xorm:"bigint not null INDEX"`
+	Comment   string    `xorm:"text not null"`
+	Created   time.Time `xorm:"created not null"`
+}
+
+func (taskCommentEdits20221108093010) TableName() string {
+	return "task_comment_edits"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221108093010",
+		Description: "Add threaded replies and edit history to task comments",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(taskComments20221108093010{})
+			if err != nil {
+				return err
+			}
+			return tx.Sync2(taskCommentEdits20221108093010{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskAttachmentPreviews20221110083745 struct {
+	ID           int64     `xorm:"bigint autoincr not null unique pk"`
+	AttachmentID int64     `xorm:"bigint not null INDEX"`
+	FileID       int64     `xorm:"bigint not null"`
+	Size         string    `xorm:"varchar(2) not null"`
+	Width        int       `xorm:"int not null"`
+	Height       int       `xorm:"int not null"`
+	Created      time.Time `xorm:"created not null"`
+}
+
+func (taskAttachmentPreviews20221110083745) TableName() string {
+	return "task_attachment_previews"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221110083745",
+		Description: "Add previews for image task attachments",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskAttachmentPreviews20221110083745{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type files20221111101512 struct {
+	Hash   string `xorm:"varchar(64) null INDEX"`
+	BlobID int64  `xorm:"bigint not null default 0 INDEX"`
+}
+
+func (files20221111101512) TableName() string {
+	return "files"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221111101512",
+		Description: "Add content hash and shared blobs to files",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(files20221111101512{})
+			if err != nil {
+				return err
+			}
+
+			// All existing files use their own blob
+			_, err = tx.Exec("UPDATE files SET blob_id = id WHERE blob_id = 0")
+			return err
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -81,14 +81,29 @@ func (bt *BulkTask) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
 func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 	for _, oldtask := range bt.Tasks {
 
+		// Keep the task as it was before the update to be able to save what changed in the history
+		originalTask := *oldtask
+
+		if bt.Task.Done && !oldtask.Done {
+			err = checkTaskIsNotBlocked(s, oldtask)
+			if err != nil {
+				return err
+			}
+		}
+
 		// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
-		updateDone(oldtask, &bt.Task)
+		err = updateDone(oldtask, &bt.Task)
+		if err != nil {
+			return err
+		}
 
 		// Update the assignees
 		if err := oldtask.updateTaskAssignees(s, bt.Assignees, a); err != nil {
 			return err
 		}
 
+		originalRepeatRule := oldtask.RepeatRule
+
 		// For whatever reason, xorm dont detect if done is updated, so we need to update this every time by hand
 		// Which is why we merge the actual task struct with the one we got from the
 		// The user struct overrides values in the actual one.
@@ -101,13 +116,20 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 			oldtask.Done = false
 		}
 
+		if err := oldtask.prepareRepeatRule(originalRepeatRule); err != nil {
+			return err
+		}
+
 		_, err = s.ID(oldtask.ID).
 			Cols("title",
 				"description",
 				"done",
 				"due_date",
 				"reminders",
-				"repeat_after",
+				"repeat_rule",
+				"repeat_exdates",
+				"repeat_start",
+				"repeat_from_current_date",
 				"priority",
 				"start_date",
 				"end_date").
@@ -115,6 +137,13 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 		if err != nil {
 			return err
 		}
+
+		// Reminders are not updated in bulk
+		oldtask.Reminders = originalTask.Reminders
+		err = recordTaskUpdate(s, a, &originalTask, oldtask)
+		if err != nil {
+			return err
+		}
 	}
 
 	return
@@ -21,6 +21,7 @@ import (
 
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestBulkTask_Update(t *testing.T) {
@@ -92,3 +93,27 @@ func TestBulkTask_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkTask_Update_RepeatRule(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	bt := &BulkTask{
+		IDs: []int64{10, 11, 12},
+		Task: Task{
+			RepeatRule:            "FREQ=DAILY",
+			RepeatFromCurrentDate: true,
+		},
+	}
+	err := bt.Update(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertExists(t, "tasks", map[string]interface{}{
+		"id":                       10,
+		"repeat_rule":              "FREQ=DAILY",
+		"repeat_from_current_date": true,
+	}, false)
+}
@@ -19,6 +19,7 @@ package models
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/web"
@@ -600,6 +601,61 @@ func (err ErrTaskAttachmentIsTooLarge) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidTaskAttachmentPreviewSize represents an error where an unknown preview size was requested
+type ErrInvalidTaskAttachmentPreviewSize struct {
+	Size string
+}
+
+// IsErrInvalidTaskAttachmentPreviewSize checks if an error is ErrInvalidTaskAttachmentPreviewSize.
+func IsErrInvalidTaskAttachmentPreviewSize(err error) bool {
+	_, ok := err.(ErrInvalidTaskAttachmentPreviewSize)
+	return ok
+}
+
+func (err ErrInvalidTaskAttachmentPreviewSize) Error() string {
+	return fmt.Sprintf("Task attachment preview size is invalid [Size: %s]", err.Size)
+}
+
+// ErrCodeInvalidTaskAttachmentPreviewSize holds the unique world-error code of this error
+const ErrCodeInvalidTaskAttachmentPreviewSize = 4027
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskAttachmentPreviewSize) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskAttachmentPreviewSize,
+		Message:  fmt.Sprintf("There is no preview size '%s'. Use one of sm, md or lg.", err.Size),
+	}
+}
+
+// ErrTaskAttachmentPreviewDoesNotExist represents an error where an attachment has no preview in the requested size
+type ErrTaskAttachmentPreviewDoesNotExist struct {
+	AttachmentID int64
+	Size         string
+}
+
+// IsErrTaskAttachmentPreviewDoesNotExist checks if an error is ErrTaskAttachmentPreviewDoesNotExist.
+func IsErrTaskAttachmentPreviewDoesNotExist(err error) bool {
+	_, ok := err.(ErrTaskAttachmentPreviewDoesNotExist)
+	return ok
+}
+
+func (err ErrTaskAttachmentPreviewDoesNotExist) Error() string {
+	return fmt.Sprintf("Task attachment preview does not exist [AttachmentID: %d, Size: %s]", err.AttachmentID, err.Size)
+}
+
+// ErrCodeTaskAttachmentPreviewDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskAttachmentPreviewDoesNotExist = 4028
+
+// HTTPError holds the http error description
+func (err ErrTaskAttachmentPreviewDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskAttachmentPreviewDoesNotExist,
+		Message:  "This attachment has no preview in this size. Either it is not an image or the preview was not generated yet.",
+	}
+}
+
 // ErrInvalidSortParam represents an error where the provided sort param is invalid
 type ErrInvalidSortParam struct {
 	SortBy string
@@ -682,6 +738,34 @@ func (err ErrTaskCommentDoesNotExist) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrTaskCommentIsDeleted represents an error where a deleted task comment should be changed or replied to
+type ErrTaskCommentIsDeleted struct {
+	ID     int64
+	TaskID int64
+}
+
+// IsErrTaskCommentIsDeleted checks if an error is ErrTaskCommentIsDeleted.
+func IsErrTaskCommentIsDeleted(err error) bool {
+	_, ok := err.(ErrTaskCommentIsDeleted)
+	return ok
+}
+
+func (err ErrTaskCommentIsDeleted) Error() string {
+	return fmt.Sprintf("Task comment is deleted [ID: %d, TaskID: %d]", err.ID, err.TaskID)
+}
+
+// ErrCodeTaskCommentIsDeleted holds the unique world-error code of this error
+const ErrCodeTaskCommentIsDeleted = 4026
+
+// HTTPError holds the http error description
+func (err ErrTaskCommentIsDeleted) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskCommentIsDeleted,
+		Message:  "This task comment was deleted. It cannot be changed or replied to.",
+	}
+}
+
 // ErrInvalidTaskField represents an error where the provided task field is invalid
 type ErrInvalidTaskField struct {
 	TaskField string
@@ -791,6 +875,173 @@ func (err ErrInvalidTaskFilterValue) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidRepeatRule represents an error where the provided task repeat rule is invalid
+type ErrInvalidRepeatRule struct {
+	Rule   string
+	Reason string
+}
+
+// IsErrInvalidRepeatRule checks if an error is ErrInvalidRepeatRule.
+func IsErrInvalidRepeatRule(err error) bool {
+	_, ok := err.(ErrInvalidRepeatRule)
+	return ok
+}
+
+func (err ErrInvalidRepeatRule) Error() string {
+	return fmt.Sprintf("Task repeat rule is invalid [Rule: %s, Reason: %s]", err.Rule, err.Reason)
+}
+
+// ErrCodeInvalidRepeatRule holds the unique world-error code of this error
+const ErrCodeInvalidRepeatRule = 4020
+
+// HTTPError holds the http error description
+func (err ErrInvalidRepeatRule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidRepeatRule,
+		Message:  fmt.Sprintf("The task repeat rule '%s' is invalid: %s", err.Rule, err.Reason),
+	}
+}
+
+// ErrTaskIsBlocked represents an error where a task cannot be marked as done because other tasks it depends on are not done yet
+type ErrTaskIsBlocked struct {
+	TaskID int64
+}
+
+// IsErrTaskIsBlocked checks if an error is ErrTaskIsBlocked.
+func IsErrTaskIsBlocked(err error) bool {
+	_, ok := err.(ErrTaskIsBlocked)
+	return ok
+}
+
+func (err ErrTaskIsBlocked) Error() string {
+	return fmt.Sprintf("Task is blocked by other tasks which are not done [TaskID: %d]", err.TaskID)
+}
+
+// ErrCodeTaskIsBlocked holds the unique world-error code of this error
+const ErrCodeTaskIsBlocked = 4021
+
+// HTTPError holds the http error description
+func (err ErrTaskIsBlocked) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskIsBlocked,
+		Message:  "This task cannot be done because it is blocked by or follows tasks which are not done yet.",
+	}
+}
+
+// ErrRelationCreatesCycle represents an error where a new task relation would create a cycle
+type ErrRelationCreatesCycle struct {
+	TaskID      int64
+	OtherTaskID int64
+	Kind        RelationKind
+}
+
+// IsErrRelationCreatesCycle checks if an error is ErrRelationCreatesCycle.
+func IsErrRelationCreatesCycle(err error) bool {
+	_, ok := err.(ErrRelationCreatesCycle)
+	return ok
+}
+
+func (err ErrRelationCreatesCycle) Error() string {
+	return fmt.Sprintf("Task relation would create a cycle [TaskID: %d, OtherTaskID: %d, Kind: %s]", err.TaskID, err.OtherTaskID, err.Kind)
+}
+
+// ErrCodeRelationCreatesCycle holds the unique world-error code of this error
+const ErrCodeRelationCreatesCycle = 4022
+
+// HTTPError holds the http error description
+func (err ErrRelationCreatesCycle) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeRelationCreatesCycle,
+		Message:  fmt.Sprintf("This '%s' relation would create a cycle between the tasks.", err.Kind),
+	}
+}
+
+// ErrTaskIdentifierDoesNotExist represents an error where no task could be found for a task identifier
+type ErrTaskIdentifierDoesNotExist struct {
+	Identifier string
+}
+
+// IsErrTaskIdentifierDoesNotExist checks if an error is ErrTaskIdentifierDoesNotExist.
+func IsErrTaskIdentifierDoesNotExist(err error) bool {
+	_, ok := err.(ErrTaskIdentifierDoesNotExist)
+	return ok
+}
+
+func (err ErrTaskIdentifierDoesNotExist) Error() string {
+	return fmt.Sprintf("Task identifier does not exist [Identifier: %s]", err.Identifier)
+}
+
+// ErrCodeTaskIdentifierDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskIdentifierDoesNotExist = 4023
+
+// HTTPError holds the http error description
+func (err ErrTaskIdentifierDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskIdentifierDoesNotExist,
+		Message:  fmt.Sprintf("There is no task with the identifier '%s'.", err.Identifier),
+	}
+}
+
+// ErrInvalidTaskFilterQuery represents an error where a task filter query cannot be parsed
+type ErrInvalidTaskFilterQuery struct {
+	Query    string
+	Position int
+	Reason   string
+}
+
+// IsErrInvalidTaskFilterQuery checks if an error is ErrInvalidTaskFilterQuery.
+func IsErrInvalidTaskFilterQuery(err error) bool {
+	_, ok := err.(ErrInvalidTaskFilterQuery)
+	return ok
+}
+
+func (err ErrInvalidTaskFilterQuery) Error() string {
+	return fmt.Sprintf("Task filter query is invalid [Query: %s, Position: %d, Reason: %s]", err.Query, err.Position, err.Reason)
+}
+
+// ErrCodeInvalidTaskFilterQuery holds the unique world-error code of this error
+const ErrCodeInvalidTaskFilterQuery = 4024
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskFilterQuery) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskFilterQuery,
+		Message:  fmt.Sprintf("The task filter query is invalid at position %d: %s", err.Position, err.Reason),
+	}
+}
+
+// ErrInvalidTaskAgendaView represents an error where an unknown task agenda view was requested
+type ErrInvalidTaskAgendaView struct {
+	View string
+}
+
+// IsErrInvalidTaskAgendaView checks if an error is ErrInvalidTaskAgendaView.
+func IsErrInvalidTaskAgendaView(err error) bool {
+	_, ok := err.(ErrInvalidTaskAgendaView)
+	return ok
+}
+
+func (err ErrInvalidTaskAgendaView) Error() string {
+	return fmt.Sprintf("Task agenda view is invalid [View: %s]", err.View)
+}
+
+// ErrCodeInvalidTaskAgendaView holds the unique world-error code of this error
+const ErrCodeInvalidTaskAgendaView = 4025
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskAgendaView) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskAgendaView,
+		Message:  fmt.Sprintf("There is no task agenda view '%s'. Use one of all, today, upcoming or overdue.", err.View),
+	}
+}
+
 // =================
 // Namespace errors
 // =================
@@ -1481,6 +1732,90 @@ func (err ErrSavedFilterNotAvailableForLinkShare) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrUserAlreadyHasSavedFilterAccess represents an error where a user already has access to a saved filter
+type ErrUserAlreadyHasSavedFilterAccess struct {
+	UserID   int64
+	FilterID int64
+}
+
+// IsErrUserAlreadyHasSavedFilterAccess checks if an error is ErrUserAlreadyHasSavedFilterAccess.
+func IsErrUserAlreadyHasSavedFilterAccess(err error) bool {
+	_, ok := err.(ErrUserAlreadyHasSavedFilterAccess)
+	return ok
+}
+
+func (err ErrUserAlreadyHasSavedFilterAccess) Error() string {
+	return fmt.Sprintf("User already has access to that saved filter. [User ID: %d, Filter ID: %d]", err.UserID, err.FilterID)
+}
+
+// ErrCodeUserAlreadyHasSavedFilterAccess holds the unique world-error code of this error
+const ErrCodeUserAlreadyHasSavedFilterAccess = 11003
+
+// HTTPError holds the http error description
+func (err ErrUserAlreadyHasSavedFilterAccess) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusConflict,
+		Code:     ErrCodeUserAlreadyHasSavedFilterAccess,
+		Message:  "This user already has access to this saved filter.",
+	}
+}
+
+// ErrUserDoesNotHaveAccessToSavedFilter represents an error where a saved filter was not shared with a user
+type ErrUserDoesNotHaveAccessToSavedFilter struct {
+	UserID   int64
+	FilterID int64
+}
+
+// IsErrUserDoesNotHaveAccessToSavedFilter checks if an error is ErrUserDoesNotHaveAccessToSavedFilter.
+func IsErrUserDoesNotHaveAccessToSavedFilter(err error) bool {
+	_, ok := err.(ErrUserDoesNotHaveAccessToSavedFilter)
+	return ok
+}
+
+func (err ErrUserDoesNotHaveAccessToSavedFilter) Error() string {
+	return fmt.Sprintf("User does not have access to the saved filter [User ID: %d, Filter ID: %d]", err.UserID, err.FilterID)
+}
+
+// ErrCodeUserDoesNotHaveAccessToSavedFilter holds the unique world-error code of this error
+const ErrCodeUserDoesNotHaveAccessToSavedFilter = 11004
+
+// HTTPError holds the http error description
+func (err ErrUserDoesNotHaveAccessToSavedFilter) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeUserDoesNotHaveAccessToSavedFilter,
+		Message:  "This user does not have access to the saved filter.",
+	}
+}
+
+// ErrTeamDoesNotHaveAccessToSavedFilter represents an error where a saved filter was not shared with a team
+type ErrTeamDoesNotHaveAccessToSavedFilter struct {
+	TeamID   int64
+	FilterID int64
+}
+
+// IsErrTeamDoesNotHaveAccessToSavedFilter checks if an error is ErrTeamDoesNotHaveAccessToSavedFilter.
+func IsErrTeamDoesNotHaveAccessToSavedFilter(err error) bool {
+	_, ok := err.(ErrTeamDoesNotHaveAccessToSavedFilter)
+	return ok
+}
+
+func (err ErrTeamDoesNotHaveAccessToSavedFilter) Error() string {
+	return fmt.Sprintf("Team does not have access to the saved filter [Team ID: %d, Filter ID: %d]", err.TeamID, err.FilterID)
+}
+
+// ErrCodeTeamDoesNotHaveAccessToSavedFilter holds the unique world-error code of this error
+const ErrCodeTeamDoesNotHaveAccessToSavedFilter = 11005
+
+// HTTPError holds the http error description
+func (err ErrTeamDoesNotHaveAccessToSavedFilter) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeTeamDoesNotHaveAccessToSavedFilter,
+		Message:  "This team does not have access to the saved filter.",
+	}
+}
+
 // =============
 // Subscriptions
 // =============
@@ -1598,3 +1933,607 @@ func (err ErrLinkSharePasswordInvalid) HTTPError() web.HTTPError {
 		Message:  "The provided link share password is invalid.",
 	}
 }
+
+// ====================
+// Time tracking errors
+// ====================
+
+// ErrTimeEntryDoesNotExist represents an error where a time entry does not exist
+type ErrTimeEntryDoesNotExist struct {
+	ID     int64
+	TaskID int64
+}
+
+// IsErrTimeEntryDoesNotExist checks if an error is ErrTimeEntryDoesNotExist.
+func IsErrTimeEntryDoesNotExist(err error) bool {
+	_, ok := err.(ErrTimeEntryDoesNotExist)
+	return ok
+}
+
+func (err ErrTimeEntryDoesNotExist) Error() string {
+	return fmt.Sprintf("Time entry does not exist [ID: %d, TaskID: %d]", err.ID, err.TaskID)
+}
+
+// ErrCodeTimeEntryDoesNotExist holds the unique world-error code of this error
+const ErrCodeTimeEntryDoesNotExist = 14001
+
+// HTTPError holds the http error description
+func (err ErrTimeEntryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTimeEntryDoesNotExist,
+		Message:  "This time entry does not exist.",
+	}
+}
+
+// ErrInvalidTimeEntry represents an error where a time entry has no or a negative duration
+type ErrInvalidTimeEntry struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsErrInvalidTimeEntry checks if an error is ErrInvalidTimeEntry.
+func IsErrInvalidTimeEntry(err error) bool {
+	_, ok := err.(ErrInvalidTimeEntry)
+	return ok
+}
+
+func (err ErrInvalidTimeEntry) Error() string {
+	return fmt.Sprintf("Time entry must end after it started [Start: %s, End: %s]", err.Start, err.End)
+}
+
+// ErrCodeInvalidTimeEntry holds the unique world-error code of this error
+const ErrCodeInvalidTimeEntry = 14002
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimeEntry) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTimeEntry,
+		Message:  "A time entry needs either an end date after its start date or a positive duration.",
+	}
+}
+
+// ErrTimerAlreadyRunning represents an error where a user tries to start a timer on a task which already has one running
+type ErrTimerAlreadyRunning struct {
+	TaskID int64
+	UserID int64
+}
+
+// IsErrTimerAlreadyRunning checks if an error is ErrTimerAlreadyRunning.
+func IsErrTimerAlreadyRunning(err error) bool {
+	_, ok := err.(ErrTimerAlreadyRunning)
+	return ok
+}
+
+func (err ErrTimerAlreadyRunning) Error() string {
+	return fmt.Sprintf("Timer is already running [TaskID: %d, UserID: %d]", err.TaskID, err.UserID)
+}
+
+// ErrCodeTimerAlreadyRunning holds the unique world-error code of this error
+const ErrCodeTimerAlreadyRunning = 14003
+
+// HTTPError holds the http error description
+func (err ErrTimerAlreadyRunning) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTimerAlreadyRunning,
+		Message:  "There is already a timer running for this task.",
+	}
+}
+
+// ErrNoRunningTimer represents an error where a user tries to stop a timer which is not running
+type ErrNoRunningTimer struct {
+	TaskID int64
+	UserID int64
+}
+
+// IsErrNoRunningTimer checks if an error is ErrNoRunningTimer.
+func IsErrNoRunningTimer(err error) bool {
+	_, ok := err.(ErrNoRunningTimer)
+	return ok
+}
+
+func (err ErrNoRunningTimer) Error() string {
+	return fmt.Sprintf("No timer is running [TaskID: %d, UserID: %d]", err.TaskID, err.UserID)
+}
+
+// ErrCodeNoRunningTimer holds the unique world-error code of this error
+const ErrCodeNoRunningTimer = 14004
+
+// HTTPError holds the http error description
+func (err ErrNoRunningTimer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeNoRunningTimer,
+		Message:  "There is no timer running for this task.",
+	}
+}
+
+// ErrInvalidTimeReportGroup represents an error where a time report should be grouped by an unknown property
+type ErrInvalidTimeReportGroup struct {
+	GroupBy string
+}
+
+// IsErrInvalidTimeReportGroup checks if an error is ErrInvalidTimeReportGroup.
+func IsErrInvalidTimeReportGroup(err error) bool {
+	_, ok := err.(ErrInvalidTimeReportGroup)
+	return ok
+}
+
+func (err ErrInvalidTimeReportGroup) Error() string {
+	return fmt.Sprintf("Time report group is invalid [GroupBy: %s]", err.GroupBy)
+}
+
+// ErrCodeInvalidTimeReportGroup holds the unique world-error code of this error
+const ErrCodeInvalidTimeReportGroup = 14005
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimeReportGroup) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTimeReportGroup,
+		Message:  fmt.Sprintf("Time reports can't be grouped by '%s'. Use one of user, list, label or date.", err.GroupBy),
+	}
+}
+
+// ============
+// Trash errors
+// ============
+
+// ErrInvalidTrashItemKind represents an error where a trash item kind does not exist
+type ErrInvalidTrashItemKind struct {
+	Kind string
+}
+
+// IsErrInvalidTrashItemKind checks if an error is ErrInvalidTrashItemKind.
+func IsErrInvalidTrashItemKind(err error) bool {
+	_, ok := err.(ErrInvalidTrashItemKind)
+	return ok
+}
+
+func (err ErrInvalidTrashItemKind) Error() string {
+	return fmt.Sprintf("Trash item kind is invalid [Kind: %s]", err.Kind)
+}
+
+// ErrCodeInvalidTrashItemKind holds the unique world-error code of this error
+const ErrCodeInvalidTrashItemKind = 15001
+
+// HTTPError holds the http error description
+func (err ErrInvalidTrashItemKind) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTrashItemKind,
+		Message:  fmt.Sprintf("'%s' is not a valid trash item kind. Use one of task, list or namespace.", err.Kind),
+	}
+}
+
+// ErrTrashItemDoesNotExist represents an error where an item is not in the trash
+type ErrTrashItemDoesNotExist struct {
+	Kind string
+	ID   int64
+}
+
+// IsErrTrashItemDoesNotExist checks if an error is ErrTrashItemDoesNotExist.
+func IsErrTrashItemDoesNotExist(err error) bool {
+	_, ok := err.(ErrTrashItemDoesNotExist)
+	return ok
+}
+
+func (err ErrTrashItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Trash item does not exist [Kind: %s, ID: %d]", err.Kind, err.ID)
+}
+
+// ErrCodeTrashItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeTrashItemDoesNotExist = 15002
+
+// HTTPError holds the http error description
+func (err ErrTrashItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTrashItemDoesNotExist,
+		Message:  "This item is not in the trash.",
+	}
+}
+
+// ErrTrashItemParentDeleted represents an error where a trash item cannot be restored because its parent is in the trash as well
+type ErrTrashItemParentDeleted struct {
+	Kind string
+	ID   int64
+}
+
+// IsErrTrashItemParentDeleted checks if an error is ErrTrashItemParentDeleted.
+func IsErrTrashItemParentDeleted(err error) bool {
+	_, ok := err.(ErrTrashItemParentDeleted)
+	return ok
+}
+
+func (err ErrTrashItemParentDeleted) Error() string {
+	return fmt.Sprintf("Trash item parent is deleted [Kind: %s, ID: %d]", err.Kind, err.ID)
+}
+
+// ErrCodeTrashItemParentDeleted holds the unique world-error code of this error
+const ErrCodeTrashItemParentDeleted = 15003
+
+// HTTPError holds the http error description
+func (err ErrTrashItemParentDeleted) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTrashItemParentDeleted,
+		Message:  "The list or namespace this item belongs to is in the trash. Restore it first.",
+	}
+}
+
+// ================
+// Checklist errors
+// ================
+
+// ErrChecklistItemDoesNotExist represents an error where a checklist item does not exist
+type ErrChecklistItemDoesNotExist struct {
+	ID     int64
+	TaskID int64
+}
+
+// IsErrChecklistItemDoesNotExist checks if an error is ErrChecklistItemDoesNotExist.
+func IsErrChecklistItemDoesNotExist(err error) bool {
+	_, ok := err.(ErrChecklistItemDoesNotExist)
+	return ok
+}
+
+func (err ErrChecklistItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Checklist item does not exist [ID: %d, TaskID: %d]", err.ID, err.TaskID)
+}
+
+// ErrCodeChecklistItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeChecklistItemDoesNotExist = 16001
+
+// HTTPError holds the http error description
+func (err ErrChecklistItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeChecklistItemDoesNotExist,
+		Message:  "This checklist item does not exist.",
+	}
+}
+
+// ErrInvalidChecklistOrder represents an error where a new checklist order does not contain all items of the checklist
+type ErrInvalidChecklistOrder struct {
+	TaskID int64
+}
+
+// IsErrInvalidChecklistOrder checks if an error is ErrInvalidChecklistOrder.
+func IsErrInvalidChecklistOrder(err error) bool {
+	_, ok := err.(ErrInvalidChecklistOrder)
+	return ok
+}
+
+func (err ErrInvalidChecklistOrder) Error() string {
+	return fmt.Sprintf("Checklist order is invalid [TaskID: %d]", err.TaskID)
+}
+
+// ErrCodeInvalidChecklistOrder holds the unique world-error code of this error
+const ErrCodeInvalidChecklistOrder = 16002
+
+// HTTPError holds the http error description
+func (err ErrInvalidChecklistOrder) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidChecklistOrder,
+		Message:  "The new order needs to contain every item of the checklist exactly once.",
+	}
+}
+
+// ===================
+// Custom field errors
+// ===================
+
+// ErrCustomFieldDoesNotExist represents an error where a custom field does not exist
+type ErrCustomFieldDoesNotExist struct {
+	ID     int64
+	ListID int64
+}
+
+// IsErrCustomFieldDoesNotExist checks if an error is ErrCustomFieldDoesNotExist.
+func IsErrCustomFieldDoesNotExist(err error) bool {
+	_, ok := err.(ErrCustomFieldDoesNotExist)
+	return ok
+}
+
+func (err ErrCustomFieldDoesNotExist) Error() string {
+	return fmt.Sprintf("Custom field does not exist [ID: %d, ListID: %d]", err.ID, err.ListID)
+}
+
+// ErrCodeCustomFieldDoesNotExist holds the unique world-error code of this error
+const ErrCodeCustomFieldDoesNotExist = 17001
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCustomFieldDoesNotExist,
+		Message:  "This custom field does not exist in the list of the task.",
+	}
+}
+
+// ErrInvalidCustomFieldType represents an error where a custom field has an invalid type
+type ErrInvalidCustomFieldType struct {
+	Type CustomFieldType
+}
+
+// IsErrInvalidCustomFieldType checks if an error is ErrInvalidCustomFieldType.
+func IsErrInvalidCustomFieldType(err error) bool {
+	_, ok := err.(ErrInvalidCustomFieldType)
+	return ok
+}
+
+func (err ErrInvalidCustomFieldType) Error() string {
+	return fmt.Sprintf("Custom field type is invalid [Type: %s]", err.Type)
+}
+
+// ErrCodeInvalidCustomFieldType holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldType = 17002
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldType) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldType,
+		Message:  fmt.Sprintf("'%s' is not a valid custom field type. Use one of text, number, date, select, multi_select, user or url.", err.Type),
+	}
+}
+
+// ErrInvalidCustomFieldOptions represents an error where the options of a select custom field are invalid
+type ErrInvalidCustomFieldOptions struct {
+	Type CustomFieldType
+}
+
+// IsErrInvalidCustomFieldOptions checks if an error is ErrInvalidCustomFieldOptions.
+func IsErrInvalidCustomFieldOptions(err error) bool {
+	_, ok := err.(ErrInvalidCustomFieldOptions)
+	return ok
+}
+
+func (err ErrInvalidCustomFieldOptions) Error() string {
+	return fmt.Sprintf("Custom field options are invalid [Type: %s]", err.Type)
+}
+
+// ErrCodeInvalidCustomFieldOptions holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldOptions = 17003
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldOptions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldOptions,
+		Message:  "A select field needs at least one option. Options cannot be empty and need to be unique.",
+	}
+}
+
+// ErrInvalidCustomFieldValue represents an error where a task value does not fit the type of its custom field
+type ErrInvalidCustomFieldValue struct {
+	FieldID int64
+	Type    CustomFieldType
+}
+
+// IsErrInvalidCustomFieldValue checks if an error is ErrInvalidCustomFieldValue.
+func IsErrInvalidCustomFieldValue(err error) bool {
+	_, ok := err.(ErrInvalidCustomFieldValue)
+	return ok
+}
+
+func (err ErrInvalidCustomFieldValue) Error() string {
+	return fmt.Sprintf("Custom field value is invalid [FieldID: %d, Type: %s]", err.FieldID, err.Type)
+}
+
+// ErrCodeInvalidCustomFieldValue holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldValue = 17004
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldValue,
+		Message:  fmt.Sprintf("The value of custom field %d is not a valid %s value.", err.FieldID, err.Type),
+	}
+}
+
+// ====================
+// Task template errors
+// ====================
+
+// ErrTaskTemplateDoesNotExist represents an error where a task template does not exist
+type ErrTaskTemplateDoesNotExist struct {
+	ID int64
+}
+
+// IsErrTaskTemplateDoesNotExist checks if an error is ErrTaskTemplateDoesNotExist.
+func IsErrTaskTemplateDoesNotExist(err error) bool {
+	_, ok := err.(ErrTaskTemplateDoesNotExist)
+	return ok
+}
+
+func (err ErrTaskTemplateDoesNotExist) Error() string {
+	return fmt.Sprintf("Task template does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeTaskTemplateDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskTemplateDoesNotExist = 18001
+
+// HTTPError holds the http error description
+func (err ErrTaskTemplateDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskTemplateDoesNotExist,
+		Message:  "This task template does not exist.",
+	}
+}
+
+// ErrUserAlreadyHasTaskTemplateAccess represents an error where a user already has access to a task template
+type ErrUserAlreadyHasTaskTemplateAccess struct {
+	UserID     int64
+	TemplateID int64
+}
+
+// IsErrUserAlreadyHasTaskTemplateAccess checks if an error is ErrUserAlreadyHasTaskTemplateAccess.
+func IsErrUserAlreadyHasTaskTemplateAccess(err error) bool {
+	_, ok := err.(ErrUserAlreadyHasTaskTemplateAccess)
+	return ok
+}
+
+func (err ErrUserAlreadyHasTaskTemplateAccess) Error() string {
+	return fmt.Sprintf("User already has access to that task template. [User ID: %d, Template ID: %d]", err.UserID, err.TemplateID)
+}
+
+// ErrCodeUserAlreadyHasTaskTemplateAccess holds the unique world-error code of this error
+const ErrCodeUserAlreadyHasTaskTemplateAccess = 18002
+
+// HTTPError holds the http error description
+func (err ErrUserAlreadyHasTaskTemplateAccess) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusConflict,
+		Code:     ErrCodeUserAlreadyHasTaskTemplateAccess,
+		Message:  "This user already has access to this task template.",
+	}
+}
+
+// ErrUserDoesNotHaveAccessToTaskTemplate represents an error where a task template was not shared with a user
+type ErrUserDoesNotHaveAccessToTaskTemplate struct {
+	UserID     int64
+	TemplateID int64
+}
+
+// IsErrUserDoesNotHaveAccessToTaskTemplate checks if an error is ErrUserDoesNotHaveAccessToTaskTemplate.
+func IsErrUserDoesNotHaveAccessToTaskTemplate(err error) bool {
+	_, ok := err.(ErrUserDoesNotHaveAccessToTaskTemplate)
+	return ok
+}
+
+func (err ErrUserDoesNotHaveAccessToTaskTemplate) Error() string {
+	return fmt.Sprintf("User does not have access to the task template [User ID: %d, Template ID: %d]", err.UserID, err.TemplateID)
+}
+
+// ErrCodeUserDoesNotHaveAccessToTaskTemplate holds the unique world-error code of this error
+const ErrCodeUserDoesNotHaveAccessToTaskTemplate = 18003
+
+// HTTPError holds the http error description
+func (err ErrUserDoesNotHaveAccessToTaskTemplate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeUserDoesNotHaveAccessToTaskTemplate,
+		Message:  "This user does not have access to the task template.",
+	}
+}
+
+// ErrTeamDoesNotHaveAccessToTaskTemplate represents an error where a task template was not shared with a team
+type ErrTeamDoesNotHaveAccessToTaskTemplate struct {
+	TeamID     int64
+	TemplateID int64
+}
+
+// IsErrTeamDoesNotHaveAccessToTaskTemplate checks if an error is ErrTeamDoesNotHaveAccessToTaskTemplate.
+func IsErrTeamDoesNotHaveAccessToTaskTemplate(err error) bool {
+	_, ok := err.(ErrTeamDoesNotHaveAccessToTaskTemplate)
+	return ok
+}
+
+func (err ErrTeamDoesNotHaveAccessToTaskTemplate) Error() string {
+	return fmt.Sprintf("Team does not have access to the task template [Team ID: %d, Template ID: %d]", err.TeamID, err.TemplateID)
+}
+
+// ErrCodeTeamDoesNotHaveAccessToTaskTemplate holds the unique world-error code of this error
+const ErrCodeTeamDoesNotHaveAccessToTaskTemplate = 18004
+
+// HTTPError holds the http error description
+func (err ErrTeamDoesNotHaveAccessToTaskTemplate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeTeamDoesNotHaveAccessToTaskTemplate,
+		Message:  "This team does not have access to the task template.",
+	}
+}
+
+// ======================
+// List blueprint errors
+// ======================
+
+// ErrListBlueprintDoesNotExist represents an error where a list blueprint does not exist
+type ErrListBlueprintDoesNotExist struct {
+	ID int64
+}
+
+// IsErrListBlueprintDoesNotExist checks if an error is ErrListBlueprintDoesNotExist.
+func IsErrListBlueprintDoesNotExist(err error) bool {
+	_, ok := err.(ErrListBlueprintDoesNotExist)
+	return ok
+}
+
+func (err ErrListBlueprintDoesNotExist) Error() string {
+	return fmt.Sprintf("List blueprint does not exist [ID: %d]", err.ID)
+}
+
+// ErrCodeListBlueprintDoesNotExist holds the unique world-error code of this error
+const ErrCodeListBlueprintDoesNotExist = 19001
+
+// HTTPError holds the http error description
+func (err ErrListBlueprintDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeListBlueprintDoesNotExist,
+		Message:  "This list blueprint does not exist.",
+	}
+}
+
+// ErrInvalidListBlueprintReference represents an error where a task of a list blueprint references
+// a bucket, label or task which is not part of the blueprint
+type ErrInvalidListBlueprintReference struct {
+	TaskIndex int
+	Kind      string
+	Reference int64
+}
+
+// IsErrInvalidListBlueprintReference checks if an error is ErrInvalidListBlueprintReference.
+func IsErrInvalidListBlueprintReference(err error) bool {
+	_, ok := err.(ErrInvalidListBlueprintReference)
+	return ok
+}
+
+func (err ErrInvalidListBlueprintReference) Error() string {
+	return fmt.Sprintf("List blueprint task references a %s which is not part of the blueprint [Task: %d, Reference: %d]", err.Kind, err.TaskIndex, err.Reference)
+}
+
+// ErrCodeInvalidListBlueprintReference holds the unique world-error code of this error
+const ErrCodeInvalidListBlueprintReference = 19002
+
+// HTTPError holds the http error description
+func (err ErrInvalidListBlueprintReference) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidListBlueprintReference,
+		Message:  fmt.Sprintf("Task %d of the blueprint references a %s which is not part of the blueprint.", err.TaskIndex, err.Kind),
+	}
+}
+
+// ErrListBlueprintHasMultipleDoneBuckets represents an error where a list blueprint has more than one done bucket
+type ErrListBlueprintHasMultipleDoneBuckets struct{}
+
+// IsErrListBlueprintHasMultipleDoneBuckets checks if an error is ErrListBlueprintHasMultipleDoneBuckets.
+func IsErrListBlueprintHasMultipleDoneBuckets(err error) bool {
+	_, ok := err.(ErrListBlueprintHasMultipleDoneBuckets)
+	return ok
+}
+
+func (err ErrListBlueprintHasMultipleDoneBuckets) Error() string {
+	return "List blueprint has more than one done bucket"
+}
+
+// ErrCodeListBlueprintHasMultipleDoneBuckets holds the unique world-error code of this error
+const ErrCodeListBlueprintHasMultipleDoneBuckets = 19003
+
+// HTTPError holds the http error description
+func (err ErrListBlueprintHasMultipleDoneBuckets) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeListBlueprintHasMultipleDoneBuckets,
+		Message:  "A list blueprint can only have one done bucket.",
+	}
+}
@@ -50,6 +50,8 @@ func (t *TaskCreatedEvent) Name() string {
 type TaskUpdatedEvent struct {
 	Task *Task
 	Doer *user.User
+	// The description of the task before it was updated
+	PreviousDescription string
 }
 
 // Name defines the name for TaskUpdatedEvent
@@ -68,6 +70,17 @@ func (t *TaskDeletedEvent) Name() string {
 	return "task.deleted"
 }
 
+// TaskRestoredEvent represents an event where a task has been restored from the trash
+type TaskRestoredEvent struct {
+	Task *Task
+	Doer web.Auth
+}
+
+// Name defines the name for TaskRestoredEvent
+func (t *TaskRestoredEvent) Name() string {
+	return "task.restored"
+}
+
 // TaskAssigneeCreatedEvent represents an event where a task has been assigned to a user
 type TaskAssigneeCreatedEvent struct {
 	Task     *Task
@@ -84,7 +97,10 @@ func (t *TaskAssigneeCreatedEvent) Name() string {
 type TaskCommentCreatedEvent struct {
 	Task    *Task
 	Comment *TaskComment
-	Doer    *user.User
+	// The comment the new comment replies to, nil if it does not reply to any comment.
+	// The thread of the new comment is in its ThreadID.
+	Parent *TaskComment
+	Doer   *user.User
 }
 
 // Name defines the name for TaskCommentCreatedEvent
@@ -97,6 +113,8 @@ type TaskCommentUpdatedEvent struct {
 	Task    *Task
 	Comment *TaskComment
 	Doer    *user.User
+	// The text of the comment before it was updated
+	PreviousComment string
 }
 
 // Name defines the name for TaskCommentUpdatedEvent
@@ -104,6 +122,56 @@ func (t *TaskCommentUpdatedEvent) Name() string {
 	return "task.comment.edited"
 }
 
+// TaskCommentDeletedEvent represents a TaskCommentDeletedEvent event
+type TaskCommentDeletedEvent struct {
+	Task    *Task
+	Comment *TaskComment
+	Doer    *user.User
+}
+
+// Name defines the name for TaskCommentDeletedEvent
+func (t *TaskCommentDeletedEvent) Name() string {
+	return "task.comment.deleted"
+}
+
+// TaskReactionCreatedEvent represents an event where someone reacted to a task or task comment
+type TaskReactionCreatedEvent struct {
+	Task *Task
+	// The comment the reaction belongs to, nil if the reaction belongs to the task.
+	Comment  *TaskComment
+	Reaction *Reaction
+	Doer     *user.User
+}
+
+// Name defines the name for TaskReactionCreatedEvent
+func (t *TaskReactionCreatedEvent) Name() string {
+	return "task.reaction.created"
+}
+
+// TaskAttachmentCreatedEvent represents a TaskAttachmentCreatedEvent event
+type TaskAttachmentCreatedEvent struct {
+	Task       *Task
+	Attachment *TaskAttachment
+	Doer       *user.User
+}
+
+// Name defines the name for TaskAttachmentCreatedEvent
+func (t *TaskAttachmentCreatedEvent) Name() string {
+	return "task.attachment.created"
+}
+
+// TaskAttachmentDeletedEvent represents a TaskAttachmentDeletedEvent event
+type TaskAttachmentDeletedEvent struct {
+	Task       *Task
+	Attachment *TaskAttachment
+	Doer       *user.User
+}
+
+// Name defines the name for TaskAttachmentDeletedEvent
+func (t *TaskAttachmentDeletedEvent) Name() string {
+	return "task.attachment.deleted"
+}
+
 //////////////////////
 // Namespace Events //
 //////////////////////
@@ -141,6 +209,17 @@ func (t *NamespaceDeletedEvent) Name() string {
 	return "namespace.deleted"
 }
 
+// NamespaceRestoredEvent represents an event where a namespace has been restored from the trash
+type NamespaceRestoredEvent struct {
+	Namespace *Namespace
+	Doer      web.Auth
+}
+
+// Name defines the name for NamespaceRestoredEvent
+func (n *NamespaceRestoredEvent) Name() string {
+	return "namespace.restored"
+}
+
 /////////////////
 // List Events //
 /////////////////
@@ -178,6 +257,17 @@ func (t *ListDeletedEvent) Name() string {
 	return "list.deleted"
 }
 
+// ListRestoredEvent represents an event where a list has been restored from the trash
+type ListRestoredEvent struct {
+	List *List
+	Doer web.Auth
+}
+
+// Name defines the name for ListRestoredEvent
+func (l *ListRestoredEvent) Name() string {
+	return "list.restored"
+}
+
 ////////////////////
 // Sharing Events //
 ////////////////////
@@ -71,6 +71,11 @@ func ExportUserData(s *xorm.Session, u *user.User) (err error) {
 	if err != nil {
 		return err
 	}
+	// Time entries
+	err = exportTimeEntries(s, u, dumpWriter)
+	if err != nil {
+		return err
+	}
 	// Background files
 	err = exportListBackgrounds(s, u, dumpWriter)
 	if err != nil {
@@ -266,7 +271,9 @@ func exportTaskAttachments(s *xorm.Session, u *user.User, wr *zip.Writer) (err e
 }
 
 func exportSavedFilters(s *xorm.Session, u *user.User, wr *zip.Writer) (err error) {
-	filters, err := getSavedFiltersForUser(s, u)
+	// Only filters the user owns are part of their data, not the ones shared with them
+	filters := []*SavedFilter{}
+	err = s.Where("owner_id = ?", u.ID).Find(&filters)
 	if err != nil {
 		return err
 	}
@@ -279,6 +286,24 @@ func exportSavedFilters(s *xorm.Session, u *user.User, wr *zip.Writer) (err erro
 	return utils.WriteBytesToZip("filters.json", data, wr)
 }
 
+func exportTimeEntries(s *xorm.Session, u *user.User, wr *zip.Writer) (err error) {
+	entries := []*TaskTimeEntry{}
+	err = s.
+		Where("user_id = ?", u.ID).
+		OrderBy("start_time ASC").
+		Find(&entries)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteBytesToZip("time_entries.json", data, wr)
+}
+
 func exportListBackgrounds(s *xorm.Session, u *user.User, wr *zip.Writer) (err error) {
 	lists, _, _, err := getRawListsForUser(
 		s,
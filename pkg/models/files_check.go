@@ -0,0 +1,327 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// The kinds of records which use files
+const (
+	FileReferenceTaskAttachment        = "task attachment"
+	FileReferenceTaskAttachmentPreview = "task attachment preview"
+	FileReferenceListBackground        = "list background"
+	FileReferenceUserAvatar            = "user avatar"
+	FileReferenceUserExport            = "user export"
+)
+
+// FileReference is a record which uses a file
+type FileReference struct {
+	// The kind of the record, one of the FileReference* constants
+	Kind string `xorm:"-"`
+	// The id of the record
+	ID     int64
+	FileID int64
+}
+
+// FileCheckResult holds all inconsistencies between the file storage, the files in the database and the records using them.
+type FileCheckResult struct {
+	// Blobs in the storage which are not used by any file
+	OrphanedBlobs []int64
+	// Files whose content does not exist in the storage
+	FilesWithoutBlob []int64
+	// Files which are not used by any record
+	UnusedFiles []int64
+	// Records which use a file which does not exist
+	MissingFiles []*FileReference
+}
+
+// HasIssues returns whether the check found any inconsistencies
+func (r *FileCheckResult) HasIssues() bool {
+	return len(r.OrphanedBlobs) > 0 ||
+		len(r.FilesWithoutBlob) > 0 ||
+		len(r.UnusedFiles) > 0 ||
+		len(r.MissingFiles) > 0
+}
+
+// Files created and blobs modified more recently than this are not reported as unused, without content or orphaned
+// because they might still be uploading.
+const fileCheckGracePeriod = time.Hour
+
+// The check refuses to fix anything if more files than this have no content and they are more than
+// fileCheckMaxShareWithoutBlob of all files. The storage is most likely misconfigured in that case.
+const (
+	fileCheckMaxFilesWithoutBlob = 10
+	fileCheckMaxShareWithoutBlob = 0.1
+)
+
+// CheckFiles cross-references the file storage, all files and all records using files to find inconsistencies.
+// If fix is true, everything found is cleaned up: Orphaned blobs, unused files and files without content are removed,
+// records using a file which does not exist are deleted (attachments and previews) or reset (backgrounds, avatars and exports).
+func CheckFiles(s *xorm.Session, fix bool) (result *FileCheckResult, err error) {
+	return checkFiles(s, fix, time.Now())
+}
+
+func checkFiles(s *xorm.Session, fix bool, now time.Time) (result *FileCheckResult, err error) {
+	result = &FileCheckResult{
+		FilesWithoutBlob: []int64{},
+		UnusedFiles:      []int64{},
+	}
+
+	gracePeriodStart := now.Add(-fileCheckGracePeriod)
+	orphanedBlobs, filesWithoutBlob, err := files.CheckBlobs(gracePeriodStart)
+	if err != nil {
+		return nil, err
+	}
+	result.OrphanedBlobs = orphanedBlobs
+
+	allFiles := []*files.File{}
+	err = s.OrderBy("id asc").Find(&allFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files without content still count as existing here. Otherwise, everything using them would be removed
+	// in the same run as the files, which is a lot to lose if the content is only missing because the storage
+	// is not available.
+	existing := make(map[int64]bool, len(allFiles))
+	for _, f := range allFiles {
+		existing[f.ID] = true
+	}
+	for _, f := range filesWithoutBlob {
+		if f.Created.After(gracePeriodStart) {
+			continue
+		}
+		result.FilesWithoutBlob = append(result.FilesWithoutBlob, f.ID)
+	}
+
+	references, err := getFileReferences(s)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[int64]bool, len(references))
+	result.MissingFiles = []*FileReference{}
+	for _, ref := range references {
+		if existing[ref.FileID] {
+			used[ref.FileID] = true
+			continue
+		}
+		result.MissingFiles = append(result.MissingFiles, ref)
+	}
+
+	for _, f := range allFiles {
+		if existing[f.ID] && !used[f.ID] && f.Created.Before(gracePeriodStart) {
+			result.UnusedFiles = append(result.UnusedFiles, f.ID)
+		}
+	}
+
+	if !fix {
+		return result, nil
+	}
+
+	err = checkFileCheckResultCanBeFixed(len(allFiles), len(filesWithoutBlob))
+	if err != nil {
+		return result, err
+	}
+
+	return result, fixFileCheckResult(s, result, gracePeriodStart)
+}
+
+// Fixing the result of a check while the storage is not available or points to the wrong location would
+// remove most files and everything using them.
+func checkFileCheckResultCanBeFixed(allFiles, filesWithoutBlob int) error {
+	if filesWithoutBlob == 0 {
+		return nil
+	}
+
+	if filesWithoutBlob == allFiles ||
+		(filesWithoutBlob > fileCheckMaxFilesWithoutBlob && float64(filesWithoutBlob) > float64(allFiles)*fileCheckMaxShareWithoutBlob) {
+		return files.ErrTooManyFilesWithoutBlob{Files: allFiles, FilesWithoutBlob: filesWithoutBlob}
+	}
+
+	return nil
+}
+
+func getFileReferences(s *xorm.Session) (references []*FileReference, err error) {
+	sources := []struct {
+		kind   string
+		table  string
+		column string
+	}{
+		{kind: FileReferenceTaskAttachment, table: "task_attachments", column: "file_id"},
+		{kind: FileReferenceTaskAttachmentPreview, table: "task_attachment_previews", column: "file_id"},
+		// Lists in the trash are included because they can still be restored
+		{kind: FileReferenceListBackground, table: "lists", column: "background_file_id"},
+		{kind: FileReferenceUserAvatar, table: "users", column: "avatar_file_id"},
+		{kind: FileReferenceUserExport, table: "users", column: "export_file_id"},
+	}
+
+	references = []*FileReference{}
+	for _, source := range sources {
+		refs := []*FileReference{}
+		err = s.
+			Table(source.table).
+			Select("id, " + source.column + " AS file_id").
+			Where(builder.And(
+				builder.NotNull{source.column},
+				builder.Neq{source.column: 0},
+			)).
+			OrderBy("id asc").
+			Find(&refs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			ref.Kind = source.kind
+		}
+		references = append(references, refs...)
+	}
+
+	return
+}
+
+func fixFileCheckResult(s *xorm.Session, result *FileCheckResult, gracePeriodStart time.Time) (err error) {
+	for _, blobID := range result.OrphanedBlobs {
+		err = files.RemoveOrphanedBlob(blobID, gracePeriodStart)
+		// A file might have started using the blob since the check
+		if err != nil && !files.IsErrBlobIsInUse(err) {
+			return err
+		}
+	}
+
+	for _, ref := range result.MissingFiles {
+		err = removeFileReference(s, ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	fileIDs := make([]int64, 0, len(result.FilesWithoutBlob)+len(result.UnusedFiles))
+	fileIDs = append(fileIDs, result.FilesWithoutBlob...)
+	fileIDs = append(fileIDs, result.UnusedFiles...)
+	for _, fileID := range fileIDs {
+		_, err = s.Where("file_id = ?", fileID).Delete(&UnsplashPhoto{})
+		if err != nil {
+			return err
+		}
+
+		f := &files.File{ID: fileID}
+		err = f.Delete()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deletes or resets a record which uses a file which does not exist
+func removeFileReference(s *xorm.Session, ref *FileReference) (err error) {
+	switch ref.Kind {
+	case FileReferenceTaskAttachment:
+		err = deleteTaskAttachmentPreviews(s, ref.ID)
+		if err != nil {
+			return err
+		}
+		_, err = s.Where("id = ?", ref.ID).Delete(&TaskAttachment{})
+	case FileReferenceTaskAttachmentPreview:
+		_, err = s.Where("id = ?", ref.ID).Delete(&TaskAttachmentPreview{})
+	case FileReferenceListBackground:
+		_, err = s.Where("file_id = ?", ref.FileID).Delete(&UnsplashPhoto{})
+		if err != nil {
+			return err
+		}
+		_, err = s.
+			Table("lists").
+			Where("id = ?", ref.ID).
+			Update(map[string]interface{}{
+				"background_file_id":   0,
+				"background_blur_hash": "",
+			})
+	case FileReferenceUserAvatar:
+		_, err = s.
+			Table("users").
+			Where("id = ?", ref.ID).
+			Update(map[string]interface{}{
+				"avatar_provider": "initials",
+				"avatar_file_id":  0,
+			})
+	case FileReferenceUserExport:
+		_, err = s.
+			Table("users").
+			Where("id = ?", ref.ID).
+			Update(map[string]interface{}{
+				"export_file_id": 0,
+			})
+	}
+	return
+}
+
+// RegisterFileCheckCron registers a cron function which checks the files on the configured schedule.
+// Inconsistencies are only cleaned up if enabled in the config, otherwise they are logged.
+func RegisterFileCheckCron() {
+	const logPrefix = "[File Check Cron] "
+
+	schedule := config.FilesCheckSchedule.GetString()
+	if schedule == "" {
+		return
+	}
+	fix := config.FilesCheckFix.GetBool()
+
+	err := cron.Schedule(schedule, func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		result, err := CheckFiles(s, fix)
+		if err != nil {
+			log.Errorf(logPrefix+"Could not check the files: %s", err)
+			return
+		}
+
+		if !result.HasIssues() {
+			log.Debugf(logPrefix + "No inconsistencies found")
+			return
+		}
+
+		action := "Found"
+		if fix {
+			action = "Cleaned up"
+		}
+		log.Infof(
+			logPrefix+"%s %d orphaned blobs, %d files without content, %d unused files and %d records using files which don't exist",
+			action,
+			len(result.OrphanedBlobs),
+			len(result.FilesWithoutBlob),
+			len(result.UnusedFiles),
+			len(result.MissingFiles),
+		)
+	})
+	if err != nil {
+		log.Fatalf("Could not register file check cron: %s", err)
+	}
+}
@@ -0,0 +1,142 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFiles(t *testing.T) {
+	setup := func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		// Start with an empty storage, other tests leave their files behind
+		files.InitTestFileHandler()
+		files.InitTestFileFixtures(t)
+
+		orphaned := &files.File{ID: 999}
+		err := orphaned.Save(strings.NewReader("orphaned"))
+		assert.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+		_, err = s.Table("lists").Where("id = ?", 1).Update(map[string]interface{}{"background_file_id": 1234})
+		assert.NoError(t, err)
+		_, err = s.Table("users").Where("id = ?", 1).Update(map[string]interface{}{"avatar_provider": "upload", "avatar_file_id": 5678})
+		assert.NoError(t, err)
+	}
+
+	t.Run("report", func(t *testing.T) {
+		setup(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// The orphaned blob was just saved, the check needs to run after the grace period
+		result, err := checkFiles(s, false, time.Now().Add(fileCheckGracePeriod+time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, result.HasIssues())
+		assert.Equal(t, []int64{999}, result.OrphanedBlobs)
+		assert.Equal(t, []int64{2}, result.FilesWithoutBlob)
+		assert.Empty(t, result.UnusedFiles)
+		assert.Equal(t, []*FileReference{
+			{Kind: FileReferenceTaskAttachment, ID: 2, FileID: 9999},
+			{Kind: FileReferenceListBackground, ID: 1, FileID: 1234},
+			{Kind: FileReferenceUserAvatar, ID: 1, FileID: 5678},
+		}, result.MissingFiles)
+
+		// Nothing is changed
+		_, err = files.FileStat(config.FilesBasePath.GetString() + "/999")
+		assert.NoError(t, err)
+		db.AssertExists(t, "files", map[string]interface{}{"id": 2}, false)
+		db.AssertExists(t, "task_attachments", map[string]interface{}{"id": 2}, false)
+	})
+	t.Run("recently saved blob", func(t *testing.T) {
+		setup(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// The file using the blob might still be uploading
+		result, err := CheckFiles(s, true)
+		assert.NoError(t, err)
+		assert.Empty(t, result.OrphanedBlobs)
+
+		_, err = files.FileStat(config.FilesBasePath.GetString() + "/999")
+		assert.NoError(t, err)
+	})
+	t.Run("fix", func(t *testing.T) {
+		setup(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := checkFiles(s, true, time.Now().Add(fileCheckGracePeriod+time.Minute))
+		assert.NoError(t, err)
+
+		_, err = files.FileStat(config.FilesBasePath.GetString() + "/999")
+		assert.True(t, os.IsNotExist(err))
+		db.AssertMissing(t, "files", map[string]interface{}{"id": 2})
+		db.AssertMissing(t, "task_attachments", map[string]interface{}{"id": 2})
+		// Records using a file without content are only removed once the file itself is gone
+		db.AssertExists(t, "task_attachment_previews", map[string]interface{}{"id": 1}, false)
+		db.AssertExists(t, "lists", map[string]interface{}{"id": 1, "background_file_id": 0}, false)
+		db.AssertExists(t, "users", map[string]interface{}{"id": 1, "avatar_provider": "initials", "avatar_file_id": 0}, false)
+
+		// Everything still in use is kept
+		db.AssertExists(t, "files", map[string]interface{}{"id": 1}, false)
+		db.AssertExists(t, "task_attachments", map[string]interface{}{"id": 1}, false)
+		_, err = files.FileStat(config.FilesBasePath.GetString() + "/1")
+		assert.NoError(t, err)
+
+		result, err := CheckFiles(s, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []*FileReference{
+			{Kind: FileReferenceTaskAttachmentPreview, ID: 1, FileID: 2},
+		}, result.MissingFiles)
+		db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{"id": 1})
+
+		result, err = CheckFiles(s, false)
+		assert.NoError(t, err)
+		assert.False(t, result.HasIssues())
+	})
+	t.Run("storage not available", func(t *testing.T) {
+		setup(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// The files directory does not exist at all
+		files.InitTestFileHandler()
+		_, err := CheckFiles(s, true)
+		assert.Error(t, err)
+
+		// It exists, but does not contain the content of any file
+		orphaned := &files.File{ID: 999}
+		err = orphaned.Save(strings.NewReader("orphaned"))
+		assert.NoError(t, err)
+		result, err := checkFiles(s, true, time.Now().Add(fileCheckGracePeriod+time.Minute))
+		assert.Error(t, err)
+		assert.True(t, files.IsErrTooManyFilesWithoutBlob(err))
+		assert.Equal(t, []int64{1, 2}, result.FilesWithoutBlob)
+		db.AssertExists(t, "files", map[string]interface{}{"id": 1}, false)
+		db.AssertExists(t, "task_attachments", map[string]interface{}{"id": 1}, false)
+	})
+}
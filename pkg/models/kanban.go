@@ -103,7 +103,7 @@ func getDoneBucketForList(s *xorm.Session, listID int64) (bucket *Bucket, err er
 
 // ReadAll returns all buckets with their tasks for a certain list
 // @Summary Get all kanban buckets of a list
-// @Description Returns all kanban buckets with belong to a list including their tasks.
+// @Description Returns all kanban buckets with belong to a list including their tasks. Saved filters have their own buckets, use the id of the saved filter's pseudo list to get them. The tasks on a saved filter are all tasks matching the filter, their `kanban_position` is the position on the filter.
 // @tags task
 // @Accept json
 // @Produce json
@@ -111,17 +111,22 @@ func getDoneBucketForList(s *xorm.Session, listID int64) (bucket *Bucket, err er
 // @Param id path int true "List Id"
 // @Param page query int false "The page number for tasks. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of tasks per bucket per page. This parameter is limited by the configured maximum of items per page."
-// @Param s query string false "Search tasks by task text."
-// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
+// @Param s query string false "Search tasks by title, description, comments and attachment names, or by their identifier."
+// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. To filter by the checklist of a task, use `checklist_total`, `checklist_done` or `checklist_progress`, the share of done checklist items between 0 and 1. To only get tasks which are blocked by or follow tasks which are not done yet, use `is_blocked`. To filter by the relations of a task, use `assignees` or `created_by` with usernames, `labels`, `namespace` or `bucket` with ids, `has_attachments` with `true` or `false` and `has_relation_kind` with a relation kind. For `assignees`, `labels` and `has_relation_kind`, `not_equals` and `not_in` match all tasks which have none of the values. To filter by a custom field, use `custom_field_` followed by the id of the field. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for."
-// @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
+// @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `not_equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like`, `in` and `not_in`. `in` and `not_in` expect comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter query like `done = false && (priority >= 3 || due_date < now+2d)`. Comparisons use the same fields, comparators and values as `filter_by`, `filter_comparator` and `filter_value`, written as `=`, `!=`, `>`, `>=`, `<`, `<=`, `like`, `in` and `not in`. They can be combined with `&&` and `||`, negated with `!` and grouped with parentheses. If the other filter parameters are provided as well, both need to match."
 // @Success 200 {array} models.Bucket "The buckets with their tasks"
 // @Failure 500 {object} models.Message "Internal server error"
 // @Router /lists/{id}/buckets [get]
 func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
 
+	if getSavedFilterIDFromListID(b.ListID) > 0 {
+		return b.readAllForSavedFilter(s, auth, search, page, perPage)
+	}
+
 	list, err := GetListSimpleByID(s, b.ListID)
 	if err != nil {
 		return nil, 0, 0, err
@@ -250,6 +255,11 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /lists/{id}/buckets [put]
 func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
+	// Buckets of saved filters only group tasks on the filter, they can't mark them as done
+	if getSavedFilterIDFromListID(b.ListID) > 0 {
+		b.IsDoneBucket = false
+	}
+
 	b.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return
@@ -282,6 +292,10 @@ func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /lists/{listID}/buckets/{bucketID} [post]
 func (b *Bucket) Update(s *xorm.Session, a web.Auth) (err error) {
+	if getSavedFilterIDFromListID(b.ListID) > 0 {
+		b.IsDoneBucket = false
+	}
+
 	doneBucket, err := getDoneBucketForList(s, b.ListID)
 	if err != nil {
 		return err
@@ -340,6 +354,12 @@ func (b *Bucket) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	// Tasks on a saved filter which were in that bucket will show up in the first bucket of the filter again
+	if getSavedFilterIDFromListID(b.ListID) > 0 {
+		_, err = s.Where("bucket_id = ?", b.ID).Delete(&SavedFilterTaskBucket{})
+		return
+	}
+
 	// Get the default bucket
 	defaultBucket, err := getDefaultBucket(s, b.ListID)
 	if err != nil {
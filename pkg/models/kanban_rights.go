@@ -23,8 +23,7 @@ import (
 
 // CanCreate checks if a user can create a new bucket
 func (b *Bucket) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
-	l := &List{ID: b.ListID}
-	return l.CanWrite(s, a)
+	return canWriteBucketsOfList(s, b.ListID, a)
 }
 
 // CanUpdate checks if a user can update an existing bucket
@@ -43,6 +42,16 @@ func (b *Bucket) canDoBucket(s *xorm.Session, a web.Auth) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	l := &List{ID: bb.ListID}
+	return canWriteBucketsOfList(s, bb.ListID, a)
+}
+
+// canWriteBucketsOfList checks if the user can manage the buckets of a list.
+// The buckets of a saved filter can only be managed by its owner and users with admin rights on it.
+func canWriteBucketsOfList(s *xorm.Session, listID int64, a web.Auth) (bool, error) {
+	if filterID := getSavedFilterIDFromListID(listID); filterID > 0 {
+		return hasSavedFilterRight(s, filterID, a, RightAdmin)
+	}
+
+	l := &List{ID: listID}
 	return l.CanWrite(s, a)
 }
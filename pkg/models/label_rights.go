@@ -77,7 +77,10 @@ func (l *Label) hasAccessToLabel(s *xorm.Session, a web.Auth) (has bool, maxRigh
 		builder.
 			Select("id").
 			From("tasks").
-			Where(builder.In("list_id", getUserListsStatement(u.ID).Select("l.id"))),
+			Where(builder.And(
+				builder.In("list_id", getUserListsStatement(u.ID).Select("l.id")),
+				builder.IsNull{"deleted"},
+			)),
 	)
 
 	ll := &LabelTask{}
@@ -65,8 +65,16 @@ func (LabelTask) TableName() string {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{task}/labels/{label} [delete]
 func (lt *LabelTask) Delete(s *xorm.Session, a web.Auth) (err error) {
-	_, err = s.Delete(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
-	return err
+	deleted, err := s.Delete(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
+	if err != nil || deleted == 0 {
+		return err
+	}
+
+	label := &Label{ID: lt.LabelID}
+	if l, err := getLabelByIDSimple(s, lt.LabelID); err == nil {
+		label = l
+	}
+	return addTaskHistoryEntry(s, a, lt.TaskID, "labels", getLabelHistoryReference(label), nil)
 }
 
 // Create adds a label to a task
@@ -100,6 +108,15 @@ func (lt *LabelTask) Create(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	label := &Label{ID: lt.LabelID}
+	if l, err := getLabelByIDSimple(s, lt.LabelID); err == nil {
+		label = l
+	}
+	err = addTaskHistoryEntry(s, a, lt.TaskID, "labels", nil, getLabelHistoryReference(label))
+	if err != nil {
+		return err
+	}
+
 	err = updateListByTaskID(s, lt.TaskID)
 	return
 }
@@ -180,7 +197,10 @@ func getLabelsByTaskIDs(s *xorm.Session, opts *LabelByTaskIDsOptions) (ls []*lab
 			builder.
 				Select("id").
 				From("tasks").
-				Where(builder.In("list_id", getUserListsStatement(opts.GetForUser).Select("l.id"))),
+				Where(builder.And(
+					builder.In("list_id", getUserListsStatement(opts.GetForUser).Select("l.id")),
+					builder.IsNull{"deleted"},
+				)),
 		), cond)
 	}
 	if opts.GetUnusedLabels {
@@ -270,7 +290,16 @@ func (t *Task) updateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 	if len(labels) == 0 && len(t.Labels) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(LabelTask{})
-		return err
+		if err != nil {
+			return err
+		}
+		for _, oldLabel := range t.Labels {
+			err = addTaskHistoryEntry(s, creator, t.ID, "labels", getLabelHistoryReference(oldLabel), nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -315,6 +344,13 @@ func (t *Task) updateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 		if err != nil {
 			return err
 		}
+
+		for _, id := range labelsToDelete {
+			err = addTaskHistoryEntry(s, creator, t.ID, "labels", getLabelHistoryReference(oldLabels[id]), nil)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Loop through our labels and add them
@@ -346,6 +382,10 @@ func (t *Task) updateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 		if err != nil {
 			return err
 		}
+		err = addTaskHistoryEntry(s, creator, t.ID, "labels", nil, getLabelHistoryReference(label))
+		if err != nil {
+			return err
+		}
 		t.Labels = append(t.Labels, label)
 	}
 
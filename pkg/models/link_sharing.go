@@ -116,7 +116,7 @@ func (share *LinkSharing) toUser() *user.User {
 
 // Create creates a new link share for a given list
 // @Summary Share a list via link
-// @Description Share a list via link. The user needs to have write-access to the list to be able do this.
+// @Description Share a list via link. The user needs to have write-access to the list to be able do this. To share a saved filter, use the id of its pseudo list.
 // @tags sharing
 // @Accept json
 // @Produce json
@@ -28,10 +28,11 @@ func (share *LinkSharing) CanRead(s *xorm.Session, a web.Auth) (bool, int, error
 		return false, 0, nil
 	}
 
-	l, err := GetListByShareHash(s, share.Hash)
+	sh, err := GetLinkShareByHash(s, share.Hash)
 	if err != nil {
 		return false, 0, err
 	}
+	l := &List{ID: sh.ListID}
 	return l.CanRead(s, a)
 }
 
@@ -56,6 +57,14 @@ func (share *LinkSharing) canDoLinkShare(s *xorm.Session, a web.Auth) (bool, err
 		return false, nil
 	}
 
+	// Saved filters can be shared through their pseudo list
+	if filterID := getSavedFilterIDFromListID(share.ListID); filterID > 0 {
+		if share.Right == RightAdmin {
+			return hasSavedFilterRight(s, filterID, a, RightAdmin)
+		}
+		return hasSavedFilterRight(s, filterID, a, RightWrite)
+	}
+
 	l, err := GetListSimpleByID(s, share.ListID)
 	if err != nil {
 		return false, err
@@ -55,6 +55,11 @@ type List struct {
 	// Whether or not a list is archived.
 	IsArchived bool `xorm:"not null default false" json:"is_archived" query:"is_archived"`
 
+	// If true, tasks in this list can only be marked as done or moved to the done bucket once all tasks blocking them or preceding them are done.
+	EnforceDependencies bool `xorm:"not null default false" json:"enforce_dependencies"`
+	// If true, moving the end or due date of a task in this list moves the start, end and due dates of all tasks following it by the same amount of time.
+	AutoSchedule bool `xorm:"not null default false" json:"auto_schedule"`
+
 	// The id of the file this list has set as background
 	BackgroundFileID int64 `xorm:"null" json:"-"`
 	// Holds extra information about the background set since some background providers require attribution or similar. If not null, the background can be accessed at /lists/{listID}/background
@@ -76,6 +81,8 @@ type List struct {
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this list was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
+	// A timestamp when this list was moved to the trash. Deleted lists are not returned anywhere except the trash.
+	Deleted time.Time `xorm:"deleted null INDEX" json:"-"`
 
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
@@ -188,6 +195,15 @@ func (l *List) ReadAll(s *xorm.Session, a web.Auth, search string, page int, per
 	// Check if we're dealing with a share auth
 	shareAuth, ok := a.(*LinkSharing)
 	if ok {
+		// Link shares of a saved filter only see the filter
+		if filterID := getSavedFilterIDFromListID(shareAuth.ListID); filterID > 0 {
+			sf, err := getSavedFilterSimpleByID(s, filterID)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			return []*List{sf.toList()}, 0, 0, nil
+		}
+
 		list, err := GetListSimpleByID(s, shareAuth.ListID)
 		if err != nil {
 			return nil, 0, 0, err
@@ -374,6 +390,11 @@ func getUserListsStatement(userID int64) *builder.Builder {
 			builder.Eq{"un.user_id": userID},
 			builder.Eq{"l.owner_id": userID},
 		)).
+		// This is a raw query which does not exclude lists and namespaces in the trash automatically
+		Where(builder.And(
+			builder.IsNull{"l.deleted"},
+			builder.IsNull{"n.deleted"},
+		)).
 		OrderBy("position").
 		GroupBy("l.id")
 }
@@ -555,9 +576,11 @@ func checkListBeforeUpdateOrDelete(s *xorm.Session, list *List) error {
 		}
 	}
 
-	// Check if the identifier is unique and not empty
+	// Check if the identifier is unique and not empty.
+	// Lists in the trash keep their identifier until they are purged.
 	if list.Identifier != "" {
 		exists, err := s.
+			Unscoped().
 			Where("identifier = ?", list.Identifier).
 			And("id != ?", list.ID).
 			Exist(&List{})
@@ -638,6 +661,8 @@ func UpdateList(s *xorm.Session, list *List, auth web.Auth, updateListBackground
 		"hex_color",
 		"namespace_id",
 		"position",
+		"enforce_dependencies",
+		"auto_schedule",
 	}
 	if list.Description != "" {
 		colsToUpdate = append(colsToUpdate, "description")
@@ -766,7 +791,7 @@ func (l *List) Create(s *xorm.Session, a web.Auth) (err error) {
 
 // Delete implements the delete method of CRUDable
 // @Summary Deletes a list
-// @Description Delets a list
+// @Description Delets a list. The list and all its tasks are moved to the trash and can be restored from there until they are purged.
 // @tags list
 // @Produce json
 // @Security JWTKeyAuth
@@ -777,22 +802,31 @@ func (l *List) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /lists/{id} [delete]
 func (l *List) Delete(s *xorm.Session, a web.Auth) (err error) {
+	return trashList(s, a, l, time.Now())
+}
 
-	// Delete the list
-	_, err = s.ID(l.ID).Delete(&List{})
+// trashList moves a list with all its tasks to the trash. They all get the same deletion timestamp
+// which is later used to restore them together.
+func trashList(s *xorm.Session, a web.Auth, l *List, deleted time.Time) (err error) {
+	l.Deleted = deleted
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		ID(l.ID).
+		Cols("deleted").
+		Update(l)
 	if err != nil {
 		return
 	}
 
-	// Delete all tasks on that list
-	// Using the loop to make sure all related entities to all tasks are properly deleted as well.
+	// Using the loop to make sure all events for every task are dispatched properly.
 	tasks, _, _, err := getRawTasksForLists(s, []*List{l}, a, &taskOptions{})
 	if err != nil {
 		return
 	}
 
 	for _, task := range tasks {
-		err = task.Delete(s, a)
+		err = trashTask(s, a, task, deleted)
 		if err != nil {
 			return err
 		}
@@ -804,6 +838,42 @@ func (l *List) Delete(s *xorm.Session, a web.Auth) (err error) {
 	})
 }
 
+// purgeList removes a list and all its tasks from the database, including the tasks which are already in the trash.
+func purgeList(s *xorm.Session, a web.Auth, l *List) (err error) {
+	_, err = s.Unscoped().ID(l.ID).Delete(&List{})
+	if err != nil {
+		return
+	}
+
+	// Using the loop to make sure all related entities to all tasks are properly deleted as well.
+	tasks := []*Task{}
+	err = s.Unscoped().Where("list_id = ?", l.ID).Find(&tasks)
+	if err != nil {
+		return
+	}
+
+	for _, task := range tasks {
+		err = purgeTask(s, a, task)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Where("list_id = ?", l.ID).Delete(&ListCustomField{})
+	if err != nil {
+		return
+	}
+
+	// Identifiers of tasks which were moved out of the list can't be resolved anymore
+	_, err = s.Where("list_id = ?", l.ID).Delete(&TaskPreviousIdentifier{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("entity_id = ? AND kind = ?", l.ID, FavoriteKindList).Delete(&Favorite{})
+	return
+}
+
 // SetListBackground sets a background file as list background in the db
 func SetListBackground(s *xorm.Session, listID int64, background *files.File, blurHash string) (err error) {
 	l := &List{
@@ -0,0 +1,781 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ListBlueprint is a reusable template to create new lists from, including their kanban buckets, labels, tasks and shares.
+type ListBlueprint struct {
+	// The unique, numeric id of this blueprint.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"listblueprint"`
+	// The name of this blueprint. Lists created from the blueprint get this title unless another one is provided.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// The description of lists created from this blueprint.
+	Description string `xorm:"longtext null" json:"description"`
+	// The color of lists created from this blueprint in hex.
+	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
+
+	// The kanban buckets of lists created from this blueprint, in the order they should appear.
+	// If empty, lists get the default bucket.
+	Buckets []*ListBlueprintBucket `xorm:"JSON null 'buckets'" json:"buckets"`
+	// The labels used by the tasks of this blueprint.
+	Labels []*ListBlueprintLabel `xorm:"JSON null 'labels'" json:"labels"`
+	// The tasks of lists created from this blueprint.
+	Tasks []*ListBlueprintTask `xorm:"JSON null 'tasks'" json:"tasks"`
+	// The users lists created from this blueprint are shared with.
+	Users []*ListBlueprintShare `xorm:"JSON null 'users'" json:"users"`
+	// The teams lists created from this blueprint are shared with.
+	Teams []*ListBlueprintShare `xorm:"JSON null 'teams'" json:"teams"`
+
+	// If set when creating a blueprint, the blueprint is created from this list. Everything except the title
+	// is then taken from the list. All dates become offsets relative to the beginning of the day of the earliest
+	// date of any task in the list. The title defaults to the title of the list.
+	ListID int64 `xorm:"-" json:"list_id,omitempty"`
+
+	OwnerID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The user who owns this blueprint.
+	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
+
+	// A timestamp when this blueprint was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this blueprint was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// ListBlueprintBucket is a kanban bucket of a list blueprint
+type ListBlueprintBucket struct {
+	// The title of the bucket.
+	Title string `json:"title" valid:"required" minLength:"1"`
+	// How many tasks can be in this bucket at the same time. 0 means no limit.
+	Limit int64 `json:"limit" minimum:"0"`
+	// Whether this is the done bucket of the list. Only one bucket can be the done bucket.
+	IsDoneBucket bool `json:"is_done_bucket"`
+}
+
+// ListBlueprintLabel is a label used by the tasks of a list blueprint.
+// When creating a list from the blueprint, the label with that id is used if the user has access to it.
+// Otherwise a new label with the same title and color is created.
+type ListBlueprintLabel struct {
+	// The id of the label the tasks should use.
+	ID int64 `json:"id"`
+	// The title of the label.
+	Title string `json:"title" valid:"runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The color of the label in hex.
+	HexColor string `json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
+}
+
+// ListBlueprintTask is a task of a list blueprint
+type ListBlueprintTask struct {
+	// The title of the task.
+	Title string `json:"title" valid:"minstringlength(1)" minLength:"1"`
+	// The description of the task.
+	Description string `json:"description"`
+	// The priority of the task.
+	Priority int64 `json:"priority"`
+	// The color of the task in hex.
+	HexColor string `json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
+	// The index of the bucket in the buckets of the blueprint this task should be put in.
+	// Ignored if the blueprint does not have any buckets.
+	BucketIndex int `json:"bucket_index"`
+	// The ids of the labels of this task. Every label must be one of the labels of the blueprint.
+	LabelIDs []int64 `json:"label_ids"`
+	// The due date of the task, in seconds after the start date of the list. Null if the task should not have a due date.
+	DueDateOffset *int64 `json:"due_date_offset"`
+	// The start date of the task, in seconds after the start date of the list. Null if the task should not have a start date.
+	StartDateOffset *int64 `json:"start_date_offset"`
+	// The end date of the task, in seconds after the start date of the list. Null if the task should not have an end date.
+	EndDateOffset *int64 `json:"end_date_offset"`
+	// The reminders of the task, in seconds after the start date of the list.
+	ReminderOffsets []int64 `json:"reminder_offsets"`
+	// The relations of this task to other tasks of the blueprint.
+	Relations []*ListBlueprintTaskRelation `json:"relations"`
+}
+
+// ListBlueprintTaskRelation is a relation between two tasks of a list blueprint
+type ListBlueprintTaskRelation struct {
+	// The index of the other task in the tasks of the blueprint.
+	TaskIndex int `json:"task_index"`
+	// The kind of the relation, seen from the task this relation belongs to.
+	RelationKind RelationKind `json:"relation_kind"`
+}
+
+// ListBlueprintShare is a user or team lists created from a blueprint are shared with
+type ListBlueprintShare struct {
+	// The id of the user or team.
+	ID int64 `json:"id"`
+	// The right this user or team gets. 0 = Read only, 1 = Read & Write, 2 = Admin.
+	Right Right `json:"right" valid:"length(0|2)" maximum:"2" default:"0"`
+}
+
+// TableName holds the table name for list blueprints
+func (lb *ListBlueprint) TableName() string {
+	return "list_blueprints"
+}
+
+func getListBlueprintSimpleByID(s *xorm.Session, id int64) (lb *ListBlueprint, err error) {
+	lb = &ListBlueprint{}
+	exists, err := s.
+		Where("id = ?", id).
+		Get(lb)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrListBlueprintDoesNotExist{ID: id}
+	}
+	return
+}
+
+// Makes sure all references between the parts of a blueprint are valid.
+func (lb *ListBlueprint) validate() error {
+	doneBuckets := 0
+	for _, b := range lb.Buckets {
+		if b.IsDoneBucket {
+			doneBuckets++
+		}
+	}
+	if doneBuckets > 1 {
+		return ErrListBlueprintHasMultipleDoneBuckets{}
+	}
+
+	labels := make(map[int64]bool, len(lb.Labels))
+	for _, l := range lb.Labels {
+		labels[l.ID] = true
+	}
+
+	for i, t := range lb.Tasks {
+		if len(lb.Buckets) > 0 && (t.BucketIndex < 0 || t.BucketIndex >= len(lb.Buckets)) {
+			return ErrInvalidListBlueprintReference{TaskIndex: i, Kind: "bucket", Reference: int64(t.BucketIndex)}
+		}
+		for _, id := range t.LabelIDs {
+			if !labels[id] {
+				return ErrInvalidListBlueprintReference{TaskIndex: i, Kind: "label", Reference: id}
+			}
+		}
+		for _, r := range t.Relations {
+			if r.TaskIndex < 0 || r.TaskIndex >= len(lb.Tasks) || r.TaskIndex == i {
+				return ErrInvalidListBlueprintReference{TaskIndex: i, Kind: "task", Reference: int64(r.TaskIndex)}
+			}
+			if !r.RelationKind.isValid() {
+				return ErrInvalidRelationKind{Kind: r.RelationKind}
+			}
+		}
+	}
+
+	for _, share := range append(lb.Users, lb.Teams...) {
+		if err := share.Right.isValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the beginning of the day of the earliest date of all tasks, the base for all offsets in a blueprint.
+func getListBlueprintBaseDate(tasks []*Task) (base time.Time) {
+	for _, t := range tasks {
+		dates := append([]time.Time{t.DueDate, t.StartDate, t.EndDate}, t.Reminders...)
+		for _, d := range dates {
+			if !d.IsZero() && (base.IsZero() || d.Before(base)) {
+				base = d
+			}
+		}
+	}
+
+	if base.IsZero() {
+		return
+	}
+
+	base = base.In(config.GetTimeZone())
+	return time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, base.Location())
+}
+
+// Takes everything except the title from the list the blueprint should be created from.
+//nolint:gocyclo
+func (lb *ListBlueprint) copyFromList(s *xorm.Session, a web.Auth) (err error) {
+	l, err := GetListSimpleByID(s, lb.ListID)
+	if err != nil {
+		return err
+	}
+
+	if lb.Title == "" {
+		lb.Title = l.Title
+	}
+	lb.Description = l.Description
+	lb.HexColor = l.HexColor
+
+	buckets := []*Bucket{}
+	err = s.
+		Where("list_id = ?", l.ID).
+		OrderBy("position asc, id asc").
+		Find(&buckets)
+	if err != nil {
+		return err
+	}
+
+	bucketIndexes := make(map[int64]int, len(buckets))
+	lb.Buckets = make([]*ListBlueprintBucket, 0, len(buckets))
+	for i, b := range buckets {
+		bucketIndexes[b.ID] = i
+		lb.Buckets = append(lb.Buckets, &ListBlueprintBucket{
+			Title:        b.Title,
+			Limit:        b.Limit,
+			IsDoneBucket: b.IsDoneBucket,
+		})
+	}
+
+	tasks, _, _, err := getTasksForLists(s, []*List{l}, a, &taskOptions{
+		sortby: []*sortParam{
+			{sortBy: taskPropertyPosition, orderBy: orderAscending},
+			{sortBy: taskPropertyID, orderBy: orderAscending},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	base := getListBlueprintBaseDate(tasks)
+
+	taskIndexes := make(map[int64]int, len(tasks))
+	taskIDs := make([]int64, 0, len(tasks))
+	labels := make(map[int64]bool)
+	lb.Labels = []*ListBlueprintLabel{}
+	lb.Tasks = make([]*ListBlueprintTask, 0, len(tasks))
+	for i, t := range tasks {
+		taskIndexes[t.ID] = i
+		taskIDs = append(taskIDs, t.ID)
+
+		bt := &ListBlueprintTask{
+			Title:           t.Title,
+			Description:     t.Description,
+			Priority:        t.Priority,
+			HexColor:        t.HexColor,
+			BucketIndex:     bucketIndexes[t.BucketID],
+			LabelIDs:        make([]int64, 0, len(t.Labels)),
+			DueDateOffset:   getDateOffset(base, t.DueDate),
+			StartDateOffset: getDateOffset(base, t.StartDate),
+			EndDateOffset:   getDateOffset(base, t.EndDate),
+			ReminderOffsets: make([]int64, 0, len(t.Reminders)),
+			Relations:       []*ListBlueprintTaskRelation{},
+		}
+		for _, label := range t.Labels {
+			bt.LabelIDs = append(bt.LabelIDs, label.ID)
+			if !labels[label.ID] {
+				labels[label.ID] = true
+				lb.Labels = append(lb.Labels, &ListBlueprintLabel{
+					ID:       label.ID,
+					Title:    label.Title,
+					HexColor: label.HexColor,
+				})
+			}
+		}
+		for _, r := range t.Reminders {
+			bt.ReminderOffsets = append(bt.ReminderOffsets, *getDateOffset(base, r))
+		}
+		lb.Tasks = append(lb.Tasks, bt)
+	}
+
+	// Only relations between tasks of the list are kept. Because every relation exists in both directions,
+	// only the one starting at the task which comes first is saved, the other one is created with it.
+	relations := []*TaskRelation{}
+	if len(taskIDs) > 0 {
+		err = s.
+			In("task_id", taskIDs).
+			OrderBy("id asc").
+			Find(&relations)
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range relations {
+		from := taskIndexes[r.TaskID]
+		to, exists := taskIndexes[r.OtherTaskID]
+		if !exists || from > to {
+			continue
+		}
+		lb.Tasks[from].Relations = append(lb.Tasks[from].Relations, &ListBlueprintTaskRelation{
+			TaskIndex:    to,
+			RelationKind: r.RelationKind,
+		})
+	}
+
+	listUsers := []*ListUser{}
+	err = s.Where("list_id = ?", l.ID).Find(&listUsers)
+	if err != nil {
+		return err
+	}
+	lb.Users = make([]*ListBlueprintShare, 0, len(listUsers))
+	for _, lu := range listUsers {
+		lb.Users = append(lb.Users, &ListBlueprintShare{ID: lu.UserID, Right: lu.Right})
+	}
+
+	listTeams := []*TeamList{}
+	err = s.Where("list_id = ?", l.ID).Find(&listTeams)
+	if err != nil {
+		return err
+	}
+	lb.Teams = make([]*ListBlueprintShare, 0, len(listTeams))
+	for _, tl := range listTeams {
+		lb.Teams = append(lb.Teams, &ListBlueprintShare{ID: tl.TeamID, Right: tl.Right})
+	}
+
+	return nil
+}
+
+func addOwnersToListBlueprints(s *xorm.Session, blueprints []*ListBlueprint) error {
+	if len(blueprints) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(blueprints))
+	for _, lb := range blueprints {
+		userIDs = append(userIDs, lb.OwnerID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, lb := range blueprints {
+		lb.Owner = users[lb.OwnerID]
+	}
+
+	return nil
+}
+
+// Create creates a new list blueprint
+// @Summary Create a list blueprint
+// @Description Creates a new list blueprint. Pass a `list_id` to create the blueprint from an existing list, the user needs at least read access to that list.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param blueprint body models.ListBlueprint true "The list blueprint"
+// @Success 201 {object} models.ListBlueprint "The created list blueprint."
+// @Failure 400 {object} web.HTTPError "Invalid list blueprint object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /listblueprints [put]
+func (lb *ListBlueprint) Create(s *xorm.Session, a web.Auth) (err error) {
+	if lb.ListID != 0 {
+		err = lb.copyFromList(s, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	if lb.Title == "" {
+		return ErrListTitleCannotBeEmpty{}
+	}
+
+	err = lb.validate()
+	if err != nil {
+		return err
+	}
+
+	lb.ID = 0
+	lb.OwnerID = a.GetID()
+	_, err = s.Insert(lb)
+	if err != nil {
+		return err
+	}
+
+	return addOwnersToListBlueprints(s, []*ListBlueprint{lb})
+}
+
+// ReadOne returns one list blueprint
+// @Summary Get one list blueprint
+// @Description Returns a list blueprint by its ID.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "List blueprint ID"
+// @Success 200 {object} models.ListBlueprint "The list blueprint."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list blueprint."
+// @Failure 404 {object} web.HTTPError "The list blueprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /listblueprints/{id} [get]
+func (lb *ListBlueprint) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	// The rights check already loaded the blueprint
+	return addOwnersToListBlueprints(s, []*ListBlueprint{lb})
+}
+
+// ReadAll returns all list blueprints of a user
+// @Summary Get all list blueprints
+// @Description Returns all list blueprints the user owns.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search list blueprints by title."
+// @Success 200 {array} models.ListBlueprint "The list blueprints."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /listblueprints [get]
+func (lb *ListBlueprint) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(
+		builder.Eq{"owner_id": a.GetID()},
+		db.ILIKE("title", search),
+	)
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	blueprints := []*ListBlueprint{}
+	query := s.
+		Where(cond).
+		OrderBy("title ASC, id ASC")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&blueprints)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addOwnersToListBlueprints(s, blueprints)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where(cond).
+		Count(&ListBlueprint{})
+	return blueprints, len(blueprints), numberOfTotalItems, err
+}
+
+// Update updates a list blueprint
+// @Summary Update a list blueprint
+// @Description Updates a list blueprint. Lists which were already created from the blueprint are not changed.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "List blueprint ID"
+// @Param blueprint body models.ListBlueprint true "The list blueprint"
+// @Success 200 {object} models.ListBlueprint "The updated list blueprint."
+// @Failure 400 {object} web.HTTPError "Invalid list blueprint object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list blueprint."
+// @Failure 404 {object} web.HTTPError "The list blueprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /listblueprints/{id} [post]
+func (lb *ListBlueprint) Update(s *xorm.Session, a web.Auth) (err error) {
+	if lb.Title == "" {
+		return ErrListTitleCannotBeEmpty{}
+	}
+
+	err = lb.validate()
+	if err != nil {
+		return err
+	}
+
+	original, err := getListBlueprintSimpleByID(s, lb.ID)
+	if err != nil {
+		return err
+	}
+
+	lb.OwnerID = original.OwnerID
+	lb.Created = original.Created
+	_, err = s.
+		Where("id = ?", lb.ID).
+		Cols(
+			"title",
+			"description",
+			"hex_color",
+			"buckets",
+			"labels",
+			"tasks",
+			"users",
+			"teams",
+		).
+		Update(lb)
+	if err != nil {
+		return err
+	}
+
+	return addOwnersToListBlueprints(s, []*ListBlueprint{lb})
+}
+
+// Delete removes a list blueprint
+// @Summary Delete a list blueprint
+// @Description Deletes a list blueprint. Lists which were already created from the blueprint are not deleted.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "List blueprint ID"
+// @Success 200 {object} models.Message "The list blueprint was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list blueprint."
+// @Failure 404 {object} web.HTTPError "The list blueprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /listblueprints/{id} [delete]
+func (lb *ListBlueprint) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = s.
+		Where("id = ?", lb.ID).
+		Delete(&ListBlueprint{})
+	return err
+}
+
+// ListFromBlueprint holds everything needed to create a new list from a blueprint
+type ListFromBlueprint struct {
+	// The id of the blueprint to create the list from
+	BlueprintID int64 `json:"-" param:"listblueprint"`
+	// The namespace the new list should be created in. The user needs write access to it.
+	NamespaceID int64 `json:"namespace_id"`
+	// The title of the new list. Defaults to the title of the blueprint.
+	Title string `json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// All dates of the tasks in the new list are calculated relative to this date. Defaults to the beginning of today.
+	StartDate time.Time `json:"start_date"`
+
+	// The created list
+	List *List `json:"list,omitempty"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// CanCreate checks if a user can create a list from a blueprint
+func (lfb *ListFromBlueprint) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	lb := &ListBlueprint{ID: lfb.BlueprintID}
+	canRead, _, err := lb.CanRead(s, a)
+	if err != nil || !canRead {
+		return canRead, err
+	}
+
+	l := &List{NamespaceID: lfb.NamespaceID}
+	return l.CanCreate(s, a)
+}
+
+// Returns the labels the tasks of the new list should use, keyed by the id of the label in the blueprint.
+// Labels the user has access to are reused, all others are created from the title and color saved in the blueprint.
+func (lb *ListBlueprint) getLabelsForNewList(s *xorm.Session, a web.Auth) (labels map[int64]*Label, err error) {
+	labels = make(map[int64]*Label, len(lb.Labels))
+	for _, bl := range lb.Labels {
+		label, err := getLabelByIDSimple(s, bl.ID)
+		if err != nil && !IsErrLabelDoesNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			has, _, err := label.hasAccessToLabel(s, a)
+			if err != nil {
+				return nil, err
+			}
+			if has {
+				labels[bl.ID] = label
+				continue
+			}
+		}
+
+		label = &Label{
+			Title:    bl.Title,
+			HexColor: bl.HexColor,
+		}
+		err = label.Create(s, a)
+		if err != nil {
+			return nil, err
+		}
+		labels[bl.ID] = label
+	}
+
+	return
+}
+
+// Create creates a new list from a blueprint
+// @Summary Create a list from a blueprint
+// @Description Creates a new list with all buckets, tasks, labels and shares of a blueprint. All dates of the tasks are calculated relative to the start date. Labels the user does not have access to are created from their title and color, users and teams which don't exist anymore are skipped.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "List blueprint ID"
+// @Param list body models.ListFromBlueprint true "The namespace the list should be created in."
+// @Success 201 {object} models.ListFromBlueprint "The created list."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the blueprint or the namespace."
+// @Failure 404 {object} web.HTTPError "The list blueprint does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /listblueprints/{id}/lists [put]
+//nolint:gocyclo
+func (lfb *ListFromBlueprint) Create(s *xorm.Session, a web.Auth) (err error) {
+	lb, err := getListBlueprintSimpleByID(s, lfb.BlueprintID)
+	if err != nil {
+		return err
+	}
+
+	if lfb.StartDate.IsZero() {
+		now := time.Now().In(config.GetTimeZone())
+		lfb.StartDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	if lfb.Title == "" {
+		lfb.Title = lb.Title
+	}
+
+	lfb.List = &List{
+		Title:       lfb.Title,
+		Description: lb.Description,
+		HexColor:    lb.HexColor,
+		NamespaceID: lfb.NamespaceID,
+	}
+	err = CreateList(s, lfb.List, a)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Creating list %d from blueprint %d", lfb.List.ID, lb.ID)
+
+	// Replace the default bucket with the ones from the blueprint
+	bucketIDs := make([]int64, 0, len(lb.Buckets))
+	if len(lb.Buckets) > 0 {
+		_, err = s.Where("list_id = ?", lfb.List.ID).Delete(&Bucket{})
+		if err != nil {
+			return err
+		}
+
+		for _, bb := range lb.Buckets {
+			b := &Bucket{
+				ListID:       lfb.List.ID,
+				Title:        bb.Title,
+				Limit:        bb.Limit,
+				IsDoneBucket: bb.IsDoneBucket,
+			}
+			err = b.Create(s, a)
+			if err != nil {
+				return err
+			}
+			bucketIDs = append(bucketIDs, b.ID)
+		}
+	}
+
+	labels, err := lb.getLabelsForNewList(s, a)
+	if err != nil {
+		return err
+	}
+
+	taskIDs := make([]int64, 0, len(lb.Tasks))
+	for _, bt := range lb.Tasks {
+		t := &Task{
+			Title:       bt.Title,
+			Description: bt.Description,
+			Priority:    bt.Priority,
+			HexColor:    bt.HexColor,
+			ListID:      lfb.List.ID,
+			DueDate:     getDateFromOffset(lfb.StartDate, bt.DueDateOffset),
+			StartDate:   getDateFromOffset(lfb.StartDate, bt.StartDateOffset),
+			EndDate:     getDateFromOffset(lfb.StartDate, bt.EndDateOffset),
+			Reminders:   make([]time.Time, 0, len(bt.ReminderOffsets)),
+		}
+		for _, offset := range bt.ReminderOffsets {
+			offset := offset
+			t.Reminders = append(t.Reminders, getDateFromOffset(lfb.StartDate, &offset))
+		}
+		if len(bucketIDs) > 0 {
+			t.BucketID = bucketIDs[bt.BucketIndex]
+		}
+
+		// Tasks in the done bucket are done, but the bucket limits are only checked when creating the task
+		err = createTask(s, t, a, false)
+		if err != nil {
+			return err
+		}
+		taskIDs = append(taskIDs, t.ID)
+
+		taskLabels := make([]*Label, 0, len(bt.LabelIDs))
+		for _, id := range bt.LabelIDs {
+			taskLabels = append(taskLabels, labels[id])
+		}
+		err = t.updateTaskLabels(s, a, taskLabels)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, bt := range lb.Tasks {
+		for _, r := range bt.Relations {
+			rel := &TaskRelation{
+				TaskID:       taskIDs[i],
+				OtherTaskID:  taskIDs[r.TaskIndex],
+				RelationKind: r.RelationKind,
+			}
+			err = rel.Create(s, a)
+			if err != nil && !IsErrRelationAlreadyExists(err) {
+				return err
+			}
+		}
+	}
+
+	log.Debugf("Created all tasks of blueprint %d in list %d", lb.ID, lfb.List.ID)
+
+	for _, share := range lb.Users {
+		_, err = user.GetUserByID(s, share.ID)
+		if user.IsErrUserDoesNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// The owner of the new list does not need to be shared with
+		if share.ID == lfb.List.OwnerID {
+			continue
+		}
+		_, err = s.Insert(&ListUser{
+			UserID: share.ID,
+			ListID: lfb.List.ID,
+			Right:  share.Right,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, share := range lb.Teams {
+		_, err = GetTeamByID(s, share.ID)
+		if IsErrTeamDoesNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = s.Insert(&TeamList{
+			TeamID: share.ID,
+			ListID: lfb.List.ID,
+			Right:  share.Right,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user has the right to read a list blueprint
+func (lb *ListBlueprint) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	can, err := lb.canDoListBlueprint(s, a)
+	return can, int(RightAdmin), err
+}
+
+// CanDelete checks if a user has the right to delete a list blueprint
+func (lb *ListBlueprint) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return lb.canDoListBlueprint(s, a)
+}
+
+// CanUpdate checks if a user has the right to update a list blueprint
+func (lb *ListBlueprint) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Using the passed struct would override the values we want to update.
+	blueprint := &ListBlueprint{ID: lb.ID}
+	return blueprint.canDoListBlueprint(s, a)
+}
+
+// CanCreate checks if a user has the right to create a list blueprint
+func (lb *ListBlueprint) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	if lb.ListID == 0 {
+		return true, nil
+	}
+
+	l := &List{ID: lb.ListID}
+	can, _, err := l.CanRead(s, a)
+	return can, err
+}
+
+// Only the owner of a list blueprint can do anything with it
+func (lb *ListBlueprint) canDoListBlueprint(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	blueprint, err := getListBlueprintSimpleByID(s, lb.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if blueprint.OwnerID != a.GetID() {
+		return false, nil
+	}
+
+	*lb = *blueprint
+	return true, nil
+}
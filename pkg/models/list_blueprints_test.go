@@ -0,0 +1,378 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListBlueprint_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{
+			Title:   "Lorem",
+			Buckets: []*ListBlueprintBucket{{Title: "Todo"}, {Title: "Done", IsDoneBucket: true}},
+			Labels:  []*ListBlueprintLabel{{ID: 1, Title: "Label #1"}},
+			Tasks: []*ListBlueprintTask{
+				{Title: "First", BucketIndex: 1, LabelIDs: []int64{1}},
+			},
+		}
+		err := lb.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), lb.Owner.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "list_blueprints", map[string]interface{}{
+			"id":       lb.ID,
+			"title":    "Lorem",
+			"owner_id": 1,
+		}, false)
+	})
+	t.Run("from list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{ListID: 1}
+		err := lb.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test1", lb.Title)
+
+		buckets := []*Bucket{}
+		err = s.Where("list_id = ?", 1).Find(&buckets)
+		assert.NoError(t, err)
+		assert.Len(t, lb.Buckets, len(buckets))
+		assert.NotEmpty(t, lb.Tasks)
+		assert.NoError(t, lb.validate())
+		for _, task := range lb.Tasks {
+			for _, r := range task.Relations {
+				assert.NotEqual(t, RelationKind(""), r.RelationKind)
+			}
+		}
+	})
+	t.Run("without title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{}
+		err := lb.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrListTitleCannotBeEmpty(err))
+	})
+	t.Run("invalid bucket reference", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{
+			Title:   "Lorem",
+			Buckets: []*ListBlueprintBucket{{Title: "Todo"}},
+			Tasks:   []*ListBlueprintTask{{Title: "First", BucketIndex: 1}},
+		}
+		err := lb.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidListBlueprintReference(err))
+	})
+	t.Run("invalid label reference", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{
+			Title: "Lorem",
+			Tasks: []*ListBlueprintTask{{Title: "First", LabelIDs: []int64{1}}},
+		}
+		err := lb.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidListBlueprintReference(err))
+	})
+	t.Run("invalid task reference", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{
+			Title: "Lorem",
+			Tasks: []*ListBlueprintTask{
+				{Title: "First", Relations: []*ListBlueprintTaskRelation{{TaskIndex: 0, RelationKind: RelationKindRelated}}},
+			},
+		}
+		err := lb.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidListBlueprintReference(err))
+	})
+	t.Run("multiple done buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{
+			Title:   "Lorem",
+			Buckets: []*ListBlueprintBucket{{Title: "Done", IsDoneBucket: true}, {Title: "Also done", IsDoneBucket: true}},
+		}
+		err := lb.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrListBlueprintHasMultipleDoneBuckets(err))
+	})
+	t.Run("from list without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{ListID: 5}
+		can, err := lb.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{Title: "Lorem"}
+		can, err := lb.CanCreate(s, &LinkSharing{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestListBlueprint_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	lb := &ListBlueprint{}
+	res, _, total, err := lb.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	blueprints := res.([]*ListBlueprint)
+	assert.Len(t, blueprints, 1)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Sprint", blueprints[0].Title)
+	assert.Len(t, blueprints[0].Buckets, 3)
+	assert.Len(t, blueprints[0].Tasks, 2)
+}
+
+func TestListBlueprint_Rights(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{ID: 1}
+		can, _, err := lb.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, "Sprint", lb.Title)
+	})
+	t.Run("other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{ID: 2}
+		can, err := lb.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lb := &ListBlueprint{ID: 9999}
+		_, err := lb.CanDelete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrListBlueprintDoesNotExist(err))
+	})
+}
+
+func TestListBlueprint_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	lb := &ListBlueprint{
+		ID:    1,
+		Title: "Updated",
+	}
+	err := lb.Update(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	updated, err := getListBlueprintSimpleByID(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated", updated.Title)
+	assert.Empty(t, updated.Tasks)
+	assert.Equal(t, int64(1), updated.OwnerID)
+}
+
+func TestListBlueprint_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	lb := &ListBlueprint{ID: 1}
+	err := lb.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "list_blueprints", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestListFromBlueprint_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2022, 10, 3, 0, 0, 0, 0, time.UTC)
+		lfb := &ListFromBlueprint{
+			BlueprintID: 1,
+			NamespaceID: 1,
+			StartDate:   start,
+		}
+		can, err := lfb.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = lfb.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Sprint", lfb.List.Title)
+		assert.Equal(t, "e8e8e8", lfb.List.HexColor)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		buckets := []*Bucket{}
+		err = s.Where("list_id = ?", lfb.List.ID).OrderBy("position asc").Find(&buckets)
+		assert.NoError(t, err)
+		assert.Len(t, buckets, 3)
+		assert.Equal(t, "To Do", buckets[0].Title)
+		assert.Equal(t, int64(3), buckets[1].Limit)
+		assert.True(t, buckets[2].IsDoneBucket)
+
+		tasks, _, _, err := getTasksForLists(s, []*List{lfb.List}, u, &taskOptions{
+			sortby: []*sortParam{{sortBy: taskPropertyID, orderBy: orderAscending}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 2)
+		assert.Equal(t, "Planning", tasks[0].Title)
+		assert.Equal(t, buckets[0].ID, tasks[0].BucketID)
+		assert.True(t, start.Add(time.Hour).Equal(tasks[0].DueDate))
+		assert.True(t, start.Equal(tasks[0].StartDate))
+		assert.True(t, tasks[0].EndDate.IsZero())
+		assert.Len(t, tasks[0].Reminders, 1)
+		assert.True(t, start.Add(30*time.Minute).Equal(tasks[0].Reminders[0]))
+		assert.Len(t, tasks[0].Labels, 1)
+		assert.Equal(t, int64(1), tasks[0].Labels[0].ID)
+		assert.Equal(t, buckets[1].ID, tasks[1].BucketID)
+		assert.True(t, start.Add(14*24*time.Hour).Equal(tasks[1].DueDate))
+
+		// Label 3 is not accessible by user 1, a copy of it has to be created instead
+		assert.Len(t, tasks[1].Labels, 2)
+		for _, l := range tasks[1].Labels {
+			if l.ID != 1 {
+				assert.NotEqual(t, int64(3), l.ID)
+				assert.Equal(t, "Label #3 - other user", l.Title)
+				assert.Equal(t, int64(1), l.CreatedByID)
+			}
+		}
+
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       tasks[0].ID,
+			"other_task_id": tasks[1].ID,
+			"relation_kind": RelationKindPreceeds,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       tasks[1].ID,
+			"other_task_id": tasks[0].ID,
+			"relation_kind": RelationKindFollows,
+		}, false)
+		db.AssertExists(t, "users_lists", map[string]interface{}{
+			"list_id": lfb.List.ID,
+			"user_id": 2,
+			"right":   RightWrite,
+		}, false)
+		db.AssertMissing(t, "users_lists", map[string]interface{}{
+			"list_id": lfb.List.ID,
+			"user_id": 9999,
+		})
+		db.AssertExists(t, "team_lists", map[string]interface{}{
+			"list_id": lfb.List.ID,
+			"team_id": 1,
+			"right":   RightAdmin,
+		}, false)
+	})
+	t.Run("with title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lfb := &ListFromBlueprint{
+			BlueprintID: 1,
+			NamespaceID: 1,
+			Title:       "Sprint 42",
+		}
+		err := lfb.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Sprint 42", lfb.List.Title)
+	})
+	t.Run("no access to the namespace", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lfb := &ListFromBlueprint{
+			BlueprintID: 1,
+			NamespaceID: 2,
+		}
+		can, err := lfb.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no access to the blueprint", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lfb := &ListFromBlueprint{
+			BlueprintID: 2,
+			NamespaceID: 1,
+		}
+		can, err := lfb.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
@@ -0,0 +1,375 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// CustomFieldType defines what kind of values a custom field holds
+type CustomFieldType string
+
+// All valid custom field types
+const (
+	CustomFieldTypeText        CustomFieldType = `text`
+	CustomFieldTypeNumber      CustomFieldType = `number`
+	CustomFieldTypeDate        CustomFieldType = `date`
+	CustomFieldTypeSelect      CustomFieldType = `select`
+	CustomFieldTypeMultiSelect CustomFieldType = `multi_select`
+	CustomFieldTypeUser        CustomFieldType = `user`
+	CustomFieldTypeURL         CustomFieldType = `url`
+)
+
+func (ct CustomFieldType) isValid() bool {
+	return ct == CustomFieldTypeText ||
+		ct == CustomFieldTypeNumber ||
+		ct == CustomFieldTypeDate ||
+		ct == CustomFieldTypeSelect ||
+		ct == CustomFieldTypeMultiSelect ||
+		ct == CustomFieldTypeUser ||
+		ct == CustomFieldTypeURL
+}
+
+func (ct CustomFieldType) hasOptions() bool {
+	return ct == CustomFieldTypeSelect || ct == CustomFieldTypeMultiSelect
+}
+
+// ListCustomField is a custom field defined on a list. Every task in that list can have a value for it.
+type ListCustomField struct {
+	// The unique, numeric id of this custom field.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"customfield"`
+	// The list this custom field belongs to.
+	ListID int64 `xorm:"bigint INDEX not null" json:"list_id" param:"list"`
+	// The title of this custom field.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The type of this custom field. Can be one of `text`, `number`, `date`, `select`, `multi_select`, `user` or `url`. You cannot change the type once the field was created.
+	Type CustomFieldType `xorm:"varchar(50) not null" json:"type"`
+	// The values to choose from for `select` and `multi_select` fields. Removing an option also removes it from all tasks.
+	Options []string `xorm:"JSON null" json:"options"`
+	// The position of this custom field in the list. Fields are sorted by this value, lowest first.
+	Position float64 `xorm:"double null" json:"position"`
+
+	CreatedByID int64 `xorm:"bigint not null" json:"-"`
+	// The user who created this custom field.
+	CreatedBy *user.User `xorm:"-" json:"created_by"`
+
+	// A timestamp when this custom field was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this custom field was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the custom fields table
+func (cf *ListCustomField) TableName() string {
+	return "list_custom_fields"
+}
+
+func getListCustomFieldSimple(s *xorm.Session, cf *ListCustomField) error {
+	exists, err := s.
+		Where("id = ? AND list_id = ?", cf.ID, cf.ListID).
+		NoAutoCondition().
+		Get(cf)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCustomFieldDoesNotExist{
+			ID:     cf.ID,
+			ListID: cf.ListID,
+		}
+	}
+
+	return nil
+}
+
+func getListCustomFieldsByIDs(s *xorm.Session, ids []int64) (fields map[int64]*ListCustomField, err error) {
+	fields = make(map[int64]*ListCustomField)
+	if len(ids) == 0 {
+		return
+	}
+
+	err = s.In("id", ids).Find(&fields)
+	return
+}
+
+func (cf *ListCustomField) validateOptions() error {
+	if !cf.Type.hasOptions() {
+		cf.Options = nil
+		return nil
+	}
+
+	if len(cf.Options) == 0 {
+		return ErrInvalidCustomFieldOptions{Type: cf.Type}
+	}
+
+	seen := make(map[string]bool, len(cf.Options))
+	for _, o := range cf.Options {
+		if o == "" || seen[o] {
+			return ErrInvalidCustomFieldOptions{Type: cf.Type}
+		}
+		seen[o] = true
+	}
+
+	return nil
+}
+
+func (cf *ListCustomField) hasOption(option string) bool {
+	for _, o := range cf.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func addUsersToCustomFields(s *xorm.Session, fields []*ListCustomField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(fields))
+	for _, f := range fields {
+		userIDs = append(userIDs, f.CreatedByID)
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		f.CreatedBy = users[f.CreatedByID]
+	}
+
+	return nil
+}
+
+// Create adds a new custom field to a list
+// @Summary Create a custom field
+// @Description Adds a new custom field to a list. Fields of the type `select` or `multi_select` need at least one option. The user doing this needs to have at least write access to the list.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listID path int true "List ID"
+// @Param field body models.ListCustomField true "The custom field"
+// @Success 201 {object} models.ListCustomField "The created custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{listID}/custom_fields [put]
+func (cf *ListCustomField) Create(s *xorm.Session, a web.Auth) (err error) {
+	if !cf.Type.isValid() {
+		return ErrInvalidCustomFieldType{Type: cf.Type}
+	}
+
+	err = cf.validateOptions()
+	if err != nil {
+		return err
+	}
+
+	cf.CreatedBy, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	cf.ID = 0
+	cf.CreatedByID = cf.CreatedBy.ID
+
+	if cf.Position == 0 {
+		last := &ListCustomField{}
+		_, err = s.
+			Where("list_id = ?", cf.ListID).
+			OrderBy("position DESC").
+			Get(last)
+		if err != nil {
+			return err
+		}
+		cf.Position = last.Position + calculateDefaultPosition(1, 0)
+	}
+
+	_, err = s.Insert(cf)
+	if err != nil {
+		return err
+	}
+
+	return updateListLastUpdated(s, &List{ID: cf.ListID})
+}
+
+// ReadOne returns a single custom field
+// @Summary Get one custom field
+// @Description Returns one custom field of a list. The user doing this needs to have at least read access to the list.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listID path int true "List ID"
+// @Param fieldID path int true "Custom field ID"
+// @Success 200 {object} models.ListCustomField "The custom field."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{listID}/custom_fields/{fieldID} [get]
+func (cf *ListCustomField) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	err = getListCustomFieldSimple(s, cf)
+	if err != nil {
+		return err
+	}
+
+	return addUsersToCustomFields(s, []*ListCustomField{cf})
+}
+
+// ReadAll returns all custom fields of a list
+// @Summary Get all custom fields of a list
+// @Description Returns all custom fields of a list, sorted by their position. The user doing this needs to have at least read access to the list.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listID path int true "List ID"
+// @Success 200 {array} models.ListCustomField "The custom fields"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{listID}/custom_fields [get]
+func (cf *ListCustomField) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := cf.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	fields := []*ListCustomField{}
+	err = s.
+		Where("list_id = ?", cf.ListID).
+		OrderBy("position ASC, id ASC").
+		Find(&fields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addUsersToCustomFields(s, fields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return fields, len(fields), int64(len(fields)), nil
+}
+
+// Update changes a custom field
+// @Summary Update a custom field
+// @Description Changes the title, options or position of a custom field. The type of a field cannot be changed. If options of a `select` or `multi_select` field are removed, they are removed from all tasks as well. The user doing this needs to have at least write access to the list.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listID path int true "List ID"
+// @Param fieldID path int true "Custom field ID"
+// @Param field body models.ListCustomField true "The custom field with updated values"
+// @Success 200 {object} models.ListCustomField "The updated custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{listID}/custom_fields/{fieldID} [post]
+func (cf *ListCustomField) Update(s *xorm.Session, a web.Auth) (err error) {
+	old := &ListCustomField{ID: cf.ID, ListID: cf.ListID}
+	err = getListCustomFieldSimple(s, old)
+	if err != nil {
+		return err
+	}
+
+	cf.Type = old.Type
+	cf.CreatedByID = old.CreatedByID
+	cf.Created = old.Created
+	if cf.Position == 0 {
+		cf.Position = old.Position
+	}
+
+	err = cf.validateOptions()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		ID(cf.ID).
+		Cols("title", "options", "position").
+		Update(cf)
+	if err != nil {
+		return err
+	}
+
+	if cf.Type.hasOptions() {
+		_, err = s.
+			Where(builder.And(
+				builder.Eq{"field_id": cf.ID},
+				builder.NotIn("value_text", cf.Options),
+			)).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = updateListLastUpdated(s, &List{ID: cf.ListID})
+	if err != nil {
+		return err
+	}
+
+	return addUsersToCustomFields(s, []*ListCustomField{cf})
+}
+
+// Delete removes a custom field
+// @Summary Delete a custom field
+// @Description Removes a custom field from a list, together with its values on all tasks. The user doing this needs to have at least write access to the list.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listID path int true "List ID"
+// @Param fieldID path int true "Custom field ID"
+// @Success 200 {object} models.Message "The custom field was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{listID}/custom_fields/{fieldID} [delete]
+func (cf *ListCustomField) Delete(s *xorm.Session, a web.Auth) (err error) {
+	deleted, err := s.
+		Where("id = ? AND list_id = ?", cf.ID, cf.ListID).
+		NoAutoCondition().
+		Delete(&ListCustomField{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCustomFieldDoesNotExist{ID: cf.ID, ListID: cf.ListID}
+	}
+
+	_, err = s.Where("field_id = ?", cf.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	return updateListLastUpdated(s, &List{ID: cf.ListID})
+}
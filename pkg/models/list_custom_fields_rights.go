@@ -0,0 +1,60 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read the custom fields of a list
+func (cf *ListCustomField) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	l := &List{ID: cf.ListID}
+	return l.CanRead(s, a)
+}
+
+// CanCreate checks if a user can add a custom field to a list
+func (cf *ListCustomField) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	l := &List{ID: cf.ListID}
+	return l.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can update a custom field
+func (cf *ListCustomField) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canModifyCustomField(s, a)
+}
+
+// CanDelete checks if a user can delete a custom field
+func (cf *ListCustomField) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canModifyCustomField(s, a)
+}
+
+func (cf *ListCustomField) canModifyCustomField(s *xorm.Session, a web.Auth) (bool, error) {
+	canWrite, err := cf.CanCreate(s, a)
+	if err != nil || !canWrite {
+		return false, err
+	}
+
+	// Makes sure the field actually belongs to the list
+	saved := &ListCustomField{ID: cf.ID, ListID: cf.ListID}
+	err = getListCustomFieldSimple(s, saved)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,266 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCustomField_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ListID: 1,
+			Title:  "Ticket",
+			Type:   CustomFieldTypeURL,
+			// Only select fields have options
+			Options: []string{"foo"},
+		}
+		err := cf.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(393216), cf.Position)
+		assert.Nil(t, cf.Options)
+		assert.Equal(t, int64(1), cf.CreatedBy.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "list_custom_fields", map[string]interface{}{
+			"id":            cf.ID,
+			"list_id":       1,
+			"title":         "Ticket",
+			"type":          "url",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("select", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ListID:  1,
+			Title:   "Size",
+			Type:    CustomFieldTypeSelect,
+			Options: []string{"S", "M", "L"},
+		}
+		err := cf.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		saved := &ListCustomField{ID: cf.ID, ListID: 1}
+		err = getListCustomFieldSimple(s, saved)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"S", "M", "L"}, saved.Options)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ListID: 1,
+			Title:  "Test",
+			Type:   "color",
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldType(err))
+	})
+	t.Run("select without options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ListID: 1,
+			Title:  "Test",
+			Type:   CustomFieldTypeMultiSelect,
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldOptions(err))
+	})
+	t.Run("duplicate options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ListID:  1,
+			Title:   "Test",
+			Type:    CustomFieldTypeSelect,
+			Options: []string{"a", "a"},
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldOptions(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{ListID: 1}
+		can, err := cf.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestListCustomField_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{ListID: 1}
+		result, _, total, err := cf.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		fields := result.([]*ListCustomField)
+		assert.Len(t, fields, 5)
+		assert.Equal(t, int64(5), total)
+		assert.Equal(t, "Customer", fields[0].Title)
+		assert.Equal(t, CustomFieldTypeMultiSelect, fields[2].Type)
+		assert.Equal(t, []string{"production", "staging", "development"}, fields[2].Options)
+		assert.Equal(t, int64(1), fields[0].CreatedBy.ID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{ListID: 1}
+		_, _, _, err := cf.ReadAll(s, &user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestListCustomField_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ID:     2,
+			ListID: 1,
+			Title:  "Estimate in hours",
+			Type:   CustomFieldTypeText,
+		}
+		err := cf.Update(s, u)
+		assert.NoError(t, err)
+		// The type cannot be changed
+		assert.Equal(t, CustomFieldTypeNumber, cf.Type)
+		assert.Equal(t, float64(131072), cf.Position)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "list_custom_fields", map[string]interface{}{
+			"id":    2,
+			"title": "Estimate in hours",
+			"type":  "number",
+		}, false)
+	})
+	t.Run("removing an option removes its values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ID:      3,
+			ListID:  1,
+			Title:   "Environment",
+			Options: []string{"production", "development"},
+		}
+		err := cf.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"id":         3,
+			"value_text": "production",
+		}, false)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"id": 4,
+		})
+	})
+	t.Run("field of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ID:     6,
+			ListID: 1,
+		}
+		can, err := cf.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.False(t, can)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+}
+
+func TestListCustomField_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ID:     1,
+			ListID: 1,
+		}
+		err := cf.Delete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "list_custom_fields", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"field_id": 1,
+		})
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ID:     9999,
+			ListID: 1,
+		}
+		err := cf.Delete(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+}
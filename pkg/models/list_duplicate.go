@@ -19,6 +19,7 @@ package models
 import (
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/web"
 	"xorm.io/xorm"
@@ -107,7 +108,27 @@ func (ld *ListDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 
 	log.Debugf("Duplicated all buckets from list %d into %d", ld.ListID, ld.List.ID)
 
-	err = duplicateTasks(s, doer, ld, bucketMap)
+	// Duplicate custom fields
+	// Old field ID as key, new field as value
+	customFieldMap := make(map[int64]*ListCustomField)
+	customFields := []*ListCustomField{}
+	err = s.Where("list_id = ?", ld.ListID).Find(&customFields)
+	if err != nil {
+		return
+	}
+	for _, cf := range customFields {
+		oldID := cf.ID
+		cf.ID = 0
+		cf.ListID = ld.List.ID
+		if err := cf.Create(s, doer); err != nil {
+			return err
+		}
+		customFieldMap[oldID] = cf
+	}
+
+	log.Debugf("Duplicated all custom fields from list %d into %d", ld.ListID, ld.List.ID)
+
+	err = duplicateTasks(s, doer, ld, bucketMap, customFieldMap)
 	if err != nil {
 		return
 	}
@@ -121,12 +142,8 @@ func (ld *ListDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 		if err := f.LoadFileMetaByID(); err != nil {
 			return err
 		}
-		if err := f.LoadFileByID(); err != nil {
-			return err
-		}
-		defer f.File.Close()
 
-		file, err := files.Create(f.File, f.Name, f.Size, doer)
+		file, err := f.Duplicate(s, doer)
 		if err != nil {
 			return err
 		}
@@ -201,7 +218,7 @@ func (ld *ListDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 	return
 }
 
-func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap map[int64]int64) (err error) {
+func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap map[int64]int64, customFieldMap map[int64]*ListCustomField) (err error) {
 	// Get all tasks + all task details
 	tasks, _, _, err := getTasksForLists(s, []*List{{ID: ld.ListID}}, doer, &taskOptions{})
 	if err != nil {
@@ -223,6 +240,8 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap
 		t.ListID = ld.List.ID
 		t.BucketID = bucketMap[t.BucketID]
 		t.UID = ""
+		// Custom field values are copied later on since they need to point to the new fields
+		t.CustomFields = nil
 		err := createTask(s, t, doer, false)
 		if err != nil {
 			return err
@@ -258,17 +277,16 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap
 			}
 			return err
 		}
-		if err := attachment.File.LoadFileByID(); err != nil {
-			return err
-		}
 
-		err := attachment.NewAttachment(s, attachment.File.File, attachment.File.Name, attachment.File.Size, doer)
+		// The new attachment uses the same content as the old one, it is not copied
+		file, err := attachment.File.Duplicate(s, doer)
 		if err != nil {
 			return err
 		}
 
-		if attachment.File.File != nil {
-			_ = attachment.File.File.Close()
+		err = attachment.create(s, file, doer)
+		if err != nil {
+			return err
 		}
 
 		log.Debugf("Duplicated attachment %d into %d from list %d into %d", oldAttachmentID, attachment.ID, ld.ListID, ld.List.ID)
@@ -316,21 +334,95 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap
 	log.Debugf("Duplicated all assignees from list %d into %d", ld.ListID, ld.List.ID)
 
 	// Comments
+	// Sorted by id so a comment is always created before its replies
 	comments := []*TaskComment{}
-	err = s.In("task_id", oldTaskIDs).Find(&comments)
+	err = s.In("task_id", oldTaskIDs).OrderBy("id asc").Find(&comments)
 	if err != nil {
 		return
 	}
+	commentMap := make(map[int64]int64, len(comments))
+	oldCommentIDs := make([]int64, 0, len(comments))
 	for _, c := range comments {
+		oldID := c.ID
+		oldCommentIDs = append(oldCommentIDs, oldID)
 		c.ID = 0
 		c.TaskID = taskMap[c.TaskID]
+		c.ParentID = commentMap[c.ParentID]
+		c.ThreadID = commentMap[c.ThreadID]
 		if _, err := s.Insert(c); err != nil {
 			return err
 		}
+		commentMap[oldID] = c.ID
+	}
+
+	commentEdits := []*TaskCommentEdit{}
+	err = s.In("comment_id", oldCommentIDs).OrderBy("id asc").Find(&commentEdits)
+	if err != nil {
+		return
+	}
+	for _, e := range commentEdits {
+		e.ID = 0
+		e.CommentID = commentMap[e.CommentID]
+		if _, err := s.Insert(e); err != nil {
+			return err
+		}
 	}
 
 	log.Debugf("Duplicated all comments from list %d into %d", ld.ListID, ld.List.ID)
 
+	// Checklists
+	checklistItems := []*TaskChecklistItem{}
+	err = s.In("task_id", oldTaskIDs).Find(&checklistItems)
+	if err != nil {
+		return
+	}
+	for _, i := range checklistItems {
+		i.ID = 0
+		i.TaskID = taskMap[i.TaskID]
+		// Only keep those assignees who have access to the new list
+		if err := i.checkAssignee(s, &Task{ListID: ld.List.ID}); err != nil {
+			if !IsErrUserDoesNotHaveAccessToList(err) {
+				return err
+			}
+			i.AssigneeID = 0
+		}
+		if _, err := s.Insert(i); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Duplicated all checklists from list %d into %d", ld.ListID, ld.List.ID)
+
+	// Custom field values
+	customFieldValues := []*TaskCustomFieldValue{}
+	err = s.In("task_id", oldTaskIDs).Find(&customFieldValues)
+	if err != nil {
+		return
+	}
+	for _, v := range customFieldValues {
+		field, has := customFieldMap[v.FieldID]
+		if !has {
+			continue
+		}
+		v.ID = 0
+		v.TaskID = taskMap[v.TaskID]
+		v.FieldID = field.ID
+		// Only copy those users who have access to the new list
+		if field.Type == CustomFieldTypeUser {
+			if _, err := field.getValueRows(s, &Task{ID: v.TaskID, ListID: ld.List.ID}, v.ValueNumber); err != nil {
+				if IsErrUserDoesNotHaveAccessToList(err) || user.IsErrUserDoesNotExist(err) {
+					continue
+				}
+				return err
+			}
+		}
+		if _, err := s.Insert(v); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Duplicated all custom field values from list %d into %d", ld.ListID, ld.List.ID)
+
 	// Relations in that list
 	// Low-Effort: Only copy those relations which are between tasks in the same list
 	// because we can do that without a lot of hassle
@@ -45,6 +45,11 @@ func TestListDuplicate(t *testing.T) {
 	assert.True(t, can)
 	err = l.Create(s, u)
 	assert.NoError(t, err)
+	// Duplicated attachments use the content of the original file
+	db.AssertExists(t, "files", map[string]interface{}{
+		"id":      3,
+		"blob_id": 1,
+	}, false)
 	// To make this test 100% useful, it would need to assert a lot more stuff, but it is good enough for now.
 	// Also, we're lacking utility functions to do all needed assertions.
 }
@@ -254,9 +254,17 @@ func TestList_Delete(t *testing.T) {
 	assert.NoError(t, err)
 	err = s.Commit()
 	assert.NoError(t, err)
-	db.AssertMissing(t, "lists", map[string]interface{}{
-		"id": 1,
-	})
+
+	_, err = GetListSimpleByID(s, 1)
+	assert.Error(t, err)
+	assert.True(t, IsErrListDoesNotExist(err))
+
+	// The list and its tasks are moved to the trash together
+	trashed, err := getTrashedList(s, 1)
+	assert.NoError(t, err)
+	task, err := getTrashedTask(s, 1)
+	assert.NoError(t, err)
+	assert.True(t, trashed.Deleted.Equal(task.Deleted))
 }
 
 func TestList_ReadAll(t *testing.T) {
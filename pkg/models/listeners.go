@@ -19,8 +19,10 @@ package models
 import (
 	"encoding/json"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/metrics"
 	"code.vikunja.io/api/pkg/modules/keyvalue"
@@ -35,14 +37,19 @@ import (
 func RegisterListeners() {
 	events.RegisterListener((&ListCreatedEvent{}).Name(), &IncreaseListCounter{})
 	events.RegisterListener((&ListDeletedEvent{}).Name(), &DecreaseListCounter{})
+	events.RegisterListener((&ListRestoredEvent{}).Name(), &IncreaseListCounter{})
 	events.RegisterListener((&NamespaceCreatedEvent{}).Name(), &IncreaseNamespaceCounter{})
 	events.RegisterListener((&NamespaceDeletedEvent{}).Name(), &DecreaseNamespaceCounter{})
+	events.RegisterListener((&NamespaceRestoredEvent{}).Name(), &IncreaseNamespaceCounter{})
 	events.RegisterListener((&TaskCreatedEvent{}).Name(), &IncreaseTaskCounter{})
 	events.RegisterListener((&TaskDeletedEvent{}).Name(), &DecreaseTaskCounter{})
+	events.RegisterListener((&TaskRestoredEvent{}).Name(), &IncreaseTaskCounter{})
 	events.RegisterListener((&TeamDeletedEvent{}).Name(), &DecreaseTeamCounter{})
 	events.RegisterListener((&TeamCreatedEvent{}).Name(), &IncreaseTeamCounter{})
 	events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &SendTaskCommentNotification{})
 	events.RegisterListener((&TaskAssigneeCreatedEvent{}).Name(), &SendTaskAssignedNotification{})
+	events.RegisterListener((&TaskReactionCreatedEvent{}).Name(), &SendTaskReactionNotification{})
+	events.RegisterListener((&TaskAttachmentCreatedEvent{}).Name(), &GenerateTaskAttachmentPreviews{})
 	events.RegisterListener((&TaskDeletedEvent{}).Name(), &SendTaskDeletedNotification{})
 	events.RegisterListener((&ListCreatedEvent{}).Name(), &SendListCreatedNotification{})
 	events.RegisterListener((&TaskAssigneeCreatedEvent{}).Name(), &SubscribeAssigneeToTask{})
@@ -50,6 +57,19 @@ func RegisterListeners() {
 	events.RegisterListener((&TaskCommentUpdatedEvent{}).Name(), &HandleTaskCommentEditMentions{})
 	events.RegisterListener((&TaskCreatedEvent{}).Name(), &HandleTaskCreateMentions{})
 	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &HandleTaskUpdatedMentions{})
+	events.RegisterListener((&TaskCreatedEvent{}).Name(), &RelateMentionedTasks{})
+	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &RelateMentionedTasks{})
+	events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &RelateMentionedTasks{})
+	events.RegisterListener((&TaskCommentUpdatedEvent{}).Name(), &RelateMentionedTasks{})
+	events.RegisterListener((&TaskCreatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskDeletedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskRestoredEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskCommentUpdatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskCommentDeletedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskAttachmentCreatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	events.RegisterListener((&TaskAttachmentDeletedEvent{}).Name(), &UpdateTaskInSearchIndex{})
 	events.RegisterListener((&UserDataExportRequestedEvent{}).Name(), &HandleUserDataExport{})
 }
 
@@ -153,6 +173,7 @@ func (s *SendTaskCommentNotification) Handle(msg *message.Message) (err error) {
 		Doer:      event.Doer,
 		Task:      event.Task,
 		Comment:   event.Comment,
+		Parent:    event.Parent,
 		Mentioned: true,
 	}
 	mentionedUsers, err := notifyMentionedUsers(sess, event.Task, event.Comment.Comment, n)
@@ -180,6 +201,7 @@ func (s *SendTaskCommentNotification) Handle(msg *message.Message) (err error) {
 			Doer:    event.Doer,
 			Task:    event.Task,
 			Comment: event.Comment,
+			Parent:  event.Parent,
 		}
 		err = notifications.Notify(subscriber.User, n)
 		if err != nil {
@@ -216,10 +238,100 @@ func (s *HandleTaskCommentEditMentions) Handle(msg *message.Message) (err error)
 		Comment:   event.Comment,
 		Mentioned: true,
 	}
+
+	if event.Comment.ParentID != 0 {
+		parent := &TaskComment{ID: event.Comment.ParentID, TaskID: event.Task.ID}
+		err = getTaskCommentSimple(sess, parent)
+		if err != nil && !IsErrTaskCommentDoesNotExist(err) {
+			return err
+		}
+		if err == nil {
+			n.Parent = parent
+		}
+	}
+
 	_, err = notifyMentionedUsers(sess, event.Task, event.Comment.Comment, n)
 	return err
 }
 
+// RelateMentionedTasks  represents a listener
+type RelateMentionedTasks struct {
+}
+
+// Name defines the name for the RelateMentionedTasks listener
+func (s *RelateMentionedTasks) Name() string {
+	return "task.mentions.relate"
+}
+
+// Handle is executed when the event RelateMentionedTasks listens on is fired
+func (s *RelateMentionedTasks) Handle(msg *message.Message) (err error) {
+	// This listener handles task and comment events which share the same payload structure.
+	// The previous texts are only set when a task or comment was updated.
+	event := &struct {
+		Task                *Task
+		Comment             *TaskComment
+		Doer                *user.User
+		PreviousDescription string
+		PreviousComment     string
+	}{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	if event.Task == nil {
+		return nil
+	}
+
+	text := event.Task.Description
+	previousText := event.PreviousDescription
+	if event.Comment != nil {
+		text = event.Comment.Comment
+		previousText = event.PreviousComment
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	err = relateMentionedTasks(sess, event.Task, text, previousText, event.Doer)
+	if err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// UpdateTaskInSearchIndex  represents a listener
+type UpdateTaskInSearchIndex struct {
+}
+
+// Name defines the name for the UpdateTaskInSearchIndex listener
+func (s *UpdateTaskInSearchIndex) Name() string {
+	return "task.search.index.update"
+}
+
+// Handle is executed when the event UpdateTaskInSearchIndex listens on is fired
+func (s *UpdateTaskInSearchIndex) Handle(msg *message.Message) (err error) {
+	// All task, comment and attachment events contain the task
+	event := &struct {
+		Task *Task
+	}{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	if event.Task == nil {
+		return nil
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	return updateTaskInSearchIndex(sess, event.Task.ID)
+}
+
 // SendTaskAssignedNotification  represents a listener
 type SendTaskAssignedNotification struct {
 }
@@ -271,6 +383,102 @@ func (s *SendTaskAssignedNotification) Handle(msg *message.Message) (err error)
 	return nil
 }
 
+// SendTaskReactionNotification  represents a listener
+type SendTaskReactionNotification struct {
+}
+
+// Name defines the name for the SendTaskReactionNotification listener
+func (s *SendTaskReactionNotification) Name() string {
+	return "task.reaction.notification.send"
+}
+
+// Handle is executed when the event SendTaskReactionNotification listens on is fired
+func (s *SendTaskReactionNotification) Handle(msg *message.Message) (err error) {
+	if !config.ServiceEnableReactionNotifications.GetBool() {
+		return nil
+	}
+
+	event := &TaskReactionCreatedEvent{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	authorID := event.Task.CreatedByID
+	if event.Comment != nil {
+		authorID = event.Comment.AuthorID
+	}
+
+	// Link shares can't receive notifications and nobody needs to know about their own reactions
+	if authorID <= 0 || authorID == event.Doer.ID {
+		return nil
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	author, err := user.GetUserByID(sess, authorID)
+	if err != nil {
+		if user.IsErrUserDoesNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	log.Debugf("Sending reaction notification to user %d for task %d", author.ID, event.Task.ID)
+
+	n := &TaskReactionNotification{
+		Doer:     event.Doer,
+		Task:     event.Task,
+		Comment:  event.Comment,
+		Reaction: event.Reaction,
+	}
+	return notifications.Notify(author, n)
+}
+
+// GenerateTaskAttachmentPreviews  represents a listener
+type GenerateTaskAttachmentPreviews struct {
+}
+
+// Name defines the name for the GenerateTaskAttachmentPreviews listener
+func (s *GenerateTaskAttachmentPreviews) Name() string {
+	return "task.attachment.previews.generate"
+}
+
+// Handle is executed when the event GenerateTaskAttachmentPreviews listens on is fired
+func (s *GenerateTaskAttachmentPreviews) Handle(msg *message.Message) (err error) {
+	event := &TaskAttachmentCreatedEvent{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	attachment := &TaskAttachment{}
+	exists, err := sess.Where("id = ?", event.Attachment.ID).Get(attachment)
+	if err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+	// The attachment might have been deleted in the meantime
+	if !exists {
+		return nil
+	}
+
+	err = generateTaskAttachmentPreviews(sess, attachment)
+	if err != nil {
+		if files.IsErrFileDoesNotExist(err) {
+			return nil
+		}
+		_ = sess.Rollback()
+		return err
+	}
+
+	return sess.Commit()
+}
+
 // SendTaskDeletedNotification  represents a listener
 type SendTaskDeletedNotification struct {
 }
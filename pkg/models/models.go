@@ -61,6 +61,22 @@ func GetTables() []interface{} {
 		&SavedFilter{},
 		&Subscription{},
 		&Favorite{},
+		&TaskTimeEntry{},
+		&TaskHistoryEntry{},
+		&TaskChecklistItem{},
+		&ListCustomField{},
+		&TaskCustomFieldValue{},
+		&TaskTemplate{},
+		&TaskTemplateUser{},
+		&TaskTemplateTeam{},
+		&ListBlueprint{},
+		&TaskPreviousIdentifier{},
+		&SavedFilterUser{},
+		&SavedFilterTeam{},
+		&SavedFilterTaskBucket{},
+		&TaskCommentEdit{},
+		&Reaction{},
+		&TaskAttachmentPreview{},
 	}
 }
 
@@ -60,6 +60,8 @@ type Namespace struct {
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this namespace was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
+	// A timestamp when this namespace was moved to the trash. Deleted namespaces are not returned anywhere except the trash.
+	Deleted time.Time `xorm:"deleted null INDEX" json:"-"`
 
 	// If set to true, will only return the namespaces, not their lists.
 	NamespacesOnly bool `xorm:"-" json:"-" query:"namespaces_only"`
@@ -454,10 +456,19 @@ func getSavedFilters(s *xorm.Session, doer *user.User) (savedFiltersNamespace *N
 		Lists:     make([]*List, 0, len(savedFilters)),
 	}
 
+	ownerIDs := make([]int64, 0, len(savedFilters))
+	for _, filter := range savedFilters {
+		ownerIDs = append(ownerIDs, filter.OwnerID)
+	}
+	owners, err := user.GetUsersByIDs(s, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, filter := range savedFilters {
 		filterList := filter.toList()
 		filterList.NamespaceID = savedFiltersNamespace.ID
-		filterList.Owner = doer
+		filterList.Owner = owners[filter.OwnerID]
 		savedFiltersNamespace.Lists = append(savedFiltersNamespace.Lists, filterList)
 	}
 
@@ -641,7 +652,7 @@ func CreateNewNamespaceForUser(s *xorm.Session, user *user.User) (err error) {
 
 // Delete deletes a namespace
 // @Summary Deletes a namespace
-// @Description Delets a namespace
+// @Description Delets a namespace. The namespace and all its lists and tasks are moved to the trash and can be restored from there until they are purged.
 // @tags namespace
 // @Produce json
 // @Security JWTKeyAuth
@@ -652,50 +663,68 @@ func CreateNewNamespaceForUser(s *xorm.Session, user *user.User) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /namespaces/{id} [delete]
 func (n *Namespace) Delete(s *xorm.Session, a web.Auth) (err error) {
-	return deleteNamespace(s, n, a, true)
-}
-
-func deleteNamespace(s *xorm.Session, n *Namespace, a web.Auth, withLists bool) (err error) {
 	// Check if the namespace exists
 	_, err = GetNamespaceByID(s, n.ID)
 	if err != nil {
 		return
 	}
 
-	// Delete the namespace
-	_, err = s.ID(n.ID).Delete(&Namespace{})
+	// Move the namespace to the trash
+	deleted := time.Now()
+	n.Deleted = deleted
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		ID(n.ID).
+		Cols("deleted").
+		Update(n)
 	if err != nil {
 		return
 	}
 
-	namespaceDeleted := &NamespaceDeletedEvent{
-		Namespace: n,
-		Doer:      a,
+	// Move all lists with their tasks to the trash as well
+	lists := []*List{}
+	err = s.Where("namespace_id = ?", n.ID).Find(&lists)
+	if err != nil {
+		return
 	}
 
-	if !withLists {
-		return events.Dispatch(namespaceDeleted)
+	for _, list := range lists {
+		err = trashList(s, a, list, deleted)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Delete all lists with their tasks
-	lists, err := GetListsByNamespaceID(s, n.ID, &user.User{})
-	if err != nil {
+	return events.Dispatch(&NamespaceDeletedEvent{
+		Namespace: n,
+		Doer:      a,
+	})
+}
+
+// purgeNamespace removes a namespace from the database, bypassing the trash.
+// If withLists is true, all lists in it are purged as well.
+func purgeNamespace(s *xorm.Session, a web.Auth, n *Namespace, withLists bool) (err error) {
+	_, err = s.Unscoped().ID(n.ID).Delete(&Namespace{})
+	if err != nil || !withLists {
 		return
 	}
 
-	if len(lists) == 0 {
-		return events.Dispatch(namespaceDeleted)
+	lists := []*List{}
+	err = s.Unscoped().Where("namespace_id = ?", n.ID).Find(&lists)
+	if err != nil {
+		return
 	}
 
 	// Looping over all lists to let the list handle properly cleaning up the tasks and everything else associated with it.
 	for _, list := range lists {
-		err = list.Delete(s, a)
+		err = purgeList(s, a, list)
 		if err != nil {
 			return err
 		}
 	}
 
-	return events.Dispatch(namespaceDeleted)
+	return nil
 }
 
 // Update implements the update method via the interface
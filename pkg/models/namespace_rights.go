@@ -72,10 +72,15 @@ func (n *Namespace) checkRight(s *xorm.Session, a web.Auth, rights ...Right) (bo
 		return false, 0, err
 	}
 
-	if a.GetID() == nn.OwnerID ||
-		nn.ID == SharedListsPseudoNamespace.ID ||
-		nn.ID == FavoritesPseudoNamespace.ID ||
-		nn.ID == SavedFiltersPseudoNamespace.ID {
+	return nn.checkRightOnLoadedNamespace(s, a, rights...)
+}
+
+// checkRightOnLoadedNamespace checks the rights of a user on a namespace which was already loaded from the database.
+func (n *Namespace) checkRightOnLoadedNamespace(s *xorm.Session, a web.Auth, rights ...Right) (bool, int, error) {
+	if a.GetID() == n.OwnerID ||
+		n.ID == SharedListsPseudoNamespace.ID ||
+		n.ID == FavoritesPseudoNamespace.ID ||
+		n.ID == SavedFiltersPseudoNamespace.ID {
 		return true, int(RightAdmin), nil
 	}
 
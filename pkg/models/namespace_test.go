@@ -175,9 +175,16 @@ func TestNamespace_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
-		db.AssertMissing(t, "namespaces", map[string]interface{}{
-			"id": 1,
-		})
+		_, err = getNamespaceSimpleByID(s, 1)
+		assert.Error(t, err)
+		assert.True(t, IsErrNamespaceDoesNotExist(err))
+
+		// The namespace and its lists are moved to the trash together
+		trashed, err := getTrashedNamespace(s, 1)
+		assert.NoError(t, err)
+		list, err := getTrashedList(s, 1)
+		assert.NoError(t, err)
+		assert.True(t, trashed.Deleted.Equal(list.Deleted))
 	})
 	t.Run("nonexisting", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
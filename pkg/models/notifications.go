@@ -58,9 +58,11 @@ func (n *ReminderDueNotification) Name() string {
 
 // TaskCommentNotification represents a TaskCommentNotification notification
 type TaskCommentNotification struct {
-	Doer      *user.User   `json:"doer"`
-	Task      *Task        `json:"task"`
-	Comment   *TaskComment `json:"comment"`
+	Doer    *user.User   `json:"doer"`
+	Task    *Task        `json:"task"`
+	Comment *TaskComment `json:"comment"`
+	// The comment this comment replies to, if any.
+	Parent    *TaskComment `json:"parent,omitempty"`
 	Mentioned bool         `json:"mentioned"`
 }
 
@@ -87,6 +89,14 @@ func (n *TaskCommentNotification) ToMail() *notifications.Mail {
 		mail.Line(lines.Text())
 	}
 
+	if n.Parent != nil && !n.Parent.IsDeleted {
+		mail.Line("In reply to:")
+		lines = bufio.NewScanner(strings.NewReader(n.Parent.Comment))
+		for lines.Scan() {
+			mail.Line("> " + lines.Text())
+		}
+	}
+
 	return mail.
 		Action("View Task", n.Task.GetFrontendURL())
 }
@@ -101,6 +111,44 @@ func (n *TaskCommentNotification) Name() string {
 	return "task.comment"
 }
 
+// TaskReactionNotification represents a TaskReactionNotification notification
+type TaskReactionNotification struct {
+	Doer *user.User `json:"doer"`
+	Task *Task      `json:"task"`
+	// The comment the reaction belongs to, if any.
+	Comment  *TaskComment `json:"comment,omitempty"`
+	Reaction *Reaction    `json:"reaction"`
+}
+
+// ToMail returns the mail notification for TaskReactionNotification
+func (n *TaskReactionNotification) ToMail() *notifications.Mail {
+	mail := notifications.NewMail().
+		Subject(n.Doer.GetName() + ` reacted to "` + n.Task.Title + `"`)
+
+	if n.Comment == nil {
+		mail.Line(n.Doer.GetName() + " reacted with " + n.Reaction.Value + ` to your task "` + n.Task.Title + `".`)
+		return mail.Action("View Task", n.Task.GetFrontendURL())
+	}
+
+	mail.Line(n.Doer.GetName() + " reacted with " + n.Reaction.Value + " to your comment:")
+	lines := bufio.NewScanner(strings.NewReader(n.Comment.Comment))
+	for lines.Scan() {
+		mail.Line("> " + lines.Text())
+	}
+
+	return mail.Action("View Task", n.Task.GetFrontendURL())
+}
+
+// ToDB returns the TaskReactionNotification notification in a format which can be saved in the db
+func (n *TaskReactionNotification) ToDB() interface{} {
+	return n
+}
+
+// Name returns the name of the notification
+func (n *TaskReactionNotification) Name() string {
+	return "task.reaction"
+}
+
 // TaskAssignedNotification represents a TaskAssignedNotification notification
 type TaskAssignedNotification struct {
 	Doer     *user.User `json:"doer"`
@@ -0,0 +1,294 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ReactionKind represents the kind of entities users can react to
+type ReactionKind int
+
+const (
+	ReactionKindTask ReactionKind = iota
+	ReactionKindComment
+)
+
+// Reaction represents a reaction of a user to a task or a task comment
+type Reaction struct {
+	// The unique, numeric id of this reaction.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+
+	// The user who reacted. Link shares are saved with their negative id, the same way as comment authors.
+	UserID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The user who reacted. Link share users show up with the name of the link share.
+	User *user.User `xorm:"-" json:"user"`
+
+	EntityID   int64        `xorm:"bigint not null INDEX" json:"-"`
+	EntityKind ReactionKind `xorm:"int not null INDEX" json:"-"`
+
+	// The actual reaction. This can be any valid utf character or text, up to a length of 20.
+	Value string `xorm:"varchar(20) not null INDEX" json:"value" valid:"required,runelength(1|20)" minLength:"1" maxLength:"20"`
+
+	// The task the reaction belongs to, either directly or through a comment.
+	TaskID int64 `xorm:"-" json:"-" param:"task"`
+	// If set, the reaction belongs to this comment instead of the task.
+	CommentID int64 `xorm:"-" json:"-" param:"commentid"`
+
+	// A timestamp when this reaction was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the reactions table
+func (*Reaction) TableName() string {
+	return "reactions"
+}
+
+// ReactionSummary holds all reactions of one kind to a task or comment
+type ReactionSummary struct {
+	// The reaction, usually an emoji.
+	Value string `json:"value"`
+	// How many users reacted this way.
+	Count int `json:"count"`
+	// All users who reacted this way, in the order they reacted.
+	Users []*user.User `json:"users"`
+}
+
+// Sets the entity the reaction belongs to from the ids in the url.
+func (r *Reaction) setEntity() {
+	if r.CommentID != 0 {
+		r.EntityID = r.CommentID
+		r.EntityKind = ReactionKindComment
+		return
+	}
+
+	r.EntityID = r.TaskID
+	r.EntityKind = ReactionKindTask
+}
+
+// ReadAll returns all reactions to a task or comment
+// @Summary Get all reactions
+// @Description Returns all reactions to a task or task comment, grouped by their value with the users who reacted. The user doing this needs to have at least read access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param commentID path int true "Comment ID"
+// @Success 200 {array} models.ReactionSummary "The reactions, most used first."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The comment does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/reactions [get]
+// @Router /tasks/{taskID}/comments/{commentID}/reactions [get]
+func (r *Reaction) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := r.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	r.setEntity()
+	reactions, err := getReactionSummaries(s, []int64{r.EntityID}, r.EntityKind)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	summaries := reactions[r.EntityID]
+	if summaries == nil {
+		summaries = []*ReactionSummary{}
+	}
+	return summaries, len(summaries), int64(len(summaries)), nil
+}
+
+// Create adds a reaction to a task or comment
+// @Summary Add a reaction
+// @Description Adds a reaction to a task or task comment. Adding the same reaction twice does nothing. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param commentID path int true "Comment ID"
+// @Param reaction body models.Reaction true "The reaction."
+// @Success 201 {object} models.Reaction "The created reaction."
+// @Failure 400 {object} web.HTTPError "Invalid reaction provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The comment does not exist."
+// @Failure 412 {object} web.HTTPError "The comment was deleted."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/reactions [put]
+// @Router /tasks/{taskID}/comments/{commentID}/reactions [put]
+func (r *Reaction) Create(s *xorm.Session, a web.Auth) (err error) {
+	r.setEntity()
+
+	u, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+	r.UserID = u.ID
+	r.User = u
+
+	existing := &Reaction{}
+	exists, err := s.
+		Where("user_id = ? AND entity_id = ? AND entity_kind = ? AND value = ?", r.UserID, r.EntityID, r.EntityKind, r.Value).
+		Get(existing)
+	if err != nil {
+		return err
+	}
+	if exists {
+		r.ID = existing.ID
+		r.Created = existing.Created
+		return nil
+	}
+
+	r.ID = 0
+	_, err = s.Insert(r)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, r.TaskID)
+	if err != nil {
+		return err
+	}
+
+	var comment *TaskComment
+	if r.EntityKind == ReactionKindComment {
+		comment, err = getTaskCommentByID(s, r.CommentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return events.Dispatch(&TaskReactionCreatedEvent{
+		Task:     &task,
+		Comment:  comment,
+		Reaction: r,
+		Doer:     u,
+	})
+}
+
+// Delete removes the reaction of the current user from a task or comment
+// @Summary Remove a reaction
+// @Description Removes the reaction of the current user with the given value from a task or task comment. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param commentID path int true "Comment ID"
+// @Param reaction body models.Reaction true "The reaction to remove."
+// @Success 200 {object} models.Message "The reaction was successfully removed."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The comment does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/reactions/delete [post]
+// @Router /tasks/{taskID}/comments/{commentID}/reactions/delete [post]
+func (r *Reaction) Delete(s *xorm.Session, a web.Auth) (err error) {
+	r.setEntity()
+
+	u, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("user_id = ? AND entity_id = ? AND entity_kind = ? AND value = ?", u.ID, r.EntityID, r.EntityKind, r.Value).
+		Delete(&Reaction{})
+	return err
+}
+
+// Returns the reactions to all entities of one kind, grouped by their value and keyed by the entity id.
+func getReactionSummaries(s *xorm.Session, entityIDs []int64, kind ReactionKind) (summaries map[int64][]*ReactionSummary, err error) {
+	summaries = make(map[int64][]*ReactionSummary, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return
+	}
+
+	reactions := []*Reaction{}
+	err = s.
+		Where(builder.And(
+			builder.In("entity_id", entityIDs),
+			builder.Eq{"entity_kind": kind},
+		)).
+		OrderBy("id asc").
+		Find(&reactions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reactions) == 0 {
+		return
+	}
+
+	userIDs := make([]int64, 0, len(reactions))
+	for _, r := range reactions {
+		userIDs = append(userIDs, r.UserID)
+	}
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range reactions {
+		var summary *ReactionSummary
+		for _, existing := range summaries[r.EntityID] {
+			if existing.Value == r.Value {
+				summary = existing
+				break
+			}
+		}
+		if summary == nil {
+			summary = &ReactionSummary{Value: r.Value, Users: []*user.User{}}
+			summaries[r.EntityID] = append(summaries[r.EntityID], summary)
+		}
+
+		u, has := users[r.UserID]
+		if !has {
+			continue
+		}
+		summary.Users = append(summary.Users, u)
+		summary.Count++
+	}
+
+	// The most used reactions come first, reactions with the same count stay in the order they were first used.
+	for _, entitySummaries := range summaries {
+		sort.SliceStable(entitySummaries, func(i, j int) bool {
+			return entitySummaries[i].Count > entitySummaries[j].Count
+		})
+	}
+
+	return
+}
+
+func deleteReactions(s *xorm.Session, cond builder.Cond) error {
+	_, err := s.Where(cond).Delete(&Reaction{})
+	return err
+}
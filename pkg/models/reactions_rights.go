@@ -0,0 +1,69 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can see the reactions to a task or comment
+func (r *Reaction) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	_, err := r.getComment(s)
+	if err != nil {
+		return false, 0, err
+	}
+
+	t := &Task{ID: r.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can react to a task or comment
+func (r *Reaction) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	comment, err := r.getComment(s)
+	if err != nil {
+		return false, err
+	}
+	if comment != nil && comment.IsDeleted {
+		return false, ErrTaskCommentIsDeleted{ID: comment.ID, TaskID: comment.TaskID}
+	}
+
+	t := &Task{ID: r.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// CanDelete checks if a user can remove their reaction from a task or comment
+func (r *Reaction) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	_, err := r.getComment(s)
+	if err != nil {
+		return false, err
+	}
+
+	t := &Task{ID: r.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// Makes sure the comment of a reaction belongs to its task. Returns nil if the reaction belongs to a task.
+func (r *Reaction) getComment(s *xorm.Session) (*TaskComment, error) {
+	if r.CommentID == 0 {
+		return nil, nil
+	}
+
+	comment := &TaskComment{ID: r.CommentID, TaskID: r.TaskID}
+	err := getTaskCommentSimple(s, comment)
+	return comment, err
+}
@@ -0,0 +1,270 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReaction_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1}
+		result, _, _, err := r.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		summaries := result.([]*ReactionSummary)
+		assert.Len(t, summaries, 1)
+		assert.Equal(t, "👋", summaries[0].Value)
+		assert.Equal(t, 1, summaries[0].Count)
+		assert.Equal(t, int64(1), summaries[0].Users[0].ID)
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, CommentID: 1}
+		result, _, _, err := r.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		summaries := result.([]*ReactionSummary)
+		assert.Len(t, summaries, 2)
+		assert.Equal(t, "👍", summaries[0].Value)
+		assert.Equal(t, 2, summaries[0].Count)
+		assert.Equal(t, int64(2), summaries[0].Users[0].ID)
+		// Link share users show up with the name of the share
+		assert.Equal(t, int64(-2), summaries[0].Users[1].ID)
+		assert.Equal(t, "Link Share", summaries[0].Users[1].Name)
+		assert.Equal(t, "🎉", summaries[1].Value)
+		assert.Equal(t, 1, summaries[1].Count)
+	})
+	t.Run("comment of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 2, CommentID: 1}
+		_, _, err := r.CanRead(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentDoesNotExist(err))
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1}
+		_, _, _, err := r.ReadAll(s, &user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("returned with tasks and comments", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := task.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.Reactions, 1)
+
+		tc := &TaskComment{ID: 1, TaskID: 1}
+		err = tc.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, tc.Reactions, 2)
+	})
+}
+
+func TestReaction_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, Value: "🚀"}
+		can, err := r.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = r.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		events.AssertDispatched(t, &TaskReactionCreatedEvent{})
+
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"user_id":     1,
+			"entity_id":   1,
+			"entity_kind": ReactionKindTask,
+			"value":       "🚀",
+		}, false)
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, CommentID: 1, Value: "👍"}
+		err := r.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"user_id":     1,
+			"entity_id":   1,
+			"entity_kind": ReactionKindComment,
+			"value":       "👍",
+		}, false)
+	})
+	t.Run("twice", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, Value: "👋"}
+		err := r.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r.ID)
+
+		count, err := s.Where("entity_id = ? AND entity_kind = ?", 1, ReactionKindTask).Count(&Reaction{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share := &LinkSharing{ID: 3, ListID: 3, Right: RightAdmin, SharedByID: 1}
+		r := &Reaction{TaskID: 32, Value: "👀"}
+		can, err := r.CanCreate(s, share)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = r.Create(s, share)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-3), r.User.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"user_id":   -3,
+			"entity_id": 32,
+			"value":     "👀",
+		}, false)
+	})
+	t.Run("read only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share := &LinkSharing{ID: 1, ListID: 1, Right: RightRead, SharedByID: 1}
+		r := &Reaction{TaskID: 1, Value: "👀"}
+		can, err := r.CanCreate(s, share)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("deleted comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&TaskComment{ID: 1, TaskID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		r := &Reaction{TaskID: 1, CommentID: 1, Value: "👀"}
+		_, err = r.CanCreate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentIsDeleted(err))
+
+		// The reactions of the comment are removed with it
+		count, err := s.Where("entity_id = ? AND entity_kind = ?", 1, ReactionKindComment).Count(&Reaction{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+	t.Run("should notify the author", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		doer := &user.User{ID: 2, Username: "user2"}
+		r := &Reaction{ID: 5, TaskID: 1, Value: "👍", User: doer}
+
+		events.TestListener(t, &TaskReactionCreatedEvent{
+			Task:     &task,
+			Reaction: r,
+			Doer:     doer,
+		}, &SendTaskReactionNotification{})
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"notifiable_id": 1,
+			"name":          (&TaskReactionNotification{}).Name(),
+		}, false)
+	})
+}
+
+func TestReaction_Delete(t *testing.T) {
+	t.Run("own reaction", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		r := &Reaction{TaskID: 1, CommentID: 1, Value: "🎉"}
+		can, err := r.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = r.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "reactions", map[string]interface{}{
+			"id": 4,
+		})
+	})
+	t.Run("only removes the own reaction", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		r := &Reaction{TaskID: 1, CommentID: 1, Value: "👍"}
+		err := r.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"id": 2,
+		}, false)
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"id": 3,
+		}, false)
+	})
+}
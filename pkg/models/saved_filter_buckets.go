@@ -0,0 +1,305 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// SavedFilterTaskBucket holds the kanban bucket a task was moved into on a saved filter.
+// The bucket of the task in its own list is not changed by this.
+type SavedFilterTaskBucket struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	// The saved filter id.
+	FilterID int64 `xorm:"bigint not null INDEX" json:"-" param:"filter"`
+	// The id of the saved filter bucket the task was moved into.
+	BucketID int64 `xorm:"bigint not null INDEX" json:"bucket_id" param:"bucket"`
+	// The id of the task.
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id"`
+	// The position of the task in the saved filter bucket. See the tasks.position property on how to use this.
+	Position float64 `xorm:"double null" json:"position"`
+
+	// A timestamp when the task was first moved into a bucket of this filter. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when the task was last moved on this filter. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for saved filter task buckets
+func (SavedFilterTaskBucket) TableName() string {
+	return "saved_filter_task_buckets"
+}
+
+// Returns the buckets of a saved filter with all tasks matching the filter in them.
+// Tasks which were never moved on the filter are put into its first bucket.
+func (b *Bucket) readAllForSavedFilter(s *xorm.Session, auth web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	sf := &SavedFilter{ID: getSavedFilterIDFromListID(b.ListID)}
+	can, _, err := sf.CanRead(s, auth)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	// The buckets are only known after all tasks matching the filter were fetched,
+	// that's why the pagination per bucket is done after sorting them into their buckets.
+	buckets, err := getSavedFilterBucketsWithTasks(s, sf, auth, search)
+	if err != nil || len(buckets) == 0 {
+		return buckets, 0, 0, err
+	}
+
+	userIDs := make([]int64, 0, len(buckets))
+	for _, bb := range buckets {
+		userIDs = append(userIDs, bb.CreatedByID)
+	}
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return
+	}
+	for _, bb := range buckets {
+		bb.CreatedBy = users[bb.CreatedByID]
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	taskMap := make(map[int64]*Task)
+	for _, bb := range buckets {
+		if limit > 0 {
+			from := start
+			if from > len(bb.Tasks) {
+				from = len(bb.Tasks)
+			}
+			to := from + limit
+			if to > len(bb.Tasks) {
+				to = len(bb.Tasks)
+			}
+			bb.Tasks = bb.Tasks[from:to]
+		}
+
+		for _, t := range bb.Tasks {
+			taskMap[t.ID] = t
+		}
+	}
+
+	err = addMoreInfoToTasks(s, taskMap, auth)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return buckets, len(buckets), int64(len(buckets)), nil
+}
+
+// Returns the buckets of a saved filter ordered by their position, each with all tasks matching the filter
+// which are shown in it, sorted by their position in the bucket. The filter must be loaded.
+// Tasks which were never moved on the filter or whose bucket was deleted are shown in the first bucket.
+func getSavedFilterBucketsWithTasks(s *xorm.Session, sf *SavedFilter, auth web.Auth, search string) (buckets []*Bucket, err error) {
+	buckets = []*Bucket{}
+	err = s.
+		Where("list_id = ?", getListIDFromSavedFilterID(sf.ID)).
+		OrderBy("position").
+		Find(&buckets)
+	if err != nil || len(buckets) == 0 {
+		return buckets, err
+	}
+
+	bucketMap := make(map[int64]*Bucket, len(buckets))
+	defaultBucketID := buckets[0].ID
+	for _, bb := range buckets {
+		bucketMap[bb.ID] = bb
+		if bb.ID < defaultBucketID {
+			defaultBucketID = bb.ID
+		}
+	}
+
+	opts, err := getTaskFilterOptsFromCollection(sf.getTaskCollection())
+	if err != nil {
+		return nil, err
+	}
+	opts.sortby = []*sortParam{
+		{
+			orderBy: orderAscending,
+			sortBy:  taskPropertyKanbanPosition,
+		},
+	}
+	opts.search = search
+
+	lists, err := sf.getListsForTasks(s, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, _, _, err := getRawTasksForLists(s, lists, auth, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	taskBuckets := []*SavedFilterTaskBucket{}
+	err = s.Where("filter_id = ?", sf.ID).Find(&taskBuckets)
+	if err != nil {
+		return nil, err
+	}
+	taskBucketMap := make(map[int64]*SavedFilterTaskBucket, len(taskBuckets))
+	for _, tb := range taskBuckets {
+		taskBucketMap[tb.TaskID] = tb
+	}
+
+	// The kanban position of a task is replaced with its position on the filter so clients
+	// can use it the same way as on list buckets.
+	for _, t := range tasks {
+		bucketID := defaultBucketID
+		if tb, has := taskBucketMap[t.ID]; has {
+			if _, exists := bucketMap[tb.BucketID]; exists {
+				bucketID = tb.BucketID
+				t.KanbanPosition = tb.Position
+			}
+		}
+		bucketMap[bucketID].Tasks = append(bucketMap[bucketID].Tasks, t)
+	}
+
+	for _, bb := range buckets {
+		sort.SliceStable(bb.Tasks, func(i, j int) bool {
+			return bb.Tasks[i].KanbanPosition < bb.Tasks[j].KanbanPosition
+		})
+	}
+
+	return buckets, nil
+}
+
+// Returns the bucket of a saved filter a task is shown in, or nil if the task does not match the filter.
+func getSavedFilterBucketOfTask(buckets []*Bucket, taskID int64) *Bucket {
+	for _, bb := range buckets {
+		for _, t := range bb.Tasks {
+			if t.ID == taskID {
+				return bb
+			}
+		}
+	}
+	return nil
+}
+
+// Update moves a task into a bucket of a saved filter
+// @Summary Move a task into a saved filter bucket
+// @Description Moves a task into a kanban bucket of a saved filter or changes its position in that bucket. The bucket of the task in its own list is not changed.
+// @tags filter
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param filterID path int true "Filter ID"
+// @Param bucketID path int true "Bucket ID"
+// @Param task body models.SavedFilterTaskBucket true "The task and its position in the bucket"
+// @Success 200 {object} models.SavedFilterTaskBucket "The task in its new bucket."
+// @Failure 400 {object} web.HTTPError "The bucket does not belong to that saved filter."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the saved filter or cannot read the task."
+// @Failure 404 {object} web.HTTPError "The bucket or task does not exist."
+// @Failure 412 {object} web.HTTPError "The bucket already exceeded its limit."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{filterID}/buckets/{bucketID}/tasks [post]
+func (tb *SavedFilterTaskBucket) Update(s *xorm.Session, a web.Auth) (err error) {
+	listID := getListIDFromSavedFilterID(tb.FilterID)
+	bucket, err := getBucketByID(s, tb.BucketID)
+	if err != nil {
+		return err
+	}
+	if bucket.ListID != listID {
+		return ErrBucketDoesNotBelongToList{BucketID: tb.BucketID, ListID: listID}
+	}
+
+	existing := &SavedFilterTaskBucket{}
+	exists, err := s.
+		Where("filter_id = ? AND task_id = ?", tb.FilterID, tb.TaskID).
+		Get(existing)
+	if err != nil {
+		return err
+	}
+
+	// Only check the bucket limit if the task is being moved between buckets, allow reordering the task within a bucket.
+	// The limit applies to the tasks actually shown in the bucket, which changes whenever tasks match the filter or not.
+	if bucket.Limit > 0 {
+		sf, err := getSavedFilterSimpleByID(s, tb.FilterID)
+		if err != nil {
+			return err
+		}
+		buckets, err := getSavedFilterBucketsWithTasks(s, sf, a, "")
+		if err != nil {
+			return err
+		}
+
+		current := getSavedFilterBucketOfTask(buckets, tb.TaskID)
+		if current == nil || current.ID != bucket.ID {
+			for _, bb := range buckets {
+				if bb.ID == bucket.ID && int64(len(bb.Tasks)) >= bucket.Limit {
+					return ErrBucketLimitExceeded{TaskID: tb.TaskID, BucketID: bucket.ID, Limit: bucket.Limit}
+				}
+			}
+		}
+	}
+
+	tb.Position = calculateDefaultPosition(tb.TaskID, tb.Position)
+
+	if !exists {
+		tb.ID = 0
+		_, err = s.Insert(tb)
+		return err
+	}
+
+	tb.ID = existing.ID
+	tb.Created = existing.Created
+	_, err = s.
+		Where("id = ?", tb.ID).
+		Cols("bucket_id", "position").
+		Update(tb)
+	return err
+}
+
+// CanUpdate checks if a user can move a task into a bucket of a saved filter.
+// This needs write access to the filter and the task needs to be one of the tasks shown on the filter.
+func (tb *SavedFilterTaskBucket) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	can, err := hasSavedFilterRight(s, tb.FilterID, a, RightWrite)
+	if err != nil || !can {
+		return false, err
+	}
+
+	// Link shares see the tasks of the user who shared the filter
+	var taskAuth = a
+	if share, is := a.(*LinkSharing); is {
+		taskAuth = &user.User{ID: share.SharedByID}
+	}
+
+	t := &Task{ID: tb.TaskID}
+	can, _, err = t.CanRead(s, taskAuth)
+	if err != nil || !can {
+		return false, err
+	}
+
+	sf, err := getSavedFilterSimpleByID(s, tb.FilterID)
+	if err != nil {
+		return false, err
+	}
+	buckets, err := getSavedFilterBucketsWithTasks(s, sf, a, "")
+	if err != nil {
+		return false, err
+	}
+	return getSavedFilterBucketOfTask(buckets, tb.TaskID) != nil, nil
+}
@@ -0,0 +1,263 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket_ReadAllForSavedFilter(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{ListID: getListIDFromSavedFilterID(1)}
+		bucketsInterface, _, _, err := b.ReadAll(s, &user.User{ID: 1}, "", 0, 0)
+		assert.NoError(t, err)
+
+		buckets, is := bucketsInterface.([]*Bucket)
+		assert.True(t, is)
+		assert.Len(t, buckets, 2)
+		assert.Equal(t, int64(36), buckets[0].ID)
+		assert.Equal(t, int64(37), buckets[1].ID)
+
+		// Task 5 was moved into bucket 37 on the filter but is still in bucket 2 in its list
+		assert.Len(t, buckets[1].Tasks, 1)
+		assert.Equal(t, int64(5), buckets[1].Tasks[0].ID)
+		assert.Equal(t, int64(2), buckets[1].Tasks[0].BucketID)
+		assert.NotEmpty(t, buckets[0].Tasks)
+		for _, task := range buckets[0].Tasks {
+			assert.NotEqual(t, int64(5), task.ID)
+		}
+	})
+	t.Run("shared read only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{ListID: getListIDFromSavedFilterID(1)}
+		bucketsInterface, _, _, err := b.ReadAll(s, &user.User{ID: 3}, "", 0, 0)
+		assert.NoError(t, err)
+		assert.Len(t, bucketsInterface, 2)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{ListID: getListIDFromSavedFilterID(1)}
+		_, _, _, err := b.ReadAll(s, &user.User{ID: 2}, "", 0, 0)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestBucket_SavedFilterRights(t *testing.T) {
+	t.Run("owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{ListID: getListIDFromSavedFilterID(1)}
+		can, err := b.CanCreate(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("shared admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{ID: 36}
+		can, err := b.CanUpdate(s, &user.User{ID: 9})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("shared read only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{ListID: getListIDFromSavedFilterID(1)}
+		can, err := b.CanCreate(s, &user.User{ID: 3})
+		assert.NoError(t, err)
+		assert.False(t, can)
+
+		b = &Bucket{ID: 36}
+		can, err = b.CanDelete(s, &user.User{ID: 3})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestBucket_DeleteForSavedFilter(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	b := &Bucket{ID: 37, ListID: getListIDFromSavedFilterID(1)}
+	err := b.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "buckets", map[string]interface{}{
+		"id": 37,
+	})
+	db.AssertMissing(t, "saved_filter_task_buckets", map[string]interface{}{
+		"bucket_id": 37,
+	})
+	db.AssertExists(t, "tasks", map[string]interface{}{
+		"id":        5,
+		"bucket_id": 2,
+	}, false)
+}
+
+func TestSavedFilterTaskBucket_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("move task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 36, TaskID: 6}
+		err := tb.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "saved_filter_task_buckets", map[string]interface{}{
+			"filter_id": 1,
+			"bucket_id": 36,
+			"task_id":   6,
+		}, false)
+		// The bucket of the task in its list stays the same
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":        6,
+			"bucket_id": 3,
+		}, false)
+	})
+	t.Run("move task again", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 36, TaskID: 5}
+		err := tb.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "saved_filter_task_buckets", map[string]interface{}{
+			"id":        1,
+			"bucket_id": 36,
+			"task_id":   5,
+		}, false)
+		db.AssertMissing(t, "saved_filter_task_buckets", map[string]interface{}{
+			"bucket_id": 37,
+		})
+	})
+	t.Run("reorder in full bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 37, TaskID: 5, Position: 42}
+		err := tb.Update(s, u)
+		assert.NoError(t, err)
+	})
+	t.Run("bucket limit exceeded", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 37, TaskID: 6}
+		err := tb.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrBucketLimitExceeded(err))
+	})
+	t.Run("bucket limit only counts tasks matching the filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Task 5 is still in bucket 37 on the filter, but does not match the filter anymore
+		_, err := s.ID(5).Cols("due_date").Update(&Task{DueDate: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)})
+		assert.NoError(t, err)
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 37, TaskID: 6}
+		err = tb.Update(s, u)
+		assert.NoError(t, err)
+	})
+	t.Run("bucket limit of the default bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Tasks which were never moved on the filter are shown in the first bucket without having a row for it
+		sf, err := getSavedFilterSimpleByID(s, 1)
+		assert.NoError(t, err)
+		buckets, err := getSavedFilterBucketsWithTasks(s, sf, u, "")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, buckets[0].Tasks)
+		_, err = s.ID(36).Cols("limit").Update(&Bucket{Limit: int64(len(buckets[0].Tasks))})
+		assert.NoError(t, err)
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 36, TaskID: 5}
+		err = tb.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrBucketLimitExceeded(err))
+	})
+	t.Run("bucket of a list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 1, TaskID: 6}
+		err := tb.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrBucketDoesNotBelongToList(err))
+	})
+	t.Run("rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &SavedFilterTaskBucket{FilterID: 1, BucketID: 36, TaskID: 6}
+		can, err := tb.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		// Read only share
+		can, err = tb.CanUpdate(s, &user.User{ID: 3})
+		assert.NoError(t, err)
+		assert.False(t, can)
+
+		// Task 1 is readable, but does not match the filter
+		tb = &SavedFilterTaskBucket{FilterID: 1, BucketID: 36, TaskID: 1}
+		can, err = tb.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
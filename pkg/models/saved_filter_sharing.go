@@ -0,0 +1,412 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// SavedFilterUser represents a saved filter <-> user relation
+type SavedFilterUser struct {
+	// The unique, numeric id of this saved filter <-> user relation.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The username.
+	Username string `xorm:"-" json:"user_id" param:"user"`
+	// Used internally to reference the user
+	UserID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The saved filter id.
+	FilterID int64 `xorm:"bigint not null INDEX" json:"-" param:"filter"`
+	// The right this user has. 0 = Read only, 1 = Read & Write, 2 = Admin. See the docs for more details.
+	Right Right `xorm:"bigint INDEX not null default 0" json:"right" valid:"length(0|2)" maximum:"2" default:"0"`
+
+	// A timestamp when this relation was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this relation was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName is the table name for SavedFilterUser
+func (SavedFilterUser) TableName() string {
+	return "saved_filter_users"
+}
+
+// SavedFilterTeam represents a saved filter <-> team relation
+type SavedFilterTeam struct {
+	// The unique, numeric id of this saved filter <-> team relation.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The team id.
+	TeamID int64 `xorm:"bigint not null INDEX" json:"team_id" param:"team"`
+	// The saved filter id.
+	FilterID int64 `xorm:"bigint not null INDEX" json:"-" param:"filter"`
+	// The right this team has. 0 = Read only, 1 = Read & Write, 2 = Admin. See the docs for more details.
+	Right Right `xorm:"bigint INDEX not null default 0" json:"right" valid:"length(0|2)" maximum:"2" default:"0"`
+
+	// A timestamp when this relation was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this relation was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName is the table name for SavedFilterTeam
+func (SavedFilterTeam) TableName() string {
+	return "saved_filter_teams"
+}
+
+// Create shares a saved filter with a user
+// @Summary Share a saved filter with a user
+// @Description Gives a user access to a saved filter.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Filter ID"
+// @Param filter body models.SavedFilterUser true "The user you want to share the filter with."
+// @Success 201 {object} models.SavedFilterUser "The created user <-> saved filter relation."
+// @Failure 400 {object} web.HTTPError "Invalid user saved filter object provided."
+// @Failure 404 {object} web.HTTPError "The user does not exist."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the saved filter."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{id}/users [put]
+func (fu *SavedFilterUser) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := fu.Right.isValid(); err != nil {
+		return err
+	}
+
+	sf, err := getSavedFilterSimpleByID(s, fu.FilterID)
+	if err != nil {
+		return err
+	}
+
+	u, err := user.GetUserByUsername(s, fu.Username)
+	if err != nil {
+		return err
+	}
+	fu.UserID = u.ID
+
+	if sf.OwnerID == fu.UserID {
+		return ErrUserAlreadyHasSavedFilterAccess{UserID: fu.UserID, FilterID: fu.FilterID}
+	}
+
+	exists, err := s.
+		Where("filter_id = ? AND user_id = ?", fu.FilterID, fu.UserID).
+		Exist(&SavedFilterUser{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUserAlreadyHasSavedFilterAccess{UserID: fu.UserID, FilterID: fu.FilterID}
+	}
+
+	fu.ID = 0
+	_, err = s.Insert(fu)
+	return err
+}
+
+// Delete removes a user from a saved filter
+// @Summary Remove a user from a saved filter
+// @Description Removes a user from a saved filter. The user won't have access to the filter anymore.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param filterID path int true "Filter ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} models.Message "The user was successfully removed from the saved filter."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the saved filter."
+// @Failure 404 {object} web.HTTPError "User or saved filter does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{filterID}/users/{userID} [delete]
+func (fu *SavedFilterUser) Delete(s *xorm.Session, a web.Auth) (err error) {
+	u, err := user.GetUserByUsername(s, fu.Username)
+	if err != nil {
+		return err
+	}
+	fu.UserID = u.ID
+
+	deleted, err := s.
+		Where("filter_id = ? AND user_id = ?", fu.FilterID, fu.UserID).
+		Delete(&SavedFilterUser{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrUserDoesNotHaveAccessToSavedFilter{UserID: fu.UserID, FilterID: fu.FilterID}
+	}
+	return nil
+}
+
+// ReadAll returns all users a saved filter was shared with
+// @Summary Get the users a saved filter was shared with
+// @Description Returns all users who have access to a saved filter through a direct share.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Filter ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search users by their name."
+// @Success 200 {array} models.UserWithRight "The users with the right they have."
+// @Failure 403 {object} web.HTTPError "No right to see the saved filter."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{id}/users [get]
+func (fu *SavedFilterUser) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	sf := &SavedFilter{ID: fu.FilterID}
+	canRead, _, err := sf.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	all := []*UserWithRight{}
+	query := s.
+		Join("INNER", "saved_filter_users", "user_id = users.id").
+		Where("saved_filter_users.filter_id = ?", fu.FilterID).
+		Where(db.ILIKE("users.username", search))
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&all)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Obfuscate all user emails
+	for _, u := range all {
+		u.Email = ""
+	}
+
+	numberOfTotalItems, err = s.
+		Join("INNER", "saved_filter_users", "user_id = users.id").
+		Where("saved_filter_users.filter_id = ?", fu.FilterID).
+		Where(db.ILIKE("users.username", search)).
+		Count(&UserWithRight{})
+
+	return all, len(all), numberOfTotalItems, err
+}
+
+// Update updates the right of a user on a saved filter
+// @Summary Update a user <-> saved filter relation
+// @Description Updates the right a user has on a saved filter.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param filterID path int true "Filter ID"
+// @Param userID path int true "User ID"
+// @Param filter body models.SavedFilterUser true "The user you want to update."
+// @Success 200 {object} models.SavedFilterUser "The updated user <-> saved filter relation."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the saved filter."
+// @Failure 404 {object} web.HTTPError "User or saved filter does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{filterID}/users/{userID} [post]
+func (fu *SavedFilterUser) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := fu.Right.isValid(); err != nil {
+		return err
+	}
+
+	u, err := user.GetUserByUsername(s, fu.Username)
+	if err != nil {
+		return err
+	}
+	fu.UserID = u.ID
+
+	_, err = s.
+		Where("filter_id = ? AND user_id = ?", fu.FilterID, fu.UserID).
+		Cols("right").
+		Update(fu)
+	return err
+}
+
+// Create shares a saved filter with a team
+// @Summary Share a saved filter with a team
+// @Description Gives a team access to a saved filter.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Filter ID"
+// @Param filter body models.SavedFilterTeam true "The team you want to share the filter with."
+// @Success 201 {object} models.SavedFilterTeam "The created team <-> saved filter relation."
+// @Failure 400 {object} web.HTTPError "Invalid team saved filter object provided."
+// @Failure 404 {object} web.HTTPError "The team does not exist."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the saved filter."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{id}/teams [put]
+func (ft *SavedFilterTeam) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err = ft.Right.isValid(); err != nil {
+		return
+	}
+
+	_, err = GetTeamByID(s, ft.TeamID)
+	if err != nil {
+		return err
+	}
+
+	_, err = getSavedFilterSimpleByID(s, ft.FilterID)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.
+		Where("filter_id = ? AND team_id = ?", ft.FilterID, ft.TeamID).
+		Exist(&SavedFilterTeam{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrTeamAlreadyHasAccess{TeamID: ft.TeamID, ID: ft.FilterID}
+	}
+
+	ft.ID = 0
+	_, err = s.Insert(ft)
+	return err
+}
+
+// Delete removes a team from a saved filter
+// @Summary Remove a team from a saved filter
+// @Description Removes a team from a saved filter. The team won't have access to the filter anymore.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param filterID path int true "Filter ID"
+// @Param teamID path int true "Team ID"
+// @Success 200 {object} models.Message "The team was successfully removed from the saved filter."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the saved filter."
+// @Failure 404 {object} web.HTTPError "Team or saved filter does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{filterID}/teams/{teamID} [delete]
+func (ft *SavedFilterTeam) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = GetTeamByID(s, ft.TeamID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.
+		Where("filter_id = ? AND team_id = ?", ft.FilterID, ft.TeamID).
+		Delete(&SavedFilterTeam{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrTeamDoesNotHaveAccessToSavedFilter{TeamID: ft.TeamID, FilterID: ft.FilterID}
+	}
+	return nil
+}
+
+// ReadAll returns all teams a saved filter was shared with
+// @Summary Get the teams a saved filter was shared with
+// @Description Returns all teams which have access to a saved filter.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Filter ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search teams by their name."
+// @Success 200 {array} models.TeamWithRight "The teams with the right they have."
+// @Failure 403 {object} web.HTTPError "No right to see the saved filter."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{id}/teams [get]
+func (ft *SavedFilterTeam) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	sf := &SavedFilter{ID: ft.FilterID}
+	canRead, _, err := sf.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	all := []*TeamWithRight{}
+	query := s.
+		Table("teams").
+		Join("INNER", "saved_filter_teams", "team_id = teams.id").
+		Where("saved_filter_teams.filter_id = ?", ft.FilterID).
+		Where(db.ILIKE("teams.name", search))
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&all)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	teams := []*Team{}
+	for _, t := range all {
+		teams = append(teams, &t.Team)
+	}
+
+	err = addMoreInfoToTeams(s, teams)
+	if err != nil {
+		return
+	}
+
+	totalItems, err = s.
+		Table("teams").
+		Join("INNER", "saved_filter_teams", "team_id = teams.id").
+		Where("saved_filter_teams.filter_id = ?", ft.FilterID).
+		Where(db.ILIKE("teams.name", search)).
+		Count(&TeamWithRight{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return all, len(all), totalItems, err
+}
+
+// Update updates the right of a team on a saved filter
+// @Summary Update a team <-> saved filter relation
+// @Description Updates the right a team has on a saved filter.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param filterID path int true "Filter ID"
+// @Param teamID path int true "Team ID"
+// @Param filter body models.SavedFilterTeam true "The team you want to update."
+// @Success 200 {object} models.SavedFilterTeam "The updated team <-> saved filter relation."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the saved filter."
+// @Failure 404 {object} web.HTTPError "Team or saved filter does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{filterID}/teams/{teamID} [post]
+func (ft *SavedFilterTeam) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := ft.Right.isValid(); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("filter_id = ? AND team_id = ?", ft.FilterID, ft.TeamID).
+		Cols("right").
+		Update(ft)
+	return err
+}
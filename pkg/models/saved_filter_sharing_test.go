@@ -0,0 +1,322 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedFilter_SharedRights(t *testing.T) {
+	t.Run("shared read only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 3}
+		sf := &SavedFilter{ID: 1}
+		can, right, err := sf.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, int(RightRead), right)
+		assert.Equal(t, "testfilter1", sf.Title)
+
+		can, err = (&SavedFilter{ID: 1}).CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("shared with write through team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 8}
+		can, err := (&SavedFilter{ID: 1}).CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		can, err = (&SavedFilter{ID: 1}).CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("shared admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 9}
+		can, err := (&SavedFilter{ID: 1}).CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		can, err = (&SavedFilterUser{FilterID: 1}).CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("link share of the filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share := &LinkSharing{ID: 5, ListID: -2, Right: RightRead, SharedByID: 1}
+		can, right, err := (&SavedFilter{ID: 1}).CanRead(s, share)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, int(RightRead), right)
+
+		can, err = (&SavedFilter{ID: 1}).CanUpdate(s, share)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("shared filters show up for the user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		filters, err := getSavedFiltersForUser(s, &user.User{ID: 8})
+		assert.NoError(t, err)
+		assert.Len(t, filters, 1)
+		assert.Equal(t, int64(1), filters[0].ID)
+
+		filters, err = getSavedFiltersForUser(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.Len(t, filters, 0)
+	})
+}
+
+func TestSavedFilter_ReadTasksShared(t *testing.T) {
+	readTasks := func(t *testing.T, a interface{ GetID() int64 }) []*Task {
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ListID: getListIDFromSavedFilterID(1)}
+		result, _, _, err := tc.ReadAll(s, a, "", 0, 50)
+		assert.NoError(t, err)
+		return result.([]*Task)
+	}
+
+	t.Run("only tasks the viewer has access to", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		owner := readTasks(t, &user.User{ID: 1})
+		shared := readTasks(t, &user.User{ID: 3})
+		assert.NotEmpty(t, owner)
+		assert.NotEqual(t, len(owner), len(shared))
+
+		s := db.NewSession()
+		defer s.Close()
+		for _, task := range shared {
+			can, _, err := (&Task{ID: task.ID}).CanRead(s, &user.User{ID: 3})
+			assert.NoError(t, err)
+			assert.True(t, can, "task %d", task.ID)
+		}
+	})
+	t.Run("link share sees the tasks of the user who shared it", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		owner := readTasks(t, &user.User{ID: 1})
+		shared := readTasks(t, &LinkSharing{ID: 5, ListID: -2, Right: RightRead, SharedByID: 1})
+		assert.Equal(t, len(owner), len(shared))
+	})
+	t.Run("not shared", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ListID: getListIDFromSavedFilterID(1)}
+		_, _, _, err := tc.ReadAll(s, &user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+
+		_, _, _, err = tc.ReadAll(s, &LinkSharing{ID: 1, ListID: 1, SharedByID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrSavedFilterNotAvailableForLinkShare(err))
+	})
+}
+
+func TestSavedFilterUser(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		fu := &SavedFilterUser{
+			FilterID: 1,
+			Username: "user2",
+			Right:    RightWrite,
+		}
+		err := fu.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "saved_filter_users", map[string]interface{}{
+			"filter_id": 1,
+			"user_id":   2,
+			"right":     RightWrite,
+		}, false)
+	})
+	t.Run("already has access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		fu := &SavedFilterUser{
+			FilterID: 1,
+			Username: "user3",
+		}
+		err := fu.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserAlreadyHasSavedFilterAccess(err))
+
+		fu = &SavedFilterUser{
+			FilterID: 1,
+			Username: "user1",
+		}
+		err = fu.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserAlreadyHasSavedFilterAccess(err))
+	})
+	t.Run("no admin access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		fu := &SavedFilterUser{FilterID: 1}
+		can, err := fu.CanCreate(s, &user.User{ID: 8})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("read all", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		fu := &SavedFilterUser{FilterID: 1}
+		result, _, _, err := fu.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		users := result.([]*UserWithRight)
+		assert.Len(t, users, 2)
+		assert.Equal(t, int64(3), users[0].ID)
+		assert.Equal(t, RightRead, users[0].Right)
+	})
+	t.Run("delete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		fu := &SavedFilterUser{
+			FilterID: 1,
+			Username: "user3",
+		}
+		err := fu.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "saved_filter_users", map[string]interface{}{
+			"filter_id": 1,
+			"user_id":   3,
+		})
+	})
+	t.Run("delete without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		fu := &SavedFilterUser{
+			FilterID: 1,
+			Username: "user2",
+		}
+		err := fu.Delete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToSavedFilter(err))
+	})
+}
+
+func TestSavedFilterTeam(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ft := &SavedFilterTeam{
+			FilterID: 1,
+			TeamID:   9,
+		}
+		err := ft.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "saved_filter_teams", map[string]interface{}{
+			"filter_id": 1,
+			"team_id":   9,
+		}, false)
+
+		// Members of the team can now see the filter
+		sf := &SavedFilter{ID: 1}
+		can, right, err := sf.CanRead(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, int(RightRead), right)
+	})
+	t.Run("already has access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ft := &SavedFilterTeam{
+			FilterID: 1,
+			TeamID:   11,
+		}
+		err := ft.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTeamAlreadyHasAccess(err))
+	})
+	t.Run("read all", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ft := &SavedFilterTeam{FilterID: 1}
+		result, _, _, err := ft.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		teams := result.([]*TeamWithRight)
+		assert.Len(t, teams, 1)
+		assert.Equal(t, int64(11), teams[0].ID)
+		assert.Equal(t, RightWrite, teams[0].Right)
+	})
+	t.Run("delete without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ft := &SavedFilterTeam{
+			FilterID: 1,
+			TeamID:   1,
+		}
+		err := ft.Delete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTeamDoesNotHaveAccessToSavedFilter(err))
+	})
+}
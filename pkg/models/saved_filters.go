@@ -21,6 +21,7 @@ import (
 
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
+	"xorm.io/builder"
 	"xorm.io/xorm"
 )
 
@@ -83,13 +84,67 @@ func getListIDFromSavedFilterID(filterID int64) (listID int64) {
 	return
 }
 
+// Condition for all saved filters a user owns or which were shared with them directly or through a team.
+func getSavedFiltersCond(userID int64) builder.Cond {
+	return builder.Or(
+		builder.Eq{"saved_filters.owner_id": userID},
+		builder.In("saved_filters.id", builder.
+			Select("filter_id").
+			From("saved_filter_users").
+			Where(builder.Eq{"user_id": userID})),
+		builder.In("saved_filters.id", builder.
+			Select("saved_filter_teams.filter_id").
+			From("saved_filter_teams").
+			Join("INNER", "team_members", "team_members.team_id = saved_filter_teams.team_id").
+			Where(builder.Eq{"team_members.user_id": userID})),
+	)
+}
+
 func getSavedFiltersForUser(s *xorm.Session, auth web.Auth) (filters []*SavedFilter, err error) {
 	// Link shares can't view or modify saved filters, therefore we can error out right away
 	if _, is := auth.(*LinkSharing); is {
 		return nil, ErrSavedFilterNotAvailableForLinkShare{LinkShareID: auth.GetID()}
 	}
 
-	err = s.Where("owner_id = ?", auth.GetID()).Find(&filters)
+	err = s.Where(getSavedFiltersCond(auth.GetID())).OrderBy("id asc").Find(&filters)
+	return
+}
+
+func (sf *SavedFilter) getTasksForLinkShare(s *xorm.Session, share *LinkSharing, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	opts, err := getTaskFilterOptsFromCollection(sf.getTaskCollection())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	opts.search = search
+	opts.page = page
+	opts.perPage = perPage
+
+	lists, err := sf.getListsForTasks(s, share)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return getTasksForLists(s, lists, share, opts)
+}
+
+// Returns all lists the tasks of a saved filter are searched in. These are all lists the user has access to.
+// Link shares don't have access to any lists themselves, they see the tasks of the filter
+// in all lists the user who shared it has access to.
+func (sf *SavedFilter) getListsForTasks(s *xorm.Session, a web.Auth) (lists []*List, err error) {
+	u := &user.User{ID: a.GetID()}
+	if share, is := a.(*LinkSharing); is {
+		// The user who shared the filter might have lost access to it in the meantime
+		u = &user.User{ID: share.SharedByID}
+		can, err := hasSavedFilterRight(s, sf.ID, u, RightRead)
+		if err != nil {
+			return nil, err
+		}
+		if !can {
+			return nil, ErrGenericForbidden{}
+		}
+	}
+
+	lists, _, _, err = getRawListsForUser(s, &listOptions{user: u, page: -1})
 	return
 }
 
@@ -114,12 +169,36 @@ func (sf *SavedFilter) toList() *List {
 // @Produce json
 // @Security JWTKeyAuth
 // @Success 201 {object} models.SavedFilter "The Saved Filter"
+// @Failure 400 {object} web.HTTPError "The filter query is invalid."
 // @Failure 403 {object} web.HTTPError "The user does not have access to that saved filter."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /filters [put]
 func (sf *SavedFilter) Create(s *xorm.Session, auth web.Auth) error {
+	err := sf.validateFilterQuery()
+	if err != nil {
+		return err
+	}
+
 	sf.OwnerID = auth.GetID()
-	_, err := s.Insert(sf)
+	_, err = s.Insert(sf)
+	if err != nil {
+		return err
+	}
+
+	// Create a first bucket so the filter can be used as a kanban board right away
+	b := &Bucket{
+		ListID: getListIDFromSavedFilterID(sf.ID),
+		Title:  "Backlog",
+	}
+	return b.Create(s, auth)
+}
+
+// validateFilterQuery makes sure the filter query of a saved filter can be parsed before storing it.
+func (sf *SavedFilter) validateFilterQuery() error {
+	if sf.Filters == nil {
+		return nil
+	}
+	_, err := parseTaskFilterQuery(sf.Filters.Filter)
 	return err
 }
 
@@ -165,6 +244,7 @@ func (sf *SavedFilter) ReadOne(s *xorm.Session, a web.Auth) error {
 // @Security JWTKeyAuth
 // @Param id path int true "Filter ID"
 // @Success 200 {object} models.SavedFilter "The Saved Filter"
+// @Failure 400 {object} web.HTTPError "The filter query is invalid."
 // @Failure 403 {object} web.HTTPError "The user does not have access to that saved filter."
 // @Failure 404 {object} web.HTTPError "The saved filter does not exist."
 // @Failure 500 {object} models.Message "Internal error"
@@ -179,6 +259,11 @@ func (sf *SavedFilter) Update(s *xorm.Session, a web.Auth) error {
 		sf.Filters = origFilter.Filters
 	}
 
+	err = sf.validateFilterQuery()
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where("id = ?", sf.ID).
 		Cols(
@@ -206,6 +291,41 @@ func (sf *SavedFilter) Update(s *xorm.Session, a web.Auth) error {
 // @Router /filters/{id} [delete]
 func (sf *SavedFilter) Delete(s *xorm.Session, a web.Auth) error {
 	_, err := s.
+		Where("filter_id = ?", sf.ID).
+		Delete(&SavedFilterUser{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("filter_id = ?", sf.ID).
+		Delete(&SavedFilterTeam{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("list_id = ?", getListIDFromSavedFilterID(sf.ID)).
+		Delete(&LinkSharing{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("filter_id = ?", sf.ID).
+		Delete(&SavedFilterTaskBucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("list_id = ?", getListIDFromSavedFilterID(sf.ID)).
+		Delete(&Bucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
 		Where("id = ?", sf.ID).
 		Delete(sf)
 	return err
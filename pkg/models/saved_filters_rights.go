@@ -23,20 +23,23 @@ import (
 
 // CanRead checks if a user has the right to read a saved filter
 func (sf *SavedFilter) CanRead(s *xorm.Session, auth web.Auth) (bool, int, error) {
-	can, err := sf.canDoFilter(s, auth)
-	return can, int(RightAdmin), err
+	sff, right, err := getSavedFilterRight(s, sf.ID, auth)
+	if err != nil || sff == nil {
+		return false, 0, err
+	}
+
+	*sf = *sff
+	return true, int(right), nil
 }
 
 // CanDelete checks if a user has the right to delete a saved filter
 func (sf *SavedFilter) CanDelete(s *xorm.Session, auth web.Auth) (bool, error) {
-	return sf.canDoFilter(s, auth)
+	return canChangeSavedFilter(s, sf.ID, auth, RightAdmin)
 }
 
 // CanUpdate checks if a user has the right to update a saved filter
 func (sf *SavedFilter) CanUpdate(s *xorm.Session, auth web.Auth) (bool, error) {
-	// A normal check would replace the passed struct which in our case would override the values we want to update.
-	sff := &SavedFilter{ID: sf.ID}
-	return sff.canDoFilter(s, auth)
+	return canChangeSavedFilter(s, sf.ID, auth, RightWrite)
 }
 
 // CanCreate checks if a user has the right to update a saved filter
@@ -48,24 +51,112 @@ func (sf *SavedFilter) CanCreate(s *xorm.Session, auth web.Auth) (bool, error) {
 	return true, nil
 }
 
-// Helper function to check saved filter rights sind they all have the same logic
-func (sf *SavedFilter) canDoFilter(s *xorm.Session, auth web.Auth) (can bool, err error) {
-	// Link shares can't view or modify saved filters, therefore we can error out right away
-	if _, is := auth.(*LinkSharing); is {
-		return false, ErrSavedFilterNotAvailableForLinkShare{LinkShareID: auth.GetID(), SavedFilterID: sf.ID}
+func hasSavedFilterRight(s *xorm.Session, filterID int64, auth web.Auth, minRight Right) (bool, error) {
+	sf, right, err := getSavedFilterRight(s, filterID, auth)
+	if err != nil || sf == nil {
+		return false, err
 	}
+	return right >= minRight, nil
+}
 
-	sff, err := getSavedFilterSimpleByID(s, sf.ID)
-	if err != nil {
+// Link shares can only read the definition of a filter, no matter their right. The filter runs with the access of
+// the user who shared it, changing it would give the link share access to all tasks of that user.
+func canChangeSavedFilter(s *xorm.Session, filterID int64, auth web.Auth, minRight Right) (bool, error) {
+	can, err := hasSavedFilterRight(s, filterID, auth, minRight)
+	if err != nil || !can {
 		return false, err
 	}
 
-	// Only owners are allowed to do something with a saved filter
-	if sff.OwnerID != auth.GetID() {
-		return false, nil
+	_, isLinkShare := auth.(*LinkSharing)
+	return !isLinkShare, nil
+}
+
+// Returns the saved filter and the highest right a user has on it. The filter is nil if the user has no access at all.
+// The owner of a filter is always admin, all other users get their right through the filter being shared with
+// them directly or with one of their teams. Link shares only have access to the filter they were created for.
+func getSavedFilterRight(s *xorm.Session, filterID int64, auth web.Auth) (sf *SavedFilter, right Right, err error) {
+	if share, is := auth.(*LinkSharing); is {
+		if share.ListID != getListIDFromSavedFilterID(filterID) {
+			return nil, RightRead, ErrSavedFilterNotAvailableForLinkShare{LinkShareID: auth.GetID(), SavedFilterID: filterID}
+		}
+
+		sf, err = getSavedFilterSimpleByID(s, filterID)
+		if err != nil {
+			return nil, RightRead, err
+		}
+		return sf, share.Right, nil
 	}
 
-	*sf = *sff
+	sf, err = getSavedFilterSimpleByID(s, filterID)
+	if err != nil {
+		return nil, RightRead, err
+	}
 
-	return true, nil
+	if sf.OwnerID == auth.GetID() {
+		return sf, RightAdmin, nil
+	}
+
+	users := []*SavedFilterUser{}
+	err = s.
+		Where("filter_id = ? AND user_id = ?", filterID, auth.GetID()).
+		Find(&users)
+	if err != nil {
+		return nil, RightRead, err
+	}
+
+	teams := []*SavedFilterTeam{}
+	err = s.
+		Join("INNER", "team_members", "team_members.team_id = saved_filter_teams.team_id").
+		Where("saved_filter_teams.filter_id = ? AND team_members.user_id = ?", filterID, auth.GetID()).
+		Find(&teams)
+	if err != nil {
+		return nil, RightRead, err
+	}
+
+	if len(users) == 0 && len(teams) == 0 {
+		return nil, RightRead, nil
+	}
+
+	for _, u := range users {
+		if u.Right > right {
+			right = u.Right
+		}
+	}
+	for _, t := range teams {
+		if t.Right > right {
+			right = t.Right
+		}
+	}
+
+	return sf, right, nil
+}
+
+// CanCreate checks if the user can share a saved filter with another user
+func (fu *SavedFilterUser) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasSavedFilterRight(s, fu.FilterID, a, RightAdmin)
+}
+
+// CanDelete checks if the user can remove a user from a saved filter
+func (fu *SavedFilterUser) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasSavedFilterRight(s, fu.FilterID, a, RightAdmin)
+}
+
+// CanUpdate checks if the user can update the right of a user on a saved filter
+func (fu *SavedFilterUser) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasSavedFilterRight(s, fu.FilterID, a, RightAdmin)
+}
+
+// CanCreate checks if the user can share a saved filter with a team
+func (ft *SavedFilterTeam) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasSavedFilterRight(s, ft.FilterID, a, RightAdmin)
+}
+
+// CanDelete checks if the user can remove a team from a saved filter
+func (ft *SavedFilterTeam) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasSavedFilterRight(s, ft.FilterID, a, RightAdmin)
+}
+
+// CanUpdate checks if the user can update the right of a team on a saved filter
+func (ft *SavedFilterTeam) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasSavedFilterRight(s, ft.FilterID, a, RightAdmin)
 }
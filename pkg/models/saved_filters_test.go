@@ -72,6 +72,25 @@ func TestSavedFilter_Create(t *testing.T) {
 		delete(vals, "filters")
 	}
 	db.AssertExists(t, "saved_filters", vals, true)
+	db.AssertExists(t, "buckets", map[string]interface{}{
+		"list_id": getListIDFromSavedFilterID(sf.ID),
+		"title":   "Backlog",
+	}, false)
+}
+
+func TestSavedFilter_Create_InvalidFilterQuery(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	sf := &SavedFilter{
+		Title:   "test",
+		Filters: &TaskCollection{Filter: "done = true && (priority > 2"},
+	}
+
+	err := sf.Create(s, &user.User{ID: 1})
+	assert.Error(t, err)
+	assert.True(t, IsErrInvalidTaskFilterQuery(err))
 }
 
 func TestSavedFilter_ReadOne(t *testing.T) {
@@ -149,6 +168,21 @@ func TestSavedFilter_Delete(t *testing.T) {
 	db.AssertMissing(t, "saved_filters", map[string]interface{}{
 		"id": 1,
 	})
+	db.AssertMissing(t, "saved_filter_users", map[string]interface{}{
+		"filter_id": 1,
+	})
+	db.AssertMissing(t, "saved_filter_teams", map[string]interface{}{
+		"filter_id": 1,
+	})
+	db.AssertMissing(t, "link_shares", map[string]interface{}{
+		"list_id": -2,
+	})
+	db.AssertMissing(t, "buckets", map[string]interface{}{
+		"list_id": -2,
+	})
+	db.AssertMissing(t, "saved_filter_task_buckets", map[string]interface{}{
+		"filter_id": 1,
+	})
 }
 
 func TestSavedFilter_Rights(t *testing.T) {
@@ -278,6 +312,21 @@ func TestSavedFilter_Rights(t *testing.T) {
 			assert.True(t, IsErrSavedFilterNotAvailableForLinkShare(err))
 			assert.False(t, can)
 		})
+		t.Run("link share of the filter", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			// Changing the filter would give the share access to all tasks of the user who created it
+			share := &LinkSharing{ID: 99, ListID: getListIDFromSavedFilterID(1), Right: RightAdmin, SharedByID: 1}
+			sf := &SavedFilter{
+				ID:    1,
+				Title: "Lorem",
+			}
+			can, err := sf.CanUpdate(s, share)
+			assert.NoError(t, err)
+			assert.False(t, can)
+		})
 	})
 	t.Run("delete", func(t *testing.T) {
 		t.Run("owner", func(t *testing.T) {
@@ -332,5 +381,20 @@ func TestSavedFilter_Rights(t *testing.T) {
 			assert.True(t, IsErrSavedFilterNotAvailableForLinkShare(err))
 			assert.False(t, can)
 		})
+		t.Run("link share of the filter", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			// Changing the filter would give the share access to all tasks of the user who created it
+			share := &LinkSharing{ID: 99, ListID: getListIDFromSavedFilterID(1), Right: RightAdmin, SharedByID: 1}
+			sf := &SavedFilter{
+				ID:    1,
+				Title: "Lorem",
+			}
+			can, err := sf.CanDelete(s, share)
+			assert.NoError(t, err)
+			assert.False(t, can)
+		})
 	})
 }
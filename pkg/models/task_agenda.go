@@ -0,0 +1,341 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// All views of the task agenda
+const (
+	TaskAgendaViewAll      = "all"
+	TaskAgendaViewToday    = "today"
+	TaskAgendaViewUpcoming = "upcoming"
+	TaskAgendaViewOverdue  = "overdue"
+)
+
+// The dates of a task an agenda entry can be based on
+const (
+	taskAgendaDateDue      = "due_date"
+	taskAgendaDateStart    = "start_date"
+	taskAgendaDateReminder = "reminder"
+)
+
+// How many days the agenda covers if nothing else was requested
+const taskAgendaDefaultDays = 30
+
+// How many future occurrences of a single repeating task are added to the agenda at most
+const taskAgendaMaxOccurrences = 100
+
+// TaskAgendaMaxDays is the maximum number of days the agenda can cover.
+const TaskAgendaMaxDays = 366
+
+// TaskAgendaOptions holds everything to build the agenda of a user
+type TaskAgendaOptions struct {
+	// One of all, today, upcoming or overdue. Defaults to all.
+	View string
+	// How many days from today on the agenda covers. Tasks and occurrences later than that are not included.
+	// Defaults to 30, at most TaskAgendaMaxDays are possible.
+	Days int
+}
+
+// TaskAgendaEntry holds a task at the date it shows up in the agenda
+type TaskAgendaEntry struct {
+	// The task.
+	Task *Task `json:"task"`
+	// The date this entry shows up at in the agenda.
+	Date time.Time `json:"date"`
+	// Which date of the task this entry is based on. One of `due_date`, `start_date` or `reminder`.
+	DateType string `json:"date_type"`
+	// True if this entry is a future occurrence of a repeating task and not the task itself. The dates of the task
+	// are the ones of its current occurrence, the date of this entry is the one of the future occurrence.
+	IsOccurrence bool `json:"is_occurrence"`
+}
+
+// TaskAgenda holds all undone tasks of a user with a date, sorted into sections relative to the current day of the user
+type TaskAgenda struct {
+	// The view this agenda was built for.
+	View string `json:"view"`
+	// The time zone the days of the agenda are calculated in.
+	Timezone string `json:"timezone"`
+	// All tasks which were due before today.
+	Overdue []*TaskAgendaEntry `json:"overdue"`
+	// All tasks which are due, start or have a reminder today. Tasks which already started and have no due date
+	// show up here as well.
+	Today []*TaskAgendaEntry `json:"today"`
+	// All tasks which are due, start or have a reminder tomorrow.
+	Tomorrow []*TaskAgendaEntry `json:"tomorrow"`
+	// All tasks which are due, start or have a reminder in the rest of the current week.
+	ThisWeek []*TaskAgendaEntry `json:"this_week"`
+	// All tasks after the current week.
+	Later []*TaskAgendaEntry `json:"later"`
+}
+
+// Holds the day boundaries of an agenda in the time zone of the user
+type taskAgendaDays struct {
+	today            time.Time
+	tomorrow         time.Time
+	dayAfterTomorrow time.Time
+	endOfWeek        time.Time
+	end              time.Time
+}
+
+func newTaskAgendaDays(now time.Time, location *time.Location, weekStart int, days int) *taskAgendaDays {
+	now = now.In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	daysSinceWeekStart := (int(today.Weekday()) - weekStart%7 + 7) % 7
+
+	return &taskAgendaDays{
+		today:            today,
+		tomorrow:         today.AddDate(0, 0, 1),
+		dayAfterTomorrow: today.AddDate(0, 0, 2),
+		endOfWeek:        today.AddDate(0, 0, 7-daysSinceWeekStart),
+		end:              today.AddDate(0, 0, days+1),
+	}
+}
+
+// Returns the date a task shows up at in the agenda and which of the dates of the task it is.
+// The due date always wins. Otherwise, the start date is used, or the first reminder from today on.
+// A zero time means the task does not show up in the agenda.
+func (d *taskAgendaDays) getDateForTask(t *Task) (date time.Time, dateType string) {
+	if !t.DueDate.IsZero() {
+		return t.DueDate, taskAgendaDateDue
+	}
+
+	if !t.StartDate.IsZero() {
+		return t.StartDate, taskAgendaDateStart
+	}
+
+	for _, r := range t.Reminders {
+		if r.Before(d.today) {
+			continue
+		}
+		if date.IsZero() || r.Before(date) {
+			date = r
+		}
+	}
+	if !date.IsZero() {
+		dateType = taskAgendaDateReminder
+	}
+
+	return
+}
+
+func (d *taskAgendaDays) addEntry(agenda *TaskAgenda, entry *TaskAgendaEntry) {
+	switch {
+	case entry.Date.Before(d.today) && entry.DateType == taskAgendaDateDue:
+		agenda.Overdue = append(agenda.Overdue, entry)
+	case entry.Date.Before(d.tomorrow):
+		// Tasks which already started but are not due yet are still relevant today
+		agenda.Today = append(agenda.Today, entry)
+	case entry.Date.Before(d.dayAfterTomorrow):
+		agenda.Tomorrow = append(agenda.Tomorrow, entry)
+	case entry.Date.Before(d.endOfWeek):
+		agenda.ThisWeek = append(agenda.ThisWeek, entry)
+	default:
+		agenda.Later = append(agenda.Later, entry)
+	}
+}
+
+// Returns the dates of all future occurrences of a repeating task until the end of the agenda.
+// Tasks which repeat from the date they were marked as done don't have any predictable occurrences.
+func (d *taskAgendaDays) getOccurrencesForTask(t *Task, date time.Time) (occurrences []time.Time, err error) {
+	if t.RepeatRule == "" || t.RepeatFromCurrentDate {
+		return nil, nil
+	}
+
+	anchor := t.getRepeatAnchor()
+	if anchor.IsZero() {
+		return nil, nil
+	}
+
+	dtstart := t.RepeatStart
+	if dtstart.IsZero() {
+		dtstart = anchor
+	}
+
+	rule, err := parseRepeatRule(t.RepeatRule, dtstart, t.RepeatExdates)
+	if err != nil {
+		return nil, err
+	}
+
+	// All dates of a task keep their difference to each other when it repeats, so the date the task
+	// shows up at in the agenda moves by the same amount as the date the rule is based on.
+	// Only occurrences from today on count towards the limit, even if the task is overdue for a long time.
+	// The task itself already shows up at its date, so the occurrence at the anchor is never added again.
+	// Occurrences of a rule are at least a second apart.
+	offset := date.Sub(anchor)
+	from := d.today.Add(-offset)
+	if !from.After(anchor) {
+		from = anchor.Add(time.Second)
+	}
+	for _, occurrence := range rule.between(from, d.end.Add(-offset), taskAgendaMaxOccurrences) {
+		occurrences = append(occurrences, occurrence.Add(offset))
+	}
+
+	return
+}
+
+// GetTaskAgenda returns all undone tasks of the user with a due date, start date or reminder,
+// sorted into overdue, today, tomorrow, this week and later.
+// The days are calculated in the time zone of the user and the week starts at the day the user configured.
+func GetTaskAgenda(s *xorm.Session, a web.Auth, opts *TaskAgendaOptions) (agenda *TaskAgenda, err error) {
+	return getTaskAgenda(s, a, opts, time.Now())
+}
+
+func getTaskAgenda(s *xorm.Session, a web.Auth, opts *TaskAgendaOptions, now time.Time) (agenda *TaskAgenda, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, ErrGenericForbidden{}
+	}
+
+	if opts.View == "" {
+		opts.View = TaskAgendaViewAll
+	}
+	switch opts.View {
+	case TaskAgendaViewAll, TaskAgendaViewToday, TaskAgendaViewUpcoming, TaskAgendaViewOverdue:
+	default:
+		return nil, ErrInvalidTaskAgendaView{View: opts.View}
+	}
+	if opts.Days <= 0 {
+		opts.Days = taskAgendaDefaultDays
+	}
+
+	u, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	location := config.GetTimeZone()
+	if u.Timezone != "" {
+		if tz, err := time.LoadLocation(u.Timezone); err == nil {
+			location = tz
+		}
+	}
+
+	days := newTaskAgendaDays(now, location, u.WeekStart, opts.Days)
+	agenda = &TaskAgenda{
+		View:     opts.View,
+		Timezone: location.String(),
+		Overdue:  []*TaskAgendaEntry{},
+		Today:    []*TaskAgendaEntry{},
+		Tomorrow: []*TaskAgendaEntry{},
+		ThisWeek: []*TaskAgendaEntry{},
+		Later:    []*TaskAgendaEntry{},
+	}
+
+	lists, _, _, err := getRawListsForUser(s, &listOptions{
+		user: u,
+		page: -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return agenda, nil
+	}
+
+	listIDs := make([]int64, 0, len(lists))
+	for _, l := range lists {
+		listIDs = append(listIDs, l.ID)
+	}
+
+	tasks := []*Task{}
+	err = s.
+		Where(builder.And(
+			builder.In("list_id", listIDs),
+			builder.Eq{"done": false},
+			builder.Or(
+				builder.NotNull{"due_date"},
+				builder.NotNull{"start_date"},
+				builder.In("id", builder.Select("task_id").From("task_reminders")),
+			),
+		)).
+		Find(&tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	taskMap := make(map[int64]*Task, len(tasks))
+	for _, t := range tasks {
+		taskMap[t.ID] = t
+	}
+
+	err = addMoreInfoToTasks(s, taskMap, a)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		date, dateType := days.getDateForTask(t)
+		if date.IsZero() || !date.Before(days.end) {
+			continue
+		}
+
+		days.addEntry(agenda, &TaskAgendaEntry{
+			Task:     t,
+			Date:     date.In(location),
+			DateType: dateType,
+		})
+
+		occurrences, err := days.getOccurrencesForTask(t, date)
+		if err != nil {
+			return nil, err
+		}
+		for _, occurrence := range occurrences {
+			days.addEntry(agenda, &TaskAgendaEntry{
+				Task:         t,
+				Date:         occurrence.In(location),
+				DateType:     dateType,
+				IsOccurrence: true,
+			})
+		}
+	}
+
+	sections := [][]*TaskAgendaEntry{agenda.Overdue, agenda.Today, agenda.Tomorrow, agenda.ThisWeek, agenda.Later}
+	for _, entries := range sections {
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Date.Equal(entries[j].Date) {
+				return entries[i].Task.ID < entries[j].Task.ID
+			}
+			return entries[i].Date.Before(entries[j].Date)
+		})
+	}
+
+	switch opts.View {
+	case TaskAgendaViewToday:
+		agenda.Tomorrow = []*TaskAgendaEntry{}
+		agenda.ThisWeek = []*TaskAgendaEntry{}
+		agenda.Later = []*TaskAgendaEntry{}
+	case TaskAgendaViewUpcoming:
+		agenda.Overdue = []*TaskAgendaEntry{}
+	case TaskAgendaViewOverdue:
+		agenda.Today = []*TaskAgendaEntry{}
+		agenda.Tomorrow = []*TaskAgendaEntry{}
+		agenda.ThisWeek = []*TaskAgendaEntry{}
+		agenda.Later = []*TaskAgendaEntry{}
+	}
+
+	return agenda, nil
+}
@@ -0,0 +1,208 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTaskAgenda(t *testing.T) {
+	// A saturday
+	now := time.Date(2018, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	getAgendaTaskIDs := func(entries []*TaskAgendaEntry) (ids []int64) {
+		for _, e := range entries {
+			ids = append(ids, e.Task.ID)
+		}
+		return
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("timezone").Update(&user.User{Timezone: "Europe/Berlin"})
+		assert.NoError(t, err)
+
+		agenda, err := getTaskAgenda(s, &user.User{ID: 1}, &TaskAgendaOptions{}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, "Europe/Berlin", agenda.Timezone)
+
+		assert.Contains(t, getAgendaTaskIDs(agenda.Overdue), int64(6))
+		assert.Contains(t, getAgendaTaskIDs(agenda.Today), int64(5))
+		// Task 27 only has reminders
+		assert.Contains(t, getAgendaTaskIDs(agenda.Today), int64(27))
+		assert.Contains(t, getAgendaTaskIDs(agenda.Later), int64(7))
+		// Task 8 only has an end date
+		assert.NotContains(t, getAgendaTaskIDs(agenda.Later), int64(8))
+		// Done tasks are not included
+		assert.NotContains(t, getAgendaTaskIDs(agenda.Overdue), int64(38))
+
+		for _, e := range agenda.Today {
+			if e.Task.ID == 5 {
+				assert.Equal(t, taskAgendaDateDue, e.DateType)
+			}
+			if e.Task.ID == 27 {
+				assert.Equal(t, taskAgendaDateReminder, e.DateType)
+			}
+		}
+	})
+	t.Run("today", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		agenda, err := getTaskAgenda(s, &user.User{ID: 1}, &TaskAgendaOptions{View: TaskAgendaViewToday}, now)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, agenda.Overdue)
+		assert.Empty(t, agenda.Later)
+	})
+	t.Run("only few days", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		agenda, err := getTaskAgenda(s, &user.User{ID: 1}, &TaskAgendaOptions{Days: 5}, now)
+		assert.NoError(t, err)
+		assert.NotContains(t, getAgendaTaskIDs(agenda.Later), int64(7))
+	})
+	t.Run("invalid view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := getTaskAgenda(s, &user.User{ID: 1}, &TaskAgendaOptions{View: "yesterday"}, now)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskAgendaView(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := getTaskAgenda(s, &LinkSharing{ID: 1}, &TaskAgendaOptions{}, now)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTaskAgendaDays(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	t.Run("day boundaries in the user's time zone", func(t *testing.T) {
+		// Already the next day in Berlin
+		d := newTaskAgendaDays(time.Date(2022, 11, 9, 23, 30, 0, 0, time.UTC), berlin, 1, 30)
+		assert.Equal(t, time.Date(2022, 11, 10, 0, 0, 0, 0, berlin), d.today)
+		assert.Equal(t, time.Date(2022, 11, 11, 0, 0, 0, 0, berlin), d.tomorrow)
+	})
+	t.Run("week start", func(t *testing.T) {
+		// A thursday
+		now := time.Date(2022, 11, 10, 12, 0, 0, 0, berlin)
+
+		d := newTaskAgendaDays(now, berlin, 1, 30)
+		assert.Equal(t, time.Date(2022, 11, 14, 0, 0, 0, 0, berlin), d.endOfWeek)
+
+		d = newTaskAgendaDays(now, berlin, 0, 30)
+		assert.Equal(t, time.Date(2022, 11, 13, 0, 0, 0, 0, berlin), d.endOfWeek)
+	})
+	t.Run("sections", func(t *testing.T) {
+		now := time.Date(2022, 11, 10, 12, 0, 0, 0, berlin)
+		d := newTaskAgendaDays(now, berlin, 1, 30)
+		agenda := &TaskAgenda{}
+
+		d.addEntry(agenda, &TaskAgendaEntry{Date: time.Date(2022, 11, 9, 12, 0, 0, 0, berlin), DateType: taskAgendaDateDue})
+		d.addEntry(agenda, &TaskAgendaEntry{Date: time.Date(2022, 11, 8, 12, 0, 0, 0, berlin), DateType: taskAgendaDateStart})
+		d.addEntry(agenda, &TaskAgendaEntry{Date: time.Date(2022, 11, 10, 8, 0, 0, 0, berlin), DateType: taskAgendaDateDue})
+		d.addEntry(agenda, &TaskAgendaEntry{Date: time.Date(2022, 11, 11, 8, 0, 0, 0, berlin), DateType: taskAgendaDateDue})
+		d.addEntry(agenda, &TaskAgendaEntry{Date: time.Date(2022, 11, 13, 8, 0, 0, 0, berlin), DateType: taskAgendaDateDue})
+		d.addEntry(agenda, &TaskAgendaEntry{Date: time.Date(2022, 11, 14, 8, 0, 0, 0, berlin), DateType: taskAgendaDateDue})
+
+		assert.Len(t, agenda.Overdue, 1)
+		// Tasks which already started are shown today
+		assert.Len(t, agenda.Today, 2)
+		assert.Len(t, agenda.Tomorrow, 1)
+		assert.Len(t, agenda.ThisWeek, 1)
+		assert.Len(t, agenda.Later, 1)
+	})
+	t.Run("occurrences", func(t *testing.T) {
+		now := time.Date(2022, 11, 10, 12, 0, 0, 0, berlin)
+		d := newTaskAgendaDays(now, berlin, 1, 14)
+		due := time.Date(2022, 11, 3, 10, 0, 0, 0, berlin)
+		task := &Task{
+			DueDate:     due,
+			RepeatRule:  "FREQ=WEEKLY",
+			RepeatStart: due,
+		}
+
+		occurrences, err := d.getOccurrencesForTask(task, due)
+		assert.NoError(t, err)
+		assert.Len(t, occurrences, 3)
+		assert.True(t, occurrences[0].Equal(time.Date(2022, 11, 10, 10, 0, 0, 0, berlin)))
+		assert.True(t, occurrences[2].Equal(time.Date(2022, 11, 24, 10, 0, 0, 0, berlin)))
+	})
+	t.Run("occurrences of a task which is overdue for a long time", func(t *testing.T) {
+		now := time.Date(2022, 11, 10, 12, 0, 0, 0, berlin)
+		d := newTaskAgendaDays(now, berlin, 1, 7)
+		due := time.Date(2022, 1, 3, 10, 0, 0, 0, berlin)
+		task := &Task{
+			DueDate:     due,
+			RepeatRule:  "FREQ=DAILY",
+			RepeatStart: due,
+		}
+
+		occurrences, err := d.getOccurrencesForTask(task, due)
+		assert.NoError(t, err)
+		assert.Len(t, occurrences, 8)
+		assert.True(t, occurrences[0].Equal(time.Date(2022, 11, 10, 10, 0, 0, 0, berlin)))
+	})
+	t.Run("occurrences of a future task", func(t *testing.T) {
+		now := time.Date(2022, 11, 10, 12, 0, 0, 0, berlin)
+		d := newTaskAgendaDays(now, berlin, 1, 14)
+		due := time.Date(2022, 11, 11, 10, 0, 0, 0, berlin)
+		task := &Task{
+			DueDate:     due,
+			RepeatRule:  "FREQ=WEEKLY",
+			RepeatStart: due,
+		}
+
+		occurrences, err := d.getOccurrencesForTask(task, due)
+		assert.NoError(t, err)
+		// The task itself is not included
+		assert.Len(t, occurrences, 1)
+		assert.True(t, occurrences[0].Equal(time.Date(2022, 11, 18, 10, 0, 0, 0, berlin)))
+	})
+	t.Run("no occurrences when repeating from the current date", func(t *testing.T) {
+		d := newTaskAgendaDays(time.Date(2022, 11, 10, 12, 0, 0, 0, berlin), berlin, 1, 14)
+		due := time.Date(2022, 11, 3, 10, 0, 0, 0, berlin)
+		task := &Task{
+			DueDate:               due,
+			RepeatRule:            "FREQ=DAILY",
+			RepeatFromCurrentDate: true,
+		}
+
+		occurrences, err := d.getOccurrencesForTask(task, due)
+		assert.NoError(t, err)
+		assert.Empty(t, occurrences)
+	})
+}
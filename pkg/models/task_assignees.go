@@ -80,8 +80,17 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer
 	if len(assignees) == 0 && len(t.Assignees) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(TaskAssginee{})
+		if err != nil {
+			return err
+		}
+		for _, oldAssignee := range t.Assignees {
+			err = addTaskHistoryEntry(s, doer, t.ID, "assignees", getUserHistoryReference(oldAssignee), nil)
+			if err != nil {
+				return err
+			}
+		}
 		t.setTaskAssignees(assignees)
-		return err
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -121,6 +130,13 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer
 		if err != nil {
 			return err
 		}
+
+		for _, id := range assigneesToDelete {
+			err = addTaskHistoryEntry(s, doer, t.ID, "assignees", getUserHistoryReference(oldAssignees[id]), nil)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Get the list to perform later checks
@@ -173,11 +189,22 @@ func (t *Task) setTaskAssignees(assignees []*user.User) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/assignees/{userID} [delete]
 func (la *TaskAssginee) Delete(s *xorm.Session, a web.Auth) (err error) {
-	_, err = s.Delete(&TaskAssginee{TaskID: la.TaskID, UserID: la.UserID})
+	deleted, err := s.Delete(&TaskAssginee{TaskID: la.TaskID, UserID: la.UserID})
 	if err != nil {
 		return err
 	}
 
+	if deleted > 0 {
+		assignee := &user.User{ID: la.UserID}
+		if u, err := user.GetUserByID(s, la.UserID); err == nil {
+			assignee = u
+		}
+		err = addTaskHistoryEntry(s, a, la.TaskID, "assignees", getUserHistoryReference(assignee), nil)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = updateListByTaskID(s, la.TaskID)
 	return
 }
@@ -229,6 +256,11 @@ func (t *Task) addNewAssigneeByID(s *xorm.Session, newAssigneeID int64, list *Li
 		return err
 	}
 
+	err = addTaskHistoryEntry(s, auth, t.ID, "assignees", nil, getUserHistoryReference(newAssignee))
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(auth)
 	err = events.Dispatch(&TaskAssigneeCreatedEvent{
 		Task:     t,
@@ -20,6 +20,7 @@ import (
 	"io"
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
@@ -37,6 +38,10 @@ type TaskAttachment struct {
 
 	File *files.File `xorm:"-" json:"file"`
 
+	// Scaled down versions of image attachments. They are created in the background after the upload,
+	// attachments which are not images don't have any.
+	Previews []*TaskAttachmentPreview `xorm:"-" json:"previews"`
+
 	Created time.Time `xorm:"created" json:"created"`
 
 	web.CRUDable `xorm:"-" json:"-"`
@@ -60,6 +65,12 @@ func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realna
 		}
 		return err
 	}
+
+	return ta.create(s, file, a)
+}
+
+// Adds an attachment for an already stored file
+func (ta *TaskAttachment) create(s *xorm.Session, file *files.File, a web.Auth) (err error) {
 	ta.File = file
 
 	// Add an entry to the db
@@ -84,7 +95,16 @@ func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realna
 		return err
 	}
 
-	return nil
+	err = addTaskHistoryEntry(s, a, ta.TaskID, "attachments", nil, &taskHistoryReference{ID: ta.ID, Title: file.Name})
+	if err != nil {
+		return err
+	}
+
+	return events.Dispatch(&TaskAttachmentCreatedEvent{
+		Task:       &Task{ID: ta.TaskID},
+		Attachment: ta,
+		Doer:       ta.CreatedBy,
+	})
 }
 
 // ReadOne returns a task attachment
@@ -100,6 +120,11 @@ func (ta *TaskAttachment) ReadOne(s *xorm.Session, a web.Auth) (err error) {
 		}
 	}
 
+	err = addPreviewsToTaskAttachments(s, []*TaskAttachment{ta})
+	if err != nil {
+		return
+	}
+
 	// Get the file
 	ta.File = &files.File{ID: ta.FileID}
 	err = ta.File.LoadFileMetaByID()
@@ -158,6 +183,11 @@ func (ta *TaskAttachment) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 		return nil, 0, 0, err
 	}
 
+	err = addPreviewsToTaskAttachments(s, attachments)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
 	for _, r := range attachments {
 		r.CreatedBy = users[r.CreatedByID]
 
@@ -203,6 +233,31 @@ func (ta *TaskAttachment) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	ref := &taskHistoryReference{ID: ta.ID}
+	if ta.File != nil {
+		ref.Title = ta.File.Name
+	}
+	err = addTaskHistoryEntry(s, a, ta.TaskID, "attachments", ref, nil)
+	if err != nil {
+		return err
+	}
+
+	// The task might be in the trash already when it is purged, so we don't load it here
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskAttachmentDeletedEvent{
+		Task:       &Task{ID: ta.TaskID},
+		Attachment: ta,
+		Doer:       doer,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = deleteTaskAttachmentPreviews(s, ta.ID)
+	if err != nil {
+		return err
+	}
+
 	// Delete the underlying file
 	err = ta.File.Delete()
 	// If the file does not exist, we don't want to error out
@@ -244,6 +299,11 @@ func getTaskAttachmentsByTaskIDs(s *xorm.Session, taskIDs []int64) (attachments
 		return nil, err
 	}
 
+	err = addPreviewsToTaskAttachments(s, attachments)
+	if err != nil {
+		return nil, err
+	}
+
 	// Obfuscate all user emails
 	for _, u := range users {
 		u.Email = ""
@@ -0,0 +1,262 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/disintegration/imaging"
+	"xorm.io/xorm"
+)
+
+// The sizes of attachment previews, the value is the maximum width and height in pixels.
+const (
+	TaskAttachmentPreviewSizeSmall  = "sm"
+	TaskAttachmentPreviewSizeMedium = "md"
+	TaskAttachmentPreviewSizeLarge  = "lg"
+)
+
+var taskAttachmentPreviewSizes = []struct {
+	name      string
+	maxPixels int
+}{
+	{name: TaskAttachmentPreviewSizeSmall, maxPixels: 128},
+	{name: TaskAttachmentPreviewSizeMedium, maxPixels: 512},
+	{name: TaskAttachmentPreviewSizeLarge, maxPixels: 1024},
+}
+
+// Images with more pixels than this are not decoded to protect the server from running out of memory.
+const maxTaskAttachmentPreviewSourcePixels = 50 * 1000 * 1000
+
+// TaskAttachmentPreview is a scaled down version of an image attachment
+type TaskAttachmentPreview struct {
+	ID           int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	AttachmentID int64 `xorm:"bigint not null INDEX" json:"-"`
+	FileID       int64 `xorm:"bigint not null" json:"-"`
+
+	// The size of the preview, one of sm, md or lg.
+	Size string `xorm:"varchar(2) not null" json:"size"`
+	// The width of the preview in pixels.
+	Width int `xorm:"int not null" json:"width"`
+	// The height of the preview in pixels.
+	Height int `xorm:"int not null" json:"height"`
+	// The url to download the preview, relative to the api root.
+	URL string `xorm:"-" json:"url"`
+
+	Created time.Time `xorm:"created not null" json:"-"`
+}
+
+// TableName returns the table name for task attachment previews
+func (TaskAttachmentPreview) TableName() string {
+	return "task_attachment_previews"
+}
+
+func isValidTaskAttachmentPreviewSize(size string) bool {
+	for _, s := range taskAttachmentPreviewSizes {
+		if s.name == size {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *TaskAttachmentPreview) setURL(ta *TaskAttachment) {
+	p.URL = "/api/v1/tasks/" + strconv.FormatInt(ta.TaskID, 10) + "/attachments/" + strconv.FormatInt(ta.ID, 10) + "?size=" + p.Size
+}
+
+func addPreviewsToTaskAttachments(s *xorm.Session, attachments []*TaskAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	attachmentIDs := make([]int64, 0, len(attachments))
+	for _, ta := range attachments {
+		attachmentIDs = append(attachmentIDs, ta.ID)
+	}
+
+	previews := []*TaskAttachmentPreview{}
+	err := s.
+		In("attachment_id", attachmentIDs).
+		OrderBy("id asc").
+		Find(&previews)
+	if err != nil {
+		return err
+	}
+
+	previewMap := make(map[int64][]*TaskAttachmentPreview, len(attachments))
+	for _, p := range previews {
+		previewMap[p.AttachmentID] = append(previewMap[p.AttachmentID], p)
+	}
+
+	for _, ta := range attachments {
+		ta.Previews = previewMap[ta.ID]
+		for _, p := range ta.Previews {
+			p.setURL(ta)
+		}
+	}
+
+	return nil
+}
+
+// GetPreview returns the file of the preview of an attachment in the given size.
+// The attachment must be loaded already.
+func (ta *TaskAttachment) GetPreview(s *xorm.Session, size string) (file *files.File, err error) {
+	if !isValidTaskAttachmentPreviewSize(size) {
+		return nil, ErrInvalidTaskAttachmentPreviewSize{Size: size}
+	}
+
+	preview := &TaskAttachmentPreview{}
+	exists, err := s.
+		Where("attachment_id = ? AND size = ?", ta.ID, size).
+		Get(preview)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTaskAttachmentPreviewDoesNotExist{AttachmentID: ta.ID, Size: size}
+	}
+
+	file = &files.File{ID: preview.FileID}
+	err = file.LoadFileMetaByID()
+	return
+}
+
+// Creates the previews of an image attachment in all sizes. Attachments which are not images are ignored.
+// Previews which already exist are replaced.
+func generateTaskAttachmentPreviews(s *xorm.Session, ta *TaskAttachment) (err error) {
+	if ta.File == nil {
+		ta.File = &files.File{ID: ta.FileID}
+		err = ta.File.LoadFileMetaByID()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ta.File.LoadFileByID()
+	if err != nil {
+		return err
+	}
+	defer ta.File.File.Close()
+
+	imgConfig, format, err := image.DecodeConfig(ta.File.File)
+	if err != nil {
+		log.Debugf("Not creating previews for attachment %d because it is not an image: %s", ta.ID, err)
+		return nil
+	}
+	if imgConfig.Width*imgConfig.Height > maxTaskAttachmentPreviewSourcePixels {
+		log.Debugf("Not creating previews for attachment %d because the image is too large (%dx%d)", ta.ID, imgConfig.Width, imgConfig.Height)
+		return nil
+	}
+
+	_, err = ta.File.File.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	img, err := imaging.Decode(ta.File.File, imaging.AutoOrientation(true))
+	if err != nil {
+		log.Errorf("Could not decode image of attachment %d to create previews: %s", ta.ID, err)
+		return nil
+	}
+
+	// Jpeg previews are a lot smaller, but we need png for everything which might be transparent.
+	encodeFormat := imaging.PNG
+	mime := "image/png"
+	extension := ".png"
+	if format == "jpeg" {
+		encodeFormat = imaging.JPEG
+		mime = "image/jpeg"
+		extension = ".jpg"
+	}
+
+	err = deleteTaskAttachmentPreviews(s, ta.ID)
+	if err != nil {
+		return err
+	}
+
+	// The previews belong to whoever uploaded the attachment
+	creator := &user.User{ID: ta.File.CreatedByID}
+
+	for _, size := range taskAttachmentPreviewSizes {
+		// Fit only ever scales down, small images are re-encoded in their original size
+		resized := imaging.Fit(img, size.maxPixels, size.maxPixels, imaging.Lanczos)
+
+		buf := &bytes.Buffer{}
+		err = imaging.Encode(buf, resized, encodeFormat)
+		if err != nil {
+			return err
+		}
+
+		var file *files.File
+		name := ta.File.Name + "-" + size.name + extension
+		file, err = files.CreateWithMimeAndSession(s, buf, name, uint64(buf.Len()), creator, mime)
+		if err != nil {
+			return err
+		}
+
+		bounds := resized.Bounds()
+		preview := &TaskAttachmentPreview{
+			AttachmentID: ta.ID,
+			FileID:       file.ID,
+			Size:         size.name,
+			Width:        bounds.Dx(),
+			Height:       bounds.Dy(),
+		}
+		_, err = s.Insert(preview)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Created %d previews for attachment %d", len(taskAttachmentPreviewSizes), ta.ID)
+
+	return nil
+}
+
+func deleteTaskAttachmentPreviews(s *xorm.Session, attachmentID int64) error {
+	previews := []*TaskAttachmentPreview{}
+	err := s.Where("attachment_id = ?", attachmentID).Find(&previews)
+	if err != nil {
+		return err
+	}
+
+	if len(previews) == 0 {
+		return nil
+	}
+
+	_, err = s.Where("attachment_id = ?", attachmentID).Delete(&TaskAttachmentPreview{})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range previews {
+		f := &files.File{ID: p.FileID}
+		err = f.Delete()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestImageAttachment(t *testing.T, width, height int) *TaskAttachment {
+	s := db.NewSession()
+	defer s.Close()
+
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	assert.NoError(t, err)
+
+	ta := &TaskAttachment{TaskID: 1}
+	err = ta.NewAttachment(s, io.NopCloser(buf), "image.png", uint64(buf.Len()), &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+	return ta
+}
+
+func getTestAttachmentPreviews(t *testing.T, attachmentID int64) []*TaskAttachmentPreview {
+	s := db.NewSession()
+	defer s.Close()
+
+	ta := &TaskAttachment{ID: attachmentID}
+	err := ta.ReadOne(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	return ta.Previews
+}
+
+func TestTaskAttachment_GeneratePreviews(t *testing.T) {
+	t.Run("image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		ta := createTestImageAttachment(t, 2000, 1000)
+
+		events.TestListener(t, &TaskAttachmentCreatedEvent{
+			Task:       &Task{ID: 1},
+			Attachment: ta,
+			Doer:       &user.User{ID: 1},
+		}, &GenerateTaskAttachmentPreviews{})
+
+		previews := getTestAttachmentPreviews(t, ta.ID)
+		assert.Len(t, previews, 3)
+		assert.Equal(t, TaskAttachmentPreviewSizeSmall, previews[0].Size)
+		assert.Equal(t, 128, previews[0].Width)
+		assert.Equal(t, 64, previews[0].Height)
+		assert.Equal(t, TaskAttachmentPreviewSizeMedium, previews[1].Size)
+		assert.Equal(t, 512, previews[1].Width)
+		assert.Equal(t, 256, previews[1].Height)
+		assert.Equal(t, TaskAttachmentPreviewSizeLarge, previews[2].Size)
+		assert.Equal(t, 1024, previews[2].Width)
+		assert.Equal(t, 512, previews[2].Height)
+		assert.Contains(t, previews[2].URL, "/attachments/")
+		assert.Contains(t, previews[2].URL, "?size=lg")
+
+		s := db.NewSession()
+		defer s.Close()
+		file, err := ta.GetPreview(s, TaskAttachmentPreviewSizeMedium)
+		assert.NoError(t, err)
+		assert.Equal(t, "image.png-md.png", file.Name)
+		assert.Equal(t, "image/png", file.Mime)
+		err = file.LoadFileByID()
+		assert.NoError(t, err)
+		img, _, err := image.Decode(file.File)
+		assert.NoError(t, err)
+		assert.Equal(t, 512, img.Bounds().Dx())
+	})
+	t.Run("small image is not scaled up", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		ta := createTestImageAttachment(t, 100, 50)
+
+		s := db.NewSession()
+		defer s.Close()
+		err := generateTaskAttachmentPreviews(s, ta)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		previews := getTestAttachmentPreviews(t, ta.ID)
+		assert.Len(t, previews, 3)
+		for _, p := range previews {
+			assert.Equal(t, 100, p.Width)
+			assert.Equal(t, 50, p.Height)
+		}
+	})
+	t.Run("not an image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAttachment{ID: 3, FileID: 1}
+		err := generateTaskAttachmentPreviews(s, ta)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{
+			"attachment_id": 3,
+		})
+	})
+}
+
+func TestTaskAttachment_GetPreview(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAttachment{ID: 1}
+		file, err := ta.GetPreview(s, TaskAttachmentPreviewSizeSmall)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), file.ID)
+	})
+	t.Run("invalid size", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAttachment{ID: 1}
+		_, err := ta.GetPreview(s, "xl")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskAttachmentPreviewSize(err))
+	})
+	t.Run("no preview in this size", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAttachment{ID: 1}
+		_, err := ta.GetPreview(s, TaskAttachmentPreviewSizeLarge)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskAttachmentPreviewDoesNotExist(err))
+	})
+	t.Run("previews are deleted with the attachment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAttachment{ID: 1}
+		err := ta.Delete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{
+			"attachment_id": 1,
+		})
+		db.AssertMissing(t, "files", map[string]interface{}{
+			"id": 2,
+		})
+	})
+}
@@ -0,0 +1,483 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"reflect"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskChecklistItem is a single item of the checklist of a task
+type TaskChecklistItem struct {
+	// The unique, numeric id of this checklist item.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"checklistitem"`
+	// The task this checklist item belongs to.
+	TaskID int64 `xorm:"bigint INDEX not null" json:"task_id" param:"task"`
+	// The title of this checklist item.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(1|250)" minLength:"1" maxLength:"250"`
+	// Whether this checklist item is done.
+	Done bool `xorm:"INDEX not null default false" json:"done"`
+	// The time when this checklist item was marked as done.
+	DoneAt time.Time `xorm:"INDEX null 'done_at'" json:"done_at"`
+	// The id of the user this item is assigned to. The user needs to have at least read access to the list of the task.
+	AssigneeID int64      `xorm:"bigint INDEX null" json:"assignee_id"`
+	Assignee   *user.User `xorm:"-" json:"assignee"`
+	// The date when this checklist item is due.
+	DueDate time.Time `xorm:"DATETIME INDEX null 'due_date'" json:"due_date"`
+	// The position of this item in the checklist. Items are sorted by this value, lowest first.
+	Position float64 `xorm:"double null" json:"position"`
+
+	CreatedByID int64 `xorm:"bigint not null" json:"-"`
+	// The user who created this checklist item.
+	CreatedBy *user.User `xorm:"-" json:"created_by"`
+
+	// A timestamp when this checklist item was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this checklist item was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the checklist items table
+func (ci *TaskChecklistItem) TableName() string {
+	return "task_checklist_items"
+}
+
+// The checklist progress of a task is not stored with the task, these filter fields are computed with a subquery instead.
+var taskChecklistFilterFields = map[string]struct {
+	expression string
+	kind       reflect.Type
+}{
+	"checklist_total": {
+		expression: "(SELECT COUNT(*) FROM task_checklist_items WHERE task_checklist_items.task_id = tasks.id)",
+		kind:       reflect.TypeOf(int64(0)),
+	},
+	"checklist_done": {
+		expression: "(SELECT COUNT(*) FROM task_checklist_items WHERE task_checklist_items.task_id = tasks.id AND task_checklist_items.done = true)",
+		kind:       reflect.TypeOf(int64(0)),
+	},
+	// Is null for tasks without a checklist
+	"checklist_progress": {
+		expression: "(SELECT SUM(CASE WHEN task_checklist_items.done = true THEN 1.0 ELSE 0.0 END) / NULLIF(COUNT(*), 0) FROM task_checklist_items WHERE task_checklist_items.task_id = tasks.id)",
+		kind:       reflect.TypeOf(float64(0)),
+	},
+}
+
+func getTaskChecklistItemSimple(s *xorm.Session, ci *TaskChecklistItem) error {
+	exists, err := s.
+		Where("id = ? AND task_id = ?", ci.ID, ci.TaskID).
+		NoAutoCondition().
+		Get(ci)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrChecklistItemDoesNotExist{
+			ID:     ci.ID,
+			TaskID: ci.TaskID,
+		}
+	}
+
+	return nil
+}
+
+func getChecklistItemsForTask(s *xorm.Session, taskID int64) (items []*TaskChecklistItem, err error) {
+	items = []*TaskChecklistItem{}
+	err = s.
+		Where("task_id = ?", taskID).
+		OrderBy("position ASC, id ASC").
+		Find(&items)
+	return
+}
+
+// Makes sure the assignee of a checklist item can actually see the task.
+func (ci *TaskChecklistItem) checkAssignee(s *xorm.Session, task *Task) error {
+	if ci.AssigneeID == 0 {
+		return nil
+	}
+
+	assignee, err := user.GetUserByID(s, ci.AssigneeID)
+	if err != nil {
+		return err
+	}
+
+	list := &List{ID: task.ListID}
+	canRead, _, err := list.CanRead(s, assignee)
+	if err != nil {
+		return err
+	}
+	if !canRead {
+		return ErrUserDoesNotHaveAccessToList{ListID: task.ListID, UserID: ci.AssigneeID}
+	}
+
+	return nil
+}
+
+func (ci *TaskChecklistItem) updateDoneAt(old *TaskChecklistItem) {
+	switch {
+	case !ci.Done:
+		ci.DoneAt = time.Time{}
+	case old == nil || !old.Done:
+		ci.DoneAt = time.Now()
+	default:
+		ci.DoneAt = old.DoneAt
+	}
+}
+
+func addUsersToChecklistItems(s *xorm.Session, items []*TaskChecklistItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(items)*2)
+	for _, i := range items {
+		userIDs = append(userIDs, i.CreatedByID)
+		if i.AssigneeID != 0 {
+			userIDs = append(userIDs, i.AssigneeID)
+		}
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range items {
+		i.CreatedBy = users[i.CreatedByID]
+		if i.AssigneeID != 0 {
+			i.Assignee = users[i.AssigneeID]
+		}
+	}
+
+	return nil
+}
+
+// updateTaskPercentDoneFromChecklist sets the percent done of a task to the share of its checklist items which are done.
+// Tasks without any checklist items keep whatever percent done they had.
+func updateTaskPercentDoneFromChecklist(s *xorm.Session, a web.Auth, taskID int64) error {
+	total, err := s.Where("task_id = ?", taskID).Count(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		return nil
+	}
+
+	done, err := s.Where("task_id = ? AND done = ?", taskID, true).Count(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		return err
+	}
+
+	percentDone := float64(done) / float64(total)
+	if task.PercentDone == percentDone {
+		return nil
+	}
+
+	oldPercentDone := task.PercentDone
+	task.PercentDone = percentDone
+	_, err = s.
+		ID(task.ID).
+		Cols("percent_done").
+		Update(&task)
+	if err != nil {
+		return err
+	}
+
+	err = addTaskHistoryEntry(s, a, task.ID, "percent_done", oldPercentDone, percentDone)
+	if err != nil {
+		return err
+	}
+
+	return updateListLastUpdated(s, &List{ID: task.ListID})
+}
+
+// Create adds a new item to the checklist of a task
+// @Summary Add a checklist item
+// @Description Adds a new item to the checklist of a task. If no position is provided, the item is added at the end of the checklist. The percent done of the task is updated to reflect the checklist progress. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param item body models.TaskChecklistItem true "The checklist item"
+// @Success 201 {object} models.TaskChecklistItem "The created checklist item."
+// @Failure 400 {object} web.HTTPError "Invalid checklist item provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/checklist [put]
+func (ci *TaskChecklistItem) Create(s *xorm.Session, a web.Auth) (err error) {
+	task, err := GetTaskByIDSimple(s, ci.TaskID)
+	if err != nil {
+		return err
+	}
+
+	err = ci.checkAssignee(s, &task)
+	if err != nil {
+		return err
+	}
+
+	ci.CreatedBy, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	ci.ID = 0
+	ci.CreatedByID = ci.CreatedBy.ID
+	ci.updateDoneAt(nil)
+
+	if ci.Position == 0 {
+		last := &TaskChecklistItem{}
+		_, err = s.
+			Where("task_id = ?", ci.TaskID).
+			OrderBy("position DESC").
+			Get(last)
+		if err != nil {
+			return err
+		}
+		ci.Position = last.Position + calculateDefaultPosition(1, 0)
+	}
+
+	_, err = s.Insert(ci)
+	if err != nil {
+		return err
+	}
+
+	err = updateTaskPercentDoneFromChecklist(s, a, ci.TaskID)
+	if err != nil {
+		return err
+	}
+
+	return addUsersToChecklistItems(s, []*TaskChecklistItem{ci})
+}
+
+// ReadOne returns a single checklist item
+// @Summary Get one checklist item
+// @Description Returns one item of the checklist of a task. The user doing this needs to have at least read access to the task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param itemID path int true "Checklist item ID"
+// @Success 200 {object} models.TaskChecklistItem "The checklist item."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/checklist/{itemID} [get]
+func (ci *TaskChecklistItem) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	err = getTaskChecklistItemSimple(s, ci)
+	if err != nil {
+		return err
+	}
+
+	return addUsersToChecklistItems(s, []*TaskChecklistItem{ci})
+}
+
+// ReadAll returns the checklist of a task
+// @Summary Get the checklist of a task
+// @Description Returns all checklist items of a task, sorted by their position. The user doing this needs to have at least read access to the task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Success 200 {array} models.TaskChecklistItem "The checklist items"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/checklist [get]
+func (ci *TaskChecklistItem) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := ci.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	items, err := getChecklistItemsForTask(s, ci.TaskID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addUsersToChecklistItems(s, items)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return items, len(items), int64(len(items)), nil
+}
+
+// Update changes a checklist item
+// @Summary Update a checklist item
+// @Description Changes the title, done status, assignee, due date or position of a checklist item. The percent done of the task is updated to reflect the checklist progress. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param itemID path int true "Checklist item ID"
+// @Param item body models.TaskChecklistItem true "The checklist item with updated values"
+// @Success 200 {object} models.TaskChecklistItem "The updated checklist item."
+// @Failure 400 {object} web.HTTPError "Invalid checklist item provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/checklist/{itemID} [post]
+func (ci *TaskChecklistItem) Update(s *xorm.Session, a web.Auth) (err error) {
+	old := &TaskChecklistItem{ID: ci.ID, TaskID: ci.TaskID}
+	err = getTaskChecklistItemSimple(s, old)
+	if err != nil {
+		return err
+	}
+
+	if ci.AssigneeID != old.AssigneeID {
+		task, err := GetTaskByIDSimple(s, ci.TaskID)
+		if err != nil {
+			return err
+		}
+		err = ci.checkAssignee(s, &task)
+		if err != nil {
+			return err
+		}
+	}
+
+	ci.CreatedByID = old.CreatedByID
+	ci.Created = old.Created
+	ci.updateDoneAt(old)
+	if ci.Position == 0 {
+		ci.Position = old.Position
+	}
+
+	_, err = s.
+		ID(ci.ID).
+		Cols("title", "done", "done_at", "assignee_id", "due_date", "position").
+		Update(ci)
+	if err != nil {
+		return err
+	}
+
+	if ci.Done != old.Done {
+		err = updateTaskPercentDoneFromChecklist(s, a, ci.TaskID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return addUsersToChecklistItems(s, []*TaskChecklistItem{ci})
+}
+
+// Delete removes a checklist item
+// @Summary Delete a checklist item
+// @Description Removes an item from the checklist of a task. The percent done of the task is updated to reflect the checklist progress. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param itemID path int true "Checklist item ID"
+// @Success 200 {object} models.Message "The checklist item was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/checklist/{itemID} [delete]
+func (ci *TaskChecklistItem) Delete(s *xorm.Session, a web.Auth) (err error) {
+	deleted, err := s.
+		Where("id = ? AND task_id = ?", ci.ID, ci.TaskID).
+		NoAutoCondition().
+		Delete(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrChecklistItemDoesNotExist{ID: ci.ID, TaskID: ci.TaskID}
+	}
+
+	return updateTaskPercentDoneFromChecklist(s, a, ci.TaskID)
+}
+
+// TaskChecklistOrder holds the new order of all items of a checklist
+type TaskChecklistOrder struct {
+	TaskID int64 `json:"-" param:"task"`
+	// The ids of all checklist items of the task in their new order.
+	ItemIDs []int64 `json:"item_ids"`
+	// The checklist items in their new order. You cannot change this value.
+	Items []*TaskChecklistItem `json:"items"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// Update reorders the checklist of a task
+// @Summary Reorder a checklist
+// @Description Puts all items of the checklist of a task in a new order. The new order needs to contain every item of the checklist exactly once. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param order body models.TaskChecklistOrder true "The ids of all checklist items in their new order"
+// @Success 200 {object} models.TaskChecklistOrder "The reordered checklist."
+// @Failure 400 {object} web.HTTPError "The order does not contain every item of the checklist exactly once."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/checklist/order [post]
+func (co *TaskChecklistOrder) Update(s *xorm.Session, a web.Auth) (err error) {
+	items, err := getChecklistItemsForTask(s, co.TaskID)
+	if err != nil {
+		return err
+	}
+
+	if len(items) != len(co.ItemIDs) {
+		return ErrInvalidChecklistOrder{TaskID: co.TaskID}
+	}
+
+	itemMap := make(map[int64]*TaskChecklistItem, len(items))
+	for _, i := range items {
+		itemMap[i.ID] = i
+	}
+
+	co.Items = make([]*TaskChecklistItem, 0, len(items))
+	for index, id := range co.ItemIDs {
+		item, has := itemMap[id]
+		if !has {
+			return ErrInvalidChecklistOrder{TaskID: co.TaskID}
+		}
+		// Makes sure every id is only used once
+		delete(itemMap, id)
+
+		item.Position = calculateDefaultPosition(int64(index+1), 0)
+		_, err = s.
+			ID(item.ID).
+			Cols("position").
+			Update(item)
+		if err != nil {
+			return err
+		}
+		co.Items = append(co.Items, item)
+	}
+
+	return addUsersToChecklistItems(s, co.Items)
+}
@@ -0,0 +1,66 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read the checklist of a task
+func (ci *TaskChecklistItem) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	t := &Task{ID: ci.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can add an item to the checklist of a task
+func (ci *TaskChecklistItem) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: ci.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can update a checklist item
+func (ci *TaskChecklistItem) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return ci.canModifyChecklistItem(s, a)
+}
+
+// CanDelete checks if a user can delete a checklist item
+func (ci *TaskChecklistItem) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return ci.canModifyChecklistItem(s, a)
+}
+
+func (ci *TaskChecklistItem) canModifyChecklistItem(s *xorm.Session, a web.Auth) (bool, error) {
+	canWrite, err := ci.CanCreate(s, a)
+	if err != nil || !canWrite {
+		return false, err
+	}
+
+	// Makes sure the item actually belongs to the task
+	saved := &TaskChecklistItem{ID: ci.ID, TaskID: ci.TaskID}
+	err = getTaskChecklistItemSimple(s, saved)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CanUpdate checks if a user can reorder the checklist of a task
+func (co *TaskChecklistOrder) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: co.TaskID}
+	return t.CanWrite(s, a)
+}
@@ -0,0 +1,429 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskChecklistItem_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID: 1,
+			Title:  "Send the slides around",
+		}
+		err := ci.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(196608), ci.Position)
+		assert.Equal(t, int64(1), ci.CreatedBy.ID)
+		assert.True(t, ci.DoneAt.IsZero())
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":            ci.ID,
+			"task_id":       1,
+			"title":         "Send the slides around",
+			"done":          false,
+			"created_by_id": 1,
+		}, false)
+		// One of three items is done now
+		task, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		assert.InDelta(t, 1.0/3.0, task.PercentDone, 0.0001)
+	})
+	t.Run("first item of a task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID: 3,
+			Title:  "Done already",
+			Done:   true,
+		}
+		err := ci.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(65536), ci.Position)
+		assert.False(t, ci.DoneAt.IsZero())
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           3,
+			"percent_done": 1,
+		}, false)
+		db.AssertExists(t, "task_history", map[string]interface{}{
+			"task_id": 3,
+			"field":   "percent_done",
+		}, false)
+	})
+	t.Run("with assignee", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID:     1,
+			Title:      "Test",
+			AssigneeID: 1,
+		}
+		err := ci.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), ci.Assignee.ID)
+	})
+	t.Run("assignee without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID:     1,
+			Title:      "Test",
+			AssigneeID: 2,
+		}
+		err := ci.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToList(err))
+	})
+	t.Run("nonexisting task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			TaskID: 9999,
+			Title:  "Test",
+		}
+		err := ci.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{TaskID: 14}
+		can, err := ci.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskChecklistItem_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{TaskID: 1}
+		result, _, total, err := ci.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		items := result.([]*TaskChecklistItem)
+		assert.Len(t, items, 2)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, int64(1), items[0].ID)
+		assert.Equal(t, int64(2), items[1].ID)
+		assert.Equal(t, int64(1), items[1].Assignee.ID)
+		assert.Equal(t, int64(1), items[0].CreatedBy.ID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{TaskID: 1}
+		_, _, _, err := ci.ReadAll(s, &user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTaskChecklistItem_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("mark as done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     2,
+			TaskID: 1,
+			Title:  "Practice the talk",
+			Done:   true,
+		}
+		err := ci.Update(s, u)
+		assert.NoError(t, err)
+		assert.False(t, ci.DoneAt.IsZero())
+		assert.Equal(t, float64(131072), ci.Position)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":   2,
+			"done": true,
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 1,
+		}, false)
+
+		entries := getTaskHistoryForTest(t, s, 1, "percent_done")
+		assert.Len(t, entries, 1)
+		assert.Equal(t, float64(0), entries[0].OldValue.Value)
+		assert.Equal(t, float64(1), entries[0].NewValue.Value)
+	})
+	t.Run("mark as undone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     1,
+			TaskID: 1,
+			Title:  "Prepare slides",
+		}
+		err := ci.Update(s, u)
+		assert.NoError(t, err)
+		assert.True(t, ci.DoneAt.IsZero())
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":   1,
+			"done": false,
+		}, false)
+		// The percent done of the task in the fixtures is 0 already, so nothing changes
+		db.AssertMissing(t, "task_history", map[string]interface{}{
+			"task_id": 1,
+			"field":   "percent_done",
+		})
+	})
+	t.Run("item of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     3,
+			TaskID: 1,
+			Title:  "Test",
+		}
+		can, err := ci.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.False(t, can)
+		assert.True(t, IsErrChecklistItemDoesNotExist(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     1,
+			TaskID: 1,
+		}
+		can, err := ci.CanUpdate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskChecklistItem_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     2,
+			TaskID: 1,
+		}
+		err := ci.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_checklist_items", map[string]interface{}{
+			"id": 2,
+		})
+		// Only the done item is left
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 1,
+		}, false)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ci := &TaskChecklistItem{
+			ID:     3,
+			TaskID: 1,
+		}
+		err := ci.Delete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrChecklistItemDoesNotExist(err))
+	})
+}
+
+func TestTaskChecklistOrder_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		co := &TaskChecklistOrder{
+			TaskID:  1,
+			ItemIDs: []int64{2, 1},
+		}
+		err := co.Update(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, co.Items, 2)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		items, err := getChecklistItemsForTask(s, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), items[0].ID)
+		assert.Equal(t, int64(1), items[1].ID)
+	})
+	t.Run("missing item", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		co := &TaskChecklistOrder{
+			TaskID:  1,
+			ItemIDs: []int64{2},
+		}
+		err := co.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidChecklistOrder(err))
+	})
+	t.Run("duplicate item", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		co := &TaskChecklistOrder{
+			TaskID:  1,
+			ItemIDs: []int64{2, 2},
+		}
+		err := co.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidChecklistOrder(err))
+	})
+	t.Run("item of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		co := &TaskChecklistOrder{
+			TaskID:  1,
+			ItemIDs: []int64{1, 3},
+		}
+		err := co.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidChecklistOrder(err))
+	})
+}
+
+func TestTaskCollection_ChecklistFilter(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	getTaskIDs := func(t *testing.T, tf *TaskCollection) (ids []int64) {
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, _, err := tf.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return
+	}
+
+	t.Run("progress", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"checklist_progress"},
+			FilterValue:      []string{"1"},
+			FilterComparator: []string{"less"},
+		})
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("progress including tasks without checklist", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:             1,
+			FilterBy:           []string{"checklist_progress"},
+			FilterValue:        []string{"1"},
+			FilterComparator:   []string{"equals"},
+			FilterIncludeNulls: true,
+		})
+		assert.Contains(t, ids, int64(2))
+		assert.Contains(t, ids, int64(3))
+		assert.NotContains(t, ids, int64(1))
+	})
+	t.Run("done items", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"checklist_done", "checklist_total"},
+			FilterValue:      []string{"1", "2"},
+			FilterComparator: []string{"equals", "equals"},
+			FilterConcat:     "and",
+		})
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tf := &TaskCollection{
+			ListID:      1,
+			FilterBy:    []string{"checklist_total"},
+			FilterValue: []string{"a lot"},
+		}
+		_, _, _, err := tf.ReadAll(s, u, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterValue(err))
+	})
+}
@@ -47,12 +47,19 @@ type TaskCollection struct {
 	FilterConcat string `query:"filter_concat" json:"filter_concat"`
 	// If set to true, the result will also include null values
 	FilterIncludeNulls bool `query:"filter_include_nulls" json:"filter_include_nulls"`
+	// A filter query like `done = false && (priority >= 3 || due_date < now+2d)`.
+	// It is combined with the other filter parameters, both need to match.
+	Filter string `query:"filter" json:"filter,omitempty"`
 
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
 
 func validateTaskField(fieldName string) error {
+	if _, is := getCustomFieldIDFromTaskField(fieldName); is {
+		return nil
+	}
+
 	switch fieldName {
 	case
 		taskPropertyID,
@@ -63,7 +70,6 @@ func validateTaskField(fieldName string) error {
 		taskPropertyDueDate,
 		taskPropertyCreatedByID,
 		taskPropertyListID,
-		taskPropertyRepeatAfter,
 		taskPropertyPriority,
 		taskPropertyStartDate,
 		taskPropertyEndDate,
@@ -74,7 +80,9 @@ func validateTaskField(fieldName string) error {
 		taskPropertyUpdated,
 		taskPropertyPosition,
 		taskPropertyKanbanPosition,
-		taskPropertyBucketID:
+		taskPropertyBucketID,
+		taskPropertyAssignees,
+		taskPropertyLabels:
 		return nil
 	}
 	return ErrInvalidTaskField{TaskField: fieldName}
@@ -114,7 +122,7 @@ func getTaskFilterOptsFromCollection(tf *TaskCollection) (opts *taskOptions, err
 		filterIncludeNulls: tf.FilterIncludeNulls,
 	}
 
-	opts.filters, err = getTaskFiltersByCollections(tf)
+	opts.filters, opts.filterTree, err = getTaskFiltersByCollections(tf)
 	return opts, err
 }
 
@@ -127,14 +135,15 @@ func getTaskFilterOptsFromCollection(tf *TaskCollection) (opts *taskOptions, err
 // @Param listID path int true "The list ID."
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
-// @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param s query string false "Search tasks by title, description, comments and attachment names, or by their identifier. Results are ordered by relevance unless sort_by is given."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`, `assignees` and `labels`. Tasks with multiple assignees or labels are sorted by the alphabetically first assignee name or label title. To sort by a custom field, use `custom_field_` followed by the id of the field. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
-// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
+// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. To filter by the checklist of a task, use `checklist_total`, `checklist_done` or `checklist_progress`, the share of done checklist items between 0 and 1. To only get tasks which are blocked by or follow tasks which are not done yet, use `is_blocked`. To filter by the relations of a task, use `assignees` or `created_by` with usernames, `labels`, `namespace` or `bucket` with ids, `has_attachments` with `true` or `false` and `has_relation_kind` with a relation kind. For `assignees`, `labels` and `has_relation_kind`, `not_equals` and `not_in` match all tasks which have none of the values. To filter by a custom field, use `custom_field_` followed by the id of the field. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for. You can use [grafana](https://grafana.com/docs/grafana/latest/dashboards/time-range-controls)- or [elasticsearch](https://www.elastic.co/guide/en/elasticsearch/reference/7.3/common-options.html#date-math)-style relative dates for all date fields like `due_date`, `start_date`, `end_date`, etc."
-// @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
+// @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `not_equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like`, `in` and `not_in`. `in` and `not_in` expect comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter query like `done = false && (priority >= 3 || due_date < now+2d)`. Comparisons use the same fields, comparators and values as `filter_by`, `filter_comparator` and `filter_value`, written as `=`, `!=`, `>`, `>=`, `<`, `<=`, `like`, `in` and `not in`. They can be combined with `&&` and `||`, negated with `!` and grouped with parentheses. If the other filter parameters are provided as well, both need to match."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
 // @Failure 500 {object} models.Message "Internal error"
@@ -144,16 +153,26 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 	// If the list id is < -1 this means we're dealing with a saved filter - in that case we get and populate the filter
 	// -1 is the favorites list which works as intended
 	if tf.ListID < -1 {
-		sf, err := getSavedFilterSimpleByID(s, getSavedFilterIDFromListID(tf.ListID))
+		sf := &SavedFilter{ID: getSavedFilterIDFromListID(tf.ListID)}
+		canRead, _, err := sf.CanRead(s, a)
 		if err != nil {
 			return nil, 0, 0, err
 		}
+		if !canRead {
+			return nil, 0, 0, ErrGenericForbidden{}
+		}
 
 		sf.Filters.SortByArr = tf.SortByArr
 		sf.Filters.SortBy = tf.SortBy
 		sf.Filters.OrderByArr = tf.OrderByArr
 		sf.Filters.OrderBy = tf.OrderBy
 
+		// Link shares don't have access to any lists themselves, they see the tasks of the filter
+		// in all lists the user who shared it has access to.
+		if share, is := a.(*LinkSharing); is {
+			return sf.getTasksForLinkShare(s, share, search, page, perPage)
+		}
+
 		return sf.getTaskCollection().ReadAll(s, a, search, page, perPage)
 	}
 
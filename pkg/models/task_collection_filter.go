@@ -42,6 +42,40 @@ const (
 	taskFilterComparatorNotEquals    taskFilterComparator = "!="
 	taskFilterComparatorLike         taskFilterComparator = "like"
 	taskFilterComparatorIn           taskFilterComparator = "in"
+	taskFilterComparatorNotIn        taskFilterComparator = "not in"
+)
+
+// takesMultipleValues returns true if the comparator compares against a list of values.
+func (c taskFilterComparator) takesMultipleValues() bool {
+	return c == taskFilterComparatorIn || c == taskFilterComparatorNotIn
+}
+
+// isNegated returns true if the comparator matches everything which is not the value.
+func (c taskFilterComparator) isNegated() bool {
+	return c == taskFilterComparatorNotEquals || c == taskFilterComparatorNotIn
+}
+
+// negate returns the opposite of a negated comparator, `!=` becomes `=` and `not in` becomes `in`.
+func (c taskFilterComparator) negate() taskFilterComparator {
+	switch c {
+	case taskFilterComparatorNotEquals:
+		return taskFilterComparatorEquals
+	case taskFilterComparatorNotIn:
+		return taskFilterComparatorIn
+	case taskFilterComparatorEquals:
+		return taskFilterComparatorNotEquals
+	case taskFilterComparatorIn:
+		return taskFilterComparatorNotIn
+	}
+	return c
+}
+
+// Filter fields which are not a property of a task but are derived from its relations.
+const (
+	taskFilterFieldBucket          = "bucket"
+	taskFilterFieldCreatedBy       = "created_by"
+	taskFilterFieldHasAttachments  = "has_attachments"
+	taskFilterFieldHasRelationKind = "has_relation_kind"
 )
 
 type taskFilter struct {
@@ -50,7 +84,7 @@ type taskFilter struct {
 	comparator taskFilterComparator
 }
 
-func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err error) {
+func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, tree *taskFilterGroup, err error) {
 
 	if len(c.FilterByArr) > 0 {
 		c.FilterBy = append(c.FilterBy, c.FilterByArr...)
@@ -65,7 +99,7 @@ func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err
 	}
 
 	if c.FilterConcat != "" && c.FilterConcat != filterConcatAnd && c.FilterConcat != filterConcatOr {
-		return nil, ErrInvalidTaskFilterConcatinator{
+		return nil, nil, ErrInvalidTaskFilterConcatinator{
 			Concatinator: taskFilterConcatinator(c.FilterConcat),
 		}
 	}
@@ -93,7 +127,7 @@ func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err
 		if len(c.FilterValue) > i {
 			filter.value, err = getNativeValueForTaskField(filter.field, filter.comparator, c.FilterValue[i])
 			if err != nil {
-				return nil, ErrInvalidTaskFilterValue{
+				return nil, nil, ErrInvalidTaskFilterValue{
 					Value: filter.field,
 					Field: c.FilterValue[i],
 				}
@@ -103,6 +137,7 @@ func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err
 		filters = append(filters, filter)
 	}
 
+	tree, err = parseTaskFilterQuery(c.Filter)
 	return
 }
 
@@ -116,7 +151,8 @@ func validateTaskFieldComparator(comparator taskFilterComparator) error {
 		taskFilterComparatorLessEquals,
 		taskFilterComparatorNotEquals,
 		taskFilterComparatorLike,
-		taskFilterComparatorIn:
+		taskFilterComparatorIn,
+		taskFilterComparatorNotIn:
 		return nil
 	case taskFilterComparatorInvalid:
 		fallthrough
@@ -143,6 +179,8 @@ func getFilterComparatorFromString(comparator string) (taskFilterComparator, err
 		return taskFilterComparatorLike, nil
 	case "in":
 		return taskFilterComparatorIn, nil
+	case "not_in":
+		return taskFilterComparatorNotIn, nil
 	default:
 		return taskFilterComparatorInvalid, ErrInvalidTaskFilterComparator{Comparator: taskFilterComparator(comparator)}
 	}
@@ -197,7 +235,7 @@ func getNativeValueForTaskField(fieldName string, comparator taskFilterComparato
 	realFieldName := strings.ReplaceAll(strcase.ToCamel(fieldName), "Id", "ID")
 
 	if realFieldName == "Namespace" {
-		if comparator == taskFilterComparatorIn {
+		if comparator.takesMultipleValues() {
 			vals := strings.Split(value, ",")
 			valueSlice := []interface{}{}
 			for _, val := range vals {
@@ -214,18 +252,64 @@ func getNativeValueForTaskField(fieldName string, comparator taskFilterComparato
 		return
 	}
 
-	if realFieldName == "Assignees" {
+	// The type of a custom field is only known once the field is loaded from the db, which is why its value is
+	// converted only when building the query.
+	if _, is := getCustomFieldIDFromTaskField(fieldName); is {
+		if comparator.takesMultipleValues() {
+			valueSlice := []interface{}{}
+			for _, val := range strings.Split(value, ",") {
+				valueSlice = append(valueSlice, val)
+			}
+			return valueSlice, nil
+		}
+		return value, nil
+	}
+
+	// Assignees and the creator of a task are filtered by their username
+	if realFieldName == "Assignees" || realFieldName == "CreatedBy" {
 		vals := strings.Split(value, ",")
 		valueSlice := append([]string{}, vals...)
 		return valueSlice, nil
 	}
 
-	field, ok := reflect.TypeOf(&Task{}).Elem().FieldByName(realFieldName)
-	if !ok {
-		return nil, ErrInvalidTaskField{TaskField: fieldName}
+	if fieldName == taskFilterFieldHasRelationKind {
+		vals := []string{value}
+		if comparator.takesMultipleValues() {
+			vals = strings.Split(value, ",")
+		}
+		valueSlice := []interface{}{}
+		for _, val := range vals {
+			if !RelationKind(val).isValid() {
+				return nil, ErrInvalidRelationKind{Kind: RelationKind(val)}
+			}
+			valueSlice = append(valueSlice, val)
+		}
+		if comparator.takesMultipleValues() {
+			return valueSlice, nil
+		}
+		return value, nil
+	}
+
+	if fieldName == taskFilterFieldHasAttachments {
+		return strconv.ParseBool(value)
+	}
+
+	if fieldName == taskFilterFieldBucket {
+		realFieldName = "BucketID"
+	}
+
+	var field reflect.StructField
+	if checklistField, is := taskChecklistFilterFields[fieldName]; is {
+		field = reflect.StructField{Name: realFieldName, Type: checklistField.kind}
+	} else {
+		var ok bool
+		field, ok = reflect.TypeOf(&Task{}).Elem().FieldByName(realFieldName)
+		if !ok {
+			return nil, ErrInvalidTaskField{TaskField: fieldName}
+		}
 	}
 
-	if comparator == taskFilterComparatorIn {
+	if comparator.takesMultipleValues() {
 		vals := strings.Split(value, ",")
 		valueSlice := []interface{}{}
 		for _, val := range vals {
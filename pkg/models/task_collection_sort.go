@@ -34,7 +34,6 @@ const (
 	taskPropertyDueDate        string = "due_date"
 	taskPropertyCreatedByID    string = "created_by_id"
 	taskPropertyListID         string = "list_id"
-	taskPropertyRepeatAfter    string = "repeat_after"
 	taskPropertyPriority       string = "priority"
 	taskPropertyStartDate      string = "start_date"
 	taskPropertyEndDate        string = "end_date"
@@ -46,8 +45,19 @@ const (
 	taskPropertyPosition       string = "position"
 	taskPropertyKanbanPosition string = "kanban_position"
 	taskPropertyBucketID       string = "bucket_id"
+	taskPropertyAssignees      string = "assignees"
+	taskPropertyLabels         string = "labels"
 )
 
+// Tasks can have multiple assignees and labels, they are sorted by the alphabetically first one.
+// Tasks without any are sorted last.
+var taskRelationSortExpressions = map[string]string{
+	taskPropertyAssignees: "(SELECT MIN(COALESCE(NULLIF(users.name, ''), users.username)) FROM task_assignees " +
+		"INNER JOIN users ON users.id = task_assignees.user_id WHERE task_assignees.task_id = tasks.id)",
+	taskPropertyLabels: "(SELECT MIN(labels.title) FROM label_tasks " +
+		"INNER JOIN labels ON labels.id = label_tasks.label_id WHERE label_tasks.task_id = tasks.id)",
+}
+
 const (
 	orderInvalid    sortOrder = "invalid"
 	orderAscending  sortOrder = "asc"
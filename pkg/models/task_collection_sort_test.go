@@ -51,7 +51,6 @@ func TestSortParamValidation(t *testing.T) {
 			taskPropertyDueDate,
 			taskPropertyCreatedByID,
 			taskPropertyListID,
-			taskPropertyRepeatAfter,
 			taskPropertyPriority,
 			taskPropertyStartDate,
 			taskPropertyEndDate,
@@ -97,6 +97,11 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Labels: []*Label{
 			label4,
 		},
+		CustomFields: map[int64]interface{}{
+			1: "Acme",
+			2: float64(5),
+			3: []string{"production", "staging"},
+		},
 		RelatedTasks: map[RelationKind][]*Task{
 			RelationKindSubtask: {
 				{
@@ -126,6 +131,18 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 					Created:     time.Unix(1570998791, 0).In(loc),
 					CreatedByID: 1,
 				},
+				Previews: []*TaskAttachmentPreview{
+					{
+						ID:           1,
+						AttachmentID: 1,
+						FileID:       2,
+						Size:         TaskAttachmentPreviewSizeSmall,
+						Width:        128,
+						Height:       64,
+						URL:          "/api/v1/tasks/1/attachments/1?size=sm",
+						Created:      time.Unix(1570998791, 0).In(loc),
+					},
+				},
 			},
 			{
 				ID:          2,
@@ -151,6 +168,13 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 				},
 			},
 		},
+		Reactions: []*ReactionSummary{
+			{
+				Value: "👋",
+				Count: 1,
+				Users: []*user.User{user1},
+			},
+		},
 		Created: time.Unix(1543626724, 0).In(loc),
 		Updated: time.Unix(1543626724, 0).In(loc),
 	}
@@ -172,6 +196,10 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Reminders: []time.Time{
 			time.Unix(1543626824, 0).In(loc),
 		},
+		CustomFields: map[int64]interface{}{
+			1: "Globex",
+			2: float64(8),
+		},
 		Created: time.Unix(1543626724, 0).In(loc),
 		Updated: time.Unix(1543626724, 0).In(loc),
 	}
@@ -188,6 +216,9 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Updated:      time.Unix(1543626724, 0).In(loc),
 		Priority:     100,
 		BucketID:     2,
+		CustomFields: map[int64]interface{}{
+			2: float64(3),
+		},
 	}
 	task4 := &Task{
 		ID:           4,
@@ -489,14 +520,14 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 	}
 	task28 := &Task{
 		ID:           28,
-		Title:        "task #28 with repeat after",
+		Title:        "task #28 with repeat rule",
 		Identifier:   "test1-13",
 		Index:        13,
 		CreatedByID:  1,
 		CreatedBy:    user1,
 		ListID:       1,
 		RelatedTasks: map[RelationKind][]*Task{},
-		RepeatAfter:  3600,
+		RepeatRule:   "FREQ=HOURLY",
 		BucketID:     1,
 		Created:      time.Unix(1543626724, 0).In(loc),
 		Updated:      time.Unix(1543626724, 0).In(loc),
@@ -64,3 +64,15 @@ func (tc *TaskComment) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
 	t := Task{ID: tc.TaskID}
 	return t.CanWrite(s, a)
 }
+
+// CanRead checks if a user can read the edit history of a comment
+func (tce *TaskCommentEdit) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	// Makes sure the comment belongs to the task
+	tc := &TaskComment{ID: tce.CommentID, TaskID: tce.TaskID}
+	err := getTaskCommentSimple(s, tc)
+	if err != nil {
+		return false, 0, err
+	}
+
+	return tc.CanRead(s, a)
+}
@@ -37,6 +37,26 @@ type TaskComment struct {
 	Author   *user.User `xorm:"-" json:"author"`
 	TaskID   int64      `xorm:"not null" json:"-" param:"task"`
 
+	// The id of the comment this comment replies to. 0 if this comment does not reply to any other comment.
+	ParentID int64 `xorm:"bigint not null default 0 INDEX" json:"parent_id"`
+	// The id of the top level comment of the thread this comment belongs to. 0 if this comment is a top level comment itself.
+	// You cannot change this value, it is set from the parent comment.
+	ThreadID int64 `xorm:"bigint not null default 0 INDEX" json:"thread_id"`
+	// How many replies this comment has in its thread. Only set for top level comments.
+	ReplyCount int64 `xorm:"-" json:"reply_count"`
+
+	// Whether this comment was edited after it was created. The previous versions are in its edit history.
+	IsEdited bool `xorm:"bool default false" json:"is_edited"`
+	// Whether this comment was deleted. Deleted comments stay in their thread, but without their text.
+	IsDeleted bool `xorm:"bool default false" json:"is_deleted"`
+
+	// All reactions to this comment, grouped by their value with the users who reacted. The most used reactions come first.
+	Reactions []*ReactionSummary `xorm:"-" json:"reactions"`
+
+	// If set, only the replies in the thread of the top level comment with this id are returned.
+	// Otherwise all top level comments are returned.
+	Thread int64 `xorm:"-" json:"-" query:"thread"`
+
 	Created time.Time `xorm:"created" json:"created"`
 	Updated time.Time `xorm:"updated" json:"updated"`
 
@@ -49,6 +69,30 @@ func (tc *TaskComment) TableName() string {
 	return "task_comments"
 }
 
+// TaskCommentEdit holds the text a comment had before it was edited
+type TaskCommentEdit struct {
+	// The unique, numeric id of this edit.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The comment this edit belongs to.
+	CommentID int64 `xorm:"bigint not null INDEX" json:"comment_id" param:"commentid"`
+	// The text of the comment before it was edited.
+	Comment string `xorm:"text not null" json:"comment"`
+
+	// The task the comment belongs to. Only used to check the rights.
+	TaskID int64 `xorm:"-" json:"-" param:"task"`
+
+	// A timestamp when the comment was edited. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the task comment edits table
+func (TaskCommentEdit) TableName() string {
+	return "task_comment_edits"
+}
+
 // Create creates a new task comment
 // @Summary Create a new task comment
 // @Description Create a new task comment. The user doing this need to have at least write access to the task this comment should belong to.
@@ -56,10 +100,12 @@ func (tc *TaskComment) TableName() string {
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
-// @Param relation body models.TaskComment true "The task comment object"
+// @Param relation body models.TaskComment true "The task comment object. To reply to another comment, set its id as `parent_id`."
 // @Param taskID path int true "Task ID"
 // @Success 201 {object} models.TaskComment "The created task comment object."
 // @Failure 400 {object} web.HTTPError "Invalid task comment object provided."
+// @Failure 404 {object} web.HTTPError "The comment to reply to does not exist."
+// @Failure 412 {object} web.HTTPError "The comment to reply to was deleted."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments [put]
 func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
@@ -69,11 +115,31 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	var parent *TaskComment
+	tc.ThreadID = 0
+	if tc.ParentID != 0 {
+		parent = &TaskComment{ID: tc.ParentID, TaskID: tc.TaskID}
+		err = getTaskCommentSimple(s, parent)
+		if err != nil {
+			return err
+		}
+		if parent.IsDeleted {
+			return ErrTaskCommentIsDeleted{ID: parent.ID, TaskID: tc.TaskID}
+		}
+
+		tc.ThreadID = parent.ThreadID
+		if tc.ThreadID == 0 {
+			tc.ThreadID = parent.ID
+		}
+	}
+
 	tc.Author, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return err
 	}
 	tc.AuthorID = tc.Author.ID
+	tc.IsEdited = false
+	tc.IsDeleted = false
 
 	_, err = s.Insert(tc)
 	if err != nil {
@@ -83,13 +149,14 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 	return events.Dispatch(&TaskCommentCreatedEvent{
 		Task:    &task,
 		Comment: tc,
+		Parent:  parent,
 		Doer:    tc.Author,
 	})
 }
 
 // Delete removes a task comment
 // @Summary Remove a task comment
-// @Description Remove a task comment. The user doing this need to have at least write access to the task this comment belongs to.
+// @Description Remove a task comment. The user doing this need to have at least write access to the task this comment belongs to. The comment stays in its thread and is marked as deleted, its text, edit history and reactions are removed.
 // @tags task
 // @Accept json
 // @Produce json
@@ -99,22 +166,60 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Success 200 {object} models.Message "The task comment was successfully deleted."
 // @Failure 400 {object} web.HTTPError "Invalid task comment object provided."
 // @Failure 404 {object} web.HTTPError "The task comment was not found."
+// @Failure 412 {object} web.HTTPError "The task comment was already deleted."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [delete]
 func (tc *TaskComment) Delete(s *xorm.Session, a web.Auth) error {
-	deleted, err := s.
+	original, err := getTaskCommentByID(s, tc.ID)
+	if err != nil {
+		return err
+	}
+	*tc = *original
+	if tc.IsDeleted {
+		return ErrTaskCommentIsDeleted{ID: tc.ID, TaskID: tc.TaskID}
+	}
+
+	// Replies to a deleted comment should still make sense, that's why it stays in the thread.
+	tc.Comment = ""
+	tc.IsDeleted = true
+	_, err = s.
 		ID(tc.ID).
+		Cols("comment", "is_deleted").
 		NoAutoCondition().
-		Delete(tc)
-	if deleted == 0 {
-		return ErrTaskCommentDoesNotExist{ID: tc.ID}
+		Update(tc)
+	if err != nil {
+		return err
 	}
-	return err
+
+	_, err = s.Where("comment_id = ?", tc.ID).Delete(&TaskCommentEdit{})
+	if err != nil {
+		return err
+	}
+
+	err = deleteReactions(s, builder.And(
+		builder.Eq{"entity_kind": ReactionKindComment},
+		builder.Eq{"entity_id": tc.ID},
+	))
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskSimple(s, &Task{ID: tc.TaskID})
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	return events.Dispatch(&TaskCommentDeletedEvent{
+		Task:    &task,
+		Comment: tc,
+		Doer:    doer,
+	})
 }
 
 // Update updates a task text by its ID
 // @Summary Update an existing task comment
-// @Description Update an existing task comment. The user doing this need to have at least write access to the task this comment belongs to.
+// @Description Update an existing task comment. The user doing this need to have at least write access to the task this comment belongs to. The previous text of the comment is kept in its edit history.
 // @tags task
 // @Accept json
 // @Produce json
@@ -124,33 +229,76 @@ func (tc *TaskComment) Delete(s *xorm.Session, a web.Auth) error {
 // @Success 200 {object} models.TaskComment "The updated task comment object."
 // @Failure 400 {object} web.HTTPError "Invalid task comment object provided."
 // @Failure 404 {object} web.HTTPError "The task comment was not found."
+// @Failure 412 {object} web.HTTPError "The task comment was deleted."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [post]
 func (tc *TaskComment) Update(s *xorm.Session, a web.Auth) error {
-	updated, err := s.
+	original, err := getTaskCommentByID(s, tc.ID)
+	if err != nil {
+		return err
+	}
+	if original.IsDeleted {
+		return ErrTaskCommentIsDeleted{ID: tc.ID, TaskID: original.TaskID}
+	}
+
+	tc.TaskID = original.TaskID
+	tc.ParentID = original.ParentID
+	tc.ThreadID = original.ThreadID
+	tc.AuthorID = original.AuthorID
+	tc.IsEdited = original.IsEdited
+	tc.Created = original.Created
+
+	if original.Comment != tc.Comment {
+		_, err = s.Insert(&TaskCommentEdit{
+			CommentID: tc.ID,
+			Comment:   original.Comment,
+		})
+		if err != nil {
+			return err
+		}
+		tc.IsEdited = true
+	}
+
+	_, err = s.
 		ID(tc.ID).
-		Cols("comment").
+		Cols("comment", "is_edited").
 		Update(tc)
-	if updated == 0 {
-		return ErrTaskCommentDoesNotExist{ID: tc.ID}
+	if err != nil {
+		return err
 	}
 
+	task, err := GetTaskSimple(s, &Task{ID: tc.TaskID})
 	if err != nil {
 		return err
 	}
 
-	task, err := GetTaskSimple(s, &Task{ID: tc.TaskID})
+	tc.Author, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return err
 	}
 
 	return events.Dispatch(&TaskCommentUpdatedEvent{
-		Task:    &task,
-		Comment: tc,
-		Doer:    tc.Author,
+		Task:            &task,
+		Comment:         tc,
+		Doer:            tc.Author,
+		PreviousComment: original.Comment,
 	})
 }
 
+func getTaskCommentByID(s *xorm.Session, id int64) (tc *TaskComment, err error) {
+	tc = &TaskComment{}
+	exists, err := s.
+		Where("id = ?", id).
+		Get(tc)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTaskCommentDoesNotExist{ID: id}
+	}
+	return
+}
+
 func getTaskCommentSimple(s *xorm.Session, tc *TaskComment) error {
 	exists, err := s.
 		Where("id = ? and task_id = ?", tc.ID, tc.TaskID).
@@ -195,17 +343,27 @@ func (tc *TaskComment) ReadOne(s *xorm.Session, a web.Auth) (err error) {
 		Where("id = ?", tc.AuthorID).
 		Get(author)
 	tc.Author = author
+
+	reactions, err := getReactionSummaries(s, []int64{tc.ID}, ReactionKindComment)
+	if err != nil {
+		return err
+	}
+	tc.Reactions = reactions[tc.ID]
 	return
 }
 
 // ReadAll returns all comments for a task
 // @Summary Get all task comments
-// @Description Get all task comments. The user doing this need to have at least read access to the task.
+// @Description Get all top level task comments with the number of replies in their thread, or all replies in one thread. When searching without a thread, all comments matching the search are returned. The user doing this need to have at least read access to the task.
 // @tags task
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
 // @Param taskID path int true "Task ID"
+// @Param thread query int false "The id of a top level comment. If set, only the replies in its thread are returned."
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search comments by their text."
 // @Success 200 {array} models.TaskComment "The array with all task comments"
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments [get]
@@ -220,21 +378,22 @@ func (tc *TaskComment) ReadAll(s *xorm.Session, auth web.Auth, search string, pa
 		return nil, 0, 0, ErrGenericForbidden{}
 	}
 
-	// Because we can't extend the type in general, we need to do this here.
-	// Not a good solution, but saves performance.
-	type TaskCommentWithAuthor struct {
-		TaskComment
-		AuthorFromDB *user.User `xorm:"extends" json:"-"`
+	cond := builder.And(
+		builder.Eq{"task_id": tc.TaskID},
+		db.ILIKE("comment", search),
+	)
+	switch {
+	case tc.Thread != 0:
+		cond = builder.And(cond, builder.Eq{"thread_id": tc.Thread})
+	case search == "":
+		cond = builder.And(cond, builder.Eq{"thread_id": 0})
 	}
 
 	limit, start := getLimitFromPageIndex(page, perPage)
 	comments := []*TaskComment{}
 	query := s.
-		Where(builder.And(
-			builder.Eq{"task_id": tc.TaskID},
-			db.ILIKE("comment", search),
-		)).
-		Join("LEFT", "users", "users.id = task_comments.author_id")
+		Where(cond).
+		OrderBy("task_comments.id asc")
 	if limit > 0 {
 		query = query.Limit(limit, start)
 	}
@@ -244,8 +403,14 @@ func (tc *TaskComment) ReadAll(s *xorm.Session, auth web.Auth, search string, pa
 	}
 
 	var authorIDs []int64
+	var commentIDs []int64
+	var topLevelIDs []int64
 	for _, comment := range comments {
 		authorIDs = append(authorIDs, comment.AuthorID)
+		commentIDs = append(commentIDs, comment.ID)
+		if comment.ThreadID == 0 {
+			topLevelIDs = append(topLevelIDs, comment.ID)
+		}
 	}
 
 	authors, err := getUsersOrLinkSharesFromIDs(s, authorIDs)
@@ -253,12 +418,90 @@ func (tc *TaskComment) ReadAll(s *xorm.Session, auth web.Auth, search string, pa
 		return
 	}
 
+	replyCounts, err := getTaskCommentReplyCounts(s, topLevelIDs)
+	if err != nil {
+		return
+	}
+
+	reactions, err := getReactionSummaries(s, commentIDs, ReactionKindComment)
+	if err != nil {
+		return
+	}
+
 	for _, comment := range comments {
 		comment.Author = authors[comment.AuthorID]
+		comment.ReplyCount = replyCounts[comment.ID]
+		comment.Reactions = reactions[comment.ID]
 	}
 
 	numberOfTotalItems, err = s.
-		Where("task_id = ? AND comment like ?", tc.TaskID, "%"+search+"%").
-		Count(&TaskCommentWithAuthor{})
+		Where(cond).
+		Count(&TaskComment{})
 	return comments, len(comments), numberOfTotalItems, err
 }
+
+// Returns how many replies the threads of the top level comments have, keyed by the comment id.
+func getTaskCommentReplyCounts(s *xorm.Session, commentIDs []int64) (counts map[int64]int64, err error) {
+	counts = make(map[int64]int64, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return
+	}
+
+	replies := []*TaskComment{}
+	err = s.
+		In("thread_id", commentIDs).
+		Cols("thread_id").
+		Find(&replies)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range replies {
+		counts[r.ThreadID]++
+	}
+	return
+}
+
+// ReadAll returns the edit history of a comment
+// @Summary Get the edit history of a task comment
+// @Description Returns all previous versions of a task comment, oldest first. The user doing this need to have at least read access to the task this comment belongs to.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param commentID path int true "Comment ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskCommentEdit "The previous versions of the comment."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The task comment was not found."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/comments/{commentID}/history [get]
+func (tce *TaskCommentEdit) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, _, err := tce.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	edits := []*TaskCommentEdit{}
+	query := s.
+		Where("comment_id = ?", tce.CommentID).
+		OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&edits)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where("comment_id = ?", tce.CommentID).
+		Count(&TaskCommentEdit{})
+	return edits, len(edits), numberOfTotalItems, err
+}
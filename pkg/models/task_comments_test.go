@@ -52,6 +52,59 @@ func TestTaskComment_Create(t *testing.T) {
 			"task_id":   1,
 		}, false)
 	})
+	t.Run("reply", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{
+			Comment:  "test",
+			TaskID:   1,
+			ParentID: 18,
+		}
+		err := tc.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), tc.ThreadID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":        tc.ID,
+			"parent_id": 18,
+			"thread_id": 1,
+		}, false)
+	})
+	t.Run("reply to a comment of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{
+			Comment:  "test",
+			TaskID:   1,
+			ParentID: 2,
+		}
+		err := tc.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentDoesNotExist(err))
+	})
+	t.Run("reply to a deleted comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&TaskComment{ID: 1, TaskID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		tc := &TaskComment{
+			Comment:  "test",
+			TaskID:   1,
+			ParentID: 1,
+		}
+		err = tc.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentIsDeleted(err))
+	})
 	t.Run("nonexisting task", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -107,8 +160,27 @@ func TestTaskComment_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
-		db.AssertMissing(t, "task_comments", map[string]interface{}{
-			"id": 1,
+		// Deleted comments are only marked as such to keep the thread intact
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":         1,
+			"comment":    "",
+			"is_deleted": true,
+		}, false)
+		events.AssertDispatched(t, &TaskCommentDeletedEvent{})
+	})
+	t.Run("removes the edit history", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{ID: 18, TaskID: 1}
+		err := tc.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_comment_edits", map[string]interface{}{
+			"comment_id": 18,
 		})
 	})
 	t.Run("nonexisting comment", func(t *testing.T) {
@@ -151,10 +223,31 @@ func TestTaskComment_Update(t *testing.T) {
 		assert.NoError(t, err)
 
 		db.AssertExists(t, "task_comments", map[string]interface{}{
-			"id":      1,
-			"comment": "testing",
+			"id":        1,
+			"comment":   "testing",
+			"is_edited": true,
+		}, false)
+		db.AssertExists(t, "task_comment_edits", map[string]interface{}{
+			"comment_id": 1,
+			"comment":    "Lorem Ipsum Dolor Sit Amet",
 		}, false)
 	})
+	t.Run("deleted comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&TaskComment{ID: 1, TaskID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		tc := &TaskComment{
+			ID:      1,
+			Comment: "testing",
+		}
+		err = tc.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentIsDeleted(err))
+	})
 	t.Run("nonexisting comment", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -221,6 +314,22 @@ func TestTaskComment_ReadAll(t *testing.T) {
 		assert.Equal(t, int64(1), resultComment[0].ID)
 		assert.Equal(t, "Lorem Ipsum Dolor Sit Amet", resultComment[0].Comment)
 		assert.NotEmpty(t, resultComment[0].Author.ID)
+		assert.Equal(t, int64(1), resultComment[0].ReplyCount)
+	})
+	t.Run("thread", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{TaskID: 1, Thread: 1}
+		u := &user.User{ID: 1}
+		result, _, total, err := tc.ReadAll(s, u, "", 0, -1)
+		assert.NoError(t, err)
+		resultComment := result.([]*TaskComment)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, int64(18), resultComment[0].ID)
+		assert.Equal(t, int64(1), resultComment[0].ParentID)
+		assert.True(t, resultComment[0].IsEdited)
 	})
 	t.Run("no access to task", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -266,3 +375,43 @@ func TestTaskComment_ReadAll(t *testing.T) {
 		assert.Equal(t, int64(15), resultComment[0].ID)
 	})
 }
+
+func TestTaskCommentEdit_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tce := &TaskCommentEdit{CommentID: 18, TaskID: 1}
+		u := &user.User{ID: 1}
+		can, _, err := tce.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		result, _, total, err := tce.ReadAll(s, u, "", 0, -1)
+		assert.NoError(t, err)
+		edits := result.([]*TaskCommentEdit)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "Reply to the frist comment", edits[0].Comment)
+	})
+	t.Run("comment of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tce := &TaskCommentEdit{CommentID: 18, TaskID: 2}
+		_, _, err := tce.CanRead(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentDoesNotExist(err))
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tce := &TaskCommentEdit{CommentID: 18, TaskID: 1}
+		can, _, err := tce.CanRead(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
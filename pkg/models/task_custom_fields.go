@@ -0,0 +1,490 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// TaskCustomFieldValue holds the value of a custom field for one task.
+// Multi select fields have one row per selected option.
+type TaskCustomFieldValue struct {
+	ID      int64 `xorm:"bigint autoincr not null unique pk"`
+	TaskID  int64 `xorm:"bigint INDEX not null"`
+	FieldID int64 `xorm:"bigint INDEX not null"`
+
+	// Only the column matching the type of the field is used.
+	ValueText   string    `xorm:"text null 'value_text'"`
+	ValueNumber float64   `xorm:"double null 'value_number'"`
+	ValueDate   time.Time `xorm:"DATETIME null 'value_date'"`
+}
+
+// TableName holds the table name for the custom field values table
+func (TaskCustomFieldValue) TableName() string {
+	return "task_custom_field_values"
+}
+
+// Custom fields are used in filter_by and sort_by with this prefix, followed by the id of the field.
+const taskCustomFieldPrefix = "custom_field_"
+
+func getCustomFieldIDFromTaskField(fieldName string) (id int64, is bool) {
+	if !strings.HasPrefix(fieldName, taskCustomFieldPrefix) {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(fieldName, taskCustomFieldPrefix), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (cf *ListCustomField) valueColumn() string {
+	switch cf.Type {
+	case CustomFieldTypeNumber, CustomFieldTypeUser:
+		return "value_number"
+	case CustomFieldTypeDate:
+		return "value_date"
+	default:
+		return "value_text"
+	}
+}
+
+func getCustomFieldNumber(raw interface{}) (number float64, ok bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// getValueRows checks a value sent by a client and converts it to the rows it is stored as.
+// Empty values don't have any rows.
+//nolint:gocyclo
+func (cf *ListCustomField) getValueRows(s *xorm.Session, task *Task, raw interface{}) (rows []*TaskCustomFieldValue, err error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	invalid := ErrInvalidCustomFieldValue{FieldID: cf.ID, Type: cf.Type}
+
+	switch cf.Type {
+	case CustomFieldTypeText, CustomFieldTypeURL, CustomFieldTypeSelect:
+		str, ok := raw.(string)
+		if !ok {
+			return nil, invalid
+		}
+		if str == "" {
+			return nil, nil
+		}
+		if cf.Type == CustomFieldTypeURL {
+			u, err := url.ParseRequestURI(str)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				return nil, invalid
+			}
+		}
+		if cf.Type == CustomFieldTypeSelect && !cf.hasOption(str) {
+			return nil, invalid
+		}
+		rows = append(rows, &TaskCustomFieldValue{ValueText: str})
+
+	case CustomFieldTypeMultiSelect:
+		var options []string
+		switch v := raw.(type) {
+		case []string:
+			options = v
+		case []interface{}:
+			for _, o := range v {
+				str, ok := o.(string)
+				if !ok {
+					return nil, invalid
+				}
+				options = append(options, str)
+			}
+		default:
+			return nil, invalid
+		}
+
+		seen := make(map[string]bool, len(options))
+		for _, o := range options {
+			if !cf.hasOption(o) {
+				return nil, invalid
+			}
+			if seen[o] {
+				continue
+			}
+			seen[o] = true
+			rows = append(rows, &TaskCustomFieldValue{ValueText: o})
+		}
+
+	case CustomFieldTypeNumber:
+		number, ok := getCustomFieldNumber(raw)
+		if !ok {
+			return nil, invalid
+		}
+		rows = append(rows, &TaskCustomFieldValue{ValueNumber: number})
+
+	case CustomFieldTypeUser:
+		number, ok := getCustomFieldNumber(raw)
+		if !ok || number != math.Trunc(number) {
+			return nil, invalid
+		}
+		u, err := user.GetUserByID(s, int64(number))
+		if err != nil {
+			return nil, err
+		}
+		list := &List{ID: task.ListID}
+		canRead, _, err := list.CanRead(s, u)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrUserDoesNotHaveAccessToList{ListID: task.ListID, UserID: u.ID}
+		}
+		rows = append(rows, &TaskCustomFieldValue{ValueNumber: float64(u.ID)})
+
+	case CustomFieldTypeDate:
+		var date time.Time
+		switch v := raw.(type) {
+		case time.Time:
+			date = v
+		case string:
+			date, err = time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, invalid
+			}
+		default:
+			return nil, invalid
+		}
+		if date.IsZero() {
+			return nil, nil
+		}
+		rows = append(rows, &TaskCustomFieldValue{ValueDate: date})
+	}
+
+	for _, r := range rows {
+		r.TaskID = task.ID
+		r.FieldID = cf.ID
+	}
+
+	return rows, nil
+}
+
+// getValueFromRows returns the value of a field for a task the way it is returned to clients.
+func (cf *ListCustomField) getValueFromRows(rows []*TaskCustomFieldValue) interface{} {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	switch cf.Type {
+	case CustomFieldTypeMultiSelect:
+		options := make([]string, 0, len(rows))
+		for _, r := range rows {
+			options = append(options, r.ValueText)
+		}
+		return options
+	case CustomFieldTypeNumber:
+		return rows[0].ValueNumber
+	case CustomFieldTypeUser:
+		return int64(rows[0].ValueNumber)
+	case CustomFieldTypeDate:
+		return rows[0].ValueDate.In(config.GetTimeZone())
+	default:
+		return rows[0].ValueText
+	}
+}
+
+func (cf *ListCustomField) getHistoryValue(rows []*TaskCustomFieldValue) interface{} {
+	value := cf.getValueFromRows(rows)
+	if date, is := value.(time.Time); is {
+		return taskHistoryTime(date)
+	}
+	return value
+}
+
+func getCustomFieldValuesForTasks(s *xorm.Session, taskIDs []int64) (values map[int64]map[int64]interface{}, err error) {
+	values = make(map[int64]map[int64]interface{})
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	rows := []*TaskCustomFieldValue{}
+	err = s.
+		In("task_id", taskIDs).
+		OrderBy("id ASC").
+		Find(&rows)
+	if err != nil || len(rows) == 0 {
+		return
+	}
+
+	rowsByTask := make(map[int64]map[int64][]*TaskCustomFieldValue)
+	fieldIDs := []int64{}
+	for _, r := range rows {
+		if _, has := rowsByTask[r.TaskID]; !has {
+			rowsByTask[r.TaskID] = make(map[int64][]*TaskCustomFieldValue)
+		}
+		rowsByTask[r.TaskID][r.FieldID] = append(rowsByTask[r.TaskID][r.FieldID], r)
+		fieldIDs = append(fieldIDs, r.FieldID)
+	}
+
+	fields, err := getListCustomFieldsByIDs(s, fieldIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for taskID, fieldRows := range rowsByTask {
+		values[taskID] = make(map[int64]interface{}, len(fieldRows))
+		for fieldID, rs := range fieldRows {
+			field, has := fields[fieldID]
+			if !has {
+				continue
+			}
+			values[taskID][fieldID] = field.getValueFromRows(rs)
+		}
+	}
+
+	return
+}
+
+func addCustomFieldsToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) (err error) {
+	values, err := getCustomFieldValuesForTasks(s, taskIDs)
+	if err != nil {
+		return
+	}
+
+	for taskID, v := range values {
+		taskMap[taskID].CustomFields = v
+	}
+	return
+}
+
+// updateCustomFields sets all passed custom field values of a task, saves what changed in the history and puts
+// all values of the task in t.CustomFields afterwards. Fields which are not passed are left untouched.
+func (t *Task) updateCustomFields(s *xorm.Session, a web.Auth, values map[int64]interface{}) (err error) {
+	if len(values) > 0 {
+		fieldIDs := make([]int64, 0, len(values))
+		for id := range values {
+			fieldIDs = append(fieldIDs, id)
+		}
+		sort.Slice(fieldIDs, func(i, j int) bool {
+			return fieldIDs[i] < fieldIDs[j]
+		})
+
+		fields := make(map[int64]*ListCustomField)
+		err = s.
+			In("id", fieldIDs).
+			And("list_id = ?", t.ListID).
+			Find(&fields)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range fieldIDs {
+			field, has := fields[id]
+			if !has {
+				return ErrCustomFieldDoesNotExist{ID: id, ListID: t.ListID}
+			}
+
+			err = t.setCustomFieldValue(s, a, field, values[id])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	allValues, err := getCustomFieldValuesForTasks(s, []int64{t.ID})
+	if err != nil {
+		return err
+	}
+	t.CustomFields = allValues[t.ID]
+	return nil
+}
+
+func (t *Task) setCustomFieldValue(s *xorm.Session, a web.Auth, field *ListCustomField, raw interface{}) error {
+	newRows, err := field.getValueRows(s, t, raw)
+	if err != nil {
+		return err
+	}
+
+	oldRows := []*TaskCustomFieldValue{}
+	err = s.
+		Where("task_id = ? AND field_id = ?", t.ID, field.ID).
+		OrderBy("id ASC").
+		Find(&oldRows)
+	if err != nil {
+		return err
+	}
+
+	oldValue := field.getHistoryValue(oldRows)
+	newValue := field.getHistoryValue(newRows)
+	if reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+
+	_, err = s.Where("task_id = ? AND field_id = ?", t.ID, field.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range newRows {
+		_, err = s.Insert(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return addTaskHistoryEntry(s, a, t.ID, taskCustomFieldPrefix+strconv.FormatInt(field.ID, 10), oldValue, newValue)
+}
+
+// When a task is moved to another list, the custom fields of the old list don't apply to it anymore.
+func removeCustomFieldValuesOfOtherLists(s *xorm.Session, t *Task) (err error) {
+	_, err = s.
+		Where(builder.And(
+			builder.Eq{"task_id": t.ID},
+			builder.NotIn("field_id", builder.
+				Select("id").
+				From("list_custom_fields").
+				Where(builder.Eq{"list_id": t.ListID})),
+		)).
+		Delete(&TaskCustomFieldValue{})
+	return
+}
+
+// getCustomFieldsForTaskOptions loads all custom fields used to filter or sort tasks. They need to belong to one of
+// the lists the tasks are searched in.
+func getCustomFieldsForTaskOptions(s *xorm.Session, listIDs []int64, opts *taskOptions) (fields map[int64]*ListCustomField, err error) {
+	fieldIDs := []int64{}
+	filters := append([]*taskFilter{}, opts.filters...)
+	filters = append(filters, opts.filterTree.allFilters()...)
+	for _, f := range filters {
+		if id, is := getCustomFieldIDFromTaskField(f.field); is {
+			fieldIDs = append(fieldIDs, id)
+		}
+	}
+	for _, param := range opts.sortby {
+		if id, is := getCustomFieldIDFromTaskField(param.sortBy); is {
+			fieldIDs = append(fieldIDs, id)
+		}
+	}
+
+	fields, err = getListCustomFieldsByIDs(s, fieldIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	validListIDs := make(map[int64]bool, len(listIDs))
+	for _, id := range listIDs {
+		validListIDs[id] = true
+	}
+
+	for _, id := range fieldIDs {
+		field, has := fields[id]
+		if !has || !validListIDs[field.ListID] {
+			return nil, ErrInvalidTaskField{TaskField: taskCustomFieldPrefix + strconv.FormatInt(id, 10)}
+		}
+	}
+
+	return fields, nil
+}
+
+// getSortExpression returns an expression to sort tasks by the value of this field.
+// Multi select fields are sorted by their lowest option.
+func (cf *ListCustomField) getSortExpression() string {
+	return "(SELECT MIN(task_custom_field_values." + cf.valueColumn() + ") FROM task_custom_field_values " +
+		"WHERE task_custom_field_values.task_id = tasks.id AND task_custom_field_values.field_id = " + strconv.FormatInt(cf.ID, 10) + ")"
+}
+
+func (cf *ListCustomField) getNativeFilterValue(rawValue string) (value interface{}, err error) {
+	field := reflect.StructField{Name: cf.Title}
+	switch cf.Type {
+	case CustomFieldTypeNumber:
+		field.Type = reflect.TypeOf(float64(0))
+	case CustomFieldTypeUser:
+		field.Type = reflect.TypeOf(int64(0))
+	case CustomFieldTypeDate:
+		field.Type = schemas.TimeType
+	default:
+		field.Type = reflect.TypeOf("")
+	}
+
+	return getValueForField(field, rawValue)
+}
+
+// getFilterCond returns the condition for a filter on this field. Tasks match if any of their values match, which
+// only makes a difference for multi select fields.
+func (cf *ListCustomField) getFilterCond(f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
+	// The type of a custom field is only known once it is loaded, so the value still is the raw filter value here
+	valueFilter := &taskFilter{
+		field:      f.field,
+		comparator: f.comparator,
+	}
+	switch v := f.value.(type) {
+	case string:
+		valueFilter.value, err = cf.getNativeFilterValue(v)
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, raw := range v {
+			value, err := cf.getNativeFilterValue(raw.(string))
+			if err != nil {
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: raw}
+			}
+			values = append(values, value)
+		}
+		valueFilter.value = values
+	}
+	if err != nil {
+		return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+	}
+
+	valueCond, err := getFilterCondForExpression("`"+cf.valueColumn()+"`", valueFilter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cond = builder.In("id", builder.
+		Select("task_id").
+		From("task_custom_field_values").
+		Where(builder.And(builder.Eq{"field_id": cf.ID}, valueCond)))
+
+	if includeNulls {
+		cond = builder.Or(cond, builder.NotIn("id", builder.
+			Select("task_id").
+			From("task_custom_field_values").
+			Where(builder.Eq{"field_id": cf.ID})))
+	}
+
+	return cond, nil
+}
@@ -0,0 +1,334 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTask_UpdateCustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("set values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		deadline := time.Date(2022, 10, 1, 12, 0, 0, 0, config.GetTimeZone())
+		task := &Task{
+			ID:     3,
+			Title:  "task #3 high prio",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				1: "Initech",
+				3: []interface{}{"staging", "staging", "development"},
+				4: deadline.Format(time.RFC3339),
+				5: float64(1),
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		// Values which were not passed are kept
+		assert.Equal(t, float64(3), task.CustomFields[2])
+		assert.Equal(t, "Initech", task.CustomFields[1])
+		assert.Equal(t, []string{"staging", "development"}, task.CustomFields[3])
+		assert.True(t, deadline.Equal(task.CustomFields[4].(time.Time)))
+		assert.Equal(t, int64(1), task.CustomFields[5])
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    3,
+			"field_id":   1,
+			"value_text": "Initech",
+		}, false)
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":      3,
+			"field_id":     5,
+			"value_number": 1,
+		}, false)
+
+		entries := getTaskHistoryForTest(t, s, 3, "custom_field_1")
+		assert.Len(t, entries, 1)
+		assert.Nil(t, entries[0].OldValue.Value)
+		assert.Equal(t, "Initech", entries[0].NewValue.Value)
+	})
+	t.Run("remove a value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "task #1",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				3: nil,
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		assert.NotContains(t, task.CustomFields, int64(3))
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  1,
+			"field_id": 3,
+		})
+	})
+	t.Run("on create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:  "Lorem",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				2: 13,
+			},
+		}
+		err := task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(13), task.CustomFields[2])
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "task #1",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				2: "five",
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("invalid option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "task #1",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				3: []interface{}{"testing"},
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("invalid url", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ListCustomField{
+			ListID: 1,
+			Title:  "Ticket",
+			Type:   CustomFieldTypeURL,
+		}
+		err := cf.Create(s, u)
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:     1,
+			Title:  "task #1",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				cf.ID: "not a url",
+			},
+		}
+		err = task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("user without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "task #1",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				5: float64(2),
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToList(err))
+	})
+	t.Run("field of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "task #1",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				6: "Test",
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+	t.Run("moving a task removes the values of the old list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			ListID: 2,
+			CustomFields: map[int64]interface{}{
+				6: "Test",
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64]interface{}{6: "Test"}, task.CustomFields)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  1,
+			"field_id": 1,
+		})
+	})
+}
+
+func TestTaskCollection_CustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	getTaskIDs := func(t *testing.T, tf *TaskCollection) (ids []int64, err error) {
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, _, err := tf.ReadAll(s, u, "", 0, 50)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return
+	}
+
+	t.Run("filter text", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids, err := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_1"},
+			FilterValue:      []string{"cme"},
+			FilterComparator: []string{"like"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("filter number", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids, err := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_2"},
+			FilterValue:      []string{"4"},
+			FilterComparator: []string{"greater"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, ids)
+	})
+	t.Run("filter multi select", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids, err := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_3"},
+			FilterValue:      []string{"staging,development"},
+			FilterComparator: []string{"in"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("filter including nulls", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids, err := getTaskIDs(t, &TaskCollection{
+			ListID:             1,
+			FilterBy:           []string{"custom_field_1"},
+			FilterValue:        []string{"Acme"},
+			FilterIncludeNulls: true,
+		})
+		assert.NoError(t, err)
+		assert.Contains(t, ids, int64(1))
+		assert.Contains(t, ids, int64(3))
+		assert.NotContains(t, ids, int64(2))
+	})
+	t.Run("filter with invalid value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		_, err := getTaskIDs(t, &TaskCollection{
+			ListID:      1,
+			FilterBy:    []string{"custom_field_2"},
+			FilterValue: []string{"a lot"},
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterValue(err))
+	})
+	t.Run("field of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		_, err := getTaskIDs(t, &TaskCollection{
+			ListID:      1,
+			FilterBy:    []string{"custom_field_6"},
+			FilterValue: []string{"Test"},
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskField(err))
+	})
+	t.Run("sort", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids, err := getTaskIDs(t, &TaskCollection{
+			ListID:  1,
+			SortBy:  []string{"custom_field_2"},
+			OrderBy: []string{"desc"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2, 1, 3}, ids[:3])
+	})
+}
@@ -0,0 +1,119 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// A task is blocked as long as a task which blocks it or which it follows is not done.
+// Both are saved from the perspective of the blocked task as "blocked" or "follows" relations.
+var taskBlockingRelationKinds = []RelationKind{RelationKindBlocked, RelationKindFollows}
+
+// Used to filter tasks by whether they are blocked, since that is not stored with the task.
+const taskIsBlockedFilterExpression = "((SELECT COUNT(*) FROM task_relations " +
+	"INNER JOIN tasks blocking_tasks ON blocking_tasks.id = task_relations.other_task_id " +
+	"WHERE task_relations.task_id = tasks.id " +
+	"AND task_relations.relation_kind IN ('blocked', 'follows') " +
+	"AND blocking_tasks.done = false " +
+	"AND blocking_tasks.deleted IS NULL) > 0)"
+
+// Relation kinds which form a hierarchy or an order and therefore must not form a cycle.
+func (rk RelationKind) isDirectional() bool {
+	return rk == RelationKindSubtask ||
+		rk == RelationKindParenttask ||
+		rk == RelationKindBlocking ||
+		rk == RelationKindBlocked ||
+		rk == RelationKindPreceeds ||
+		rk == RelationKindFollows
+}
+
+func isTaskBlocked(s *xorm.Session, taskID int64) (bool, error) {
+	return s.
+		Table("task_relations").
+		Join("INNER", "tasks", "tasks.id = task_relations.other_task_id").
+		Where(builder.And(
+			builder.Eq{"task_relations.task_id": taskID},
+			builder.In("task_relations.relation_kind", taskBlockingRelationKinds),
+			builder.Eq{"tasks.done": false},
+			builder.IsNull{"tasks.deleted"},
+		)).
+		Exist()
+}
+
+// Makes sure a task can only be marked as done once all tasks it depends on are done.
+// This is only enforced if the list of the task has it enabled.
+func checkTaskIsNotBlocked(s *xorm.Session, task *Task) error {
+	l, err := GetListSimpleByID(s, task.ListID)
+	if err != nil {
+		return err
+	}
+
+	if !l.EnforceDependencies {
+		return nil
+	}
+
+	blocked, err := isTaskBlocked(s, task.ID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrTaskIsBlocked{TaskID: task.ID}
+	}
+
+	return nil
+}
+
+// Checks if a new relation would close a cycle. Because every relation exists in both directions,
+// it is enough to check if the other task already reaches the task via relations of the same kind.
+func checkRelationCreatesCycle(s *xorm.Session, rel *TaskRelation) error {
+	if !rel.RelationKind.isDirectional() {
+		return nil
+	}
+
+	visited := map[int64]bool{rel.OtherTaskID: true}
+	current := []int64{rel.OtherTaskID}
+	for len(current) > 0 {
+		relations := []*TaskRelation{}
+		err := s.
+			In("task_id", current).
+			And("relation_kind = ?", rel.RelationKind).
+			Find(&relations)
+		if err != nil {
+			return err
+		}
+
+		next := []int64{}
+		for _, r := range relations {
+			if r.OtherTaskID == rel.TaskID {
+				return ErrRelationCreatesCycle{
+					TaskID:      rel.TaskID,
+					OtherTaskID: rel.OtherTaskID,
+					Kind:        rel.RelationKind,
+				}
+			}
+			if !visited[r.OtherTaskID] {
+				visited[r.OtherTaskID] = true
+				next = append(next, r.OtherTaskID)
+			}
+		}
+		current = next
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func setupBlockedTask(t *testing.T, s *xorm.Session, enforce bool) {
+	// Task 1 is blocked by task 3, both are in list 1 and not done
+	rel := &TaskRelation{
+		TaskID:       1,
+		OtherTaskID:  3,
+		RelationKind: RelationKindBlocked,
+	}
+	err := rel.Create(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+
+	_, err = s.
+		Where("id = ?", 1).
+		Cols("enforce_dependencies").
+		Update(&List{EnforceDependencies: enforce})
+	assert.NoError(t, err)
+}
+
+func TestTask_Update_Dependencies(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("mark blocked task as done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, true)
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Done: true}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+	})
+	t.Run("move blocked task to the done bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, true)
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, BucketID: 3}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+	})
+	t.Run("mark following task as done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, true)
+
+		rel := &TaskRelation{
+			TaskID:       4,
+			OtherTaskID:  3,
+			RelationKind: RelationKindFollows,
+		}
+		err := rel.Create(s, u)
+		assert.NoError(t, err)
+
+		task := &Task{ID: 4, Title: "test", ListID: 1, Done: true}
+		err = task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+	})
+	t.Run("bulk update blocked task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, true)
+
+		bt := &BulkTask{
+			IDs:  []int64{1},
+			Task: Task{Done: true},
+		}
+		allowed, err := bt.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+		err = bt.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+	})
+	t.Run("blocking task is done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, true)
+
+		blocking := &Task{ID: 3, Title: "test", ListID: 1, Done: true}
+		err := blocking.Update(s, u)
+		assert.NoError(t, err)
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Done: true}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+	})
+	t.Run("not enforced", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, false)
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Done: true}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTask_IsBlocked(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("computed flag", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, false)
+
+		task := &Task{ID: 1}
+		err := task.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.True(t, task.IsBlocked)
+
+		task = &Task{ID: 3}
+		err = task.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.False(t, task.IsBlocked)
+	})
+	t.Run("filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupBlockedTask(t, s, false)
+
+		tc := &TaskCollection{
+			ListID:      1,
+			FilterBy:    []string{"is_blocked"},
+			FilterValue: []string{"true"},
+		}
+		res, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		tasks := res.([]*Task)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(1), tasks[0].ID)
+
+		tc = &TaskCollection{
+			ListID:      1,
+			FilterBy:    []string{"is_blocked"},
+			FilterValue: []string{"false"},
+		}
+		res, _, _, err = tc.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		for _, task := range res.([]*Task) {
+			assert.NotEqual(t, int64(1), task.ID)
+		}
+	})
+}
@@ -0,0 +1,353 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// taskFilterGroup is a group of filters and other groups which are concatenated with the same concatinator.
+// Groups are built from filter queries like `done = false && (priority >= 3 || due_date < now+2d)`.
+type taskFilterGroup struct {
+	concat  taskFilterConcatinator
+	negate  bool
+	filters []*taskFilter
+	groups  []*taskFilterGroup
+}
+
+// allFilters returns all filters of the group and all groups in it.
+func (g *taskFilterGroup) allFilters() (filters []*taskFilter) {
+	if g == nil {
+		return nil
+	}
+	filters = append(filters, g.filters...)
+	for _, sub := range g.groups {
+		filters = append(filters, sub.allFilters()...)
+	}
+	return
+}
+
+type filterQueryTokenKind int
+
+const (
+	filterQueryTokenEnd filterQueryTokenKind = iota
+	filterQueryTokenWord
+	filterQueryTokenString
+	filterQueryTokenComparator
+	filterQueryTokenAnd
+	filterQueryTokenOr
+	filterQueryTokenNot
+	filterQueryTokenOpen
+	filterQueryTokenClose
+	filterQueryTokenComma
+)
+
+type filterQueryToken struct {
+	kind     filterQueryTokenKind
+	value    string
+	position int
+}
+
+// Words and keywords can't contain any of these, they need to be quoted instead.
+const filterQueryReservedChars = "()!=<>&|,\"'"
+
+func tokenizeTaskFilterQuery(query string) (tokens []*filterQueryToken, err error) {
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+
+		two := ""
+		if i+1 < len(runes) {
+			two = string(runes[i : i+2])
+		}
+
+		switch {
+		case two == "&&":
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenAnd, value: two, position: start})
+			i += 2
+		case two == "||":
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenOr, value: two, position: start})
+			i += 2
+		case two == "!=" || two == ">=" || two == "<=" || two == "==":
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenComparator, value: two, position: start})
+			i += 2
+		case r == '=' || r == '>' || r == '<':
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenComparator, value: string(r), position: start})
+			i++
+		case r == '!':
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenNot, value: "!", position: start})
+			i++
+		case r == '(':
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenOpen, value: "(", position: start})
+			i++
+		case r == ')':
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenClose, value: ")", position: start})
+			i++
+		case r == ',':
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenComma, value: ",", position: start})
+			i++
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, ErrInvalidTaskFilterQuery{Query: query, Position: start, Reason: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenString, value: value.String(), position: start})
+		case r == '&' || r == '|':
+			return nil, ErrInvalidTaskFilterQuery{Query: query, Position: start, Reason: "unexpected '" + string(r) + "', did you mean '" + string(r) + string(r) + "'?"}
+		default:
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(filterQueryReservedChars, runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			token := &filterQueryToken{kind: filterQueryTokenWord, value: word, position: start}
+			switch strings.ToLower(word) {
+			case "and":
+				token.kind = filterQueryTokenAnd
+			case "or":
+				token.kind = filterQueryTokenOr
+			case "not":
+				token.kind = filterQueryTokenNot
+			}
+			tokens = append(tokens, token)
+		}
+	}
+
+	tokens = append(tokens, &filterQueryToken{kind: filterQueryTokenEnd, position: len(runes)})
+	return
+}
+
+type taskFilterQueryParser struct {
+	query  string
+	tokens []*filterQueryToken
+	pos    int
+}
+
+func (p *taskFilterQueryParser) peek() *filterQueryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *taskFilterQueryParser) next() *filterQueryToken {
+	t := p.tokens[p.pos]
+	if t.kind != filterQueryTokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (p *taskFilterQueryParser) error(t *filterQueryToken, reason string) error {
+	return ErrInvalidTaskFilterQuery{Query: p.query, Position: t.position, Reason: reason}
+}
+
+// parseTaskFilterQuery parses a filter query into a filter group. Returns nil if the query is empty.
+//
+// The query consists of comparisons like `priority >= 3` which can be combined with `&&` (or `and`),
+// `||` (or `or`), negated with `!` (or `not`) and grouped with parentheses. `&&` binds stronger than `||`.
+// Available comparators are `=`, `!=`, `>`, `>=`, `<`, `<=`, `like`, `in` and `not in`. `in` and `not in` expect
+// a comma-separated list of values. Values containing spaces or any of the special characters need to be quoted.
+func parseTaskFilterQuery(query string) (group *taskFilterGroup, err error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeTaskFilterQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &taskFilterQueryParser{query: query, tokens: tokens}
+	group, err = p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != filterQueryTokenEnd {
+		return nil, p.error(t, "unexpected '"+t.value+"'")
+	}
+
+	return group, nil
+}
+
+// addToGroup adds a parsed expression to a group. Expressions with the same concatinator are merged
+// into the group so that `a && b && c` becomes a single group.
+func addToGroup(group *taskFilterGroup, expr *taskFilterGroup) {
+	if !expr.negate && (expr.concat == group.concat || len(expr.filters)+len(expr.groups) == 1) {
+		group.filters = append(group.filters, expr.filters...)
+		group.groups = append(group.groups, expr.groups...)
+		return
+	}
+	group.groups = append(group.groups, expr)
+}
+
+func (p *taskFilterQueryParser) parseBinary(concat taskFilterConcatinator, operator filterQueryTokenKind, operand func() (*taskFilterGroup, error)) (*taskFilterGroup, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != operator {
+		return first, nil
+	}
+
+	group := &taskFilterGroup{concat: concat}
+	addToGroup(group, first)
+	for p.peek().kind == operator {
+		p.next()
+		expr, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		addToGroup(group, expr)
+	}
+	return group, nil
+}
+
+func (p *taskFilterQueryParser) parseOr() (*taskFilterGroup, error) {
+	return p.parseBinary(filterConcatOr, filterQueryTokenOr, p.parseAnd)
+}
+
+func (p *taskFilterQueryParser) parseAnd() (*taskFilterGroup, error) {
+	return p.parseBinary(filterConcatAnd, filterQueryTokenAnd, p.parseUnary)
+}
+
+func (p *taskFilterQueryParser) parseUnary() (*taskFilterGroup, error) {
+	t := p.peek()
+	switch t.kind {
+	case filterQueryTokenNot:
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if expr.negate {
+			return &taskFilterGroup{concat: filterConcatAnd, groups: []*taskFilterGroup{expr}, negate: true}, nil
+		}
+		expr.negate = true
+		return expr, nil
+	case filterQueryTokenOpen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != filterQueryTokenClose {
+			return nil, p.error(c, "expected ')'")
+		}
+		return expr, nil
+	default:
+		f, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		return &taskFilterGroup{concat: filterConcatAnd, filters: []*taskFilter{f}}, nil
+	}
+}
+
+func (p *taskFilterQueryParser) parseValue() (string, error) {
+	t := p.next()
+	if t.kind != filterQueryTokenWord && t.kind != filterQueryTokenString {
+		return "", p.error(t, "expected a value")
+	}
+	return t.value, nil
+}
+
+func (p *taskFilterQueryParser) parseComparison() (f *taskFilter, err error) {
+	field := p.next()
+	if field.kind != filterQueryTokenWord {
+		return nil, p.error(field, "expected a field name")
+	}
+
+	comparatorToken := p.next()
+	var comparator taskFilterComparator
+	switch {
+	case comparatorToken.kind == filterQueryTokenComparator:
+		comparator = taskFilterComparator(comparatorToken.value)
+		if comparator == "==" {
+			comparator = taskFilterComparatorEquals
+		}
+	case comparatorToken.kind == filterQueryTokenWord && strings.EqualFold(comparatorToken.value, "like"):
+		comparator = taskFilterComparatorLike
+	case comparatorToken.kind == filterQueryTokenWord && strings.EqualFold(comparatorToken.value, "in"):
+		comparator = taskFilterComparatorIn
+	case comparatorToken.kind == filterQueryTokenNot && strings.EqualFold(comparatorToken.value, "not") &&
+		p.peek().kind == filterQueryTokenWord && strings.EqualFold(p.peek().value, "in"):
+		p.next()
+		comparator = taskFilterComparatorNotIn
+	default:
+		return nil, p.error(comparatorToken, "expected a comparator after '"+field.value+"'")
+	}
+
+	valueToken := p.peek()
+	var value string
+	if comparator.takesMultipleValues() {
+		values := []string{}
+		parenthesized := p.peek().kind == filterQueryTokenOpen
+		if parenthesized {
+			p.next()
+		}
+		for {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			if p.peek().kind != filterQueryTokenComma {
+				break
+			}
+			p.next()
+		}
+		if parenthesized {
+			if c := p.next(); c.kind != filterQueryTokenClose {
+				return nil, p.error(c, "expected ')'")
+			}
+		}
+		value = strings.Join(values, ",")
+	} else {
+		value, err = p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f = &taskFilter{
+		field:      field.value,
+		comparator: comparator,
+	}
+	f.value, err = getNativeValueForTaskField(f.field, f.comparator, value)
+	if err != nil {
+		if IsErrInvalidTaskField(err) {
+			return nil, p.error(field, "unknown field '"+field.value+"'")
+		}
+		return nil, p.error(valueToken, "invalid value '"+value+"' for field '"+field.value+"'")
+	}
+
+	return f, nil
+}
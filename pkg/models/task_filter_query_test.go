@@ -0,0 +1,268 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTaskFilterQuery(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		group, err := parseTaskFilterQuery("  ")
+		assert.NoError(t, err)
+		assert.Nil(t, group)
+	})
+	t.Run("single comparison", func(t *testing.T) {
+		group, err := parseTaskFilterQuery("priority >= 3")
+		assert.NoError(t, err)
+		assert.Len(t, group.filters, 1)
+		assert.Empty(t, group.groups)
+		assert.Equal(t, "priority", group.filters[0].field)
+		assert.Equal(t, taskFilterComparatorGreateEquals, group.filters[0].comparator)
+		assert.Equal(t, int64(3), group.filters[0].value)
+	})
+	t.Run("and binds stronger than or", func(t *testing.T) {
+		group, err := parseTaskFilterQuery("done = true && priority = 1 || priority = 2")
+		assert.NoError(t, err)
+		assert.Equal(t, taskFilterConcatinator(filterConcatOr), group.concat)
+		assert.Len(t, group.filters, 1)
+		assert.Equal(t, int64(2), group.filters[0].value)
+		assert.Len(t, group.groups, 1)
+		assert.Equal(t, taskFilterConcatinator(filterConcatAnd), group.groups[0].concat)
+		assert.Len(t, group.groups[0].filters, 2)
+	})
+	t.Run("keywords", func(t *testing.T) {
+		group, err := parseTaskFilterQuery("done = true AND NOT (priority = 1 or priority = 2)")
+		assert.NoError(t, err)
+		assert.Equal(t, taskFilterConcatinator(filterConcatAnd), group.concat)
+		assert.Len(t, group.filters, 1)
+		assert.Len(t, group.groups, 1)
+		assert.True(t, group.groups[0].negate)
+		assert.Equal(t, taskFilterConcatinator(filterConcatOr), group.groups[0].concat)
+	})
+	t.Run("in", func(t *testing.T) {
+		for _, query := range []string{"priority in 1, 2,3", "priority in (1, 2, 3)", "priority IN '1',2,\"3\""} {
+			group, err := parseTaskFilterQuery(query)
+			assert.NoError(t, err, query)
+			assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, group.filters[0].value, query)
+		}
+	})
+	t.Run("quoted value", func(t *testing.T) {
+		group, err := parseTaskFilterQuery(`title like "high \"prio\" (urgent)"`)
+		assert.NoError(t, err)
+		assert.Equal(t, taskFilterComparatorLike, group.filters[0].comparator)
+		assert.Equal(t, `high "prio" (urgent)`, group.filters[0].value)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{
+			"done =",
+			"done = true &&",
+			"(done = true",
+			"done = true)",
+			"done true",
+			"done & true",
+			"= true",
+			`title = "unterminated`,
+			"unknown_field = 1",
+			"priority = abc",
+			"priority in (1, 2",
+		} {
+			_, err := parseTaskFilterQuery(query)
+			assert.Error(t, err, query)
+			assert.True(t, IsErrInvalidTaskFilterQuery(err), query)
+		}
+	})
+}
+
+func readTaskIDs(t *testing.T, tc *TaskCollection) []int64 {
+	s := db.NewSession()
+	defer s.Close()
+
+	result, _, _, err := tc.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	ids := []int64{}
+	for _, task := range result.([]*Task) {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func TestTaskCollection_ReadAll_FilterQuery(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("nested", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{
+			ListID: 1,
+			Filter: "done = false && (priority >= 100 || due_date < 2018-12-01T00:00:00+00:00)",
+		})
+		assert.Equal(t, []int64{3, 6}, ids)
+	})
+	t.Run("negated", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "!(done = false)"})
+		assert.Equal(t, []int64{2}, ids)
+	})
+	t.Run("in", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "priority in (1, 100)"})
+		assert.Equal(t, []int64{3, 4}, ids)
+	})
+	t.Run("like", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: `title like "high prio"`})
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("multiple values of a separate table", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		_, err := s.Insert(&LabelTask{TaskID: 1, LabelID: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, s.Commit())
+		s.Close()
+
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "labels = 4 && labels = 1"})
+		assert.Equal(t, []int64{1}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "labels = 4 && !(labels = 1)"})
+		assert.Equal(t, []int64{2}, ids)
+	})
+	t.Run("combined with flat filters", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"priority", "priority"},
+			FilterValue:      []string{"1", "100"},
+			FilterComparator: []string{"equals", "equals"},
+			FilterConcat:     "or",
+			Filter:           "title like low",
+		})
+		assert.Equal(t, []int64{4}, ids)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ListID: 1, Filter: "done = "}
+		_, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterQuery(err))
+	})
+}
+
+func TestTaskCollection_ReadAll_RelationFilters(t *testing.T) {
+	t.Run("labels not in", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		_, err := s.Insert(&LabelTask{TaskID: 1, LabelID: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, s.Commit())
+		s.Close()
+
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "labels = 4 && labels != 1"})
+		assert.Equal(t, []int64{2}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"labels", "done"},
+			FilterValue:      []string{"1,4", "false"},
+			FilterComparator: []string{"not_in", "equals"},
+			FilterConcat:     "and",
+		})
+		assert.NotContains(t, ids, int64(1))
+		assert.NotContains(t, ids, int64(2))
+		assert.Contains(t, ids, int64(3))
+	})
+	t.Run("assignees", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "assignees in user1, user2"})
+		assert.Equal(t, []int64{30}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "assignees not in user2"})
+		assert.NotContains(t, ids, int64(30))
+		assert.Contains(t, ids, int64(1))
+	})
+	t.Run("created by", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "created_by = user1 && priority = 100"})
+		assert.Equal(t, []int64{3}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "created_by != user1"})
+		assert.Empty(t, ids)
+	})
+	t.Run("namespace not in", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{Filter: "namespace not in 1 && (namespace in 7, 8)"})
+		assert.Equal(t, []int64{21, 22}, ids)
+	})
+	t.Run("bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "bucket = 2"})
+		assert.Equal(t, []int64{3, 4, 5}, ids)
+	})
+	t.Run("has attachments", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "has_attachments = true"})
+		assert.Equal(t, []int64{1}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "has_attachments = false"})
+		assert.NotContains(t, ids, int64(1))
+		assert.Contains(t, ids, int64(2))
+
+		_, _, err := getTaskFiltersByCollections(&TaskCollection{Filter: "has_attachments = maybe"})
+		assert.True(t, IsErrInvalidTaskFilterQuery(err))
+	})
+	t.Run("has relation kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "has_relation_kind = subtask"})
+		assert.Equal(t, []int64{1}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "has_relation_kind in parenttask, subtask"})
+		assert.Equal(t, []int64{1, 29}, ids)
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, Filter: "has_relation_kind != subtask"})
+		assert.NotContains(t, ids, int64(1))
+		assert.Contains(t, ids, int64(29))
+
+		_, _, err := getTaskFiltersByCollections(&TaskCollection{Filter: "has_relation_kind = sibling"})
+		assert.True(t, IsErrInvalidTaskFilterQuery(err))
+	})
+	t.Run("sort by labels", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		_, err := s.Insert(&LabelTask{TaskID: 3, LabelID: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, s.Commit())
+		s.Close()
+
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, SortBy: []string{"labels"}})
+		assert.Equal(t, []int64{3, 1, 2, 4}, ids[:4])
+
+		ids = readTaskIDs(t, &TaskCollection{ListID: 1, SortBy: []string{"labels"}, OrderBy: []string{"desc"}})
+		assert.Equal(t, []int64{1, 2, 3, 4}, ids[:4])
+	})
+	t.Run("sort by assignees", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		ids := readTaskIDs(t, &TaskCollection{ListID: 1, SortBy: []string{"assignees"}})
+		assert.Equal(t, int64(30), ids[0])
+	})
+}
@@ -0,0 +1,293 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskHistoryEntry holds one change of one property of a task
+type TaskHistoryEntry struct {
+	// The unique, numeric id of this history entry.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The task which was changed.
+	TaskID int64 `xorm:"bigint INDEX not null" json:"task_id" param:"task"`
+	DoerID int64 `xorm:"bigint not null" json:"-"`
+	// The user or link share who made the change.
+	Doer *user.User `xorm:"-" json:"doer"`
+	// The changed property. Either the json name of a task field like `due_date` or one of `assignees`, `labels`,
+	// `attachments`, `related_tasks`, `list` or `bucket`.
+	Field string `xorm:"varchar(250) not null" json:"field"`
+	// The value before the change. Is null when something was added to the task, like an assignee.
+	OldValue TaskHistoryValue `xorm:"longtext null" json:"old_value"`
+	// The value after the change. Is null when something was removed from the task, like an assignee.
+	NewValue TaskHistoryValue `xorm:"longtext null" json:"new_value"`
+
+	// A timestamp when this change was made.
+	Created time.Time `xorm:"created not null INDEX" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the task history
+func (*TaskHistoryEntry) TableName() string {
+	return "task_history"
+}
+
+// TaskHistoryValue holds an arbitrary old or new value of a history entry.
+// It is saved as json in the database and serialized as the plain value.
+type TaskHistoryValue struct {
+	Value interface{}
+}
+
+// FromDB unmarshals the value from the database
+func (v *TaskHistoryValue) FromDB(data []byte) error {
+	v.Value = nil
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, &v.Value)
+}
+
+// ToDB marshals the value to json to save it in the database
+func (v *TaskHistoryValue) ToDB() ([]byte, error) {
+	if v.Value == nil {
+		return nil, nil
+	}
+	return json.Marshal(v.Value)
+}
+
+// MarshalJSON returns the plain value as json
+func (v TaskHistoryValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value)
+}
+
+// UnmarshalJSON sets the value from json
+func (v *TaskHistoryValue) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &v.Value)
+}
+
+// taskHistoryReference is what gets saved in the history for things which are related to a task,
+// like assignees or labels. The title is saved as well so the history is still readable after the
+// referenced thing was deleted.
+type taskHistoryReference struct {
+	ID    int64        `json:"id"`
+	Title string       `json:"title"`
+	Kind  RelationKind `json:"kind,omitempty"`
+}
+
+// All task fields which are tracked in the history with a function to get a comparable value of them.
+var taskHistoryFields = []struct {
+	field string
+	value func(t *Task) interface{}
+}{
+	{"title", func(t *Task) interface{} { return t.Title }},
+	{"description", func(t *Task) interface{} { return t.Description }},
+	{"done", func(t *Task) interface{} { return t.Done }},
+	{"due_date", func(t *Task) interface{} { return taskHistoryTime(t.DueDate) }},
+	{"reminder_dates", func(t *Task) interface{} { return taskHistoryTimes(t.Reminders) }},
+	{"repeat_rule", func(t *Task) interface{} { return t.RepeatRule }},
+	{"repeat_exdates", func(t *Task) interface{} { return taskHistoryTimes(t.RepeatExdates) }},
+	{"repeat_from_current_date", func(t *Task) interface{} { return t.RepeatFromCurrentDate }},
+	{"priority", func(t *Task) interface{} { return t.Priority }},
+	{"start_date", func(t *Task) interface{} { return taskHistoryTime(t.StartDate) }},
+	{"end_date", func(t *Task) interface{} { return taskHistoryTime(t.EndDate) }},
+	{"hex_color", func(t *Task) interface{} { return t.HexColor }},
+	{"percent_done", func(t *Task) interface{} { return t.PercentDone }},
+}
+
+func taskHistoryTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.In(config.GetTimeZone()).Format(time.RFC3339)
+}
+
+func taskHistoryTimes(ts []time.Time) interface{} {
+	if len(ts) == 0 {
+		return nil
+	}
+	formatted := make([]interface{}, 0, len(ts))
+	for _, t := range ts {
+		formatted = append(formatted, taskHistoryTime(t))
+	}
+	return formatted
+}
+
+// Link shares are saved with their negated id, the same way as comment authors.
+func getTaskHistoryDoerID(a web.Auth) int64 {
+	if a == nil {
+		return 0
+	}
+	if share, is := a.(*LinkSharing); is {
+		return share.ID * -1
+	}
+	return a.GetID()
+}
+
+func addTaskHistoryEntry(s *xorm.Session, a web.Auth, taskID int64, field string, oldValue, newValue interface{}) (err error) {
+	_, err = s.Insert(&TaskHistoryEntry{
+		TaskID:   taskID,
+		DoerID:   getTaskHistoryDoerID(a),
+		Field:    field,
+		OldValue: TaskHistoryValue{Value: oldValue},
+		NewValue: TaskHistoryValue{Value: newValue},
+	})
+	return
+}
+
+// recordTaskUpdate compares the fields of a task before and after an update and saves every change in the history.
+func recordTaskUpdate(s *xorm.Session, a web.Auth, oldTask, newTask *Task) (err error) {
+	for _, f := range taskHistoryFields {
+		oldValue := f.value(oldTask)
+		newValue := f.value(newTask)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		err = addTaskHistoryEntry(s, a, newTask.ID, f.field, oldValue, newValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	if oldTask.ListID != newTask.ListID {
+		err = addTaskHistoryEntry(s, a, newTask.ID, "list", getListHistoryReference(s, oldTask.ListID), getListHistoryReference(s, newTask.ListID))
+		if err != nil {
+			return err
+		}
+	}
+
+	if oldTask.BucketID != newTask.BucketID {
+		err = addTaskHistoryEntry(s, a, newTask.ID, "bucket", getBucketHistoryReference(s, oldTask.BucketID), getBucketHistoryReference(s, newTask.BucketID))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The references are only informational, if the list or bucket does not exist anymore we still want the id.
+func getListHistoryReference(s *xorm.Session, listID int64) *taskHistoryReference {
+	if listID == 0 {
+		return nil
+	}
+	ref := &taskHistoryReference{ID: listID}
+	l := &List{}
+	has, err := s.Where("id = ?", listID).Get(l)
+	if err == nil && has {
+		ref.Title = l.Title
+	}
+	return ref
+}
+
+func getBucketHistoryReference(s *xorm.Session, bucketID int64) *taskHistoryReference {
+	if bucketID == 0 {
+		return nil
+	}
+	ref := &taskHistoryReference{ID: bucketID}
+	b := &Bucket{}
+	has, err := s.Where("id = ?", bucketID).Get(b)
+	if err == nil && has {
+		ref.Title = b.Title
+	}
+	return ref
+}
+
+func getUserHistoryReference(u *user.User) *taskHistoryReference {
+	return &taskHistoryReference{ID: u.ID, Title: u.Username}
+}
+
+func getLabelHistoryReference(l *Label) *taskHistoryReference {
+	return &taskHistoryReference{ID: l.ID, Title: l.Title}
+}
+
+func getTaskHistoryReference(s *xorm.Session, taskID int64, kind RelationKind) *taskHistoryReference {
+	ref := &taskHistoryReference{ID: taskID, Kind: kind}
+	t := &Task{}
+	has, err := s.Where("id = ?", taskID).Get(t)
+	if err == nil && has {
+		ref.Title = t.Title
+	}
+	return ref
+}
+
+// ReadAll returns the history of a task
+// @Summary Get the history of a task
+// @Description Returns all changes made to a task, newest first. Each entry holds the changed field with its value before and after the change and who made it.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskHistoryEntry "The history entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/history [get]
+func (th *TaskHistoryEntry) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := th.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	query := s.
+		Where("task_id = ?", th.TaskID).
+		OrderBy("created DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	entries := []*TaskHistoryEntry{}
+	err = query.Find(&entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	doerIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		doerIDs = append(doerIDs, e.DoerID)
+	}
+
+	doers, err := getUsersOrLinkSharesFromIDs(s, doerIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, e := range entries {
+		e.Doer = doers[e.DoerID]
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", th.TaskID).
+		Count(&TaskHistoryEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
@@ -0,0 +1,197 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func getTaskHistoryForTest(t *testing.T, s *xorm.Session, taskID int64, field string) []*TaskHistoryEntry {
+	entries := []*TaskHistoryEntry{}
+	err := s.
+		Where("task_id = ? AND field = ?", taskID, field).
+		OrderBy("id ASC").
+		Find(&entries)
+	assert.NoError(t, err)
+	return entries
+}
+
+func TestTaskHistoryEntry_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		th := &TaskHistoryEntry{TaskID: 1}
+		result, resultCount, total, err := th.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, resultCount)
+		assert.Equal(t, int64(2), total)
+
+		entries := result.([]*TaskHistoryEntry)
+		assert.Equal(t, int64(2), entries[0].ID)
+		assert.Equal(t, "title", entries[0].Field)
+		assert.Equal(t, "task #1", entries[0].OldValue.Value)
+		assert.Equal(t, "task #1 - edited", entries[0].NewValue.Value)
+		assert.Equal(t, int64(-1), entries[0].Doer.ID)
+		assert.Equal(t, int64(1), entries[1].ID)
+		assert.Nil(t, entries[1].OldValue.Value)
+		assert.Equal(t, "user1", entries[1].Doer.Username)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		th := &TaskHistoryEntry{TaskID: 14}
+		_, _, _, err := th.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTaskHistory_Record(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task fields", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "task #1",
+			Description: "Lorem Ipsum",
+			ListID:      1,
+			BucketID:    1,
+			Position:    2,
+			DueDate:     testCreatedTime,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+
+		entries := getTaskHistoryForTest(t, s, 1, "due_date")
+		assert.Len(t, entries, 2) // One from the fixtures
+		assert.Nil(t, entries[1].OldValue.Value)
+		assert.Equal(t, "2018-12-01T15:13:12Z", entries[1].NewValue.Value)
+		assert.Equal(t, int64(1), entries[1].DoerID)
+
+		// Nothing else was changed
+		assert.Empty(t, getTaskHistoryForTest(t, s, 1, "description"))
+		assert.Empty(t, getTaskHistoryForTest(t, s, 1, "bucket"))
+	})
+	t.Run("bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "task #1",
+			Description: "Lorem Ipsum",
+			ListID:      1,
+			BucketID:    3, // Done bucket
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+
+		entries := getTaskHistoryForTest(t, s, 1, "bucket")
+		assert.Len(t, entries, 1)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "title": "testbucket1"}, entries[0].OldValue.Value)
+		assert.Equal(t, map[string]interface{}{"id": float64(3), "title": "testbucket3"}, entries[0].NewValue.Value)
+
+		entries = getTaskHistoryForTest(t, s, 1, "done")
+		assert.Len(t, entries, 1)
+		assert.Equal(t, false, entries[0].OldValue.Value)
+		assert.Equal(t, true, entries[0].NewValue.Value)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:       1,
+			Title:    "changed",
+			ListID:   1,
+			BucketID: 1,
+		}
+		err := task.Update(s, &LinkSharing{ID: 1, ListID: 1})
+		assert.NoError(t, err)
+
+		entries := getTaskHistoryForTest(t, s, 1, "title")
+		assert.Len(t, entries, 2)
+		assert.Equal(t, int64(-1), entries[1].DoerID)
+		assert.Equal(t, "changed", entries[1].NewValue.Value)
+	})
+	t.Run("assignees", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAssginee{TaskID: 1, UserID: 1}
+		err := ta.Create(s, u)
+		assert.NoError(t, err)
+		err = ta.Delete(s, u)
+		assert.NoError(t, err)
+
+		entries := getTaskHistoryForTest(t, s, 1, "assignees")
+		assert.Len(t, entries, 2)
+		assert.Nil(t, entries[0].OldValue.Value)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "title": "user1"}, entries[0].NewValue.Value)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "title": "user1"}, entries[1].OldValue.Value)
+		assert.Nil(t, entries[1].NewValue.Value)
+	})
+	t.Run("labels", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lt := &LabelTask{TaskID: 1, LabelID: 1}
+		err := lt.Create(s, u)
+		assert.NoError(t, err)
+		err = lt.Delete(s, u)
+		assert.NoError(t, err)
+
+		entries := getTaskHistoryForTest(t, s, 1, "labels")
+		assert.Len(t, entries, 2)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "title": "Label #1"}, entries[0].NewValue.Value)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "title": "Label #1"}, entries[1].OldValue.Value)
+	})
+	t.Run("relations", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := &TaskRelation{TaskID: 1, OtherTaskID: 2, RelationKind: RelationKindSubtask}
+		err := rel.Create(s, u)
+		assert.NoError(t, err)
+
+		entries := getTaskHistoryForTest(t, s, 1, "related_tasks")
+		assert.Len(t, entries, 1)
+		assert.Equal(t, map[string]interface{}{"id": float64(2), "title": "task #2 done", "kind": "subtask"}, entries[0].NewValue.Value)
+
+		entries = getTaskHistoryForTest(t, s, 2, "related_tasks")
+		assert.Len(t, entries, 1)
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "title": "task #1", "kind": "parenttask"}, entries[0].NewValue.Value)
+	})
+}
@@ -0,0 +1,206 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskByIdentifier resolves a task by its human readable identifier (like PROJ-42)
+// or by its index in a list.
+type TaskByIdentifier struct {
+	// The identifier of the task, made up of the identifier of its list and its index.
+	Identifier string `json:"-" param:"identifier"`
+	// The id of the list the task is in, when resolving a task by its index.
+	ListID int64 `json:"-" param:"list"`
+	// The index of the task in the list.
+	Index int64 `json:"-" param:"index"`
+
+	task *Task
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// MarshalJSON returns the resolved task.
+func (ti *TaskByIdentifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ti.task)
+}
+
+func (ti *TaskByIdentifier) getTaskID(s *xorm.Session) (taskID int64, err error) {
+	if ti.Identifier != "" {
+		taskID, err = getTaskIDByIdentifier(s, ti.Identifier)
+	} else {
+		ti.Identifier = strconv.FormatInt(ti.Index, 10)
+		taskID, err = getTaskIDByListIndex(s, ti.ListID, ti.Index)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if taskID == 0 {
+		return 0, ErrTaskIdentifierDoesNotExist{Identifier: ti.Identifier}
+	}
+	return
+}
+
+// CanRead checks if a user can read the task the identifier resolves to
+func (ti *TaskByIdentifier) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	taskID, err := ti.getTaskID(s)
+	if err != nil {
+		return false, 0, err
+	}
+
+	ti.task = &Task{ID: taskID}
+	return ti.task.CanRead(s, a)
+}
+
+// ReadOne gets one task by its identifier
+// @Summary Get one task by its identifier
+// @Description Returns one task by its identifier, made up of the identifier of its list and the index of the task (like `PROJ-42`). Identifiers a task had before it was moved to another list still resolve to it. Tasks in lists without an identifier can be resolved by their index in the list instead.
+// @tags task
+// @Accept json
+// @Produce json
+// @Param identifier path string true "The task identifier"
+// @Param id path int true "List ID"
+// @Param index path int true "The task index"
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Task "The task"
+// @Failure 404 {object} models.Message "Task not found"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/by-identifier/{identifier} [get]
+// @Router /lists/{id}/tasks/by-index/{index} [get]
+func (ti *TaskByIdentifier) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	return ti.task.ReadOne(s, a)
+}
+
+var taskIdentifierRegex = regexp.MustCompile(`\b[\w]+-\d+\b`)
+
+// findMentionedTasksInText returns the ids of all tasks referenced by their identifier in a text.
+func findMentionedTasksInText(s *xorm.Session, text string) (taskIDs []int64, err error) {
+	matches := taskIdentifierRegex.FindAllString(text, -1)
+	if len(matches) == 0 {
+		return
+	}
+
+	// Text like utf-8 or 2022-10 looks like an identifier as well, only those of existing lists are resolved.
+	prefixes := make([]string, 0, len(matches))
+	for _, match := range matches {
+		prefixes = append(prefixes, match[:strings.LastIndex(match, "-")])
+	}
+	lists := []*List{}
+	err = s.
+		Cols("identifier").
+		In("identifier", prefixes).
+		Find(&lists)
+	if err != nil {
+		return nil, err
+	}
+	listIdentifiers := make(map[string]bool, len(lists))
+	for _, l := range lists {
+		listIdentifiers[strings.ToLower(l.Identifier)] = true
+	}
+
+	seen := make(map[string]bool, len(matches))
+	for i, match := range matches {
+		if seen[match] || !listIdentifiers[strings.ToLower(prefixes[i])] {
+			continue
+		}
+		seen[match] = true
+
+		taskID, err := getTaskIDByIdentifier(s, match)
+		if err != nil {
+			return nil, err
+		}
+		if taskID != 0 {
+			taskIDs = append(taskIDs, taskID)
+		}
+	}
+	return
+}
+
+// relateMentionedTasks adds a "related" relation between a task and all tasks mentioned by their
+// identifier in a text which were not already mentioned in its previous version. That way, relations
+// the user removed are not added again when the text is edited.
+// Tasks the doer cannot read are ignored, as is the task itself.
+func relateMentionedTasks(s *xorm.Session, task *Task, text string, previousText string, doer *user.User) (err error) {
+	if doer == nil || doer.ID <= 0 {
+		return nil
+	}
+
+	taskIDs, err := findMentionedTasksInText(s, text)
+	if err != nil || len(taskIDs) == 0 {
+		return err
+	}
+
+	previousTaskIDs, err := findMentionedTasksInText(s, previousText)
+	if err != nil {
+		return err
+	}
+	previouslyMentioned := make(map[int64]bool, len(previousTaskIDs))
+	for _, id := range previousTaskIDs {
+		previouslyMentioned[id] = true
+	}
+
+	canUpdate, err := (&Task{ID: task.ID}).CanUpdate(s, doer)
+	if isErrTaskOrListDoesNotExist(err) {
+		return nil
+	}
+	if err != nil || !canUpdate {
+		return err
+	}
+
+	for _, id := range taskIDs {
+		if id == task.ID || previouslyMentioned[id] {
+			continue
+		}
+
+		canRead, _, err := (&Task{ID: id}).CanRead(s, doer)
+		// The mentioned task might have been deleted or moved to the trash
+		if isErrTaskOrListDoesNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !canRead {
+			continue
+		}
+
+		rel := &TaskRelation{
+			TaskID:       task.ID,
+			OtherTaskID:  id,
+			RelationKind: RelationKindRelated,
+		}
+		err = rel.Create(s, doer)
+		if err != nil && !IsErrRelationAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isErrTaskOrListDoesNotExist(err error) bool {
+	return IsErrTaskDoesNotExist(err) || IsErrListDoesNotExist(err)
+}
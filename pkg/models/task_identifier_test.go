@@ -0,0 +1,175 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskByIdentifier_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("by identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TaskByIdentifier{Identifier: "test1-2"}
+		can, _, err := ti.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ti.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), ti.task.ID)
+		assert.Equal(t, "test1-2", ti.task.Identifier)
+	})
+	t.Run("by previous identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TaskByIdentifier{Identifier: "test11-99"}
+		can, _, err := ti.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ti.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), ti.task.ID)
+	})
+	t.Run("by list index", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TaskByIdentifier{ListID: 2, Index: 1}
+		can, _, err := ti.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ti.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), ti.task.ListID)
+		assert.Equal(t, int64(1), ti.task.Index)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TaskByIdentifier{Identifier: "test5-1"}
+		can, _, err := ti.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		for _, identifier := range []string{"test1-9999", "nope-1", "test1", "-1"} {
+			ti := &TaskByIdentifier{Identifier: identifier}
+			_, _, err := ti.CanRead(s, u)
+			assert.Error(t, err)
+			assert.True(t, IsErrTaskIdentifierDoesNotExist(err), identifier)
+		}
+	})
+}
+
+func TestRelateMentionedTasks(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := relateMentionedTasks(s, task, "See test1-2, test1-2 again, test5-1, test1-1 and nope-3 in utf-8.", "", u)
+		assert.NoError(t, err)
+		// Mentioning the task again should not fail
+		err = relateMentionedTasks(s, task, "Still test1-2", "", u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       1,
+			"other_task_id": 2,
+			"relation_kind": RelationKindRelated,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       2,
+			"other_task_id": 1,
+			"relation_kind": RelationKindRelated,
+		}, false)
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       1,
+			"other_task_id": 14,
+		})
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       1,
+			"other_task_id": 1,
+		})
+	})
+	t.Run("only newly mentioned tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// test1-2 was already mentioned before, the user might have removed the relation on purpose
+		err := relateMentionedTasks(s, &Task{ID: 1}, "See test1-2 and test1-3", "See test1-2", u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       1,
+			"other_task_id": 2,
+		})
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       1,
+			"other_task_id": 3,
+			"relation_kind": RelationKindRelated,
+		}, false)
+	})
+	t.Run("task was deleted in the meantime", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := relateMentionedTasks(s, &Task{ID: 9999}, "See test1-2", "", u)
+		assert.NoError(t, err)
+	})
+	t.Run("no write access to the task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := relateMentionedTasks(s, &Task{ID: 14}, "See test1-2", "", u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       14,
+			"other_task_id": 2,
+		})
+	})
+}
@@ -0,0 +1,373 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskPreviousIdentifier holds the list and index a task had before it was moved to another list.
+// These are used to still resolve the old identifier of a task to the task.
+type TaskPreviousIdentifier struct {
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id"`
+	ListID int64 `xorm:"bigint not null INDEX" json:"list_id"`
+	Index  int64 `xorm:"bigint not null" json:"index"`
+
+	Created time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName holds the table name for previous task identifiers
+func (TaskPreviousIdentifier) TableName() string {
+	return "task_previous_identifiers"
+}
+
+func addTaskPreviousIdentifier(s *xorm.Session, t *Task) (err error) {
+	_, err = s.Insert(&TaskPreviousIdentifier{
+		TaskID: t.ID,
+		ListID: t.ListID,
+		Index:  t.Index,
+	})
+	return
+}
+
+// Returns the index a new task in a list should get.
+func getNextTaskIndex(s *xorm.Session, listID int64) (index int64, err error) {
+	// Tasks in the trash are included so a restored task does not end up with a duplicate index.
+	latestTask := &Task{}
+	_, err = s.Unscoped().Where("list_id = ?", listID).OrderBy("`index` desc").Get(latestTask)
+	if err != nil {
+		return 0, err
+	}
+	index = latestTask.Index + 1
+
+	// The indexes of tasks which were moved to another list still resolve to them and can therefore not be used again.
+	previous := &TaskPreviousIdentifier{}
+	_, err = s.Where("list_id = ?", listID).OrderBy("`index` desc").Get(previous)
+	if err != nil {
+		return 0, err
+	}
+	if previous.Index >= index {
+		index = previous.Index + 1
+	}
+
+	return
+}
+
+// Returns the id of the task with an identifier like PROJ-42, or 0 if there is no such task.
+// Identifiers a task had before it was moved to another list still resolve to it.
+func getTaskIDByIdentifier(s *xorm.Session, identifier string) (taskID int64, err error) {
+	separator := strings.LastIndex(identifier, "-")
+	if separator < 1 {
+		return 0, nil
+	}
+
+	index, err := strconv.ParseInt(identifier[separator+1:], 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+
+	l := &List{}
+	exists, err := s.
+		Where("identifier = ?", identifier[:separator]).
+		Get(l)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	return getTaskIDByListIndex(s, l.ID, index)
+}
+
+// getTaskIDByListIndex returns the id of the task which has (or had, before it was moved) the index
+// in the list. Returns 0 if there is no such task.
+func getTaskIDByListIndex(s *xorm.Session, listID int64, index int64) (taskID int64, err error) {
+	task := &Task{}
+	exists, err := s.
+		Where("list_id = ? AND `index` = ?", listID, index).
+		Get(task)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return task.ID, nil
+	}
+
+	previous := &TaskPreviousIdentifier{}
+	_, err = s.
+		Where("list_id = ? AND `index` = ?", listID, index).
+		OrderBy("id desc").
+		Get(previous)
+	return previous.TaskID, err
+}
+
+// TaskMove moves one or more tasks to another list
+type TaskMove struct {
+	// The id of the task to move.
+	TaskID int64 `json:"-" param:"listtask"`
+	// The ids of the tasks to move when moving multiple tasks at once.
+	TaskIDs []int64 `json:"task_ids"`
+	// The id of the list the tasks should be moved to.
+	ListID int64 `json:"list_id"`
+
+	// If true, all labels are removed from the moved tasks.
+	DropLabels bool `json:"drop_labels"`
+	// If true, all assignees are removed from the moved tasks. Otherwise only assignees who don't have access to the new list are removed.
+	DropAssignees bool `json:"drop_assignees"`
+	// If true, all relations of the moved tasks to other tasks are removed.
+	DropRelations bool `json:"drop_relations"`
+
+	// The moved tasks.
+	Tasks []*Task `json:"tasks"`
+
+	tasks []*Task
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+func (tm *TaskMove) getTaskIDs() []int64 {
+	if tm.TaskID != 0 {
+		return []int64{tm.TaskID}
+	}
+	return tm.TaskIDs
+}
+
+// CanUpdate checks if a user can move the tasks. The user needs write access to the lists of all tasks and the target list.
+func (tm *TaskMove) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	taskIDs := tm.getTaskIDs()
+	if len(taskIDs) == 0 {
+		return false, ErrBulkTasksNeedAtLeastOne{}
+	}
+
+	tasks := make(map[int64]*Task, len(taskIDs))
+	err := s.In("id", taskIDs).Find(&tasks)
+	if err != nil {
+		return false, err
+	}
+
+	target := &List{ID: tm.ListID}
+	can, err := target.CanWrite(s, a)
+	if err != nil || !can {
+		return can, err
+	}
+
+	lists := make(map[int64]bool)
+	tm.tasks = make([]*Task, 0, len(tasks))
+	for _, id := range taskIDs {
+		t, exists := tasks[id]
+		if !exists {
+			return false, ErrTaskDoesNotExist{ID: id}
+		}
+
+		canWrite, checked := lists[t.ListID]
+		if !checked {
+			l := &List{ID: t.ListID}
+			canWrite, err = l.CanWrite(s, a)
+			if err != nil {
+				return false, err
+			}
+			lists[t.ListID] = canWrite
+		}
+		if !canWrite {
+			return false, nil
+		}
+
+		tm.tasks = append(tm.tasks, t)
+	}
+
+	return true, nil
+}
+
+// Update moves the tasks to the new list
+// @Summary Move a task to another list
+// @Description Moves a task to another list. The task gets the next free index in the new list and is put into its default bucket, or into its done bucket if the task is done. The old identifier of the task still resolves to it. The user needs write access to both lists.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task ID"
+// @Param move body models.TaskMove true "The list the task should be moved to and what should be kept."
+// @Success 200 {object} models.TaskMove "The moved task."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to one of the lists."
+// @Failure 404 {object} web.HTTPError "The task or list does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{id}/move [post]
+func (tm *TaskMove) Update(s *xorm.Session, a web.Auth) (err error) {
+	target, err := GetListSimpleByID(s, tm.ListID)
+	if err != nil {
+		return err
+	}
+
+	defaultBucket, err := getDefaultBucket(s, target.ID)
+	if err != nil {
+		return err
+	}
+	doneBucket, err := getDoneBucketForList(s, target.ID)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	taskMap := make(map[int64]*Task, len(tm.tasks))
+	for _, t := range tm.tasks {
+		taskMap[t.ID] = t
+		if t.ListID == target.ID {
+			continue
+		}
+
+		originalTask := *t
+
+		err = addTaskPreviousIdentifier(s, t)
+		if err != nil {
+			return err
+		}
+
+		t.Index, err = getNextTaskIndex(s, target.ID)
+		if err != nil {
+			return err
+		}
+		t.ListID = target.ID
+
+		bucket := defaultBucket
+		if t.Done && doneBucket != nil {
+			bucket = doneBucket
+		}
+		if bucket.ID != 0 {
+			err = checkBucketLimit(s, t, bucket)
+			if err != nil {
+				return err
+			}
+		}
+		t.BucketID = bucket.ID
+		if bucket.IsDoneBucket && !t.Done {
+			t.Done = true
+			t.DoneAt = time.Now()
+		}
+
+		_, err = s.
+			ID(t.ID).
+			Cols("list_id", "index", "bucket_id", "done", "done_at").
+			Update(t)
+		if err != nil {
+			return err
+		}
+
+		err = removeCustomFieldValuesOfOtherLists(s, t)
+		if err != nil {
+			return err
+		}
+
+		err = tm.removeFromTask(s, a, t, target)
+		if err != nil {
+			return err
+		}
+
+		err = recordTaskUpdate(s, a, &originalTask, t)
+		if err != nil {
+			return err
+		}
+
+		err = events.Dispatch(&TaskUpdatedEvent{
+			Task:                t,
+			Doer:                doer,
+			PreviousDescription: originalTask.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = updateListLastUpdated(s, &List{ID: originalTask.ListID})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = updateListLastUpdated(s, target)
+	if err != nil {
+		return err
+	}
+
+	err = addMoreInfoToTasks(s, taskMap, a)
+	if err != nil {
+		return err
+	}
+
+	tm.Tasks = tm.tasks
+	return nil
+}
+
+// Removes labels, assignees and relations from a moved task, depending on the options of the move.
+func (tm *TaskMove) removeFromTask(s *xorm.Session, a web.Auth, t *Task, target *List) (err error) {
+	if tm.DropLabels {
+		err = addLabelsToTasks(s, []int64{t.ID}, map[int64]*Task{t.ID: t})
+		if err != nil {
+			return err
+		}
+		err = t.updateTaskLabels(s, a, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	assignees := []*TaskAssginee{}
+	err = s.Where("task_id = ?", t.ID).Find(&assignees)
+	if err != nil {
+		return err
+	}
+	for _, assignee := range assignees {
+		if !tm.DropAssignees {
+			canRead, _, err := target.CanRead(s, &user.User{ID: assignee.UserID})
+			if err != nil {
+				return err
+			}
+			if canRead {
+				continue
+			}
+		}
+
+		err = assignee.Delete(s, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tm.DropRelations {
+		relations := []*TaskRelation{}
+		err = s.Where("task_id = ? OR other_task_id = ?", t.ID, t.ID).Find(&relations)
+		if err != nil {
+			return err
+		}
+		for _, rel := range relations {
+			err = rel.Delete(s, a)
+			// When moving multiple related tasks, the relation was already removed with the other task
+			if err != nil && !IsErrRelationDoesNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
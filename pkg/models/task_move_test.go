@@ -0,0 +1,219 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskMove_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{
+			TaskID: 30,
+			ListID: 2,
+		}
+		can, err := tm.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = tm.Update(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, tm.Tasks, 1)
+		task := tm.Tasks[0]
+		assert.Equal(t, int64(2), task.ListID)
+		assert.Equal(t, int64(4), task.BucketID) // bucket 4 is the default and done bucket of list 2
+		assert.True(t, task.Done)
+		assert.Equal(t, "test2-"+strconv.FormatInt(task.Index, 10), task.Identifier)
+		assert.NotEmpty(t, task.Assignees)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_previous_identifiers", map[string]interface{}{
+			"task_id": 30,
+			"list_id": 1,
+			"index":   15,
+		}, false)
+		db.AssertExists(t, "task_assignees", map[string]interface{}{
+			"task_id": 30,
+			"user_id": 1,
+		}, false)
+
+		id, err := getTaskIDByIdentifier(s, "test1-15")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(30), id)
+	})
+	t.Run("index of new tasks after moving an older task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{
+			TaskID: 1,
+			ListID: 2,
+		}
+		err := tm.Update(s, u)
+		assert.NoError(t, err)
+		moved := tm.Tasks[0]
+
+		// The moved task has the highest index, but not the highest id in the list
+		task := &Task{
+			Title:  "Lorem",
+			ListID: 2,
+		}
+		err = task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, moved.Index+1, task.Index)
+	})
+	t.Run("drop labels, assignees and relations", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{
+			TaskIDs:       []int64{1, 30},
+			ListID:        2,
+			DropLabels:    true,
+			DropAssignees: true,
+			DropRelations: true,
+		}
+		can, err := tm.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = tm.Update(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, tm.Tasks, 2)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "label_tasks", map[string]interface{}{
+			"task_id": 1,
+		})
+		db.AssertMissing(t, "task_assignees", map[string]interface{}{
+			"task_id": 30,
+		})
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id": 1,
+		})
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"other_task_id": 1,
+		})
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":      1,
+			"list_id": 2,
+		}, false)
+	})
+	t.Run("no tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{ListID: 2}
+		_, err := tm.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrBulkTasksNeedAtLeastOne(err))
+	})
+	t.Run("no access to the target list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{
+			TaskID: 1,
+			ListID: 5,
+		}
+		can, err := tm.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no access to the task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{
+			TaskIDs: []int64{1, 14},
+			ListID:  2,
+		}
+		can, err := tm.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TaskMove{
+			TaskID: 9999,
+			ListID: 2,
+		}
+		_, err := tm.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+	})
+}
+
+func TestTaskPreviousIdentifier(t *testing.T) {
+	t.Run("resolve", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		id, err := getTaskIDByIdentifier(s, "test11-99")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+
+		id, err = getTaskIDByIdentifier(s, "test1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+
+		id, err = getTaskIDByIdentifier(s, "nope-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), id)
+	})
+	t.Run("index is not reused", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		index, err := getNextTaskIndex(s, 11)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(100), index)
+	})
+	t.Run("search", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ListID: 1}
+		res, _, _, err := tc.ReadAll(s, &user.User{ID: 1}, "test11-99", 0, 50)
+		assert.NoError(t, err)
+		tasks := res.([]*Task)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(1), tasks[0].ID)
+	})
+}
@@ -83,6 +83,8 @@ type TaskRelation struct {
 	TaskID int64 `xorm:"bigint not null" json:"task_id" param:"task"`
 	// The ID of the other task, the task which is being related.
 	OtherTaskID int64 `xorm:"bigint not null" json:"other_task_id" param:"otherTask"`
+	// The identifier of the other task (like PROJ-42). Can be used instead of the id when creating a relation.
+	OtherTaskIdentifier string `xorm:"-" json:"other_task_identifier,omitempty"`
 	// The kind of the relation.
 	RelationKind RelationKind `xorm:"varchar(50) not null" json:"relation_kind" param:"relationKind"`
 
@@ -106,9 +108,25 @@ func (TaskRelation) TableName() string {
 // This avoids the need for an extra type TaskWithRelation (or similar).
 type RelatedTaskMap map[RelationKind][]*Task
 
+// resolveOtherTaskIdentifier sets the id of the other task from its identifier if only the identifier was provided.
+func (rel *TaskRelation) resolveOtherTaskIdentifier(s *xorm.Session) (err error) {
+	if rel.OtherTaskID != 0 || rel.OtherTaskIdentifier == "" {
+		return nil
+	}
+
+	rel.OtherTaskID, err = getTaskIDByIdentifier(s, rel.OtherTaskIdentifier)
+	if err != nil {
+		return err
+	}
+	if rel.OtherTaskID == 0 {
+		return ErrTaskIdentifierDoesNotExist{Identifier: rel.OtherTaskIdentifier}
+	}
+	return nil
+}
+
 // Create creates a new task relation
 // @Summary Create a new relation between two tasks
-// @Description Creates a new relation between two tasks. The user needs to have update rights on the base task and at least read rights on the other task. Both tasks do not need to be on the same list. Take a look at the docs for available task relation kinds.
+// @Description Creates a new relation between two tasks. The user needs to have update rights on the base task and at least read rights on the other task. Both tasks do not need to be on the same list. Take a look at the docs for available task relation kinds. Subtask, blocking and precedes relations (and their counterparts) cannot form a cycle. Instead of the id of the other task you can also provide its identifier (like `PROJ-42`) as `other_task_identifier`.
 // @tags task
 // @Accept json
 // @Produce json
@@ -117,10 +135,16 @@ type RelatedTaskMap map[RelationKind][]*Task
 // @Param taskID path int true "Task ID"
 // @Success 201 {object} models.TaskRelation "The created task relation object."
 // @Failure 400 {object} web.HTTPError "Invalid task relation object provided."
+// @Failure 404 {object} web.HTTPError "The task identifier does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/relations [put]
 func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 
+	err := rel.resolveOtherTaskIdentifier(s)
+	if err != nil {
+		return err
+	}
+
 	// Check if both tasks are the same
 	if rel.TaskID == rel.OtherTaskID {
 		return ErrRelationTasksCannotBeTheSame{
@@ -145,6 +169,11 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		}
 	}
 
+	err = checkRelationCreatesCycle(s, rel)
+	if err != nil {
+		return err
+	}
+
 	rel.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return err
@@ -190,7 +219,15 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		rel,
 		otherRelation,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = addTaskHistoryEntry(s, a, rel.TaskID, "related_tasks", nil, getTaskHistoryReference(s, rel.OtherTaskID, rel.RelationKind))
+	if err != nil {
+		return err
+	}
+	return addTaskHistoryEntry(s, a, otherRelation.TaskID, "related_tasks", nil, getTaskHistoryReference(s, otherRelation.OtherTaskID, otherRelation.RelationKind))
 }
 
 // Delete removes a task relation
@@ -238,8 +275,27 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 		}
 	}
 
+	relations := []*TaskRelation{}
+	err = s.
+		Where(cond).
+		Find(&relations)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where(cond).
 		Delete(&TaskRelation{})
-	return err
+	if err != nil {
+		return err
+	}
+
+	for _, r := range relations {
+		err = addTaskHistoryEntry(s, a, r.TaskID, "related_tasks", getTaskHistoryReference(s, r.OtherTaskID, r.RelationKind), nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
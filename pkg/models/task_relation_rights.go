@@ -35,6 +35,11 @@ func (rel *TaskRelation) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
 		return false, ErrInvalidRelationKind{Kind: rel.RelationKind}
 	}
 
+	err := rel.resolveOtherTaskIdentifier(s)
+	if err != nil {
+		return false, err
+	}
+
 	// Needs have write access to the base task and at least read access to the other task
 	baseTask := &Task{ID: rel.TaskID}
 	has, err := baseTask.CanUpdate(s, a)
@@ -46,6 +46,40 @@ func TestTaskRelation_Create(t *testing.T) {
 			"created_by_id": 1,
 		}, false)
 	})
+	t.Run("By identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := TaskRelation{
+			TaskID:              1,
+			OtherTaskIdentifier: "test1-2",
+			RelationKind:        RelationKindSubtask,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       1,
+			"other_task_id": 2,
+			"relation_kind": RelationKindSubtask,
+		}, false)
+	})
+	t.Run("Nonexisting identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := TaskRelation{
+			TaskID:              1,
+			OtherTaskIdentifier: "test1-9999",
+			RelationKind:        RelationKindSubtask,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIdentifierDoesNotExist(err))
+	})
 	t.Run("Two Tasks In Different Lists", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -94,6 +128,51 @@ func TestTaskRelation_Create(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, IsErrRelationTasksCannotBeTheSame(err))
 	})
+	t.Run("Direct Cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Task 1 already is a subtask of task 29
+		rel := TaskRelation{
+			TaskID:       29,
+			OtherTaskID:  1,
+			RelationKind: RelationKindSubtask,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrRelationCreatesCycle(err))
+	})
+	t.Run("Indirect Cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := TaskRelation{
+			TaskID:       3,
+			OtherTaskID:  4,
+			RelationKind: RelationKindBlocking,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		rel = TaskRelation{
+			TaskID:       4,
+			OtherTaskID:  5,
+			RelationKind: RelationKindBlocking,
+		}
+		err = rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+
+		// Task 5 is blocked by task 3 through task 4
+		rel = TaskRelation{
+			TaskID:       3,
+			OtherTaskID:  5,
+			RelationKind: RelationKindBlocked,
+		}
+		err = rel.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrRelationCreatesCycle(err))
+	})
 }
 
 func TestTaskRelation_Delete(t *testing.T) {
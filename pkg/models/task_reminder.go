@@ -124,6 +124,7 @@ func getTasksWithRemindersDueAndTheirUsers(s *xorm.Session, now time.Time) (remi
 		// All reminders from -12h to +14h to include all time zones
 		Where("reminder >= ? and reminder < ?", now.Add(time.Hour*-12).Format(dbTimeFormat), nextMinute.Add(time.Hour*14).Format(dbTimeFormat)).
 		And("tasks.done = false").
+		And("tasks.deleted IS NULL").
 		Find(&reminders)
 	if err != nil {
 		return
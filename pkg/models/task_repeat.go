@@ -0,0 +1,259 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+
+	"github.com/teambition/rrule-go"
+)
+
+// taskRepeatRule holds a parsed task repeat rule together with the dates excluded from it.
+type taskRepeatRule struct {
+	rule    *rrule.RRule
+	exdates []time.Time
+}
+
+// Parses and validates a repeat rule and returns it in its canonical form.
+// Both a plain RRULE value like `FREQ=WEEKLY;BYDAY=TU` and one prefixed with `RRULE:` are accepted.
+func normalizeRepeatRule(rule string) (string, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return "", nil
+	}
+
+	opts, err := rrule.StrToROption(rule)
+	if err != nil {
+		return "", ErrInvalidRepeatRule{Rule: rule, Reason: err.Error()}
+	}
+
+	if !opts.Dtstart.IsZero() {
+		return "", ErrInvalidRepeatRule{Rule: rule, Reason: "DTSTART is not supported, the rule always starts at the task's dates"}
+	}
+
+	if err := checkRepeatFrequency(rule, opts.Freq); err != nil {
+		return "", err
+	}
+
+	if opts.Count > 0 && !opts.Until.IsZero() {
+		return "", ErrInvalidRepeatRule{Rule: rule, Reason: "COUNT and UNTIL cannot be used together"}
+	}
+
+	if _, err := rrule.NewRRule(*opts); err != nil {
+		return "", ErrInvalidRepeatRule{Rule: rule, Reason: err.Error()}
+	}
+
+	return opts.RRuleString(), nil
+}
+
+// Tasks can repeat at most every hour. Finding the next occurrence walks the series from its start,
+// which would take millions of steps for rules repeating every second or minute which started long ago.
+func checkRepeatFrequency(rule string, freq rrule.Frequency) error {
+	if freq == rrule.MINUTELY || freq == rrule.SECONDLY {
+		return ErrInvalidRepeatRule{Rule: rule, Reason: "tasks can repeat at most every hour"}
+	}
+	return nil
+}
+
+// Parses a repeat rule whose series starts at dtstart. The rule is evaluated in the configured time zone so that
+// weekdays and month days match what the user sees.
+func parseRepeatRule(rule string, dtstart time.Time, exdates []time.Time) (*taskRepeatRule, error) {
+	opts, err := rrule.StrToROptionInLocation(rule, config.GetTimeZone())
+	if err != nil {
+		return nil, ErrInvalidRepeatRule{Rule: rule, Reason: err.Error()}
+	}
+
+	if err := checkRepeatFrequency(rule, opts.Freq); err != nil {
+		return nil, err
+	}
+
+	opts.Dtstart = dtstart.In(config.GetTimeZone())
+	r, err := rrule.NewRRule(*opts)
+	if err != nil {
+		return nil, ErrInvalidRepeatRule{Rule: rule, Reason: err.Error()}
+	}
+
+	return &taskRepeatRule{
+		rule:    r,
+		exdates: exdates,
+	}, nil
+}
+
+// Checks if an occurrence is excluded through one of the exception dates of the rule.
+// An exception date at midnight excludes the whole day, all others only exclude an occurrence at exactly that time.
+func (r *taskRepeatRule) isExcluded(occurrence time.Time) bool {
+	tz := config.GetTimeZone()
+	occurrence = occurrence.In(tz)
+
+	for _, exdate := range r.exdates {
+		exdate = exdate.In(tz)
+		if occurrence.Equal(exdate) {
+			return true
+		}
+
+		if exdate.Hour() == 0 && exdate.Minute() == 0 && exdate.Second() == 0 &&
+			exdate.Year() == occurrence.Year() && exdate.YearDay() == occurrence.YearDay() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the first occurrence of the rule after the given date which is not excluded.
+// If the rule has no more occurrences after that date, a zero time is returned.
+func (r *taskRepeatRule) after(dt time.Time) time.Time {
+	next := r.rule.Iterator()
+	for {
+		occurrence, ok := next()
+		if !ok {
+			return time.Time{}
+		}
+
+		if !occurrence.After(dt) || r.isExcluded(occurrence) {
+			continue
+		}
+
+		return occurrence
+	}
+}
+
+// Returns all occurrences of the rule from from until to which are not excluded, but at most limit.
+// The limit only counts occurrences from from on, no matter how far before it the rule starts.
+func (r *taskRepeatRule) between(from, to time.Time, limit int) (occurrences []time.Time) {
+	next := r.rule.Iterator()
+	for len(occurrences) < limit {
+		occurrence, ok := next()
+		if !ok || occurrence.After(to) {
+			break
+		}
+
+		if occurrence.Before(from) || r.isExcluded(occurrence) {
+			continue
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+	return
+}
+
+// Returns the date a task's recurrence is based on. This is the due date if the task has one, otherwise its
+// start date, end date or earliest reminder, in that order.
+func (t *Task) getRepeatAnchor() time.Time {
+	switch {
+	case !t.DueDate.IsZero():
+		return t.DueDate
+	case !t.StartDate.IsZero():
+		return t.StartDate
+	case !t.EndDate.IsZero():
+		return t.EndDate
+	}
+
+	var earliest time.Time
+	for _, r := range t.Reminders {
+		if earliest.IsZero() || r.Before(earliest) {
+			earliest = r
+		}
+	}
+
+	return earliest
+}
+
+// Validates the repeat rule of a task and makes sure the series has a start date. The series starts at the date
+// the rule is based on when it is first set or changed. That way, COUNT and UNTIL are evaluated against the original
+// series and not restarted every time the task is marked as done.
+func (t *Task) prepareRepeatRule(originalRule string) (err error) {
+	t.RepeatRule, err = normalizeRepeatRule(t.RepeatRule)
+	if err != nil {
+		return err
+	}
+
+	if t.RepeatRule == "" {
+		t.RepeatStart = time.Time{}
+		t.RepeatExdates = nil
+		return nil
+	}
+
+	if t.RepeatStart.IsZero() || t.RepeatRule != originalRule {
+		t.RepeatStart = t.getRepeatAnchor()
+	}
+
+	return nil
+}
+
+// Moves the due, start and end date and all reminders of a repeating task to the next occurrence of its repeat rule
+// and marks it as undone. All dates keep their difference to each other.
+// If the rule does not have any more occurrences, the task stays done.
+func setTaskDatesFromRepeatRule(oldTask, newTask *Task) (err error) {
+	// Current time in an extra variable to base all calculations on the same time
+	now := time.Now()
+
+	anchor := oldTask.getRepeatAnchor()
+
+	dtstart := oldTask.RepeatStart
+	after := now
+	if oldTask.RepeatFromCurrentDate || dtstart.IsZero() {
+		dtstart = now
+	}
+	// The next occurrence should always be after the current one, even if that is already in the future.
+	if !oldTask.RepeatFromCurrentDate && anchor.After(after) {
+		after = anchor
+	}
+
+	rule, err := parseRepeatRule(oldTask.RepeatRule, dtstart, oldTask.RepeatExdates)
+	if err != nil {
+		return err
+	}
+
+	next := rule.after(after)
+	if next.IsZero() {
+		return nil
+	}
+
+	newTask.Done = false
+
+	if anchor.IsZero() {
+		return nil
+	}
+
+	diff := next.Sub(anchor)
+
+	if !oldTask.DueDate.IsZero() {
+		newTask.DueDate = oldTask.DueDate.Add(diff)
+	}
+
+	if !oldTask.StartDate.IsZero() {
+		newTask.StartDate = oldTask.StartDate.Add(diff)
+	}
+
+	if !oldTask.EndDate.IsZero() {
+		newTask.EndDate = oldTask.EndDate.Add(diff)
+	}
+
+	newTask.Reminders = oldTask.Reminders
+	if len(oldTask.Reminders) > 0 {
+		newTask.Reminders = make([]time.Time, 0, len(oldTask.Reminders))
+		for _, r := range oldTask.Reminders {
+			newTask.Reminders = append(newTask.Reminders, r.Add(diff))
+		}
+	}
+
+	return nil
+}
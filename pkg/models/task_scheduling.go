@@ -0,0 +1,202 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// Returns how far a task was moved in time. The end date is what following tasks depend on,
+// the due date is used instead for tasks without an end date.
+func getRescheduleOffset(oldTask, newTask *Task) time.Duration {
+	if !oldTask.EndDate.IsZero() && !newTask.EndDate.IsZero() && !oldTask.EndDate.Equal(newTask.EndDate) {
+		return newTask.EndDate.Sub(oldTask.EndDate)
+	}
+
+	if !oldTask.DueDate.IsZero() && !newTask.DueDate.IsZero() {
+		return newTask.DueDate.Sub(oldTask.DueDate)
+	}
+
+	return 0
+}
+
+func shiftTaskDate(date time.Time, offset time.Duration) time.Time {
+	if date.IsZero() {
+		return date
+	}
+	return date.Add(offset)
+}
+
+// Shifts the start, end and due dates of all tasks following the task by the same offset the task itself was moved.
+// Because all following tasks are moved by the same offset, the lag between them stays the same.
+// Only tasks in lists with automatic scheduling enabled are moved, including everything following them.
+func shiftFollowingTasks(s *xorm.Session, a web.Auth, task *Task, offset time.Duration) (rescheduled []*Task, err error) {
+	rescheduled = []*Task{}
+	if offset == 0 {
+		return
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	lists := make(map[int64]bool)
+	visited := map[int64]bool{task.ID: true}
+	current := []int64{task.ID}
+
+	for len(current) > 0 {
+		relations := []*TaskRelation{}
+		err = s.
+			In("task_id", current).
+			And("relation_kind = ?", RelationKindPreceeds).
+			Find(&relations)
+		if err != nil {
+			return nil, err
+		}
+
+		followerIDs := make([]int64, 0, len(relations))
+		for _, r := range relations {
+			if !visited[r.OtherTaskID] {
+				visited[r.OtherTaskID] = true
+				followerIDs = append(followerIDs, r.OtherTaskID)
+			}
+		}
+		if len(followerIDs) == 0 {
+			break
+		}
+
+		followers := []*Task{}
+		err = s.
+			In("id", followerIDs).
+			OrderBy("id asc").
+			Find(&followers)
+		if err != nil {
+			return nil, err
+		}
+
+		current = []int64{}
+		for _, follower := range followers {
+			canReschedule, has := lists[follower.ListID]
+			if !has {
+				canReschedule, err = canRescheduleTasksInList(s, a, follower.ListID)
+				if err != nil {
+					return nil, err
+				}
+				lists[follower.ListID] = canReschedule
+			}
+			if !canReschedule {
+				continue
+			}
+
+			originalTask := *follower
+			follower.StartDate = shiftTaskDate(follower.StartDate, offset)
+			follower.EndDate = shiftTaskDate(follower.EndDate, offset)
+			follower.DueDate = shiftTaskDate(follower.DueDate, offset)
+
+			_, err = s.
+				ID(follower.ID).
+				Cols("start_date", "end_date", "due_date").
+				Update(follower)
+			if err != nil {
+				return nil, err
+			}
+
+			err = recordTaskUpdate(s, a, &originalTask, follower)
+			if err != nil {
+				return nil, err
+			}
+
+			err = events.Dispatch(&TaskUpdatedEvent{
+				Task:                follower,
+				Doer:                doer,
+				PreviousDescription: originalTask.Description,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			err = updateListLastUpdated(s, &List{ID: follower.ListID})
+			if err != nil {
+				return nil, err
+			}
+
+			log.Debugf("Rescheduled task %d by %s because task %d was moved", follower.ID, offset, task.ID)
+
+			rescheduled = append(rescheduled, follower)
+			current = append(current, follower.ID)
+		}
+	}
+
+	return
+}
+
+func canRescheduleTasksInList(s *xorm.Session, a web.Auth, listID int64) (bool, error) {
+	l, err := GetListSimpleByID(s, listID)
+	if err != nil {
+		return false, err
+	}
+
+	if !l.AutoSchedule {
+		return false, nil
+	}
+
+	can, err := l.CanWrite(s, a)
+	if IsErrListIsArchived(err) {
+		return false, nil
+	}
+	return can, err
+}
+
+// Moves the tasks following the task if it was moved and its list has automatic scheduling enabled.
+func (t *Task) rescheduleFollowingTasks(s *xorm.Session, a web.Auth, originalTask *Task) error {
+	offset := getRescheduleOffset(originalTask, t)
+	if offset == 0 {
+		return nil
+	}
+
+	l, err := GetListSimpleByID(s, t.ListID)
+	if err != nil {
+		return err
+	}
+	if !l.AutoSchedule {
+		return nil
+	}
+
+	rescheduled, err := shiftFollowingTasks(s, a, t, offset)
+	if err != nil {
+		return err
+	}
+	if len(rescheduled) == 0 {
+		return nil
+	}
+
+	taskMap := make(map[int64]*Task, len(rescheduled))
+	for _, task := range rescheduled {
+		taskMap[task.ID] = task
+	}
+	err = addMoreInfoToTasks(s, taskMap, a)
+	if err != nil {
+		return err
+	}
+
+	t.RescheduledTasks = rescheduled
+	return nil
+}
@@ -0,0 +1,133 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func setupScheduledTasks(t *testing.T, s *xorm.Session, autoSchedule bool) {
+	// Task 5 precedes task 7 which precedes task 8
+	for _, rel := range []*TaskRelation{
+		{TaskID: 5, OtherTaskID: 7, RelationKind: RelationKindPreceeds},
+		{TaskID: 7, OtherTaskID: 8, RelationKind: RelationKindPreceeds},
+	} {
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+	}
+
+	_, err := s.
+		Where("id = ?", 1).
+		Cols("auto_schedule").
+		Update(&List{AutoSchedule: autoSchedule})
+	assert.NoError(t, err)
+}
+
+func TestTask_Update_Reschedule(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("move predecessor", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupScheduledTasks(t, s, true)
+
+		original, err := GetTaskByIDSimple(s, 5)
+		assert.NoError(t, err)
+		follower, err := GetTaskByIDSimple(s, 7)
+		assert.NoError(t, err)
+		last, err := GetTaskByIDSimple(s, 8)
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:       5,
+			Title:    original.Title,
+			ListID:   1,
+			BucketID: original.BucketID,
+			DueDate:  original.DueDate.Add(24 * time.Hour),
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.RescheduledTasks, 2)
+
+		updated, err := GetTaskByIDSimple(s, 7)
+		assert.NoError(t, err)
+		assert.True(t, follower.StartDate.Add(24*time.Hour).Equal(updated.StartDate))
+		assert.True(t, updated.DueDate.IsZero())
+		updated, err = GetTaskByIDSimple(s, 8)
+		assert.NoError(t, err)
+		assert.True(t, last.EndDate.Add(24*time.Hour).Equal(updated.EndDate))
+		assert.True(t, updated.StartDate.IsZero())
+	})
+	t.Run("move predecessor back", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupScheduledTasks(t, s, true)
+
+		original, err := GetTaskByIDSimple(s, 5)
+		assert.NoError(t, err)
+		follower, err := GetTaskByIDSimple(s, 7)
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:       5,
+			Title:    original.Title,
+			ListID:   1,
+			BucketID: original.BucketID,
+			DueDate:  original.DueDate.Add(-time.Hour),
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+
+		updated, err := GetTaskByIDSimple(s, 7)
+		assert.NoError(t, err)
+		assert.True(t, follower.StartDate.Add(-time.Hour).Equal(updated.StartDate))
+	})
+	t.Run("not enabled", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		setupScheduledTasks(t, s, false)
+
+		original, err := GetTaskByIDSimple(s, 5)
+		assert.NoError(t, err)
+		follower, err := GetTaskByIDSimple(s, 7)
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:       5,
+			Title:    original.Title,
+			ListID:   1,
+			BucketID: original.BucketID,
+			DueDate:  original.DueDate.Add(24 * time.Hour),
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Empty(t, task.RescheduledTasks)
+
+		updated, err := GetTaskByIDSimple(s, 7)
+		assert.NoError(t, err)
+		assert.True(t, follower.StartDate.Equal(updated.StartDate))
+	})
+}
@@ -0,0 +1,294 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/modules/search"
+	"code.vikunja.io/api/pkg/modules/search/document"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const (
+	// The maximum number of tasks the user has access to returned from the search index for a single search
+	maxSearchResults = 1000
+	// How many tasks are indexed at once when rebuilding the search index
+	searchIndexBatchSize = 500
+
+	searchBoostTitle       = 3
+	searchBoostDescription = 1
+	searchBoostComment     = 1
+	searchBoostAttachment  = 2
+)
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+func stripHTML(text string) string {
+	return htmlTagRegex.ReplaceAllString(text, " ")
+}
+
+func getTaskSearchDocument(t *Task) *document.Document {
+	return &document.Document{
+		ID:     "task-" + strconv.FormatInt(t.ID, 10),
+		TaskID: t.ID,
+		Fields: []*document.Field{
+			{Text: t.Title, Boost: searchBoostTitle},
+			{Text: stripHTML(t.Description), Boost: searchBoostDescription},
+		},
+	}
+}
+
+func getTaskCommentSearchDocument(tc *TaskComment) *document.Document {
+	return &document.Document{
+		ID:     "comment-" + strconv.FormatInt(tc.ID, 10),
+		TaskID: tc.TaskID,
+		Fields: []*document.Field{
+			{Text: stripHTML(tc.Comment), Boost: searchBoostComment},
+		},
+	}
+}
+
+func getTaskAttachmentSearchDocument(ta *TaskAttachment) *document.Document {
+	return &document.Document{
+		ID:     "attachment-" + strconv.FormatInt(ta.ID, 10),
+		TaskID: ta.TaskID,
+		Fields: []*document.Field{
+			{Text: ta.File.Name, Boost: searchBoostAttachment},
+		},
+	}
+}
+
+// getSearchDocumentsForTasks returns the search documents of the tasks, their comments and their attachments.
+func getSearchDocumentsForTasks(s *xorm.Session, tasks []*Task) (docs []*document.Document, err error) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+		docs = append(docs, getTaskSearchDocument(t))
+	}
+
+	comments := []*TaskComment{}
+	err = s.In("task_id", taskIDs).Find(&comments)
+	if err != nil {
+		return nil, err
+	}
+	for _, tc := range comments {
+		docs = append(docs, getTaskCommentSearchDocument(tc))
+	}
+
+	attachments := []*TaskAttachment{}
+	err = s.In("task_id", taskIDs).Find(&attachments)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return
+	}
+
+	fileIDs := make([]int64, 0, len(attachments))
+	for _, ta := range attachments {
+		fileIDs = append(fileIDs, ta.FileID)
+	}
+	fs := make(map[int64]*files.File)
+	err = s.In("id", fileIDs).Find(&fs)
+	if err != nil {
+		return nil, err
+	}
+	for _, ta := range attachments {
+		var has bool
+		ta.File, has = fs[ta.FileID]
+		if !has {
+			continue
+		}
+		docs = append(docs, getTaskAttachmentSearchDocument(ta))
+	}
+
+	return
+}
+
+// updateTaskInSearchIndex replaces everything indexed for a task with its current state.
+// If the task does not exist anymore, it is removed from the index.
+func updateTaskInSearchIndex(s *xorm.Session, taskID int64) error {
+	if !search.Enabled() {
+		return nil
+	}
+
+	err := search.DeleteTask(taskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		if IsErrTaskDoesNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	docs, err := getSearchDocumentsForTasks(s, []*Task{&task})
+	if err != nil {
+		return err
+	}
+
+	return search.Add(docs...)
+}
+
+// RebuildSearchIndex removes everything from the search index and indexes all tasks,
+// comments and attachments again.
+func RebuildSearchIndex() (indexed int64, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	err = search.Clear()
+	if err != nil {
+		return 0, err
+	}
+
+	var lastID int64
+	for {
+		tasks := []*Task{}
+		err = s.
+			Where("id > ?", lastID).
+			OrderBy("id asc").
+			Limit(searchIndexBatchSize).
+			Find(&tasks)
+		if err != nil {
+			return indexed, err
+		}
+		if len(tasks) == 0 {
+			return indexed, nil
+		}
+
+		docs, err := getSearchDocumentsForTasks(s, tasks)
+		if err != nil {
+			return indexed, err
+		}
+
+		err = search.Add(docs...)
+		if err != nil {
+			return indexed, err
+		}
+
+		indexed += int64(len(tasks))
+		lastID = tasks[len(tasks)-1].ID
+		log.Infof("Indexed %d tasks", indexed)
+	}
+}
+
+// getTaskSearchCond returns the condition to search tasks and, if the search index is used,
+// an expression to order the tasks by relevance. permittedCond limits the results of the search index
+// to the tasks the user has access to.
+func getTaskSearchCond(s *xorm.Session, searchString string, permittedCond builder.Cond) (cond builder.Cond, rankOrder string, err error) {
+	var rankedTaskIDs []int64
+
+	searchTaskID, err := getTaskIDByIdentifier(s, searchString)
+	if err != nil {
+		return nil, "", err
+	}
+	if searchTaskID > 0 {
+		rankedTaskIDs = append(rankedTaskIDs, searchTaskID)
+	}
+
+	if search.Enabled() {
+		// The index contains the tasks of all users. Only limiting the results after checking the access
+		// prevents the tasks of other users from pushing out all results of this one.
+		taskIDs, err := search.Search(searchString, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		taskIDs, err = filterPermittedSearchResults(s, taskIDs, permittedCond, maxSearchResults)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, id := range taskIDs {
+			if id != searchTaskID {
+				rankedTaskIDs = append(rankedTaskIDs, id)
+			}
+		}
+		cond = builder.In("id", rankedTaskIDs)
+	} else {
+		cond = db.ILIKE("title", searchString)
+		if searchTaskID > 0 {
+			cond = builder.Or(cond, builder.Eq{"id": searchTaskID})
+		}
+	}
+
+	searchIndex := getTaskIndexFromSearchString(searchString)
+	if searchIndex > 0 {
+		cond = builder.Or(cond, builder.Eq{"`index`": searchIndex})
+	}
+
+	if len(rankedTaskIDs) == 0 {
+		return
+	}
+
+	// The ids are numbers from the db so it is safe to use them directly in the order expression
+	var order strings.Builder
+	order.WriteString("CASE id")
+	for i, id := range rankedTaskIDs {
+		order.WriteString(" WHEN " + strconv.FormatInt(id, 10) + " THEN " + strconv.Itoa(i))
+	}
+	order.WriteString(" ELSE " + strconv.Itoa(len(rankedTaskIDs)) + " END")
+	return cond, order.String(), nil
+}
+
+// Returns the ids of the tasks matching the condition, keeping the order of taskIDs, but at most limit.
+// The tasks are checked in chunks so that huge result lists of the search index don't end up in a single query.
+func filterPermittedSearchResults(s *xorm.Session, taskIDs []int64, cond builder.Cond, limit int) (permitted []int64, err error) {
+	permitted = []int64{}
+	for start := 0; start < len(taskIDs) && len(permitted) < limit; start += limit {
+		end := start + limit
+		if end > len(taskIDs) {
+			end = len(taskIDs)
+		}
+		chunk := taskIDs[start:end]
+
+		ids := []int64{}
+		err = s.
+			Table("tasks").
+			Where(builder.And(cond, builder.In("id", chunk))).
+			Cols("id").
+			Find(&ids)
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			found[id] = true
+		}
+		for _, id := range chunk {
+			if found[id] && len(permitted) < limit {
+				permitted = append(permitted, id)
+			}
+		}
+	}
+
+	return permitted, nil
+}
@@ -0,0 +1,140 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/modules/search"
+	"code.vikunja.io/api/pkg/modules/search/embedded"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+)
+
+func setupTestSearchIndex(t *testing.T) {
+	i, err := embedded.OpenInMemory()
+	assert.NoError(t, err)
+	search.SetIndex(i)
+	t.Cleanup(func() {
+		search.SetIndex(nil)
+		_ = i.Close()
+	})
+
+	_, err = RebuildSearchIndex()
+	assert.NoError(t, err)
+}
+
+func searchTaskIDs(t *testing.T, s string, sortBy ...string) []int64 {
+	sess := db.NewSession()
+	defer sess.Close()
+
+	tc := &TaskCollection{ListID: 1, SortBy: sortBy}
+	result, _, _, err := tc.ReadAll(sess, &user.User{ID: 1}, s, 0, 50)
+	assert.NoError(t, err)
+
+	ids := []int64{}
+	for _, task := range result.([]*Task) {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func TestTaskSearch(t *testing.T) {
+	t.Run("title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		assert.Equal(t, []int64{3, 4}, searchTaskIDs(t, "prio"))
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		assert.Equal(t, []int64{1}, searchTaskIDs(t, "dolor"))
+	})
+	t.Run("typo", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		assert.Equal(t, []int64{5}, searchTaskIDs(t, "hihger"))
+	})
+	t.Run("identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		assert.Equal(t, []int64{2}, searchTaskIDs(t, "test1-2"))
+	})
+	t.Run("no results", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		assert.Empty(t, searchTaskIDs(t, "nonexistingword"))
+	})
+	t.Run("ordered by relevance", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		ids := searchTaskIDs(t, "lorem")
+		assert.NotEmpty(t, ids)
+		// Task 1 has the word in its description and in a comment, others only in the description
+		assert.Equal(t, int64(1), ids[0])
+	})
+	t.Run("ordered by sort param", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setupTestSearchIndex(t)
+
+		assert.Equal(t, []int64{4, 3}, searchTaskIDs(t, "prio", "priority"))
+	})
+}
+
+func TestFilterPermittedSearchResults(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	// Task 14 is in a list of another user, it must not count towards the limit
+	ids, err := filterPermittedSearchResults(s, []int64{14, 3, 1, 2}, builder.Eq{"list_id": 1}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1}, ids)
+}
+
+func TestUpdateTaskInSearchIndex(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	setupTestSearchIndex(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err := s.ID(1).Cols("title").Update(&Task{Title: "Water the plants"})
+	assert.NoError(t, err)
+	err = updateTaskInSearchIndex(s, 1)
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int64{1}, searchTaskIDs(t, "plants"))
+
+	_, err = s.ID(1).Delete(&Task{})
+	assert.NoError(t, err)
+	err = updateTaskInSearchIndex(s, 1)
+	assert.NoError(t, err)
+
+	ids, err := search.Search("plants", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,412 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskTemplateUser represents a task template <-> user relation
+type TaskTemplateUser struct {
+	// The unique, numeric id of this task template <-> user relation.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The username.
+	Username string `xorm:"-" json:"user_id" param:"user"`
+	// Used internally to reference the user
+	UserID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The task template id.
+	TemplateID int64 `xorm:"bigint not null INDEX" json:"-" param:"tasktemplate"`
+	// The right this user has. 0 = Read only (can create tasks from the template), 1 = Read & Write, 2 = Admin.
+	Right Right `xorm:"bigint INDEX not null default 0" json:"right" valid:"length(0|2)" maximum:"2" default:"0"`
+
+	// A timestamp when this relation was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this relation was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName is the table name for TaskTemplateUser
+func (TaskTemplateUser) TableName() string {
+	return "task_template_users"
+}
+
+// TaskTemplateTeam represents a task template <-> team relation
+type TaskTemplateTeam struct {
+	// The unique, numeric id of this task template <-> team relation.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The team id.
+	TeamID int64 `xorm:"bigint not null INDEX" json:"team_id" param:"team"`
+	// The task template id.
+	TemplateID int64 `xorm:"bigint not null INDEX" json:"-" param:"tasktemplate"`
+	// The right this team has. 0 = Read only (can create tasks from the template), 1 = Read & Write, 2 = Admin.
+	Right Right `xorm:"bigint INDEX not null default 0" json:"right" valid:"length(0|2)" maximum:"2" default:"0"`
+
+	// A timestamp when this relation was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this relation was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName is the table name for TaskTemplateTeam
+func (TaskTemplateTeam) TableName() string {
+	return "task_template_teams"
+}
+
+// Create shares a task template with a user
+// @Summary Share a task template with a user
+// @Description Gives a user access to a task template.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Param template body models.TaskTemplateUser true "The user you want to share the template with."
+// @Success 201 {object} models.TaskTemplateUser "The created user <-> task template relation."
+// @Failure 400 {object} web.HTTPError "Invalid user task template object provided."
+// @Failure 404 {object} web.HTTPError "The user does not exist."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id}/users [put]
+func (tu *TaskTemplateUser) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := tu.Right.isValid(); err != nil {
+		return err
+	}
+
+	tt, err := getTaskTemplateSimpleByID(s, tu.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	u, err := user.GetUserByUsername(s, tu.Username)
+	if err != nil {
+		return err
+	}
+	tu.UserID = u.ID
+
+	if tt.OwnerID == tu.UserID {
+		return ErrUserAlreadyHasTaskTemplateAccess{UserID: tu.UserID, TemplateID: tu.TemplateID}
+	}
+
+	exists, err := s.
+		Where("template_id = ? AND user_id = ?", tu.TemplateID, tu.UserID).
+		Exist(&TaskTemplateUser{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUserAlreadyHasTaskTemplateAccess{UserID: tu.UserID, TemplateID: tu.TemplateID}
+	}
+
+	tu.ID = 0
+	_, err = s.Insert(tu)
+	return err
+}
+
+// Delete removes a user from a task template
+// @Summary Remove a user from a task template
+// @Description Removes a user from a task template. The user won't have access to the template anymore.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param templateID path int true "Task template ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} models.Message "The user was successfully removed from the task template."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 404 {object} web.HTTPError "User or task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{templateID}/users/{userID} [delete]
+func (tu *TaskTemplateUser) Delete(s *xorm.Session, a web.Auth) (err error) {
+	u, err := user.GetUserByUsername(s, tu.Username)
+	if err != nil {
+		return err
+	}
+	tu.UserID = u.ID
+
+	deleted, err := s.
+		Where("template_id = ? AND user_id = ?", tu.TemplateID, tu.UserID).
+		Delete(&TaskTemplateUser{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrUserDoesNotHaveAccessToTaskTemplate{UserID: tu.UserID, TemplateID: tu.TemplateID}
+	}
+	return nil
+}
+
+// ReadAll returns all users a task template was shared with
+// @Summary Get the users a task template was shared with
+// @Description Returns all users who have access to a task template through a direct share.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search users by their name."
+// @Success 200 {array} models.UserWithRight "The users with the right they have."
+// @Failure 403 {object} web.HTTPError "No right to see the task template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id}/users [get]
+func (tu *TaskTemplateUser) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	tt := &TaskTemplate{ID: tu.TemplateID}
+	canRead, _, err := tt.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	all := []*UserWithRight{}
+	query := s.
+		Join("INNER", "task_template_users", "user_id = users.id").
+		Where("task_template_users.template_id = ?", tu.TemplateID).
+		Where(db.ILIKE("users.username", search))
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&all)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Obfuscate all user emails
+	for _, u := range all {
+		u.Email = ""
+	}
+
+	numberOfTotalItems, err = s.
+		Join("INNER", "task_template_users", "user_id = users.id").
+		Where("task_template_users.template_id = ?", tu.TemplateID).
+		Where(db.ILIKE("users.username", search)).
+		Count(&UserWithRight{})
+
+	return all, len(all), numberOfTotalItems, err
+}
+
+// Update updates the right of a user on a task template
+// @Summary Update a user <-> task template relation
+// @Description Updates the right a user has on a task template.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param templateID path int true "Task template ID"
+// @Param userID path int true "User ID"
+// @Param template body models.TaskTemplateUser true "The user you want to update."
+// @Success 200 {object} models.TaskTemplateUser "The updated user <-> task template relation."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 404 {object} web.HTTPError "User or task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{templateID}/users/{userID} [post]
+func (tu *TaskTemplateUser) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := tu.Right.isValid(); err != nil {
+		return err
+	}
+
+	u, err := user.GetUserByUsername(s, tu.Username)
+	if err != nil {
+		return err
+	}
+	tu.UserID = u.ID
+
+	_, err = s.
+		Where("template_id = ? AND user_id = ?", tu.TemplateID, tu.UserID).
+		Cols("right").
+		Update(tu)
+	return err
+}
+
+// Create shares a task template with a team
+// @Summary Share a task template with a team
+// @Description Gives a team access to a task template.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Param template body models.TaskTemplateTeam true "The team you want to share the template with."
+// @Success 201 {object} models.TaskTemplateTeam "The created team <-> task template relation."
+// @Failure 400 {object} web.HTTPError "Invalid team task template object provided."
+// @Failure 404 {object} web.HTTPError "The team does not exist."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id}/teams [put]
+func (tt *TaskTemplateTeam) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err = tt.Right.isValid(); err != nil {
+		return
+	}
+
+	_, err = GetTeamByID(s, tt.TeamID)
+	if err != nil {
+		return err
+	}
+
+	_, err = getTaskTemplateSimpleByID(s, tt.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	exists, err := s.
+		Where("template_id = ? AND team_id = ?", tt.TemplateID, tt.TeamID).
+		Exist(&TaskTemplateTeam{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrTeamAlreadyHasAccess{TeamID: tt.TeamID, ID: tt.TemplateID}
+	}
+
+	tt.ID = 0
+	_, err = s.Insert(tt)
+	return err
+}
+
+// Delete removes a team from a task template
+// @Summary Remove a team from a task template
+// @Description Removes a team from a task template. The team won't have access to the template anymore.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param templateID path int true "Task template ID"
+// @Param teamID path int true "Team ID"
+// @Success 200 {object} models.Message "The team was successfully removed from the task template."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 404 {object} web.HTTPError "Team or task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{templateID}/teams/{teamID} [delete]
+func (tt *TaskTemplateTeam) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = GetTeamByID(s, tt.TeamID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.
+		Where("template_id = ? AND team_id = ?", tt.TemplateID, tt.TeamID).
+		Delete(&TaskTemplateTeam{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrTeamDoesNotHaveAccessToTaskTemplate{TeamID: tt.TeamID, TemplateID: tt.TemplateID}
+	}
+	return nil
+}
+
+// ReadAll returns all teams a task template was shared with
+// @Summary Get the teams a task template was shared with
+// @Description Returns all teams which have access to a task template.
+// @tags sharing
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search teams by their name."
+// @Success 200 {array} models.TeamWithRight "The teams with the right they have."
+// @Failure 403 {object} web.HTTPError "No right to see the task template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id}/teams [get]
+func (tt *TaskTemplateTeam) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	template := &TaskTemplate{ID: tt.TemplateID}
+	canRead, _, err := template.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	all := []*TeamWithRight{}
+	query := s.
+		Table("teams").
+		Join("INNER", "task_template_teams", "team_id = teams.id").
+		Where("task_template_teams.template_id = ?", tt.TemplateID).
+		Where(db.ILIKE("teams.name", search))
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&all)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	teams := []*Team{}
+	for _, t := range all {
+		teams = append(teams, &t.Team)
+	}
+
+	err = addMoreInfoToTeams(s, teams)
+	if err != nil {
+		return
+	}
+
+	totalItems, err = s.
+		Table("teams").
+		Join("INNER", "task_template_teams", "team_id = teams.id").
+		Where("task_template_teams.template_id = ?", tt.TemplateID).
+		Where(db.ILIKE("teams.name", search)).
+		Count(&TeamWithRight{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return all, len(all), totalItems, err
+}
+
+// Update updates the right of a team on a task template
+// @Summary Update a team <-> task template relation
+// @Description Updates the right a team has on a task template.
+// @tags sharing
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param templateID path int true "Task template ID"
+// @Param teamID path int true "Team ID"
+// @Param template body models.TaskTemplateTeam true "The team you want to update."
+// @Success 200 {object} models.TaskTemplateTeam "The updated team <-> task template relation."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 404 {object} web.HTTPError "Team or task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{templateID}/teams/{teamID} [post]
+func (tt *TaskTemplateTeam) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := tt.Right.isValid(); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("template_id = ? AND team_id = ?", tt.TemplateID, tt.TeamID).
+		Cols("right").
+		Update(tt)
+	return err
+}
@@ -0,0 +1,584 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskTemplate holds everything needed to create a new task over and over again.
+// All dates are stored as offsets in seconds relative to the time a task is created from the template.
+type TaskTemplate struct {
+	// The unique, numeric id of this task template.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"tasktemplate"`
+	// The title of the template. Tasks created from this template get the same title.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// The description of tasks created from this template.
+	Description string `xorm:"longtext null" json:"description"`
+	// The priority of tasks created from this template.
+	Priority int64 `xorm:"bigint null" json:"priority"`
+	// The color of tasks created from this template in hex.
+	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
+
+	LabelIDs []int64 `xorm:"JSON null 'label_ids'" json:"-"`
+	// The labels of tasks created from this template. Only the id of each label is used when saving a template.
+	Labels []*Label `xorm:"-" json:"labels"`
+
+	AssigneeIDs []int64 `xorm:"JSON null 'assignee_ids'" json:"-"`
+	// The users assigned to tasks created from this template. Only the id of each user is used when saving a template.
+	// Users who don't have access to the list a task is created in are not assigned.
+	Assignees []*user.User `xorm:"-" json:"assignees"`
+	// The checklist of tasks created from this template.
+	Checklist []*TaskTemplateChecklistItem `xorm:"JSON null 'checklist'" json:"checklist"`
+
+	// The reminders of tasks created from this template, in seconds after the task was created.
+	ReminderOffsets []int64 `xorm:"JSON null 'reminder_offsets'" json:"reminder_offsets"`
+	// The due date of tasks created from this template, in seconds after the task was created. Null if tasks should not have a due date.
+	DueDateOffset *int64 `xorm:"bigint null" json:"due_date_offset"`
+	// The start date of tasks created from this template, in seconds after the task was created. Null if tasks should not have a start date.
+	StartDateOffset *int64 `xorm:"bigint null" json:"start_date_offset"`
+	// The end date of tasks created from this template, in seconds after the task was created. Null if tasks should not have an end date.
+	EndDateOffset *int64 `xorm:"bigint null" json:"end_date_offset"`
+
+	// If set when creating a template, the template is created from this task. Everything except the title
+	// is then taken from the task, dates become offsets relative to the time the task was created.
+	// The title defaults to the title of the task.
+	TaskID int64 `xorm:"-" json:"task_id,omitempty"`
+
+	OwnerID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The user who owns this template.
+	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
+
+	// A timestamp when this template was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this template was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TaskTemplateChecklistItem is a single item of the checklist of a task template
+type TaskTemplateChecklistItem struct {
+	// The title of this checklist item.
+	Title string `json:"title" valid:"runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The due date of this checklist item, in seconds after the task was created. Null if the item should not have a due date.
+	DueDateOffset *int64 `json:"due_date_offset"`
+}
+
+// TableName holds the table name for task templates
+func (tt *TaskTemplate) TableName() string {
+	return "task_templates"
+}
+
+func getTaskTemplateSimpleByID(s *xorm.Session, id int64) (tt *TaskTemplate, err error) {
+	tt = &TaskTemplate{}
+	exists, err := s.
+		Where("id = ?", id).
+		Get(tt)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTaskTemplateDoesNotExist{ID: id}
+	}
+	return
+}
+
+// Returns the offset of a date relative to the base. Zero dates don't have an offset.
+func getDateOffset(base time.Time, date time.Time) *int64 {
+	if date.IsZero() {
+		return nil
+	}
+	offset := int64(date.Sub(base).Seconds())
+	return &offset
+}
+
+func getDateFromOffset(base time.Time, offset *int64) time.Time {
+	if offset == nil {
+		return time.Time{}
+	}
+	return base.Add(time.Duration(*offset) * time.Second)
+}
+
+// Condition for all templates a user owns or which were shared with them directly or through a team.
+func getTaskTemplatesCond(userID int64) builder.Cond {
+	return builder.Or(
+		builder.Eq{"task_templates.owner_id": userID},
+		builder.In("task_templates.id", builder.
+			Select("template_id").
+			From("task_template_users").
+			Where(builder.Eq{"user_id": userID})),
+		builder.In("task_templates.id", builder.
+			Select("task_template_teams.template_id").
+			From("task_template_teams").
+			Join("INNER", "team_members", "team_members.team_id = task_template_teams.team_id").
+			Where(builder.Eq{"team_members.user_id": userID})),
+	)
+}
+
+// Takes everything except the title from the task the template should be created from.
+func (tt *TaskTemplate) copyFromTask(s *xorm.Session, a web.Auth) (err error) {
+	task := &Task{ID: tt.TaskID}
+	err = task.ReadOne(s, a)
+	if err != nil {
+		return err
+	}
+
+	if tt.Title == "" {
+		tt.Title = task.Title
+	}
+	tt.Description = task.Description
+	tt.Priority = task.Priority
+	tt.HexColor = task.HexColor
+	tt.Labels = task.Labels
+	tt.Assignees = task.Assignees
+
+	tt.DueDateOffset = getDateOffset(task.Created, task.DueDate)
+	tt.StartDateOffset = getDateOffset(task.Created, task.StartDate)
+	tt.EndDateOffset = getDateOffset(task.Created, task.EndDate)
+
+	tt.ReminderOffsets = make([]int64, 0, len(task.Reminders))
+	for _, r := range task.Reminders {
+		tt.ReminderOffsets = append(tt.ReminderOffsets, *getDateOffset(task.Created, r))
+	}
+
+	items, err := getChecklistItemsForTask(s, task.ID)
+	if err != nil {
+		return err
+	}
+	tt.Checklist = make([]*TaskTemplateChecklistItem, 0, len(items))
+	for _, i := range items {
+		tt.Checklist = append(tt.Checklist, &TaskTemplateChecklistItem{
+			Title:         i.Title,
+			DueDateOffset: getDateOffset(task.Created, i.DueDate),
+		})
+	}
+
+	return nil
+}
+
+// Makes sure all labels and assignees of a template exist and the user saving the template
+// can see the labels and sets the ids which are actually saved.
+func (tt *TaskTemplate) setLabelsAndAssignees(s *xorm.Session, a web.Auth) (err error) {
+	tt.LabelIDs = make([]int64, 0, len(tt.Labels))
+	for _, l := range tt.Labels {
+		label, err := getLabelByIDSimple(s, l.ID)
+		if err != nil {
+			return err
+		}
+		has, _, err := label.hasAccessToLabel(s, a)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrUserHasNoAccessToLabel{LabelID: l.ID, UserID: a.GetID()}
+		}
+		tt.LabelIDs = append(tt.LabelIDs, l.ID)
+	}
+
+	tt.AssigneeIDs = make([]int64, 0, len(tt.Assignees))
+	for _, assignee := range tt.Assignees {
+		u, err := user.GetUserByID(s, assignee.ID)
+		if err != nil {
+			return err
+		}
+		tt.AssigneeIDs = append(tt.AssigneeIDs, u.ID)
+	}
+
+	return nil
+}
+
+func addMoreInfoToTaskTemplates(s *xorm.Session, templates []*TaskTemplate) (err error) {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	userIDs := []int64{}
+	labelIDs := []int64{}
+	for _, tt := range templates {
+		userIDs = append(userIDs, tt.OwnerID)
+		userIDs = append(userIDs, tt.AssigneeIDs...)
+		labelIDs = append(labelIDs, tt.LabelIDs...)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[int64]*Label)
+	if len(labelIDs) > 0 {
+		err = s.In("id", labelIDs).Find(&labels)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tt := range templates {
+		tt.Owner = users[tt.OwnerID]
+
+		// Labels or users which were deleted in the meantime are left out
+		tt.Labels = make([]*Label, 0, len(tt.LabelIDs))
+		for _, id := range tt.LabelIDs {
+			if l, has := labels[id]; has {
+				tt.Labels = append(tt.Labels, l)
+			}
+		}
+		tt.Assignees = make([]*user.User, 0, len(tt.AssigneeIDs))
+		for _, id := range tt.AssigneeIDs {
+			if u, has := users[id]; has {
+				tt.Assignees = append(tt.Assignees, u)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Create creates a new task template
+// @Summary Create a task template
+// @Description Creates a new task template. Pass a `task_id` to create the template from an existing task, the user needs at least read access to that task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template body models.TaskTemplate true "The task template"
+// @Success 201 {object} models.TaskTemplate "The created task template."
+// @Failure 400 {object} web.HTTPError "Invalid task template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates [put]
+func (tt *TaskTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	if tt.TaskID != 0 {
+		err = tt.copyFromTask(s, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	if tt.Title == "" {
+		return ErrTaskCannotBeEmpty{}
+	}
+
+	err = tt.setLabelsAndAssignees(s, a)
+	if err != nil {
+		return err
+	}
+
+	tt.ID = 0
+	tt.OwnerID = a.GetID()
+	_, err = s.Insert(tt)
+	if err != nil {
+		return err
+	}
+
+	return addMoreInfoToTaskTemplates(s, []*TaskTemplate{tt})
+}
+
+// ReadOne returns one task template
+// @Summary Get one task template
+// @Description Returns a task template by its ID.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Success 200 {object} models.TaskTemplate "The task template."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id} [get]
+func (tt *TaskTemplate) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	// The rights check already loaded the template
+	return addMoreInfoToTaskTemplates(s, []*TaskTemplate{tt})
+}
+
+// ReadAll returns all task templates a user has access to
+// @Summary Get all task templates
+// @Description Returns all task templates the user owns or which were shared with them, directly or through a team.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search task templates by title."
+// @Success 200 {array} models.TaskTemplate "The task templates."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates [get]
+func (tt *TaskTemplate) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(
+		getTaskTemplatesCond(a.GetID()),
+		db.ILIKE("task_templates.title", search),
+	)
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	templates := []*TaskTemplate{}
+	query := s.
+		Where(cond).
+		OrderBy("title ASC, id ASC")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&templates)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addMoreInfoToTaskTemplates(s, templates)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where(cond).
+		Count(&TaskTemplate{})
+	return templates, len(templates), numberOfTotalItems, err
+}
+
+// Update updates a task template
+// @Summary Update a task template
+// @Description Updates a task template. Tasks which were already created from the template are not changed.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Param template body models.TaskTemplate true "The task template"
+// @Success 200 {object} models.TaskTemplate "The updated task template."
+// @Failure 400 {object} web.HTTPError "Invalid task template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id} [post]
+func (tt *TaskTemplate) Update(s *xorm.Session, a web.Auth) (err error) {
+	if tt.Title == "" {
+		return ErrTaskCannotBeEmpty{}
+	}
+
+	original, err := getTaskTemplateSimpleByID(s, tt.ID)
+	if err != nil {
+		return err
+	}
+
+	err = tt.setLabelsAndAssignees(s, a)
+	if err != nil {
+		return err
+	}
+
+	tt.OwnerID = original.OwnerID
+	tt.Created = original.Created
+	_, err = s.
+		Where("id = ?", tt.ID).
+		Cols(
+			"title",
+			"description",
+			"priority",
+			"hex_color",
+			"label_ids",
+			"assignee_ids",
+			"checklist",
+			"reminder_offsets",
+			"due_date_offset",
+			"start_date_offset",
+			"end_date_offset",
+		).
+		Update(tt)
+	if err != nil {
+		return err
+	}
+
+	return addMoreInfoToTaskTemplates(s, []*TaskTemplate{tt})
+}
+
+// Delete removes a task template
+// @Summary Delete a task template
+// @Description Deletes a task template. Tasks which were already created from the template are not deleted.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Success 200 {object} models.Message "The task template was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id} [delete]
+func (tt *TaskTemplate) Delete(s *xorm.Session, a web.Auth) (err error) {
+	return deleteTaskTemplates(s, builder.Eq{"id": tt.ID})
+}
+
+func deleteTaskTemplates(s *xorm.Session, cond builder.Cond) (err error) {
+	ids := []int64{}
+	err = s.
+		Table("task_templates").
+		Where(cond).
+		Cols("id").
+		Find(&ids)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err = s.In("template_id", ids).Delete(&TaskTemplateUser{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.In("template_id", ids).Delete(&TaskTemplateTeam{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.In("id", ids).Delete(&TaskTemplate{})
+	return err
+}
+
+// TaskFromTemplate holds everything needed to create a new task from a template
+type TaskFromTemplate struct {
+	// The id of the template to create the task from
+	TemplateID int64 `json:"-" param:"tasktemplate"`
+	// The list the new task should be created in. The user needs write access to it.
+	ListID int64 `json:"list_id"`
+
+	// The created task
+	Task *Task `json:"task,omitempty"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// CanCreate checks if a user can create a task from a template
+func (tft *TaskFromTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	tt := &TaskTemplate{ID: tft.TemplateID}
+	canRead, _, err := tt.CanRead(s, a)
+	if err != nil || !canRead {
+		return canRead, err
+	}
+
+	l := &List{ID: tft.ListID}
+	return l.CanWrite(s, a)
+}
+
+// Create creates a new task from a template
+// @Summary Create a task from a template
+// @Description Creates a new task from a task template in any list the user has write access to. All dates of the new task are calculated from the offsets in the template, relative to now. Labels the user does not have access to and assignees who don't have access to the list are left out.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task template ID"
+// @Param task body models.TaskFromTemplate true "The list the task should be created in."
+// @Success 201 {object} models.TaskFromTemplate "The created task."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task template or the list."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{id}/tasks [put]
+func (tft *TaskFromTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	tt, err := getTaskTemplateSimpleByID(s, tft.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	t := &Task{
+		Title:       tt.Title,
+		Description: tt.Description,
+		Priority:    tt.Priority,
+		HexColor:    tt.HexColor,
+		ListID:      tft.ListID,
+		DueDate:     getDateFromOffset(now, tt.DueDateOffset),
+		StartDate:   getDateFromOffset(now, tt.StartDateOffset),
+		EndDate:     getDateFromOffset(now, tt.EndDateOffset),
+		Reminders:   make([]time.Time, 0, len(tt.ReminderOffsets)),
+	}
+	for _, offset := range tt.ReminderOffsets {
+		offset := offset
+		t.Reminders = append(t.Reminders, getDateFromOffset(now, &offset))
+	}
+
+	err = createTask(s, t, a, false)
+	if err != nil {
+		return err
+	}
+
+	l, err := GetListSimpleByID(s, tft.ListID)
+	if err != nil {
+		return err
+	}
+	for _, id := range tt.AssigneeIDs {
+		err = t.addNewAssigneeByID(s, id, l, a)
+		if err != nil {
+			if IsErrUserDoesNotHaveAccessToList(err) || user.IsErrUserDoesNotExist(err) {
+				continue
+			}
+			return err
+		}
+	}
+
+	labels := make([]*Label, 0, len(tt.LabelIDs))
+	for _, id := range tt.LabelIDs {
+		label, err := getLabelByIDSimple(s, id)
+		if err != nil {
+			if IsErrLabelDoesNotExist(err) {
+				continue
+			}
+			return err
+		}
+		has, _, err := label.hasAccessToLabel(s, a)
+		if err != nil {
+			return err
+		}
+		if has {
+			labels = append(labels, label)
+		}
+	}
+	err = t.updateTaskLabels(s, a, labels)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range tt.Checklist {
+		ci := &TaskChecklistItem{
+			TaskID:  t.ID,
+			Title:   item.Title,
+			DueDate: getDateFromOffset(now, item.DueDateOffset),
+		}
+		err = ci.Create(s, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Reload the task to get everything which was added after creating it
+	tft.Task = &Task{ID: t.ID}
+	return tft.Task.ReadOne(s, a)
+}
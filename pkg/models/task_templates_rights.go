@@ -0,0 +1,149 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read a task template and create tasks from it
+func (tt *TaskTemplate) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	template, right, err := getTaskTemplateRight(s, tt.ID, a)
+	if err != nil || template == nil {
+		return false, 0, err
+	}
+
+	*tt = *template
+	return true, int(right), nil
+}
+
+// CanCreate checks if a user can create a task template
+func (tt *TaskTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	if tt.TaskID == 0 {
+		return true, nil
+	}
+
+	// Creating a template from a task requires being able to see the task
+	t := &Task{ID: tt.TaskID}
+	can, _, err := t.CanRead(s, a)
+	return can, err
+}
+
+// CanUpdate checks if a user can update a task template
+func (tt *TaskTemplate) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tt.ID, a, RightWrite)
+}
+
+// CanDelete checks if a user can delete a task template
+func (tt *TaskTemplate) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tt.ID, a, RightAdmin)
+}
+
+func hasTaskTemplateRight(s *xorm.Session, templateID int64, a web.Auth, minRight Right) (bool, error) {
+	template, right, err := getTaskTemplateRight(s, templateID, a)
+	if err != nil || template == nil {
+		return false, err
+	}
+	return right >= minRight, nil
+}
+
+// Returns the template and the highest right a user has on it. The template is nil if the user has no access at all.
+// The owner of a template is always admin, all other users get their right through the template being shared with
+// them directly or with one of their teams. Link shares can't access templates.
+func getTaskTemplateRight(s *xorm.Session, templateID int64, a web.Auth) (template *TaskTemplate, right Right, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, RightRead, nil
+	}
+
+	template, err = getTaskTemplateSimpleByID(s, templateID)
+	if err != nil {
+		return nil, RightRead, err
+	}
+
+	if template.OwnerID == a.GetID() {
+		return template, RightAdmin, nil
+	}
+
+	users := []*TaskTemplateUser{}
+	err = s.
+		Where("template_id = ? AND user_id = ?", templateID, a.GetID()).
+		Find(&users)
+	if err != nil {
+		return nil, RightRead, err
+	}
+
+	teams := []*TaskTemplateTeam{}
+	err = s.
+		Join("INNER", "team_members", "team_members.team_id = task_template_teams.team_id").
+		Where("task_template_teams.template_id = ? AND team_members.user_id = ?", templateID, a.GetID()).
+		Find(&teams)
+	if err != nil {
+		return nil, RightRead, err
+	}
+
+	if len(users) == 0 && len(teams) == 0 {
+		return nil, RightRead, nil
+	}
+
+	for _, u := range users {
+		if u.Right > right {
+			right = u.Right
+		}
+	}
+	for _, t := range teams {
+		if t.Right > right {
+			right = t.Right
+		}
+	}
+
+	return template, right, nil
+}
+
+// CanCreate checks if the user can share a task template with another user
+func (tu *TaskTemplateUser) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tu.TemplateID, a, RightAdmin)
+}
+
+// CanDelete checks if the user can remove a user from a task template
+func (tu *TaskTemplateUser) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tu.TemplateID, a, RightAdmin)
+}
+
+// CanUpdate checks if the user can update the right of a user on a task template
+func (tu *TaskTemplateUser) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tu.TemplateID, a, RightAdmin)
+}
+
+// CanCreate checks if the user can share a task template with a team
+func (tt *TaskTemplateTeam) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tt.TemplateID, a, RightAdmin)
+}
+
+// CanDelete checks if the user can remove a team from a task template
+func (tt *TaskTemplateTeam) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tt.TemplateID, a, RightAdmin)
+}
+
+// CanUpdate checks if the user can update the right of a team on a task template
+func (tt *TaskTemplateTeam) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return hasTaskTemplateRight(s, tt.TemplateID, a, RightAdmin)
+}
@@ -0,0 +1,528 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		dueDateOffset := int64(3600)
+		tt := &TaskTemplate{
+			Title:         "Lorem",
+			Labels:        []*Label{{ID: 1}},
+			Assignees:     []*user.User{{ID: 2}},
+			DueDateOffset: &dueDateOffset,
+			Checklist: []*TaskTemplateChecklistItem{
+				{Title: "First"},
+			},
+		}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), tt.Owner.ID)
+		assert.Len(t, tt.Labels, 1)
+		assert.Equal(t, "Label #1", tt.Labels[0].Title)
+		assert.Len(t, tt.Assignees, 1)
+		assert.Equal(t, "user2", tt.Assignees[0].Username)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_templates", map[string]interface{}{
+			"id":              tt.ID,
+			"title":           "Lorem",
+			"owner_id":        1,
+			"due_date_offset": 3600,
+		}, false)
+
+		saved, err := getTaskTemplateSimpleByID(s, tt.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, saved.LabelIDs)
+		assert.Equal(t, []int64{2}, saved.AssigneeIDs)
+		assert.Len(t, saved.Checklist, 1)
+		assert.Nil(t, saved.StartDateOffset)
+	})
+	t.Run("from task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{TaskID: 5}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "task #5 higher due date", tt.Title)
+		assert.Equal(t, int64(10000), *tt.DueDateOffset)
+		assert.Nil(t, tt.StartDateOffset)
+	})
+	t.Run("from task with checklist and reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{TaskID: 1, Title: "Custom title"}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Custom title", tt.Title)
+		assert.Equal(t, "Lorem Ipsum", tt.Description)
+		assert.Equal(t, []int64{4}, tt.LabelIDs)
+		assert.Len(t, tt.Checklist, 2)
+
+		tt = &TaskTemplate{TaskID: 27}
+		err = tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{0, 100}, tt.ReminderOffsets)
+	})
+	t.Run("without title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCannotBeEmpty(err))
+	})
+	t.Run("label without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:  "Lorem",
+			Labels: []*Label{{ID: 3}},
+		}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserHasNoAccessToLabel(err))
+	})
+	t.Run("from task without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{TaskID: 1}
+		can, err := tt.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{Title: "Lorem"}
+		can, err := tt.CanCreate(s, &LinkSharing{ID: 1, ListID: 1, Right: RightAdmin})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTemplate_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{}
+	result, _, total, err := tt.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	templates := result.([]*TaskTemplate)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, templates, 3)
+	assert.Equal(t, int64(1), templates[0].ID)
+	assert.Equal(t, int64(2), templates[1].ID)
+	assert.Equal(t, int64(3), templates[2].ID)
+	assert.Equal(t, "Label #1", templates[0].Labels[0].Title)
+	assert.Equal(t, int64(2), templates[1].Owner.ID)
+}
+
+func TestTaskTemplate_Rights(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 1}
+		can, right, err := tt.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, int(RightAdmin), right)
+		assert.Equal(t, "Onboarding", tt.Title)
+	})
+	t.Run("shared read only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 2}
+		can, _, err := tt.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		can, err = tt.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("shared with write through team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 3}
+		can, err := tt.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		can, err = tt.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("not shared", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 4}
+		can, _, err := tt.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 9999}
+		can, _, err := tt.CanRead(s, u)
+		assert.Error(t, err)
+		assert.False(t, can)
+		assert.True(t, IsErrTaskTemplateDoesNotExist(err))
+	})
+}
+
+func TestTaskTemplate_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{
+		ID:    1,
+		Title: "Onboarding v2",
+	}
+	err := tt.Update(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tt.Owner.ID)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	saved, err := getTaskTemplateSimpleByID(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Onboarding v2", saved.Title)
+	assert.Equal(t, int64(1), saved.OwnerID)
+	assert.Empty(t, saved.LabelIDs)
+	assert.Empty(t, saved.Checklist)
+	assert.Nil(t, saved.DueDateOffset)
+}
+
+func TestTaskTemplate_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{ID: 1}
+	err := tt.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "task_templates", map[string]interface{}{
+		"id": 1,
+	})
+	db.AssertMissing(t, "task_template_users", map[string]interface{}{
+		"template_id": 1,
+	})
+}
+
+func TestTaskFromTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		before := time.Now()
+		tft := &TaskFromTemplate{
+			TemplateID: 1,
+			ListID:     1,
+		}
+		err := tft.Create(s, u)
+		assert.NoError(t, err)
+		task := tft.Task
+		assert.Equal(t, "Onboarding", task.Title)
+		assert.Equal(t, int64(2), task.Priority)
+		assert.Equal(t, int64(1), task.ListID)
+		assert.WithinDuration(t, before.Add(7*24*time.Hour), task.DueDate, 5*time.Second)
+		assert.WithinDuration(t, before, task.StartDate, 5*time.Second)
+		assert.True(t, task.EndDate.IsZero())
+		assert.Len(t, task.Reminders, 1)
+		assert.WithinDuration(t, before.Add(time.Hour), task.Reminders[0], 5*time.Second)
+		assert.Len(t, task.Labels, 1)
+		assert.Equal(t, int64(1), task.Labels[0].ID)
+		assert.Len(t, task.Assignees, 1)
+		assert.Equal(t, int64(1), task.Assignees[0].ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		items, err := getChecklistItemsForTask(s, task.ID)
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, "Create accounts", items[0].Title)
+		assert.True(t, items[0].DueDate.IsZero())
+		assert.WithinDuration(t, before.Add(24*time.Hour), items[1].DueDate, 5*time.Second)
+	})
+	t.Run("assignee without access to the list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			ID:        1,
+			Title:     "Onboarding",
+			Assignees: []*user.User{{ID: 1}, {ID: 2}},
+		}
+		err := tt.Update(s, u)
+		assert.NoError(t, err)
+
+		tft := &TaskFromTemplate{
+			TemplateID: 1,
+			ListID:     1,
+		}
+		err = tft.Create(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, tft.Task.Assignees, 1)
+		assert.Equal(t, int64(1), tft.Task.Assignees[0].ID)
+	})
+	t.Run("no write access to the list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{
+			TemplateID: 1,
+			ListID:     1,
+		}
+		can, err := tft.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no access to the template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{
+			TemplateID: 4,
+			ListID:     1,
+		}
+		can, err := tft.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTemplateUser(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tu := &TaskTemplateUser{
+			TemplateID: 1,
+			Username:   "user3",
+			Right:      RightWrite,
+		}
+		err := tu.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_template_users", map[string]interface{}{
+			"template_id": 1,
+			"user_id":     3,
+			"right":       RightWrite,
+		}, false)
+	})
+	t.Run("already has access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tu := &TaskTemplateUser{
+			TemplateID: 1,
+			Username:   "user2",
+		}
+		err := tu.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserAlreadyHasTaskTemplateAccess(err))
+
+		tu = &TaskTemplateUser{
+			TemplateID: 1,
+			Username:   "user1",
+		}
+		err = tu.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserAlreadyHasTaskTemplateAccess(err))
+	})
+	t.Run("no admin access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tu := &TaskTemplateUser{TemplateID: 3}
+		can, err := tu.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("read all", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tu := &TaskTemplateUser{TemplateID: 1}
+		result, _, _, err := tu.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		users := result.([]*UserWithRight)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(2), users[0].ID)
+		assert.Equal(t, RightRead, users[0].Right)
+	})
+	t.Run("delete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tu := &TaskTemplateUser{
+			TemplateID: 1,
+			Username:   "user2",
+		}
+		err := tu.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_template_users", map[string]interface{}{
+			"template_id": 1,
+			"user_id":     2,
+		})
+	})
+	t.Run("delete without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tu := &TaskTemplateUser{
+			TemplateID: 1,
+			Username:   "user3",
+		}
+		err := tu.Delete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToTaskTemplate(err))
+	})
+}
+
+func TestTaskTemplateTeam(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplateTeam{
+			TemplateID: 1,
+			TeamID:     1,
+		}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_template_teams", map[string]interface{}{
+			"template_id": 1,
+			"team_id":     1,
+		}, false)
+
+		// Members of the team can now see the template
+		template := &TaskTemplate{ID: 1}
+		can, right, err := template.CanRead(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, int(RightRead), right)
+	})
+	t.Run("already has access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplateTeam{
+			TemplateID: 4,
+			TeamID:     9,
+		}
+		err := tt.Create(s, &user.User{ID: 2})
+		assert.Error(t, err)
+		assert.True(t, IsErrTeamAlreadyHasAccess(err))
+	})
+	t.Run("read all", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplateTeam{TemplateID: 3}
+		result, _, _, err := tt.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		teams := result.([]*TeamWithRight)
+		assert.Len(t, teams, 1)
+		assert.Equal(t, int64(3), teams[0].ID)
+		assert.Equal(t, RightWrite, teams[0].Right)
+	})
+	t.Run("delete without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplateTeam{
+			TemplateID: 1,
+			TeamID:     1,
+		}
+		err := tt.Delete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTeamDoesNotHaveAccessToTaskTemplate(err))
+	})
+}
@@ -0,0 +1,330 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskTimeEntry represents a span of time a user spent working on a task
+type TaskTimeEntry struct {
+	// The unique, numeric id of this time entry.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"timeentry"`
+	// The task this time entry belongs to.
+	TaskID int64 `xorm:"bigint INDEX not null" json:"task_id" param:"task"`
+	// The user who logged this time entry.
+	UserID int64      `xorm:"bigint INDEX not null" json:"-"`
+	User   *user.User `xorm:"-" json:"user"`
+
+	// When the work on the task started.
+	Start time.Time `xorm:"DATETIME INDEX not null 'start_time'" json:"start"`
+	// When the work on the task ended. Is null as long as the timer of this entry is still running.
+	End time.Time `xorm:"DATETIME INDEX null 'end_time'" json:"end"`
+	// How long the work took, in seconds. When creating or updating an entry you can provide either an end date or
+	// a duration. If you provide both, the end date wins.
+	Duration int64 `xorm:"bigint not null default 0" json:"duration"`
+	// A note about what was done during this time.
+	Note string `xorm:"text null" json:"note"`
+
+	// A timestamp when this time entry was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this time entry was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the time entries table
+func (te *TaskTimeEntry) TableName() string {
+	return "task_time_entries"
+}
+
+// isRunning returns true if the timer of this entry was not stopped yet.
+func (te *TaskTimeEntry) isRunning() bool {
+	return te.End.IsZero()
+}
+
+// trackedDuration returns the time tracked with this entry. For running entries, that's the time since the timer started.
+func (te *TaskTimeEntry) trackedDuration() time.Duration {
+	if te.isRunning() {
+		return time.Since(te.Start)
+	}
+	return time.Duration(te.Duration) * time.Second
+}
+
+// calculateDates fills the start and end date of a manually logged time entry from whatever the user provided
+// and checks the result makes sense.
+func (te *TaskTimeEntry) calculateDates() error {
+	duration := time.Duration(te.Duration) * time.Second
+
+	switch {
+	case !te.Start.IsZero() && !te.End.IsZero():
+		// Nothing to do, the dates win
+	case !te.Start.IsZero() && duration > 0:
+		te.End = te.Start.Add(duration)
+	case !te.End.IsZero() && duration > 0:
+		te.Start = te.End.Add(-duration)
+	case duration > 0:
+		te.End = time.Now()
+		te.Start = te.End.Add(-duration)
+	}
+
+	if te.Start.IsZero() || !te.End.After(te.Start) {
+		return ErrInvalidTimeEntry{Start: te.Start, End: te.End}
+	}
+
+	te.Duration = int64(te.End.Sub(te.Start) / time.Second)
+	return nil
+}
+
+// Create logs a new time entry on a task
+// @Summary Log time on a task
+// @Description Manually log time spent on a task. Provide a start date and either an end date or a duration in seconds. If you only provide a duration, the entry will end now. The user doing this needs to have at least write access to the task.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entry body models.TaskTimeEntry true "The time entry"
+// @Success 201 {object} models.TaskTimeEntry "The created time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time_entries [put]
+func (te *TaskTimeEntry) Create(s *xorm.Session, a web.Auth) (err error) {
+	te.ID = 0
+	te.UserID = a.GetID()
+
+	err = te.calculateDates()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(te)
+	if err != nil {
+		return err
+	}
+
+	te.User, err = user.GetUserByID(s, te.UserID)
+	return
+}
+
+func getTaskTimeEntrySimple(s *xorm.Session, te *TaskTimeEntry) error {
+	exists, err := s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		NoAutoCondition().
+		Get(te)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTimeEntryDoesNotExist{
+			ID:     te.ID,
+			TaskID: te.TaskID,
+		}
+	}
+
+	return nil
+}
+
+// ReadOne returns a single time entry
+// @Summary Get one time entry
+// @Description Returns one time entry of a task. The user doing this needs to have at least read access to the task.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.TaskTimeEntry "The time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time_entries/{entryID} [get]
+func (te *TaskTimeEntry) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	err = getTaskTimeEntrySimple(s, te)
+	if err != nil {
+		return err
+	}
+
+	te.User, err = user.GetUserByID(s, te.UserID)
+	return
+}
+
+// ReadAll returns all time entries of a task
+// @Summary Get all time entries of a task
+// @Description Returns all time entries logged on a task, newest first. The user doing this needs to have at least read access to the task.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search time entries by their note."
+// @Success 200 {array} models.TaskTimeEntry "The time entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time_entries [get]
+func (te *TaskTimeEntry) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := te.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	var cond builder.Cond = builder.Eq{"task_id": te.TaskID}
+	if search != "" {
+		cond = builder.And(cond, db.ILIKE("note", search))
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	query := s.
+		Where(cond).
+		OrderBy("start_time DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	entries := []*TaskTimeEntry{}
+	err = query.Find(&entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addUsersToTimeEntries(s, entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.Where(cond).Count(&TaskTimeEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+func addUsersToTimeEntries(s *xorm.Session, entries []*TaskTimeEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		userIDs = append(userIDs, e.UserID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		e.User = users[e.UserID]
+	}
+
+	return nil
+}
+
+// Update changes a time entry
+// @Summary Update a time entry
+// @Description Changes the dates, duration or note of a time entry. Only the user who logged the entry can change it. They need to still have write access to the task.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Param entry body models.TaskTimeEntry true "The time entry with updated values"
+// @Success 200 {object} models.TaskTimeEntry "The updated time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time_entries/{entryID} [post]
+func (te *TaskTimeEntry) Update(s *xorm.Session, a web.Auth) (err error) {
+	old := &TaskTimeEntry{ID: te.ID, TaskID: te.TaskID}
+	err = getTaskTimeEntrySimple(s, old)
+	if err != nil {
+		return err
+	}
+
+	te.UserID = old.UserID
+	if te.Start.IsZero() {
+		te.Start = old.Start
+	}
+	if te.End.IsZero() && te.Duration == 0 {
+		te.End = old.End
+	}
+
+	cols := []string{"start_time", "note"}
+	if old.isRunning() && te.End.IsZero() {
+		// A running timer only gets a new start date, stopping it is done through the timer.
+		if te.Start.After(time.Now()) {
+			return ErrInvalidTimeEntry{Start: te.Start}
+		}
+		te.Duration = 0
+	} else {
+		err = te.calculateDates()
+		if err != nil {
+			return err
+		}
+		cols = append(cols, "end_time", "duration")
+	}
+
+	_, err = s.
+		ID(te.ID).
+		Cols(cols...).
+		Update(te)
+	if err != nil {
+		return err
+	}
+
+	return te.ReadOne(s, a)
+}
+
+// Delete removes a time entry
+// @Summary Delete a time entry
+// @Description Removes a time entry. Only the user who logged the entry can delete it. They need to still have write access to the task.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.Message "The time entry was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time_entries/{entryID} [delete]
+func (te *TaskTimeEntry) Delete(s *xorm.Session, a web.Auth) (err error) {
+	deleted, err := s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		NoAutoCondition().
+		Delete(&TaskTimeEntry{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrTimeEntryDoesNotExist{ID: te.ID, TaskID: te.TaskID}
+	}
+	return nil
+}
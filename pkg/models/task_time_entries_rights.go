@@ -0,0 +1,65 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read the time entries of a task
+func (te *TaskTimeEntry) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	t := &Task{ID: te.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can log time on a task
+func (te *TaskTimeEntry) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Time is always tracked per user, link shares don't have one
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	t := &Task{ID: te.TaskID}
+	return t.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can update a time entry
+func (te *TaskTimeEntry) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return te.canModifyTimeEntry(s, a)
+}
+
+// CanDelete checks if a user can delete a time entry
+func (te *TaskTimeEntry) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return te.canModifyTimeEntry(s, a)
+}
+
+// Only the user who logged a time entry may modify it, as long as they can still write to the task.
+func (te *TaskTimeEntry) canModifyTimeEntry(s *xorm.Session, a web.Auth) (bool, error) {
+	canWrite, err := te.CanCreate(s, a)
+	if err != nil || !canWrite {
+		return false, err
+	}
+
+	saved := &TaskTimeEntry{ID: te.ID, TaskID: te.TaskID}
+	err = getTaskTimeEntrySimple(s, saved)
+	if err != nil {
+		return false, err
+	}
+
+	return saved.UserID == a.GetID(), nil
+}
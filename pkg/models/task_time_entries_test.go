@@ -0,0 +1,360 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTimeEntry_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("with start and end", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(90 * time.Minute),
+			Note:   "test",
+		}
+		err := te.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5400), te.Duration)
+		assert.Equal(t, int64(1), te.User.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       te.ID,
+			"task_id":  1,
+			"user_id":  1,
+			"duration": 5400,
+			"note":     "test",
+		}, false)
+	})
+	t.Run("with start and duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+		te := &TaskTimeEntry{
+			TaskID:   1,
+			Start:    start,
+			Duration: 600,
+		}
+		err := te.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(10*time.Minute), te.End)
+	})
+	t.Run("with duration only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID:   1,
+			Duration: 600,
+		}
+		err := te.Create(s, u)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), te.End, time.Second)
+		assert.Equal(t, te.End.Add(-10*time.Minute), te.Start)
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(-time.Hour),
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntry(err))
+	})
+	t.Run("no duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  time.Now(),
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntry(err))
+	})
+}
+
+func TestTaskTimeEntry_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	te := &TaskTimeEntry{TaskID: 1}
+	result, resultCount, total, err := te.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resultCount)
+	assert.Equal(t, int64(2), total)
+	entries := result.([]*TaskTimeEntry)
+	assert.Equal(t, int64(3), entries[0].ID)
+	assert.Equal(t, int64(3), entries[0].User.ID)
+	assert.Equal(t, int64(1), entries[1].ID)
+	assert.Equal(t, int64(1), entries[1].User.ID)
+}
+
+func TestTaskTimeEntry_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("note only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:     1,
+			TaskID: 1,
+			Note:   "changed",
+		}
+		err := te.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3600), te.Duration)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       1,
+			"note":     "changed",
+			"duration": 3600,
+		}, false)
+	})
+	t.Run("duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:       1,
+			TaskID:   1,
+			Duration: 1200,
+		}
+		err := te.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1200), te.Duration)
+		assert.Equal(t, te.Start.Add(20*time.Minute).Unix(), te.End.Unix())
+	})
+	t.Run("running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:     5,
+			TaskID: 2,
+			Note:   "still running",
+		}
+		err := te.Update(s, u)
+		assert.NoError(t, err)
+		assert.True(t, te.End.IsZero())
+		assert.Equal(t, "still running", te.Note)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:     9999,
+			TaskID: 1,
+		}
+		err := te.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+}
+
+func TestTaskTimeEntry_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 1, TaskID: 1}
+		err := te.Delete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_time_entries", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("wrong task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 1, TaskID: 2}
+		err := te.Delete(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+}
+
+func TestTaskTimeEntry_Rights(t *testing.T) {
+	t.Run("can modify own entry", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 1, TaskID: 1}
+		can, err := te.CanUpdate(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("can't modify entries of other users", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 3, TaskID: 1}
+		can, err := te.CanDelete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{TaskID: 14}
+		can, err := te.CanCreate(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+		can, _, err = te.CanRead(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{TaskID: 1}
+		can, err := te.CanCreate(s, &LinkSharing{ID: 2, ListID: 1, Right: RightWrite})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTimer(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 1, Note: "working"}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.True(t, tt.TimeEntry.End.IsZero())
+		assert.Equal(t, "working", tt.TimeEntry.Note)
+
+		running, err := getRunningTimeEntry(s, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.TimeEntry.ID, running.ID)
+	})
+	t.Run("start stops the running timer on another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 1}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+
+		stopped := &TaskTimeEntry{ID: 5, TaskID: 2}
+		err = getTaskTimeEntrySimple(s, stopped)
+		assert.NoError(t, err)
+		assert.False(t, stopped.End.IsZero())
+		assert.Greater(t, stopped.Duration, int64(0))
+	})
+	t.Run("already running", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 2}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimerAlreadyRunning(err))
+	})
+	t.Run("get running", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 2}
+		err := tt.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), tt.TimeEntry.ID)
+	})
+	t.Run("stop", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 2, Note: "done"}
+		err := tt.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), tt.TimeEntry.ID)
+		assert.WithinDuration(t, time.Now(), tt.TimeEntry.End, time.Second)
+		assert.Equal(t, int64(tt.TimeEntry.End.Sub(tt.TimeEntry.Start)/time.Second), tt.TimeEntry.Duration)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":   5,
+			"note": "done",
+		}, false)
+	})
+	t.Run("stop without running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 1}
+		err := tt.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+}
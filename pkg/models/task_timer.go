@@ -0,0 +1,194 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskTimer starts and stops the time tracking timer of the current user on a task.
+// A running timer is a time entry without an end date. Each user can only have one timer running at a time.
+type TaskTimer struct {
+	TaskID int64 `json:"-" param:"task"`
+	// An optional note which will be saved with the time entry when starting or stopping the timer.
+	Note string `json:"note"`
+	// The time entry which was started or stopped.
+	TimeEntry *TaskTimeEntry `json:"time_entry"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+func getRunningTimeEntry(s *xorm.Session, taskID, userID int64) (entry *TaskTimeEntry, err error) {
+	entry = &TaskTimeEntry{}
+	exists, err := s.
+		Where("task_id = ? AND user_id = ? AND end_time IS NULL", taskID, userID).
+		Get(entry)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNoRunningTimer{TaskID: taskID, UserID: userID}
+	}
+	return
+}
+
+func stopTimeEntry(s *xorm.Session, entry *TaskTimeEntry, end time.Time) (err error) {
+	entry.End = end
+	if !entry.End.After(entry.Start) {
+		// The start date of a running entry can be changed by the user and might therefore be in the future
+		entry.End = entry.Start
+	}
+	entry.Duration = int64(entry.End.Sub(entry.Start) / time.Second)
+	_, err = s.
+		ID(entry.ID).
+		Cols("end_time", "duration", "note").
+		Update(entry)
+	return
+}
+
+// Create starts a new timer
+// @Summary Start a timer
+// @Description Starts a timer for the current user on a task. If the user has a timer running on another task, that one is stopped first. The user needs to have at least write access to the task.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param timer body models.TaskTimer true "The timer, only holding an optional note"
+// @Success 201 {object} models.TaskTimer "The timer with the started time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 412 {object} web.HTTPError "There is already a timer running on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/timer [put]
+func (tt *TaskTimer) Create(s *xorm.Session, a web.Auth) (err error) {
+	_, err = getRunningTimeEntry(s, tt.TaskID, a.GetID())
+	if err == nil {
+		return ErrTimerAlreadyRunning{TaskID: tt.TaskID, UserID: a.GetID()}
+	}
+	if !IsErrNoRunningTimer(err) {
+		return err
+	}
+
+	now := time.Now()
+
+	running := []*TaskTimeEntry{}
+	err = s.
+		Where("user_id = ? AND end_time IS NULL", a.GetID()).
+		Find(&running)
+	if err != nil {
+		return err
+	}
+	for _, entry := range running {
+		err = stopTimeEntry(s, entry, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	tt.TimeEntry = &TaskTimeEntry{
+		TaskID: tt.TaskID,
+		UserID: a.GetID(),
+		Start:  now,
+		Note:   tt.Note,
+	}
+	_, err = s.Insert(tt.TimeEntry)
+	if err != nil {
+		return err
+	}
+
+	tt.TimeEntry.User, err = user.GetUserByID(s, tt.TimeEntry.UserID)
+	return
+}
+
+// ReadOne returns the running timer
+// @Summary Get the running timer
+// @Description Returns the timer the current user has running on a task.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Success 200 {object} models.TaskTimer "The timer with the running time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 412 {object} web.HTTPError "There is no timer running on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/timer [get]
+func (tt *TaskTimer) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	tt.TimeEntry, err = getRunningTimeEntry(s, tt.TaskID, a.GetID())
+	if err != nil {
+		return err
+	}
+
+	tt.Note = tt.TimeEntry.Note
+	tt.TimeEntry.User, err = user.GetUserByID(s, tt.TimeEntry.UserID)
+	return
+}
+
+// Update stops a running timer
+// @Summary Stop a timer
+// @Description Stops the timer the current user has running on a task. If a note is provided, it replaces the note of the time entry.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param timer body models.TaskTimer true "The timer, only holding an optional note"
+// @Success 200 {object} models.TaskTimer "The timer with the stopped time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 412 {object} web.HTTPError "There is no timer running on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/timer [post]
+func (tt *TaskTimer) Update(s *xorm.Session, a web.Auth) (err error) {
+	tt.TimeEntry, err = getRunningTimeEntry(s, tt.TaskID, a.GetID())
+	if err != nil {
+		return err
+	}
+
+	if tt.Note != "" {
+		tt.TimeEntry.Note = tt.Note
+	}
+
+	err = stopTimeEntry(s, tt.TimeEntry, time.Now())
+	if err != nil {
+		return err
+	}
+
+	tt.Note = tt.TimeEntry.Note
+	tt.TimeEntry.User, err = user.GetUserByID(s, tt.TimeEntry.UserID)
+	return
+}
+
+// CanRead checks if a user can see their running timer on a task
+func (tt *TaskTimer) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	can, err := tt.CanCreate(s, a)
+	return can, int(RightWrite), err
+}
+
+// CanCreate checks if a user can start a timer on a task
+func (tt *TaskTimer) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	te := &TaskTimeEntry{TaskID: tt.TaskID}
+	return te.CanCreate(s, a)
+}
+
+// CanUpdate checks if a user can stop a timer on a task
+func (tt *TaskTimer) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return tt.CanCreate(s, a)
+}
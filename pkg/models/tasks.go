@@ -19,7 +19,6 @@ package models
 import (
 	"math"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -39,14 +38,6 @@ import (
 	"xorm.io/xorm/schemas"
 )
 
-type TaskRepeatMode int
-
-const (
-	TaskRepeatModeDefault TaskRepeatMode = iota
-	TaskRepeatModeMonth
-	TaskRepeatModeFromCurrentDate
-)
-
 // Task represents an task in a todolist
 type Task struct {
 	// The unique, numeric id of this task.
@@ -65,10 +56,14 @@ type Task struct {
 	Reminders []time.Time `xorm:"-" json:"reminder_dates"`
 	// The list this task belongs to.
 	ListID int64 `xorm:"bigint INDEX not null" json:"list_id" param:"list"`
-	// An amount in seconds this task repeats itself. If this is set, when marking the task as done, it will mark itself as "undone" and then increase all remindes and the due date by its amount.
-	RepeatAfter int64 `xorm:"bigint INDEX null" json:"repeat_after" valid:"range(0|9223372036854775807)"`
-	// Can have three possible values which will trigger when the task is marked as done: 0 = repeats after the amount specified in repeat_after, 1 = repeats all dates each months (ignoring repeat_after), 3 = repeats from the current date rather than the last set date.
-	RepeatMode TaskRepeatMode `xorm:"not null default 0" json:"repeat_mode"`
+	// The recurrence rule of this task in the RFC 5545 RRULE format, for example `FREQ=WEEKLY;INTERVAL=2;BYDAY=TU` or `FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1`. If this is set, when marking the task as done, it will mark itself as "undone" and move its due, start and end date and all reminders to the next occurrence of the rule. Occurrences in the past are skipped. Once the rule has no more occurrences (because of `COUNT` or `UNTIL`), the task stays done. Tasks can repeat at most every hour, `MINUTELY` and `SECONDLY` rules are not supported.
+	RepeatRule string `xorm:"text null" json:"repeat_rule"`
+	// Dates which are excluded from the recurrence rule, like the RFC 5545 EXDATE property. A date at midnight excludes all occurrences on that day, all other dates only exclude the occurrence at exactly that time.
+	RepeatExdates []time.Time `xorm:"json null" json:"repeat_exdates"`
+	// If true, the next occurrence is calculated from the date the task was marked as done rather than from its last set date.
+	RepeatFromCurrentDate bool `xorm:"null" json:"repeat_from_current_date"`
+	// The date the recurring series started, used to evaluate `COUNT` and `UNTIL` of the rule.
+	RepeatStart time.Time `xorm:"DATETIME null 'repeat_start'" json:"-"`
 	// The task priority. Can be anything you want, it is possible to sort by this later.
 	Priority int64 `xorm:"bigint null" json:"priority"`
 	// When this task starts.
@@ -83,6 +78,9 @@ type Task struct {
 	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
 	// Determines how far a task is left from being done
 	PercentDone float64 `xorm:"DOUBLE null" json:"percent_done"`
+	// The values of the custom fields of the list this task belongs to, keyed by the id of the field. Values of `text`, `url` and `select` fields are strings, values of `number` fields numbers, values of `date` fields dates, values of `multi_select` fields arrays of strings and values of `user` fields the id of the user.
+	// When updating a task, only the fields you pass are changed. Set a field to null to remove its value.
+	CustomFields map[int64]interface{} `xorm:"-" json:"custom_fields"`
 
 	// The task identifier, based on the list identifier and the task's index
 	Identifier string `xorm:"-" json:"identifier"`
@@ -98,9 +96,18 @@ type Task struct {
 	// All attachments this task has
 	Attachments []*TaskAttachment `xorm:"-" json:"attachments"`
 
+	// All reactions to this task, grouped by their value with the users who reacted. The most used reactions come first.
+	Reactions []*ReactionSummary `xorm:"-" json:"reactions"`
+
 	// True if a task is a favorite task. Favorite tasks show up in a separate "Important" list. This value depends on the user making the call to the api.
 	IsFavorite bool `xorm:"-" json:"is_favorite"`
 
+	// True if the task is blocked by or follows another task which is not done yet. You can only read this property, use task relations to change it.
+	IsBlocked bool `xorm:"-" json:"is_blocked"`
+
+	// All tasks which were moved because this task was moved and they follow it. Only returned when updating a task in a list with automatic scheduling enabled.
+	RescheduledTasks []*Task `xorm:"-" json:"rescheduled_tasks,omitempty"`
+
 	// The subscription status for the user reading this task. You can only read this property, use the subscription endpoints to modify it.
 	// Will only returned when retreiving one task.
 	Subscription *Subscription `xorm:"-" json:"subscription,omitempty"`
@@ -109,6 +116,8 @@ type Task struct {
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this task was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
+	// A timestamp when this task was moved to the trash. Deleted tasks are not returned anywhere except the trash.
+	Deleted time.Time `xorm:"deleted null INDEX" json:"-"`
 
 	// BucketID is the ID of the kanban bucket this task belongs to.
 	BucketID int64 `xorm:"bigint null" json:"bucket_id"`
@@ -167,6 +176,7 @@ type taskOptions struct {
 	perPage            int
 	sortby             []*sortParam
 	filters            []*taskFilter
+	filterTree         *taskFilterGroup
 	filterConcat       taskFilterConcatinator
 	filterIncludeNulls bool
 }
@@ -179,14 +189,15 @@ type taskOptions struct {
 // @Produce json
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
-// @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param s query string false "Search tasks by title, description, comments and attachment names, or by their identifier. Results are ordered by relevance unless sort_by is given."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`, `assignees` and `labels`. Tasks with multiple assignees or labels are sorted by the alphabetically first assignee name or label title. To sort by a custom field, use `custom_field_` followed by the id of the field. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
-// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
+// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. To filter by the checklist of a task, use `checklist_total`, `checklist_done` or `checklist_progress`, the share of done checklist items between 0 and 1. To only get tasks which are blocked by or follow tasks which are not done yet, use `is_blocked`. To filter by the relations of a task, use `assignees` or `created_by` with usernames, `labels`, `namespace` or `bucket` with ids, `has_attachments` with `true` or `false` and `has_relation_kind` with a relation kind. For `assignees`, `labels` and `has_relation_kind`, `not_equals` and `not_in` match all tasks which have none of the values. To filter by a custom field, use `custom_field_` followed by the id of the field. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for."
-// @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
+// @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `not_equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like`, `in` and `not_in`. `in` and `not_in` expect comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter query like `done = false && (priority >= 3 || due_date < now+2d)`. Comparisons use the same fields, comparators and values as `filter_by`, `filter_comparator` and `filter_value`, written as `=`, `!=`, `>`, `>=`, `<`, `<=`, `like`, `in` and `not in`. They can be combined with `&&` and `||`, negated with `!` and grouped with parentheses. If the other filter parameters are provided as well, both need to match."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
 // @Failure 500 {object} models.Message "Internal error"
@@ -196,7 +207,11 @@ func (t *Task) ReadAll(s *xorm.Session, a web.Auth, search string, page int, per
 }
 
 func getFilterCond(f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
-	field := "`" + f.field + "`"
+	return getFilterCondForExpression("`"+f.field+"`", f, includeNulls)
+}
+
+// getFilterCondForExpression builds the filter condition for anything which can be compared in sql, not only a column.
+func getFilterCondForExpression(field string, f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
 	switch f.comparator {
 	case taskFilterComparatorEquals:
 		cond = &builder.Eq{field: f.value}
@@ -218,6 +233,8 @@ func getFilterCond(f *taskFilter, includeNulls bool) (cond builder.Cond, err err
 		cond = &builder.Like{field, "%" + val + "%"}
 	case taskFilterComparatorIn:
 		cond = builder.In(field, f.value)
+	case taskFilterComparatorNotIn:
+		cond = builder.NotIn(field, f.value)
 	case taskFilterComparatorInvalid:
 		// Nothing to do
 	}
@@ -247,6 +264,283 @@ func getFilterCondForSeparateTable(table string, concat taskFilterConcatinator,
 	)
 }
 
+// getNegatedFilterCondForSeparateTable builds the condition for a negated filter like `labels != 1` on a field
+// stored in a separate table. It matches all tasks which don't have any row matching the filter, instead of
+// all tasks which have any row not matching it.
+func getNegatedFilterCondForSeparateTable(table string, f *taskFilter, column string) (builder.Cond, error) {
+	positive := &taskFilter{
+		field:      column,
+		value:      f.value,
+		comparator: f.comparator.negate(),
+	}
+	cond, err := getFilterCond(positive, false)
+	if err != nil {
+		return nil, err
+	}
+	return builder.NotIn("id", builder.Select("task_id").From(table).Where(cond)), nil
+}
+
+// getUserIDsByUsernameCond returns a subquery for the ids of all users matching a filter on their username.
+func getUserIDsByUsernameCond(f *taskFilter) (*builder.Builder, error) {
+	cond, err := getFilterCond(&taskFilter{field: "username", value: f.value, comparator: f.comparator}, false)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Select("id").From("users").Where(cond), nil
+}
+
+// getFilterCondForFilters builds the condition for a list of filters which are all concatenated with the same concatinator.
+func getFilterCondForFilters(taskFilters []*taskFilter, concat taskFilterConcatinator, includeNulls bool, customFields map[int64]*ListCustomField) (cond builder.Cond, err error) {
+	// Some filters need a special treatment since they are in a separate table
+	reminderFilters := []builder.Cond{}
+	assigneeFilters := []builder.Cond{}
+	labelFilters := []builder.Cond{}
+	namespaceFilters := []builder.Cond{}
+
+	var filters = make([]builder.Cond, 0, len(taskFilters))
+	// To still find tasks with nil values, we exclude 0s when comparing with >/< values.
+	for _, original := range taskFilters {
+		// Copy the filter since some fields are renamed to their name in the db
+		f := &taskFilter{}
+		*f = *original
+
+		if f.field == "reminders" {
+			f.field = "reminder" // This is the name in the db
+			filter, err := getFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			reminderFilters = append(reminderFilters, filter)
+			continue
+		}
+
+		if f.field == "assignees" {
+			if f.comparator == taskFilterComparatorLike {
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+			}
+			if f.comparator.isNegated() {
+				users, err := getUserIDsByUsernameCond(&taskFilter{value: f.value, comparator: taskFilterComparatorIn})
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, builder.NotIn("id", builder.
+					Select("task_id").
+					From("task_assignees").
+					Where(builder.In("user_id", users))))
+				continue
+			}
+			f.field = "username"
+			filter, err := getFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			assigneeFilters = append(assigneeFilters, filter)
+			continue
+		}
+
+		if f.field == taskFilterFieldCreatedBy {
+			if f.comparator == taskFilterComparatorLike {
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+			}
+			users, err := getUserIDsByUsernameCond(&taskFilter{value: f.value, comparator: taskFilterComparatorIn})
+			if err != nil {
+				return nil, err
+			}
+			if f.comparator.isNegated() {
+				filters = append(filters, builder.NotIn("created_by_id", users))
+			} else {
+				filters = append(filters, builder.In("created_by_id", users))
+			}
+			continue
+		}
+
+		if f.field == taskFilterFieldHasAttachments {
+			has, is := f.value.(bool)
+			if !is || (f.comparator != taskFilterComparatorEquals && f.comparator != taskFilterComparatorNotEquals) {
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+			}
+			if f.comparator == taskFilterComparatorNotEquals {
+				has = !has
+			}
+			attachments := builder.Select("task_id").From("task_attachments")
+			if has {
+				filters = append(filters, builder.In("id", attachments))
+			} else {
+				filters = append(filters, builder.NotIn("id", attachments))
+			}
+			continue
+		}
+
+		if f.field == taskFilterFieldHasRelationKind {
+			if f.comparator != taskFilterComparatorEquals && !f.comparator.takesMultipleValues() && !f.comparator.isNegated() {
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+			}
+			if f.comparator.isNegated() {
+				filter, err := getNegatedFilterCondForSeparateTable("task_relations", f, "relation_kind")
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, filter)
+				continue
+			}
+			f.field = "relation_kind"
+			filter, err := getFilterCond(f, false)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, getFilterCondForSeparateTable("task_relations", filterConcatAnd, []builder.Cond{filter}))
+			continue
+		}
+
+		if f.field == taskFilterFieldBucket {
+			f.field = taskPropertyBucketID
+		}
+
+		if f.field == "labels" || f.field == "label_id" {
+			f.field = "label_id"
+			if f.comparator.isNegated() {
+				filter, err := getNegatedFilterCondForSeparateTable("label_tasks", f, f.field)
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, filter)
+				continue
+			}
+			filter, err := getFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			labelFilters = append(labelFilters, filter)
+			continue
+		}
+
+		if f.field == "namespace" || f.field == "namespace_id" {
+			f.field = "namespace_id"
+			filter, err := getFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			namespaceFilters = append(namespaceFilters, filter)
+			continue
+		}
+
+		if fieldID, is := getCustomFieldIDFromTaskField(f.field); is {
+			filter, err := customFields[fieldID].getFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
+		if f.field == "is_blocked" {
+			filter, err := getFilterCondForExpression(taskIsBlockedFilterExpression, f, false)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
+		if checklistField, is := taskChecklistFilterFields[f.field]; is {
+			filter, err := getFilterCondForExpression(checklistField.expression, f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
+		filter, err := getFilterCond(f, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	if len(reminderFilters) > 0 {
+		filters = append(filters, getFilterCondForSeparateTable("task_reminders", concat, reminderFilters))
+	}
+
+	if len(assigneeFilters) > 0 {
+		assigneeFilter := []builder.Cond{
+			builder.In("user_id",
+				builder.Select("id").
+					From("users").
+					Where(builder.Or(assigneeFilters...)),
+			)}
+		filters = append(filters, getFilterCondForSeparateTable("task_assignees", concat, assigneeFilter))
+	}
+
+	if len(labelFilters) > 0 {
+		filters = append(filters, getFilterCondForSeparateTable("label_tasks", concat, labelFilters))
+	}
+
+	if len(namespaceFilters) > 0 {
+		var filtercond builder.Cond
+		if concat == filterConcatOr {
+			filtercond = builder.Or(namespaceFilters...)
+		}
+		if concat == filterConcatAnd {
+			filtercond = builder.And(namespaceFilters...)
+		}
+
+		filters = append(filters, builder.In(
+			"list_id",
+			builder.
+				Select("id").
+				From("lists").
+				Where(filtercond),
+		))
+	}
+
+	if len(filters) > 0 {
+		if concat == filterConcatOr {
+			cond = builder.Or(filters...)
+		}
+		if concat == filterConcatAnd {
+			cond = builder.And(filters...)
+		}
+	}
+
+	return cond, nil
+}
+
+// getFilterCondForGroup builds the condition for a group of filters and all groups nested in it.
+func getFilterCondForGroup(group *taskFilterGroup, includeNulls bool, customFields map[int64]*ListCustomField) (cond builder.Cond, err error) {
+	conds := []builder.Cond{}
+
+	// Each filter gets its own condition, so that filters on fields stored in a separate table
+	// (like `labels = 1 && labels = 2`) each look at all rows of that table.
+	for _, f := range group.filters {
+		filterCond, err := getFilterCondForFilters([]*taskFilter{f}, group.concat, includeNulls, customFields)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, filterCond)
+	}
+
+	for _, sub := range group.groups {
+		subCond, err := getFilterCondForGroup(sub, includeNulls, customFields)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, subCond)
+	}
+
+	if group.concat == filterConcatOr {
+		cond = builder.Or(conds...)
+	} else {
+		cond = builder.And(conds...)
+	}
+
+	if group.negate {
+		cond = builder.Not{cond}
+	}
+
+	return cond, nil
+}
+
 func getTaskIndexFromSearchString(s string) (index int64) {
 	re := regexp.MustCompile("#([0-9]+)")
 	in := re.FindString(s)
@@ -280,6 +574,13 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 		listIDs = append(listIDs, l.ID)
 	}
 
+	customFields, err := getCustomFieldsForTaskOptions(s, listIDs, opts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	sortedByUser := len(opts.sortby) > 0
+
 	// Add the id parameter as the last parameter to sorty by default, but only if it is not already passed as the last parameter.
 	if len(opts.sortby) == 0 ||
 		len(opts.sortby) > 0 && opts.sortby[len(opts.sortby)-1].sortBy != taskPropertyID {
@@ -300,13 +601,21 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 		}
 
 		// Mysql sorts columns with null values before ones without null value.
+		sortBy := param.sortBy
+		if fieldID, is := getCustomFieldIDFromTaskField(sortBy); is {
+			sortBy = customFields[fieldID].getSortExpression()
+		}
+		if expression, is := taskRelationSortExpressions[sortBy]; is {
+			sortBy = expression
+		}
+
 		// Because it does not have support for NULLS FIRST or NULLS LAST we work around this by
 		// first sorting for null (or not null) values and then the order we actually want to.
 		if db.Type() == schemas.MYSQL {
-			orderby += param.sortBy + " IS NULL, "
+			orderby += sortBy + " IS NULL, "
 		}
 
-		orderby += param.sortBy + " " + param.orderBy.String()
+		orderby += sortBy + " " + param.orderBy.String()
 
 		// Postgres and sqlite allow us to control how columns with null values are sorted.
 		// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
@@ -319,77 +628,9 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 		}
 	}
 
-	// Some filters need a special treatment since they are in a separate table
-	reminderFilters := []builder.Cond{}
-	assigneeFilters := []builder.Cond{}
-	labelFilters := []builder.Cond{}
-	namespaceFilters := []builder.Cond{}
-
-	var filters = make([]builder.Cond, 0, len(opts.filters))
-	// To still find tasks with nil values, we exclude 0s when comparing with >/< values.
-	for _, f := range opts.filters {
-		if f.field == "reminders" {
-			f.field = "reminder" // This is the name in the db
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
-			if err != nil {
-				return nil, 0, 0, err
-			}
-			reminderFilters = append(reminderFilters, filter)
-			continue
-		}
-
-		if f.field == "assignees" {
-			if f.comparator == taskFilterComparatorLike {
-				return nil, 0, 0, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
-			}
-			f.field = "username"
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
-			if err != nil {
-				return nil, 0, 0, err
-			}
-			assigneeFilters = append(assigneeFilters, filter)
-			continue
-		}
-
-		if f.field == "labels" || f.field == "label_id" {
-			f.field = "label_id"
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
-			if err != nil {
-				return nil, 0, 0, err
-			}
-			labelFilters = append(labelFilters, filter)
-			continue
-		}
-
-		if f.field == "namespace" || f.field == "namespace_id" {
-			f.field = "namespace_id"
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
-			if err != nil {
-				return nil, 0, 0, err
-			}
-			namespaceFilters = append(namespaceFilters, filter)
-			continue
-		}
-
-		filter, err := getFilterCond(f, opts.filterIncludeNulls)
-		if err != nil {
-			return nil, 0, 0, err
-		}
-		filters = append(filters, filter)
-	}
-
 	// Then return all tasks for that lists
 	var where builder.Cond
 
-	if opts.search != "" {
-		where = db.ILIKE("title", opts.search)
-
-		searchIndex := getTaskIndexFromSearchString(opts.search)
-		if searchIndex > 0 {
-			where = builder.Or(where, builder.Eq{"`index`": searchIndex})
-		}
-	}
-
 	var listIDCond builder.Cond
 	var listCond builder.Cond
 	if len(listIDs) > 0 {
@@ -428,51 +669,30 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 		listCond = builder.And(listCond, builder.And(builder.In("id", favCond), builder.In("list_id", userListIDs)))
 	}
 
-	if len(reminderFilters) > 0 {
-		filters = append(filters, getFilterCondForSeparateTable("task_reminders", opts.filterConcat, reminderFilters))
-	}
-
-	if len(assigneeFilters) > 0 {
-		assigneeFilter := []builder.Cond{
-			builder.In("user_id",
-				builder.Select("id").
-					From("users").
-					Where(builder.Or(assigneeFilters...)),
-			)}
-		filters = append(filters, getFilterCondForSeparateTable("task_assignees", opts.filterConcat, assigneeFilter))
+	var rankOrder string
+	if opts.search != "" {
+		where, rankOrder, err = getTaskSearchCond(s, opts.search, listCond)
+		if err != nil {
+			return nil, 0, 0, err
+		}
 	}
 
-	if len(labelFilters) > 0 {
-		filters = append(filters, getFilterCondForSeparateTable("label_tasks", opts.filterConcat, labelFilters))
+	// Search results are ordered by relevance unless a different order was requested
+	if rankOrder != "" && !sortedByUser {
+		orderby = rankOrder + ", " + orderby
 	}
 
-	if len(namespaceFilters) > 0 {
-		var filtercond builder.Cond
-		if opts.filterConcat == filterConcatOr {
-			filtercond = builder.Or(namespaceFilters...)
-		}
-		if opts.filterConcat == filterConcatAnd {
-			filtercond = builder.And(namespaceFilters...)
-		}
-
-		cond := builder.In(
-			"list_id",
-			builder.
-				Select("id").
-				From("lists").
-				Where(filtercond),
-		)
-		filters = append(filters, cond)
+	filterCond, err := getFilterCondForFilters(opts.filters, opts.filterConcat, opts.filterIncludeNulls, customFields)
+	if err != nil {
+		return nil, 0, 0, err
 	}
 
-	var filterCond builder.Cond
-	if len(filters) > 0 {
-		if opts.filterConcat == filterConcatOr {
-			filterCond = builder.Or(filters...)
-		}
-		if opts.filterConcat == filterConcatAnd {
-			filterCond = builder.And(filters...)
+	if opts.filterTree != nil {
+		treeCond, err := getFilterCondForGroup(opts.filterTree, opts.filterIncludeNulls, customFields)
+		if err != nil {
+			return nil, 0, 0, err
 		}
+		filterCond = builder.And(filterCond, treeCond)
 	}
 
 	limit, start := getLimitFromPageIndex(opts.page, opts.perPage)
@@ -698,6 +918,10 @@ func addRelatedTasksToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]
 		}
 		otherTask.RelatedTasks = nil
 		taskMap[rt.TaskID].RelatedTasks[rt.RelationKind] = append(taskMap[rt.TaskID].RelatedTasks[rt.RelationKind], otherTask)
+
+		if !otherTask.Done && (rt.RelationKind == RelationKindBlocked || rt.RelationKind == RelationKindFollows) {
+			taskMap[rt.TaskID].IsBlocked = true
+		}
 	}
 
 	return
@@ -737,6 +961,11 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		return
 	}
 
+	err = addCustomFieldsToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
 	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
 	if err != nil {
 		return
@@ -752,6 +981,11 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		return err
 	}
 
+	taskReactions, err := getReactionSummaries(s, taskIDs, ReactionKindTask)
+	if err != nil {
+		return err
+	}
+
 	// Get all identifiers
 	lists, err := GetListsByIDs(s, listIDs)
 	if err != nil {
@@ -774,6 +1008,8 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		task.setIdentifier(lists[task.ListID])
 
 		task.IsFavorite = taskFavorites[task.ID]
+
+		task.Reactions = taskReactions[task.ID]
 	}
 
 	// Get all related tasks
@@ -910,20 +1146,29 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		t.UID = uuid.NewString()
 	}
 
+	err = t.prepareRepeatRule("")
+	if err != nil {
+		return err
+	}
+
 	// Get the default bucket and move the task there
 	err = setTaskBucket(s, t, nil, true)
 	if err != nil {
 		return
 	}
 
-	// Get the index for this task
+	// Get the index for this task.
+	t.Index, err = getNextTaskIndex(s, t.ListID)
+	if err != nil {
+		return err
+	}
+
 	latestTask := &Task{}
-	_, err = s.Where("list_id = ?", t.ListID).OrderBy("id desc").Get(latestTask)
+	_, err = s.Unscoped().Where("list_id = ?", t.ListID).OrderBy("id desc").Get(latestTask)
 	if err != nil {
 		return err
 	}
 
-	t.Index = latestTask.Index + 1
 	// If no position was supplied, set a default one
 	t.Position = calculateDefaultPosition(latestTask.ID+1, t.Position)
 	t.KanbanPosition = calculateDefaultPosition(latestTask.ID+1, t.KanbanPosition)
@@ -945,6 +1190,10 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return err
 	}
 
+	if err := t.updateCustomFields(s, a, t.CustomFields); err != nil {
+		return err
+	}
+
 	t.setIdentifier(l)
 
 	if t.IsFavorite {
@@ -967,7 +1216,7 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 
 // Update updates a list task
 // @Summary Update a task
-// @Description Updates a task. This includes marking it as done. Assignees you pass will be updated, see their individual endpoints for more details on how this is done. To update labels, see the description of the endpoint.
+// @Description Updates a task. This includes marking it as done. Assignees you pass will be updated, see their individual endpoints for more details on how this is done. To update labels, see the description of the endpoint. If the list has automatic scheduling enabled, moving the end or due date of the task moves all tasks following it by the same amount of time. These tasks are returned as `rescheduled_tasks`.
 // @tags task
 // @Accept json
 // @Produce json
@@ -977,6 +1226,7 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 // @Success 200 {object} models.Task "The updated task object."
 // @Failure 400 {object} web.HTTPError "Invalid task object provided."
 // @Failure 403 {object} web.HTTPError "The user does not have access to the task (aka its list)"
+// @Failure 412 {object} web.HTTPError "The task is blocked by other tasks which are not done yet."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{id} [post]
 //nolint:gocyclo
@@ -1003,13 +1253,36 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		ot.Reminders[i] = r.Reminder
 	}
 
+	// Keep the task as it was before the update to be able to save what changed in the history
+	originalTask := ot
+	customFields := t.CustomFields
+
+	markedDone := t.Done && !ot.Done
+	if markedDone {
+		err = checkTaskIsNotBlocked(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
 	// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
-	updateDone(&ot, t)
+	err = updateDone(&ot, t)
+	if err != nil {
+		return err
+	}
 
 	if err := setTaskBucket(s, t, &ot, t.BucketID != ot.BucketID); err != nil {
 		return err
 	}
 
+	// Moving a task into the done bucket marks it as done
+	if !markedDone && t.Done && !ot.Done {
+		err = checkTaskIsNotBlocked(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update the assignees
 	if err := ot.updateTaskAssignees(s, t.Assignees, a); err != nil {
 		return err
@@ -1026,7 +1299,10 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		"description",
 		"done",
 		"due_date",
-		"repeat_after",
+		"repeat_rule",
+		"repeat_exdates",
+		"repeat_from_current_date",
+		"repeat_start",
 		"priority",
 		"start_date",
 		"end_date",
@@ -1036,19 +1312,20 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		"list_id",
 		"bucket_id",
 		"position",
-		"repeat_mode",
 		"kanban_position",
 	}
 
 	// If the task is being moved between lists, make sure to move the bucket + index as well
 	if t.ListID != 0 && ot.ListID != t.ListID {
-		latestTask := &Task{}
-		_, err = s.Where("list_id = ?", t.ListID).OrderBy("id desc").Get(latestTask)
+		err = addTaskPreviousIdentifier(s, &ot)
 		if err != nil {
 			return err
 		}
 
-		t.Index = latestTask.Index + 1
+		t.Index, err = getNextTaskIndex(s, t.ListID)
+		if err != nil {
+			return err
+		}
 		colsToUpdate = append(colsToUpdate, "index")
 	}
 
@@ -1085,6 +1362,8 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	// We also set this here to prevent it being overwritten later on.
 	// t.Labels = ot.Labels
 
+	originalRepeatRule := ot.RepeatRule
+
 	// For whatever reason, xorm dont detect if done is updated, so we need to update this every time by hand
 	// Which is why we merge the actual task struct with the one we got from the db
 	// The user struct overrides values in the actual one.
@@ -1112,9 +1391,15 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.DueDate.IsZero() {
 		ot.DueDate = time.Time{}
 	}
-	// Repeat after
-	if t.RepeatAfter == 0 {
-		ot.RepeatAfter = 0
+	// Repeat rule
+	if t.RepeatRule == "" {
+		ot.RepeatRule = ""
+	}
+	if len(t.RepeatExdates) == 0 {
+		ot.RepeatExdates = nil
+	}
+	if !t.RepeatFromCurrentDate {
+		ot.RepeatFromCurrentDate = false
 	}
 	// Start date
 	if t.StartDate.IsZero() {
@@ -1139,15 +1424,15 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.KanbanPosition == 0 {
 		ot.KanbanPosition = 0
 	}
-	// Repeat from current date
-	if t.RepeatMode == TaskRepeatModeDefault {
-		ot.RepeatMode = TaskRepeatModeDefault
-	}
 	// Is Favorite
 	if !t.IsFavorite {
 		ot.IsFavorite = false
 	}
 
+	if err := ot.prepareRepeatRule(originalRepeatRule); err != nil {
+		return err
+	}
+
 	_, err = s.ID(t.ID).
 		Cols(colsToUpdate...).
 		Update(ot)
@@ -1164,184 +1449,56 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	}
 	t.Updated = nt.Updated
 
-	doer, _ := user.GetFromAuth(a)
-	err = events.Dispatch(&TaskUpdatedEvent{
-		Task: t,
-		Doer: doer,
-	})
-	if err != nil {
-		return err
-	}
-
-	return updateListLastUpdated(s, &List{ID: t.ListID})
-}
-
-func addOneMonthToDate(d time.Time) time.Time {
-	return time.Date(d.Year(), d.Month()+1, d.Day(), d.Hour(), d.Minute(), d.Second(), d.Nanosecond(), config.GetTimeZone())
-}
-
-func setTaskDatesDefault(oldTask, newTask *Task) {
-	if oldTask.RepeatAfter == 0 {
-		return
-	}
-
-	// Current time in an extra variable to base all calculations on the same time
-	now := time.Now()
-
-	repeatDuration := time.Duration(oldTask.RepeatAfter) * time.Second
-
-	// assuming we'll merge the new task over the old task
-	if !oldTask.DueDate.IsZero() {
-		// Always add one instance of the repeating interval to catch cases where a due date is already in the future
-		// but not the repeating interval
-		newTask.DueDate = oldTask.DueDate.Add(repeatDuration)
-		// Add the repeating interval until the new due date is in the future
-		for !newTask.DueDate.After(now) {
-			newTask.DueDate = newTask.DueDate.Add(repeatDuration)
-		}
-	}
-
-	newTask.Reminders = oldTask.Reminders
-	// When repeating from the current date, all reminders should keep their difference to each other.
-	// To make this easier, we sort them first because we can then rely on the fact the first is the smallest
-	if len(oldTask.Reminders) > 0 {
-		for in, r := range oldTask.Reminders {
-			newTask.Reminders[in] = r.Add(repeatDuration)
-			for !newTask.Reminders[in].After(now) {
-				newTask.Reminders[in] = newTask.Reminders[in].Add(repeatDuration)
-			}
-		}
-	}
-
-	// If a task has a start and end date, the end date should keep the difference to the start date when setting them as new
-	if !oldTask.StartDate.IsZero() {
-		newTask.StartDate = oldTask.StartDate.Add(repeatDuration)
-		for !newTask.StartDate.After(now) {
-			newTask.StartDate = newTask.StartDate.Add(repeatDuration)
-		}
-	}
-
-	if !oldTask.EndDate.IsZero() {
-		newTask.EndDate = oldTask.EndDate.Add(repeatDuration)
-		for !newTask.EndDate.After(now) {
-			newTask.EndDate = newTask.EndDate.Add(repeatDuration)
-		}
-	}
-
-	newTask.Done = false
-}
-
-func setTaskDatesMonthRepeat(oldTask, newTask *Task) {
-	if !oldTask.DueDate.IsZero() {
-		newTask.DueDate = addOneMonthToDate(oldTask.DueDate)
-	}
-
-	newTask.Reminders = oldTask.Reminders
-	if len(oldTask.Reminders) > 0 {
-		for in, r := range oldTask.Reminders {
-			newTask.Reminders[in] = addOneMonthToDate(r)
-		}
-	}
-
-	if !oldTask.StartDate.IsZero() && !oldTask.EndDate.IsZero() {
-		diff := oldTask.EndDate.Sub(oldTask.StartDate)
-		newTask.StartDate = addOneMonthToDate(oldTask.StartDate)
-		newTask.EndDate = newTask.StartDate.Add(diff)
-	} else {
-		if !oldTask.StartDate.IsZero() {
-			newTask.StartDate = addOneMonthToDate(oldTask.StartDate)
-		}
-
-		if !oldTask.EndDate.IsZero() {
-			newTask.EndDate = addOneMonthToDate(oldTask.EndDate)
+	if originalTask.ListID != t.ListID {
+		err = removeCustomFieldValuesOfOtherLists(s, t)
+		if err != nil {
+			return err
 		}
 	}
 
-	newTask.Done = false
-}
-
-func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
-	if oldTask.RepeatAfter == 0 {
-		return
+	err = t.updateCustomFields(s, a, customFields)
+	if err != nil {
+		return err
 	}
 
-	// Current time in an extra variable to base all calculations on the same time
-	now := time.Now()
-
-	repeatDuration := time.Duration(oldTask.RepeatAfter) * time.Second
-
-	// assuming we'll merge the new task over the old task
-	if !oldTask.DueDate.IsZero() {
-		newTask.DueDate = now.Add(repeatDuration)
+	err = recordTaskUpdate(s, a, &originalTask, t)
+	if err != nil {
+		return err
 	}
 
-	newTask.Reminders = oldTask.Reminders
-	// When repeating from the current date, all reminders should keep their difference to each other.
-	// To make this easier, we sort them first because we can then rely on the fact the first is the smallest
-	if len(oldTask.Reminders) > 0 {
-		sort.Slice(oldTask.Reminders, func(i, j int) bool {
-			return oldTask.Reminders[i].Unix() < oldTask.Reminders[j].Unix()
-		})
-		first := oldTask.Reminders[0]
-		for in, r := range oldTask.Reminders {
-			diff := r.Sub(first)
-			newTask.Reminders[in] = now.Add(repeatDuration + diff)
-		}
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskUpdatedEvent{
+		Task:                t,
+		Doer:                doer,
+		PreviousDescription: originalTask.Description,
+	})
+	if err != nil {
+		return err
 	}
 
-	// We want to preserve intervals among the due, start and end dates.
-	// The due date is used as a reference point for all new dates, so the
-	// behaviour depends on whether the due date is set at all.
-	if oldTask.DueDate.IsZero() {
-		// If a task has no due date, but does have a start and end date, the
-		// end date should keep the difference to the start date when setting
-		// them as new
-		if !oldTask.StartDate.IsZero() && !oldTask.EndDate.IsZero() {
-			diff := oldTask.EndDate.Sub(oldTask.StartDate)
-			newTask.StartDate = now.Add(repeatDuration)
-			newTask.EndDate = now.Add(repeatDuration + diff)
-		} else {
-			if !oldTask.StartDate.IsZero() {
-				newTask.StartDate = now.Add(repeatDuration)
-			}
-
-			if !oldTask.EndDate.IsZero() {
-				newTask.EndDate = now.Add(repeatDuration)
-			}
-		}
-	} else {
-		// If the old task has a start and due date, we set the new start date
-		// to preserve the interval between them.
-		if !oldTask.StartDate.IsZero() {
-			diff := oldTask.DueDate.Sub(oldTask.StartDate)
-			newTask.StartDate = newTask.DueDate.Add(-diff)
-		}
-
-		// If the old task has an end and due date, we set the new end date
-		// to preserve the interval between them.
-		if !oldTask.EndDate.IsZero() {
-			diff := oldTask.DueDate.Sub(oldTask.EndDate)
-			newTask.EndDate = newTask.DueDate.Add(-diff)
+	// Dates of repeating tasks marked as done are moved to the next occurrence, that is not a reason to move other tasks
+	if !markedDone {
+		err = t.rescheduleFollowingTasks(s, a, &originalTask)
+		if err != nil {
+			return err
 		}
 	}
 
-	newTask.Done = false
+	return updateListLastUpdated(s, &List{ID: t.ListID})
 }
 
 // This helper function updates the reminders, doneAt, start and end dates of the *old* task
 // and saves the new values in the newTask object.
 // We make a few assumtions here:
-//   1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatAfter has a value > 0
+//   1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatRule is set
 //   2. Because of 1., this functions should not be used to update values other than Done in the same go
-func updateDone(oldTask *Task, newTask *Task) {
+func updateDone(oldTask *Task, newTask *Task) (err error) {
 	if !oldTask.Done && newTask.Done {
-		switch oldTask.RepeatMode {
-		case TaskRepeatModeMonth:
-			setTaskDatesMonthRepeat(oldTask, newTask)
-		case TaskRepeatModeFromCurrentDate:
-			setTaskDatesFromCurrentDateRepeat(oldTask, newTask)
-		case TaskRepeatModeDefault:
-			setTaskDatesDefault(oldTask, newTask)
+		if oldTask.RepeatRule != "" {
+			err = setTaskDatesFromRepeatRule(oldTask, newTask)
+			if err != nil {
+				return err
+			}
 		}
 
 		newTask.DoneAt = time.Now()
@@ -1351,6 +1508,8 @@ func updateDone(oldTask *Task, newTask *Task) {
 	if oldTask.Done && !newTask.Done {
 		newTask.DoneAt = time.Time{}
 	}
+
+	return nil
 }
 
 // Removes all old reminders and adds the new ones. This is a lot easier and less buggy than
@@ -1385,7 +1544,7 @@ func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err erro
 
 // Delete implements the delete method for listTask
 // @Summary Delete a task
-// @Description Deletes a task from a list. This does not mean "mark it done".
+// @Description Deletes a task from a list. This does not mean "mark it done". The task is moved to the trash and can be restored from there until it is purged.
 // @tags task
 // @Produce json
 // @Security JWTKeyAuth
@@ -1396,8 +1555,45 @@ func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err erro
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{id} [delete]
 func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
+	ot, err := GetTaskByIDSimple(s, t.ID)
+	if err != nil {
+		return err
+	}
+	*t = ot
 
-	if _, err = s.ID(t.ID).Delete(Task{}); err != nil {
+	return trashTask(s, a, t, time.Now())
+}
+
+// trashTask moves a task to the trash. Everything associated with it is kept so it can be restored later.
+func trashTask(s *xorm.Session, a web.Auth, t *Task, deleted time.Time) (err error) {
+	t.Deleted = deleted
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		ID(t.ID).
+		Cols("deleted").
+		Update(t)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskDeletedEvent{
+		Task: t,
+		Doer: doer,
+	})
+	if err != nil {
+		return
+	}
+
+	err = updateListLastUpdated(s, &List{ID: t.ListID})
+	return
+}
+
+// purgeTask removes a task and everything associated with it from the database.
+func purgeTask(s *xorm.Session, a web.Auth, t *Task) (err error) {
+
+	if _, err = s.Unscoped().ID(t.ID).Delete(&Task{}); err != nil {
 		return err
 	}
 
@@ -1407,7 +1603,7 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 	}
 
 	// Delete Favorites
-	err = removeFromFavorite(s, t.ID, a, FavoriteKindTask)
+	_, err = s.Where("entity_id = ? AND kind = ?", t.ID, FavoriteKindTask).Delete(&Favorite{})
 	if err != nil {
 		return
 	}
@@ -1431,12 +1627,44 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		}
 	}
 
-	// Delete all comments
+	// Delete all comments with their edit history and reactions
+	commentIDs := builder.Select("id").From("task_comments").Where(builder.Eq{"task_id": t.ID})
+	_, err = s.
+		In("comment_id", commentIDs).
+		Delete(&TaskCommentEdit{})
+	if err != nil {
+		return
+	}
+	err = deleteReactions(s, builder.Or(
+		builder.And(builder.Eq{"entity_kind": ReactionKindTask}, builder.Eq{"entity_id": t.ID}),
+		builder.And(builder.Eq{"entity_kind": ReactionKindComment}, builder.In("entity_id", commentIDs)),
+	))
+	if err != nil {
+		return
+	}
 	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskComment{})
 	if err != nil {
 		return
 	}
 
+	// Delete all time entries
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskTimeEntry{})
+	if err != nil {
+		return
+	}
+
+	// Delete the checklist
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskChecklistItem{})
+	if err != nil {
+		return
+	}
+
+	// Delete all custom field values
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
 	// Delete all relations
 	_, err = s.Where("task_id = ? OR other_task_id = ?", t.ID, t.ID).Delete(&TaskRelation{})
 	if err != nil {
@@ -1449,16 +1677,20 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
-	doer, _ := user.GetFromAuth(a)
-	err = events.Dispatch(&TaskDeletedEvent{
-		Task: t,
-		Doer: doer,
-	})
+	// Delete the identifiers the task had in other lists
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskPreviousIdentifier{})
 	if err != nil {
 		return
 	}
 
-	err = updateListLastUpdated(s, &List{ID: t.ListID})
+	// Delete the buckets the task was moved into on saved filters
+	_, err = s.Where("task_id = ?", t.ID).Delete(&SavedFilterTaskBucket{})
+	if err != nil {
+		return
+	}
+
+	// Delete the history, this needs to happen after everything else because deleting attachments adds to it
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskHistoryEntry{})
 	return
 }
 
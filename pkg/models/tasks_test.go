@@ -20,9 +20,9 @@ import (
 	"testing"
 	"time"
 
-	"code.vikunja.io/api/pkg/events"
-
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
 )
@@ -127,6 +127,41 @@ func TestTask_Create(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, IsErrBucketLimitExceeded(err))
 	})
+	t.Run("with repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:      "Lorem",
+			ListID:     1,
+			RepeatRule: "rrule:freq=weekly;byday=tu",
+		}
+		err := task.Create(s, usr)
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=TU", task.RepeatRule)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          task.ID,
+			"repeat_rule": "FREQ=WEEKLY;BYDAY=TU",
+		}, false)
+	})
+	t.Run("invalid repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:      "Lorem",
+			ListID:     1,
+			RepeatRule: "FREQ=SOMETIMES",
+		}
+		err := task.Create(s, usr)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
 }
 
 func TestTask_Update(t *testing.T) {
@@ -340,9 +375,19 @@ func TestTask_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
-		db.AssertMissing(t, "tasks", map[string]interface{}{
-			"id": 1,
-		})
+		_, err = GetTaskByIDSimple(s, 1)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+
+		// The task is only moved to the trash
+		_, err = getTrashedTask(s, 1)
+		assert.NoError(t, err)
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+		}, false)
+		db.AssertExists(t, "task_attachments", map[string]interface{}{
+			"task_id": 1,
+		}, false)
 	})
 }
 
@@ -354,7 +399,8 @@ func TestUpdateDone(t *testing.T) {
 
 		oldTask := &Task{Done: false}
 		newTask := &Task{Done: true}
-		updateDone(oldTask, newTask)
+		err := updateDone(oldTask, newTask)
+		assert.NoError(t, err)
 		assert.NotEqual(t, time.Time{}, newTask.DoneAt)
 	})
 	t.Run("unmarking a task as done", func(t *testing.T) {
@@ -364,64 +410,68 @@ func TestUpdateDone(t *testing.T) {
 
 		oldTask := &Task{Done: true}
 		newTask := &Task{Done: false}
-		updateDone(oldTask, newTask)
+		err := updateDone(oldTask, newTask)
+		assert.NoError(t, err)
 		assert.Equal(t, time.Time{}, newTask.DoneAt)
 	})
-	t.Run("no interval set, default repeat mode", func(t *testing.T) {
+	t.Run("no repeat rule set", func(t *testing.T) {
 		dueDate := time.Unix(1550000000, 0)
 		oldTask := &Task{
-			Done:        false,
-			RepeatAfter: 0,
-			RepeatMode:  TaskRepeatModeDefault,
-			DueDate:     dueDate,
+			Done:    false,
+			DueDate: dueDate,
 		}
 		newTask := &Task{
 			Done:    true,
 			DueDate: dueDate,
 		}
-		updateDone(oldTask, newTask)
+		err := updateDone(oldTask, newTask)
+		assert.NoError(t, err)
 
 		assert.Equal(t, dueDate.Unix(), newTask.DueDate.Unix())
 		assert.True(t, newTask.Done)
 	})
-	t.Run("repeating interval", func(t *testing.T) {
+	t.Run("repeat rule", func(t *testing.T) {
 		t.Run("normal", func(t *testing.T) {
 			oldTask := &Task{
 				Done:        false,
-				RepeatAfter: 8600,
+				RepeatRule:  "FREQ=HOURLY;INTERVAL=3",
+				RepeatStart: time.Unix(1550000000, 0),
 				DueDate:     time.Unix(1550000000, 0),
 			}
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
-			var expected = time.Unix(1550008600, 0)
+			var expected = time.Unix(1550010800, 0)
 			for time.Since(expected) > 0 {
-				expected = expected.Add(time.Second * time.Duration(oldTask.RepeatAfter))
+				expected = expected.Add(time.Second * 10800)
 			}
 
-			assert.Equal(t, expected, newTask.DueDate)
+			assert.Equal(t, expected.Unix(), newTask.DueDate.Unix())
 			assert.False(t, newTask.Done)
 		})
 		t.Run("don't update if due date is zero", func(t *testing.T) {
 			oldTask := &Task{
-				Done:        false,
-				RepeatAfter: 8600,
-				DueDate:     time.Time{},
+				Done:       false,
+				RepeatRule: "FREQ=HOURLY;INTERVAL=3",
+				DueDate:    time.Time{},
 			}
 			newTask := &Task{
 				Done:    true,
 				DueDate: time.Unix(1543626724, 0),
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 			assert.Equal(t, time.Unix(1543626724, 0), newTask.DueDate)
 			assert.False(t, newTask.Done)
 		})
 		t.Run("update reminders", func(t *testing.T) {
 			oldTask := &Task{
 				Done:        false,
-				RepeatAfter: 8600,
+				RepeatRule:  "FREQ=HOURLY;INTERVAL=3",
+				RepeatStart: time.Unix(1550000000, 0),
 				Reminders: []time.Time{
 					time.Unix(1550000000, 0),
 					time.Unix(1555000000, 0),
@@ -430,189 +480,264 @@ func TestUpdateDone(t *testing.T) {
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
-			var expected1 = time.Unix(1550008600, 0)
-			var expected2 = time.Unix(1555008600, 0)
+			var expected1 = time.Unix(1550010800, 0)
 			for time.Since(expected1) > 0 {
-				expected1 = expected1.Add(time.Duration(oldTask.RepeatAfter) * time.Second)
-			}
-			for time.Since(expected2) > 0 {
-				expected2 = expected2.Add(time.Duration(oldTask.RepeatAfter) * time.Second)
+				expected1 = expected1.Add(time.Second * 10800)
 			}
+			// All reminders keep their difference to each other
+			var expected2 = time.Unix(1555000000, 0).Add(expected1.Sub(time.Unix(1550000000, 0)))
 
 			assert.Len(t, newTask.Reminders, 2)
-			assert.Equal(t, expected1, newTask.Reminders[0])
-			assert.Equal(t, expected2, newTask.Reminders[1])
+			assert.Equal(t, expected1.Unix(), newTask.Reminders[0].Unix())
+			assert.Equal(t, expected2.Unix(), newTask.Reminders[1].Unix())
 			assert.False(t, newTask.Done)
 		})
 		t.Run("update start date", func(t *testing.T) {
 			oldTask := &Task{
 				Done:        false,
-				RepeatAfter: 8600,
+				RepeatRule:  "FREQ=HOURLY;INTERVAL=3",
+				RepeatStart: time.Unix(1550000000, 0),
 				StartDate:   time.Unix(1550000000, 0),
 			}
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
-			var expected = time.Unix(1550008600, 0)
+			var expected = time.Unix(1550010800, 0)
 			for time.Since(expected) > 0 {
-				expected = expected.Add(time.Second * time.Duration(oldTask.RepeatAfter))
+				expected = expected.Add(time.Second * 10800)
 			}
 
-			assert.Equal(t, expected, newTask.StartDate)
+			assert.Equal(t, expected.Unix(), newTask.StartDate.Unix())
 			assert.False(t, newTask.Done)
 		})
 		t.Run("update end date", func(t *testing.T) {
 			oldTask := &Task{
 				Done:        false,
-				RepeatAfter: 8600,
+				RepeatRule:  "FREQ=HOURLY;INTERVAL=3",
+				RepeatStart: time.Unix(1550000000, 0),
 				EndDate:     time.Unix(1550000000, 0),
 			}
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
-			var expected = time.Unix(1550008600, 0)
+			var expected = time.Unix(1550010800, 0)
 			for time.Since(expected) > 0 {
-				expected = expected.Add(time.Second * time.Duration(oldTask.RepeatAfter))
+				expected = expected.Add(time.Second * 10800)
 			}
 
-			assert.Equal(t, expected, newTask.EndDate)
+			assert.Equal(t, expected.Unix(), newTask.EndDate.Unix())
 			assert.False(t, newTask.Done)
 		})
 		t.Run("ensure due date is repeated even if the original one is in the future", func(t *testing.T) {
+			dueDate := time.Now().Add(time.Hour).Truncate(time.Second)
 			oldTask := &Task{
 				Done:        false,
-				RepeatAfter: 8600,
-				DueDate:     time.Now().Add(time.Hour),
+				RepeatRule:  "FREQ=HOURLY;INTERVAL=3",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
 			}
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
-			expected := oldTask.DueDate.Add(time.Duration(oldTask.RepeatAfter) * time.Second)
-			assert.Equal(t, expected, newTask.DueDate)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			expected := oldTask.DueDate.Add(time.Second * 10800)
+			assert.Equal(t, expected.Unix(), newTask.DueDate.Unix())
 			assert.False(t, newTask.Done)
 		})
-		t.Run("repeat from current date", func(t *testing.T) {
-			t.Run("due date", func(t *testing.T) {
-				oldTask := &Task{
-					Done:        false,
-					RepeatAfter: 8600,
-					RepeatMode:  TaskRepeatModeFromCurrentDate,
-					DueDate:     time.Unix(1550000000, 0),
-				}
-				newTask := &Task{
-					Done: true,
-				}
-				updateDone(oldTask, newTask)
-
-				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
-				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.DueDate.Unix())
-				assert.False(t, newTask.Done)
-			})
-			t.Run("reminders", func(t *testing.T) {
+		t.Run("all dates keep their difference", func(t *testing.T) {
+			dueDate := time.Date(2100, 1, 1, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=WEEKLY",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
+				StartDate:   dueDate.Add(-time.Hour),
+				EndDate:     dueDate.Add(time.Hour),
+				Reminders: []time.Time{
+					dueDate.Add(-2 * time.Hour),
+				},
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			week := 7 * 24 * time.Hour
+			assert.Equal(t, dueDate.Add(week).Unix(), newTask.DueDate.Unix())
+			assert.Equal(t, dueDate.Add(week-time.Hour).Unix(), newTask.StartDate.Unix())
+			assert.Equal(t, dueDate.Add(week+time.Hour).Unix(), newTask.EndDate.Unix())
+			assert.Len(t, newTask.Reminders, 1)
+			assert.Equal(t, dueDate.Add(week-2*time.Hour).Unix(), newTask.Reminders[0].Unix())
+			assert.False(t, newTask.Done)
+		})
+		t.Run("weekdays only", func(t *testing.T) {
+			// 2100-01-01 is a friday
+			dueDate := time.Date(2100, 1, 1, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Date(2100, 1, 4, 9, 0, 0, 0, config.GetTimeZone()).Unix(), newTask.DueDate.Unix())
+			assert.False(t, newTask.Done)
+		})
+		t.Run("every second tuesday", func(t *testing.T) {
+			dueDate := time.Date(2100, 1, 5, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Date(2100, 1, 19, 9, 0, 0, 0, config.GetTimeZone()).Unix(), newTask.DueDate.Unix())
+			assert.False(t, newTask.Done)
+		})
+		t.Run("last weekday of the month", func(t *testing.T) {
+			dueDate := time.Date(2100, 1, 29, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Date(2100, 2, 26, 9, 0, 0, 0, config.GetTimeZone()).Unix(), newTask.DueDate.Unix())
+			assert.False(t, newTask.Done)
+		})
+		t.Run("each month", func(t *testing.T) {
+			dueDate := time.Date(2100, 1, 15, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=MONTHLY",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Date(2100, 2, 15, 9, 0, 0, 0, config.GetTimeZone()).Unix(), newTask.DueDate.Unix())
+			assert.False(t, newTask.Done)
+		})
+		t.Run("count", func(t *testing.T) {
+			start := time.Date(2100, 1, 1, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=DAILY;COUNT=2",
+				RepeatStart: start,
+				DueDate:     start.Add(24 * time.Hour),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+		})
+		t.Run("until", func(t *testing.T) {
+			dueDate := time.Date(2100, 1, 1, 9, 0, 0, 0, config.GetTimeZone())
+			oldTask := &Task{
+				Done:        false,
+				RepeatRule:  "FREQ=DAILY;UNTIL=21000101T235959Z",
+				RepeatStart: dueDate,
+				DueDate:     dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+		})
+		t.Run("exdates", func(t *testing.T) {
+			dueDate := time.Date(2100, 1, 1, 9, 0, 0, 0, config.GetTimeZone())
+			t.Run("whole day", func(t *testing.T) {
 				oldTask := &Task{
 					Done:        false,
-					RepeatAfter: 8600,
-					RepeatMode:  TaskRepeatModeFromCurrentDate,
-					Reminders: []time.Time{
-						time.Unix(1550000000, 0),
-						time.Unix(1555000000, 0),
+					RepeatRule:  "FREQ=DAILY",
+					RepeatStart: dueDate,
+					DueDate:     dueDate,
+					RepeatExdates: []time.Time{
+						time.Date(2100, 1, 2, 0, 0, 0, 0, config.GetTimeZone()),
 					},
 				}
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
-
-				diff := oldTask.Reminders[1].Sub(oldTask.Reminders[0])
-
-				assert.Len(t, newTask.Reminders, 2)
-				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
-				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.Reminders[0].Unix())
-				assert.Equal(t, time.Now().Add(diff+time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.Reminders[1].Unix())
-				assert.False(t, newTask.Done)
-			})
-			t.Run("start date", func(t *testing.T) {
-				oldTask := &Task{
-					Done:        false,
-					RepeatAfter: 8600,
-					RepeatMode:  TaskRepeatModeFromCurrentDate,
-					StartDate:   time.Unix(1550000000, 0),
-				}
-				newTask := &Task{
-					Done: true,
-				}
-				updateDone(oldTask, newTask)
-
-				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
-				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.StartDate.Unix())
-				assert.False(t, newTask.Done)
-			})
-			t.Run("end date", func(t *testing.T) {
-				oldTask := &Task{
-					Done:        false,
-					RepeatAfter: 8600,
-					RepeatMode:  TaskRepeatModeFromCurrentDate,
-					EndDate:     time.Unix(1560000000, 0),
-				}
-				newTask := &Task{
-					Done: true,
-				}
-				updateDone(oldTask, newTask)
-
-				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
-				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.EndDate.Unix())
-				assert.False(t, newTask.Done)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
+				assert.Equal(t, time.Date(2100, 1, 3, 9, 0, 0, 0, config.GetTimeZone()).Unix(), newTask.DueDate.Unix())
 			})
-			t.Run("start and end date", func(t *testing.T) {
+			t.Run("exact time", func(t *testing.T) {
 				oldTask := &Task{
 					Done:        false,
-					RepeatAfter: 8600,
-					RepeatMode:  TaskRepeatModeFromCurrentDate,
-					StartDate:   time.Unix(1550000000, 0),
-					EndDate:     time.Unix(1560000000, 0),
+					RepeatRule:  "FREQ=HOURLY",
+					RepeatStart: dueDate,
+					DueDate:     dueDate,
+					RepeatExdates: []time.Time{
+						dueDate.Add(time.Hour),
+					},
 				}
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
-
-				diff := oldTask.EndDate.Sub(oldTask.StartDate)
-
-				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
-				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.StartDate.Unix())
-				assert.Equal(t, time.Now().Add(diff+time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.EndDate.Unix())
-				assert.False(t, newTask.Done)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
+				assert.Equal(t, dueDate.Add(2*time.Hour).Unix(), newTask.DueDate.Unix())
 			})
 		})
-		t.Run("repeat each month", func(t *testing.T) {
+		t.Run("repeat from current date", func(t *testing.T) {
 			t.Run("due date", func(t *testing.T) {
 				oldTask := &Task{
-					Done:       false,
-					RepeatMode: TaskRepeatModeMonth,
-					DueDate:    time.Unix(1550000000, 0),
+					Done:                  false,
+					RepeatRule:            "FREQ=HOURLY;INTERVAL=3",
+					RepeatFromCurrentDate: true,
+					DueDate:               time.Unix(1550000000, 0),
 				}
 				newTask := &Task{
 					Done: true,
 				}
-				oldDueDate := oldTask.DueDate
-
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
-				assert.True(t, newTask.DueDate.After(oldDueDate))
-				assert.NotEqual(t, oldDueDate.Month(), newTask.DueDate.Month())
+				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
+				assert.Equal(t, time.Now().Add(time.Second*10800).Unix(), newTask.DueDate.Unix())
 				assert.False(t, newTask.Done)
 			})
 			t.Run("reminders", func(t *testing.T) {
 				oldTask := &Task{
-					Done:       false,
-					RepeatMode: TaskRepeatModeMonth,
+					Done:                  false,
+					RepeatRule:            "FREQ=HOURLY;INTERVAL=3",
+					RepeatFromCurrentDate: true,
 					Reminders: []time.Time{
 						time.Unix(1550000000, 0),
 						time.Unix(1555000000, 0),
@@ -621,79 +746,88 @@ func TestUpdateDone(t *testing.T) {
 				newTask := &Task{
 					Done: true,
 				}
-				oldReminders := make([]time.Time, len(oldTask.Reminders))
-				copy(oldReminders, oldTask.Reminders)
-
-				updateDone(oldTask, newTask)
-
-				assert.Len(t, newTask.Reminders, len(oldReminders))
-				for i, r := range newTask.Reminders {
-					assert.True(t, r.After(oldReminders[i]))
-					assert.NotEqual(t, oldReminders[i].Month(), r.Month())
-				}
-				assert.False(t, newTask.Done)
-			})
-			t.Run("start date", func(t *testing.T) {
-				oldTask := &Task{
-					Done:       false,
-					RepeatMode: TaskRepeatModeMonth,
-					StartDate:  time.Unix(1550000000, 0),
-				}
-				newTask := &Task{
-					Done: true,
-				}
-				oldStartDate := oldTask.StartDate
-
-				updateDone(oldTask, newTask)
-
-				assert.True(t, newTask.StartDate.After(oldStartDate))
-				assert.NotEqual(t, oldStartDate.Month(), newTask.StartDate.Month())
-				assert.False(t, newTask.Done)
-			})
-			t.Run("end date", func(t *testing.T) {
-				oldTask := &Task{
-					Done:       false,
-					RepeatMode: TaskRepeatModeMonth,
-					EndDate:    time.Unix(1560000000, 0),
-				}
-				newTask := &Task{
-					Done: true,
-				}
-				oldEndDate := oldTask.EndDate
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
-				updateDone(oldTask, newTask)
+				diff := oldTask.Reminders[1].Sub(oldTask.Reminders[0])
 
-				assert.True(t, newTask.EndDate.After(oldEndDate))
-				assert.NotEqual(t, oldEndDate.Month(), newTask.EndDate.Month())
+				assert.Len(t, newTask.Reminders, 2)
+				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
+				assert.Equal(t, time.Now().Add(time.Second*10800).Unix(), newTask.Reminders[0].Unix())
+				assert.Equal(t, time.Now().Add(diff+time.Second*10800).Unix(), newTask.Reminders[1].Unix())
 				assert.False(t, newTask.Done)
 			})
 			t.Run("start and end date", func(t *testing.T) {
 				oldTask := &Task{
-					Done:       false,
-					RepeatMode: TaskRepeatModeMonth,
-					StartDate:  time.Unix(1550000000, 0),
-					EndDate:    time.Unix(1560000000, 0),
+					Done:                  false,
+					RepeatRule:            "FREQ=HOURLY;INTERVAL=3",
+					RepeatFromCurrentDate: true,
+					StartDate:             time.Unix(1550000000, 0),
+					EndDate:               time.Unix(1560000000, 0),
 				}
 				newTask := &Task{
 					Done: true,
 				}
-				oldStartDate := oldTask.StartDate
-				oldEndDate := oldTask.EndDate
-				oldDiff := oldTask.EndDate.Sub(oldTask.StartDate)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
-				updateDone(oldTask, newTask)
+				diff := oldTask.EndDate.Sub(oldTask.StartDate)
 
-				assert.True(t, newTask.StartDate.After(oldStartDate))
-				assert.NotEqual(t, oldStartDate.Month(), newTask.StartDate.Month())
-				assert.True(t, newTask.EndDate.After(oldEndDate))
-				assert.NotEqual(t, oldEndDate.Month(), newTask.EndDate.Month())
-				assert.Equal(t, oldDiff, newTask.EndDate.Sub(newTask.StartDate))
+				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
+				assert.Equal(t, time.Now().Add(time.Second*10800).Unix(), newTask.StartDate.Unix())
+				assert.Equal(t, time.Now().Add(diff+time.Second*10800).Unix(), newTask.EndDate.Unix())
 				assert.False(t, newTask.Done)
 			})
 		})
 	})
 }
 
+func TestNormalizeRepeatRule(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		rule, err := normalizeRepeatRule("FREQ=WEEKLY;INTERVAL=2;BYDAY=TU")
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", rule)
+	})
+	t.Run("with prefix and lowercase", func(t *testing.T) {
+		rule, err := normalizeRepeatRule("rrule:freq=monthly;bysetpos=-1;byday=mo,tu,we,th,fr")
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=MONTHLY;BYSETPOS=-1;BYDAY=MO,TU,WE,TH,FR", rule)
+	})
+	t.Run("empty", func(t *testing.T) {
+		rule, err := normalizeRepeatRule("")
+		assert.NoError(t, err)
+		assert.Equal(t, "", rule)
+	})
+	t.Run("invalid frequency", func(t *testing.T) {
+		_, err := normalizeRepeatRule("FREQ=SOMETIMES")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("missing frequency", func(t *testing.T) {
+		_, err := normalizeRepeatRule("BYDAY=MO")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("count and until", func(t *testing.T) {
+		_, err := normalizeRepeatRule("FREQ=DAILY;COUNT=3;UNTIL=21000101T000000Z")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("out of bounds", func(t *testing.T) {
+		_, err := normalizeRepeatRule("FREQ=MONTHLY;BYMONTHDAY=32")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("more often than every hour", func(t *testing.T) {
+		_, err := normalizeRepeatRule("FREQ=MINUTELY;INTERVAL=30")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+		_, err = normalizeRepeatRule("FREQ=SECONDLY")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+}
+
 func TestTask_ReadOne(t *testing.T) {
 	u := &user.User{ID: 1}
 
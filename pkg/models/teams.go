@@ -319,6 +319,12 @@ func (t *Team) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	// Delete team <-> task template relations
+	_, err = s.Where("team_id = ?", t.ID).Delete(&TaskTemplateTeam{})
+	if err != nil {
+		return
+	}
+
 	return events.Dispatch(&TeamDeletedEvent{
 		Team: t,
 		Doer: a,
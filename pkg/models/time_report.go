@@ -0,0 +1,314 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// All properties a time report can be grouped by
+const (
+	TimeReportGroupByUser  = "user"
+	TimeReportGroupByList  = "list"
+	TimeReportGroupByLabel = "label"
+	TimeReportGroupByDate  = "date"
+)
+
+// TimeReportOptions holds everything to filter and group time entries for a report
+type TimeReportOptions struct {
+	// One of user, list, label or date. Defaults to user.
+	GroupBy string
+	// Only entries which started at or after this date are included.
+	From time.Time
+	// Only entries which started before this date are included.
+	To time.Time
+	// If not zero, only entries logged by this user are included.
+	UserID int64
+	// If not zero, only entries on tasks in this list are included.
+	ListID int64
+	// If not zero, only entries on tasks with this label are included.
+	LabelID int64
+}
+
+// TimeReportRow holds the time logged for one user, list, label or date
+type TimeReportRow struct {
+	// The id of the user, list or label this row is about. Zero when grouping by date or for tasks without a label.
+	ID int64 `json:"id"`
+	// The username, list or label title or the date (formatted as YYYY-MM-DD) this row is about.
+	Title string `json:"title"`
+	// How many time entries this row contains.
+	Entries int64 `json:"entries"`
+	// The logged time in seconds.
+	Duration int64 `json:"duration"`
+}
+
+// TimeReport holds the time logged on all tasks a user has access to, aggregated by a property
+type TimeReport struct {
+	// The property the rows of this report are grouped by.
+	GroupBy string    `json:"group_by"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	// One row per user, list, label or date.
+	Rows []*TimeReportRow `json:"rows"`
+	// The total logged time in seconds. When grouping by label this can be less than the sum of all rows since
+	// time logged on a task with multiple labels counts towards each of them.
+	Total int64 `json:"total"`
+}
+
+// GetTimeReport aggregates the time logged on all tasks the user has access to.
+// Running timers count with the time elapsed until now.
+func GetTimeReport(s *xorm.Session, a web.Auth, opts *TimeReportOptions) (report *TimeReport, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, ErrGenericForbidden{}
+	}
+
+	if opts.GroupBy == "" {
+		opts.GroupBy = TimeReportGroupByUser
+	}
+	switch opts.GroupBy {
+	case TimeReportGroupByUser, TimeReportGroupByList, TimeReportGroupByLabel, TimeReportGroupByDate:
+	default:
+		return nil, ErrInvalidTimeReportGroup{GroupBy: opts.GroupBy}
+	}
+
+	u, err := user.GetFromAuth(a)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, _, _, err := getRawListsForUser(s, &listOptions{
+		user:       u,
+		page:       -1,
+		isArchived: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listMap := make(map[int64]*List, len(lists))
+	listIDs := make([]int64, 0, len(lists))
+	for _, l := range lists {
+		if opts.ListID != 0 && l.ID != opts.ListID {
+			continue
+		}
+		listMap[l.ID] = l
+		listIDs = append(listIDs, l.ID)
+	}
+
+	if opts.ListID != 0 && len(listIDs) == 0 {
+		return nil, ErrGenericForbidden{}
+	}
+
+	report = &TimeReport{
+		GroupBy: opts.GroupBy,
+		From:    opts.From,
+		To:      opts.To,
+		Rows:    []*TimeReportRow{},
+	}
+
+	if len(listIDs) == 0 {
+		return report, nil
+	}
+
+	entries, err := getTimeEntriesForReport(s, listIDs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return report, nil
+	}
+
+	rows := make(map[string]*TimeReportRow)
+	addToRow := func(key string, id int64, title string, e *TaskTimeEntry) {
+		row, exists := rows[key]
+		if !exists {
+			row = &TimeReportRow{ID: id, Title: title}
+			rows[key] = row
+		}
+		row.Entries++
+		row.Duration += int64(e.trackedDuration() / time.Second)
+	}
+
+	for _, e := range entries {
+		report.Total += int64(e.trackedDuration() / time.Second)
+	}
+
+	switch opts.GroupBy {
+	case TimeReportGroupByUser:
+		userIDs := make([]int64, 0, len(entries))
+		for _, e := range entries {
+			userIDs = append(userIDs, e.UserID)
+		}
+		users, err := user.GetUsersByIDs(s, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			var title string
+			if u, has := users[e.UserID]; has {
+				title = u.Username
+			}
+			addToRow(strconv.FormatInt(e.UserID, 10), e.UserID, title, e)
+		}
+	case TimeReportGroupByList:
+		taskLists, err := getListIDsForTimeEntries(s, entries)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			listID := taskLists[e.TaskID]
+			var title string
+			if l, has := listMap[listID]; has {
+				title = l.Title
+			}
+			addToRow(strconv.FormatInt(listID, 10), listID, title, e)
+		}
+	case TimeReportGroupByLabel:
+		taskIDs := make([]int64, 0, len(entries))
+		for _, e := range entries {
+			taskIDs = append(taskIDs, e.TaskID)
+		}
+		labels, _, _, err := getLabelsByTaskIDs(s, &LabelByTaskIDsOptions{
+			TaskIDs: taskIDs,
+			Page:    -1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		taskLabels := make(map[int64][]*labelWithTaskID)
+		for _, l := range labels {
+			taskLabels[l.TaskID] = append(taskLabels[l.TaskID], l)
+		}
+		for _, e := range entries {
+			if len(taskLabels[e.TaskID]) == 0 {
+				addToRow("0", 0, "", e)
+				continue
+			}
+			for _, l := range taskLabels[e.TaskID] {
+				addToRow(strconv.FormatInt(l.ID, 10), l.ID, l.Title, e)
+			}
+		}
+	case TimeReportGroupByDate:
+		for _, e := range entries {
+			date := e.Start.In(config.GetTimeZone()).Format("2006-01-02")
+			addToRow(date, 0, date, e)
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Title == report.Rows[j].Title {
+			return report.Rows[i].ID < report.Rows[j].ID
+		}
+		return report.Rows[i].Title < report.Rows[j].Title
+	})
+
+	return report, nil
+}
+
+func getTimeEntriesForReport(s *xorm.Session, listIDs []int64, opts *TimeReportOptions) (entries []*TaskTimeEntry, err error) {
+	cond := builder.And(
+		builder.In("task_id", builder.
+			Select("id").
+			From("tasks").
+			Where(builder.And(
+				builder.In("list_id", listIDs),
+				builder.IsNull{"deleted"},
+			))),
+	)
+	if !opts.From.IsZero() {
+		cond = builder.And(cond, builder.Gte{"start_time": opts.From})
+	}
+	if !opts.To.IsZero() {
+		cond = builder.And(cond, builder.Lt{"start_time": opts.To})
+	}
+	if opts.UserID != 0 {
+		cond = builder.And(cond, builder.Eq{"user_id": opts.UserID})
+	}
+	if opts.LabelID != 0 {
+		cond = builder.And(cond, builder.In("task_id", builder.Select("task_id").From("label_tasks").Where(builder.Eq{"label_id": opts.LabelID})))
+	}
+
+	entries = []*TaskTimeEntry{}
+	err = s.
+		Where(cond).
+		OrderBy("start_time ASC").
+		Find(&entries)
+	return
+}
+
+func getListIDsForTimeEntries(s *xorm.Session, entries []*TaskTimeEntry) (taskLists map[int64]int64, err error) {
+	taskIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		taskIDs = append(taskIDs, e.TaskID)
+	}
+
+	tasks := []*Task{}
+	err = s.
+		Select("id, list_id").
+		In("id", taskIDs).
+		Find(&tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	taskLists = make(map[int64]int64, len(tasks))
+	for _, t := range tasks {
+		taskLists[t.ID] = t.ListID
+	}
+	return
+}
+
+// WriteCSV writes the rows of a time report as csv, one line per row with a header line at the top.
+func (r *TimeReport) WriteCSV(w io.Writer) error {
+	wr := csv.NewWriter(w)
+
+	err := wr.Write([]string{r.GroupBy + "_id", r.GroupBy, "entries", "duration_seconds", "duration_hours"})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		err = wr.Write([]string{
+			strconv.FormatInt(row.ID, 10),
+			row.Title,
+			strconv.FormatInt(row.Entries, 10),
+			strconv.FormatInt(row.Duration, 10),
+			strconv.FormatFloat(float64(row.Duration)/3600, 'f', 2, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	wr.Flush()
+	return wr.Error()
+}
@@ -0,0 +1,149 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTimeReport(t *testing.T) {
+	u := &user.User{ID: 1}
+	from := time.Date(2018, 12, 1, 0, 0, 0, 0, config.GetTimeZone())
+	to := time.Date(2018, 12, 3, 0, 0, 0, 0, config.GetTimeZone())
+
+	t.Run("by user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{From: from, To: to})
+		assert.NoError(t, err)
+		assert.Equal(t, TimeReportGroupByUser, report.GroupBy)
+		assert.Equal(t, int64(6300), report.Total)
+		assert.Equal(t, []*TimeReportRow{
+			{ID: 1, Title: "user1", Entries: 2, Duration: 5400},
+			{ID: 3, Title: "user3", Entries: 1, Duration: 900},
+		}, report.Rows)
+	})
+	t.Run("by list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{GroupBy: TimeReportGroupByList, From: from, To: to})
+		assert.NoError(t, err)
+		assert.Equal(t, []*TimeReportRow{
+			{ID: 1, Title: "Test1", Entries: 3, Duration: 6300},
+		}, report.Rows)
+	})
+	t.Run("by label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{GroupBy: TimeReportGroupByLabel, From: from, To: to})
+		assert.NoError(t, err)
+		assert.Equal(t, []*TimeReportRow{
+			{ID: 0, Title: "", Entries: 1, Duration: 1800},
+			{ID: 4, Title: "Label #4 - visible via other task", Entries: 2, Duration: 4500},
+		}, report.Rows)
+	})
+	t.Run("by date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{GroupBy: TimeReportGroupByDate, From: from, To: to})
+		assert.NoError(t, err)
+		assert.Equal(t, []*TimeReportRow{
+			{ID: 0, Title: "2018-12-01", Entries: 1, Duration: 3600},
+			{ID: 0, Title: "2018-12-02", Entries: 2, Duration: 2700},
+		}, report.Rows)
+	})
+	t.Run("filtered by user and label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{UserID: 1, LabelID: 4, From: from, To: to})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3600), report.Total)
+		assert.Len(t, report.Rows, 1)
+	})
+	t.Run("includes running timers", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{From: to})
+		assert.NoError(t, err)
+		assert.Len(t, report.Rows, 1)
+		assert.Equal(t, int64(1), report.Rows[0].Entries)
+		assert.Greater(t, report.Total, int64(0))
+	})
+	t.Run("list without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTimeReport(s, u, &TimeReportOptions{ListID: 5})
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("invalid group", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTimeReport(s, u, &TimeReportOptions{GroupBy: "task"})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeReportGroup(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTimeReport(s, &LinkSharing{ID: 1, ListID: 1}, &TimeReportOptions{})
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTimeReport_WriteCSV(t *testing.T) {
+	report := &TimeReport{
+		GroupBy: TimeReportGroupByList,
+		Rows: []*TimeReportRow{
+			{ID: 1, Title: "Test1", Entries: 3, Duration: 6300},
+			{ID: 2, Title: "With, comma", Entries: 1, Duration: 60},
+		},
+	}
+	buf := &bytes.Buffer{}
+	err := report.WriteCSV(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `list_id,list,entries,duration_seconds,duration_hours
+1,Test1,3,6300,1.75
+2,"With, comma",1,60,0.02
+`, buf.String())
+}
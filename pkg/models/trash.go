@@ -0,0 +1,682 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// All kinds of items which can be in the trash
+const (
+	TrashItemKindTask      = "task"
+	TrashItemKindList      = "list"
+	TrashItemKindNamespace = "namespace"
+)
+
+// TrashItem represents a task, list or namespace which was deleted and can still be restored
+type TrashItem struct {
+	// The kind of the deleted item. Either `task`, `list` or `namespace`.
+	Kind string `json:"kind" param:"kind"`
+	// The id of the deleted item.
+	ID int64 `json:"id" param:"id"`
+	// The title of the deleted item.
+	Title string `json:"title"`
+	// The id of the list a deleted task belongs to or the id of the namespace a deleted list belongs to.
+	ParentID int64 `json:"parent_id"`
+	// A timestamp when this item was moved to the trash.
+	Deleted time.Time `json:"deleted"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+func getTrashedTask(s *xorm.Session, id int64) (task *Task, err error) {
+	task = &Task{}
+	exists, err := s.
+		Unscoped().
+		Where("id = ? AND deleted IS NOT NULL", id).
+		Get(task)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTrashItemDoesNotExist{Kind: TrashItemKindTask, ID: id}
+	}
+	return
+}
+
+func getTrashedList(s *xorm.Session, id int64) (list *List, err error) {
+	list = &List{}
+	exists, err := s.
+		Unscoped().
+		Where("id = ? AND deleted IS NOT NULL", id).
+		Get(list)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTrashItemDoesNotExist{Kind: TrashItemKindList, ID: id}
+	}
+	return
+}
+
+func getTrashedNamespace(s *xorm.Session, id int64) (namespace *Namespace, err error) {
+	namespace = &Namespace{}
+	exists, err := s.
+		Unscoped().
+		Where("id = ? AND deleted IS NOT NULL", id).
+		Get(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTrashItemDoesNotExist{Kind: TrashItemKindNamespace, ID: id}
+	}
+	return
+}
+
+func newTrashItemFromTask(t *Task) *TrashItem {
+	return &TrashItem{
+		Kind:     TrashItemKindTask,
+		ID:       t.ID,
+		Title:    t.Title,
+		ParentID: t.ListID,
+		Deleted:  t.Deleted,
+	}
+}
+
+func newTrashItemFromList(l *List) *TrashItem {
+	return &TrashItem{
+		Kind:     TrashItemKindList,
+		ID:       l.ID,
+		Title:    l.Title,
+		ParentID: l.NamespaceID,
+		Deleted:  l.Deleted,
+	}
+}
+
+func newTrashItemFromNamespace(n *Namespace) *TrashItem {
+	return &TrashItem{
+		Kind:    TrashItemKindNamespace,
+		ID:      n.ID,
+		Title:   n.Title,
+		Deleted: n.Deleted,
+	}
+}
+
+// getTrashedNamespacesForUser returns all namespaces in the trash the user has admin rights on.
+func getTrashedNamespacesForUser(s *xorm.Session, u *user.User, search string) (namespaces []*Namespace, err error) {
+	namespaces = []*Namespace{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL").
+		And(db.ILIKE("title", search)).
+		And(builder.Or(
+			builder.Eq{"owner_id": u.ID},
+			builder.In("id", builder.
+				Select("namespace_id").
+				From("users_namespaces").
+				Where(builder.Eq{
+					"users_namespaces.user_id": u.ID,
+					"users_namespaces.right":   RightAdmin,
+				})),
+			builder.In("id", builder.
+				Select("team_namespaces.namespace_id").
+				From("team_namespaces").
+				Join("INNER", "team_members", "team_members.team_id = team_namespaces.team_id").
+				Where(builder.Eq{
+					"team_members.user_id":  u.ID,
+					"team_namespaces.right": RightAdmin,
+				})),
+		)).
+		Find(&namespaces)
+	return
+}
+
+// getTrashedListsForUser returns all lists in the trash which the user has admin rights on.
+// Lists whose namespace is in the trash as well are not returned, they can only be restored together with it.
+func getTrashedListsForUser(s *xorm.Session, u *user.User, search string) (lists []*List, err error) {
+	accessibleNamespaces := builder.
+		Select("namespaces.id").
+		From("namespaces").
+		Join("LEFT", "users_namespaces", "users_namespaces.namespace_id = namespaces.id").
+		Join("LEFT", "team_namespaces", "team_namespaces.namespace_id = namespaces.id").
+		Join("LEFT", "team_members", "team_members.team_id = team_namespaces.team_id").
+		Where(builder.Or(
+			builder.Eq{"namespaces.owner_id": u.ID},
+			builder.Eq{"users_namespaces.user_id": u.ID},
+			builder.Eq{"team_members.user_id": u.ID},
+		))
+
+	candidates := []*List{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL").
+		And(db.ILIKE("title", search)).
+		And(builder.In("namespace_id", builder.
+			Select("id").
+			From("namespaces").
+			Where(builder.IsNull{"deleted"}))).
+		And(builder.Or(
+			builder.Eq{"owner_id": u.ID},
+			builder.In("namespace_id", accessibleNamespaces),
+			builder.In("id", builder.
+				Select("list_id").
+				From("users_lists").
+				Where(builder.Eq{"users_lists.user_id": u.ID})),
+			builder.In("id", builder.
+				Select("team_lists.list_id").
+				From("team_lists").
+				Join("INNER", "team_members", "team_members.team_id = team_lists.team_id").
+				Where(builder.Eq{"team_members.user_id": u.ID})),
+		)).
+		Find(&candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	lists = make([]*List, 0, len(candidates))
+	for _, l := range candidates {
+		isAdmin := l.isOwner(u)
+		if !isAdmin {
+			isAdmin, _, err = l.checkRight(s, u, RightAdmin)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if isAdmin {
+			lists = append(lists, l)
+		}
+	}
+
+	return
+}
+
+// getTrashedTasksForUser returns all tasks in the trash which the user could restore.
+// Tasks whose list is in the trash as well are not returned, they can only be restored together with it.
+func getTrashedTasksForUser(s *xorm.Session, u *user.User, search string) (tasks []*Task, err error) {
+	lists, _, _, err := getRawListsForUser(s, &listOptions{
+		user:       u,
+		page:       -1,
+		isArchived: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lists) == 0 {
+		return []*Task{}, nil
+	}
+
+	listIDs := make([]int64, 0, len(lists))
+	for _, l := range lists {
+		listIDs = append(listIDs, l.ID)
+	}
+
+	candidates := []*Task{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL").
+		And(db.ILIKE("title", search)).
+		In("list_id", listIDs).
+		Find(&candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	canWrite := make(map[int64]bool)
+	tasks = make([]*Task, 0, len(candidates))
+	for _, t := range candidates {
+		can, checked := canWrite[t.ListID]
+		if !checked {
+			l := &List{ID: t.ListID}
+			can, err = l.CanWrite(s, u)
+			if err != nil && !IsErrListIsArchived(err) && !IsErrNamespaceIsArchived(err) {
+				return nil, err
+			}
+			// Tasks can't be restored into archived lists
+			can = can && err == nil
+			err = nil
+			canWrite[t.ListID] = can
+		}
+		if can {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return
+}
+
+// ReadAll returns all tasks, lists and namespaces in the trash the user can restore
+// @Summary Get all items in the trash
+// @Description Returns all deleted tasks, lists and namespaces the current user can restore, sorted by the time they were deleted, newest first. Items which were deleted together with their list or namespace are only restored with it and therefore not returned.
+// @tags trash
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search items by title."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.TrashItem "The items in the trash."
+// @Failure 403 {object} web.HTTPError "Link shares don't have a trash."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash [get]
+func (ti *TrashItem) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	u := &user.User{ID: a.GetID()}
+	items := []*TrashItem{}
+
+	namespaces, err := getTrashedNamespacesForUser(s, u, search)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, n := range namespaces {
+		items = append(items, newTrashItemFromNamespace(n))
+	}
+
+	lists, err := getTrashedListsForUser(s, u, search)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, l := range lists {
+		items = append(items, newTrashItemFromList(l))
+	}
+
+	tasks, err := getTrashedTasksForUser(s, u, search)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, t := range tasks {
+		items = append(items, newTrashItemFromTask(t))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Deleted.Equal(items[j].Deleted) {
+			return items[i].Deleted.After(items[j].Deleted)
+		}
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].ID < items[j].ID
+	})
+
+	numberOfTotalItems = int64(len(items))
+	limit, start := getLimitFromPageIndex(page, perPage)
+	if limit > 0 {
+		if start > len(items) {
+			start = len(items)
+		}
+		end := start + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		items = items[start:end]
+	}
+
+	return items, len(items), numberOfTotalItems, nil
+}
+
+func restoreTask(s *xorm.Session, a web.Auth, t *Task) (err error) {
+	// The bucket of the task might have been deleted while the task was in the trash
+	bucketExists := false
+	if t.BucketID != 0 {
+		bucketExists, err = s.
+			Where("id = ? AND list_id = ?", t.BucketID, t.ListID).
+			Exist(&Bucket{})
+		if err != nil {
+			return err
+		}
+	}
+	if !bucketExists {
+		t.BucketID = 0
+		err = setTaskBucket(s, t, nil, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.Deleted = time.Time{}
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		ID(t.ID).
+		Cols("deleted", "bucket_id").
+		Update(t)
+	if err != nil {
+		return err
+	}
+
+	err = events.Dispatch(&TaskRestoredEvent{
+		Task: t,
+		Doer: a,
+	})
+	if err != nil {
+		return err
+	}
+
+	return updateListLastUpdated(s, &List{ID: t.ListID})
+}
+
+func restoreList(s *xorm.Session, a web.Auth, l *List) (err error) {
+	// Only restore the tasks which were moved to the trash together with the list
+	tasks := []*Task{}
+	err = s.
+		Unscoped().
+		Where("list_id = ? AND deleted = (SELECT deleted FROM lists WHERE id = ?)", l.ID, l.ID).
+		Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	l.Deleted = time.Time{}
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		ID(l.ID).
+		Cols("deleted").
+		Update(l)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		err = restoreTask(s, a, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return events.Dispatch(&ListRestoredEvent{
+		List: l,
+		Doer: a,
+	})
+}
+
+func restoreNamespace(s *xorm.Session, a web.Auth, n *Namespace) (err error) {
+	// Only restore the lists which were moved to the trash together with the namespace
+	lists := []*List{}
+	err = s.
+		Unscoped().
+		Where("namespace_id = ? AND deleted = (SELECT deleted FROM namespaces WHERE id = ?)", n.ID, n.ID).
+		Find(&lists)
+	if err != nil {
+		return err
+	}
+
+	n.Deleted = time.Time{}
+	_, err = s.
+		Unscoped().
+		NoAutoTime().
+		ID(n.ID).
+		Cols("deleted").
+		Update(n)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lists {
+		err = restoreList(s, a, l)
+		if err != nil {
+			return err
+		}
+	}
+
+	return events.Dispatch(&NamespaceRestoredEvent{
+		Namespace: n,
+		Doer:      a,
+	})
+}
+
+// Update restores an item from the trash
+// @Summary Restore an item from the trash
+// @Description Restores a task, list or namespace from the trash. Restoring a list restores all tasks which were deleted together with it, restoring a namespace restores all lists deleted together with it. All relations, labels, assignees, comments and attachments are restored as well.
+// @tags trash
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param kind path string true "The kind of the item. Either task, list or namespace."
+// @Param id path int true "The id of the item."
+// @Success 200 {object} models.TrashItem "The restored item."
+// @Failure 400 {object} web.HTTPError "Invalid trash item kind."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the item."
+// @Failure 404 {object} web.HTTPError "The item is not in the trash."
+// @Failure 412 {object} web.HTTPError "The list or namespace of the item is in the trash as well."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash/{kind}/{id} [post]
+func (ti *TrashItem) Update(s *xorm.Session, a web.Auth) (err error) {
+	switch ti.Kind {
+	case TrashItemKindTask:
+		t, err := getTrashedTask(s, ti.ID)
+		if err != nil {
+			return err
+		}
+		*ti = *newTrashItemFromTask(t)
+		err = restoreTask(s, a, t)
+		if err != nil {
+			return err
+		}
+	case TrashItemKindList:
+		l, err := getTrashedList(s, ti.ID)
+		if err != nil {
+			return err
+		}
+		*ti = *newTrashItemFromList(l)
+		err = restoreList(s, a, l)
+		if err != nil {
+			return err
+		}
+	case TrashItemKindNamespace:
+		n, err := getTrashedNamespace(s, ti.ID)
+		if err != nil {
+			return err
+		}
+		*ti = *newTrashItemFromNamespace(n)
+		err = restoreNamespace(s, a, n)
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidTrashItemKind{Kind: ti.Kind}
+	}
+
+	ti.Deleted = time.Time{}
+	return nil
+}
+
+// Delete removes an item from the trash
+// @Summary Permanently delete an item from the trash
+// @Description Permanently deletes a task, list or namespace which is in the trash, together with everything belonging to it. This cannot be undone.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param kind path string true "The kind of the item. Either task, list or namespace."
+// @Param id path int true "The id of the item."
+// @Success 200 {object} models.Message "The item was permanently deleted."
+// @Failure 400 {object} web.HTTPError "Invalid trash item kind."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the item."
+// @Failure 404 {object} web.HTTPError "The item is not in the trash."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash/{kind}/{id} [delete]
+func (ti *TrashItem) Delete(s *xorm.Session, a web.Auth) (err error) {
+	switch ti.Kind {
+	case TrashItemKindTask:
+		t, err := getTrashedTask(s, ti.ID)
+		if err != nil {
+			return err
+		}
+		return purgeTask(s, a, t)
+	case TrashItemKindList:
+		l, err := getTrashedList(s, ti.ID)
+		if err != nil {
+			return err
+		}
+		return purgeList(s, a, l)
+	case TrashItemKindNamespace:
+		n, err := getTrashedNamespace(s, ti.ID)
+		if err != nil {
+			return err
+		}
+		return purgeNamespace(s, a, n, true)
+	default:
+		return ErrInvalidTrashItemKind{Kind: ti.Kind}
+	}
+}
+
+// purgeTrash permanently deletes everything which was moved to the trash before the given time.
+func purgeTrash(s *xorm.Session, before time.Time) (purged int, err error) {
+	namespaces := []*Namespace{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND deleted < ?", before).
+		Find(&namespaces)
+	if err != nil {
+		return
+	}
+	for _, n := range namespaces {
+		err = purgeNamespace(s, nil, n, true)
+		if err != nil {
+			return
+		}
+		purged++
+	}
+
+	lists := []*List{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND deleted < ?", before).
+		Find(&lists)
+	if err != nil {
+		return
+	}
+	for _, l := range lists {
+		err = purgeList(s, nil, l)
+		if err != nil {
+			return
+		}
+		purged++
+	}
+
+	tasks := []*Task{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND deleted < ?", before).
+		Find(&tasks)
+	if err != nil {
+		return
+	}
+	for _, t := range tasks {
+		err = purgeTask(s, nil, t)
+		if err != nil {
+			return
+		}
+		purged++
+	}
+
+	return
+}
+
+// purgeTrashOwnedByUser permanently deletes all namespaces and lists in the trash owned by a user.
+func purgeTrashOwnedByUser(s *xorm.Session, u *user.User) (err error) {
+	namespaces := []*Namespace{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND owner_id = ?", u.ID).
+		Find(&namespaces)
+	if err != nil {
+		return
+	}
+	for _, n := range namespaces {
+		err = purgeNamespace(s, u, n, true)
+		if err != nil {
+			return
+		}
+	}
+
+	lists := []*List{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND owner_id = ?", u.ID).
+		Find(&lists)
+	if err != nil {
+		return
+	}
+	for _, l := range lists {
+		err = purgeList(s, u, l)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// RegisterTrashPurgeCron registers a cron function which runs every hour and permanently deletes everything
+// which is in the trash for longer than the configured retention period.
+func RegisterTrashPurgeCron() {
+	const logPrefix = "[Trash Purge Cron] "
+
+	retentionDays := config.ServiceTrashRetentionDays.GetInt()
+	if retentionDays <= 0 {
+		log.Info(logPrefix + "Trash retention is disabled, not purging the trash")
+		return
+	}
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		if err := s.Begin(); err != nil {
+			log.Errorf(logPrefix+"Could not start transaction: %s", err)
+			return
+		}
+
+		before := time.Now().Add(-time.Hour * 24 * time.Duration(retentionDays))
+		purged, err := purgeTrash(s, before)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf(logPrefix+"Could not purge the trash: %s", err)
+			return
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Errorf(logPrefix+"Could not commit the transaction: %s", err)
+			return
+		}
+
+		if purged > 0 {
+			log.Debugf(logPrefix+"Permanently deleted %d items which were in the trash since before %s", purged, before)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register trash purge cron: %s", err)
+	}
+}
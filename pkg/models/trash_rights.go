@@ -0,0 +1,84 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanUpdate checks if a user can restore an item from the trash
+func (ti *TrashItem) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return ti.canDoTrashItem(s, a)
+}
+
+// CanDelete checks if a user can permanently delete an item from the trash
+func (ti *TrashItem) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return ti.canDoTrashItem(s, a)
+}
+
+// A user can restore or purge a trashed item if they had the right to delete it in the first place.
+// Items whose parent is in the trash as well can only be restored or purged together with it.
+func (ti *TrashItem) canDoTrashItem(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	switch ti.Kind {
+	case TrashItemKindTask:
+		t, err := getTrashedTask(s, ti.ID)
+		if err != nil {
+			return false, err
+		}
+
+		l := &List{ID: t.ListID}
+		can, err := l.CanWrite(s, a)
+		if IsErrListDoesNotExist(err) {
+			return false, ErrTrashItemParentDeleted{Kind: ti.Kind, ID: ti.ID}
+		}
+		return can, err
+	case TrashItemKindList:
+		l, err := getTrashedList(s, ti.ID)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = getNamespaceSimpleByID(s, l.NamespaceID)
+		if IsErrNamespaceDoesNotExist(err) {
+			return false, ErrTrashItemParentDeleted{Kind: ti.Kind, ID: ti.ID}
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if l.OwnerID == a.GetID() {
+			return true, nil
+		}
+		is, _, err := l.checkRight(s, a, RightAdmin)
+		return is, err
+	case TrashItemKindNamespace:
+		n, err := getTrashedNamespace(s, ti.ID)
+		if err != nil {
+			return false, err
+		}
+
+		is, _, err := n.checkRightOnLoadedNamespace(s, a, RightAdmin)
+		return is, err
+	default:
+		return false, ErrInvalidTrashItemKind{Kind: ti.Kind}
+	}
+}
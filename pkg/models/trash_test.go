@@ -0,0 +1,329 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashItem_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("empty", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{}
+		result, resultCount, total, err := ti.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, resultCount)
+		assert.Equal(t, int64(0), total)
+		assert.Empty(t, result)
+	})
+	t.Run("deleted items", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := task.Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{}
+		result, resultCount, _, err := ti.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resultCount)
+		items := result.([]*TrashItem)
+		assert.Equal(t, TrashItemKindTask, items[0].Kind)
+		assert.Equal(t, int64(1), items[0].ID)
+		assert.Equal(t, "task #1", items[0].Title)
+		assert.Equal(t, int64(1), items[0].ParentID)
+
+		// Items in a deleted namespace are only shown through the namespace
+		n := &Namespace{ID: 1}
+		err = n.Delete(s, u)
+		assert.NoError(t, err)
+
+		result, resultCount, _, err = ti.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resultCount)
+		items = result.([]*TrashItem)
+		assert.Equal(t, TrashItemKindNamespace, items[0].Kind)
+		assert.Equal(t, int64(1), items[0].ID)
+	})
+	t.Run("deleted items are hidden everywhere else", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		n := &Namespace{ID: 1}
+		err := n.Delete(s, u)
+		assert.NoError(t, err)
+
+		nn := &Namespace{}
+		result, _, _, err := nn.ReadAll(s, u, "", 1, -1)
+		assert.NoError(t, err)
+		for _, n := range result.([]*NamespaceWithLists) {
+			assert.NotEqual(t, int64(1), n.ID)
+		}
+
+		lists, _, _, err := getRawListsForUser(s, &listOptions{user: u, page: -1})
+		assert.NoError(t, err)
+		for _, l := range lists {
+			assert.NotEqual(t, int64(1), l.ID)
+		}
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 14}
+		err := task.Delete(s, &user.User{ID: 5})
+		assert.NoError(t, err)
+
+		ti := &TrashItem{}
+		_, resultCount, _, err := ti.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, resultCount)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{}
+		_, _, _, err := ti.ReadAll(s, &LinkSharing{ID: 1, ListID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTrashItem_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := task.Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 1}
+		can, err := ti.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "task #1", ti.Title)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		restored, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), restored.BucketID)
+		db.AssertExists(t, "task_attachments", map[string]interface{}{
+			"task_id": 1,
+		}, false)
+	})
+	t.Run("task in deleted bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := task.Delete(s, u)
+		assert.NoError(t, err)
+		_, err = s.Where("id = ?", 1).Delete(&Bucket{})
+		assert.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 1}
+		err = ti.Update(s, u)
+		assert.NoError(t, err)
+
+		restored, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), restored.BucketID)
+	})
+	t.Run("list with its tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Task 1 was deleted before the list and should therefore stay in the trash
+		task, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		err = trashTask(s, u, &task, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+
+		l := &List{ID: 1}
+		err = l.Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindList, ID: 1}
+		can, err := ti.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Update(s, u)
+		assert.NoError(t, err)
+
+		_, err = GetListSimpleByID(s, 1)
+		assert.NoError(t, err)
+		_, err = GetTaskByIDSimple(s, 2)
+		assert.NoError(t, err)
+		_, err = getTrashedTask(s, 1)
+		assert.NoError(t, err)
+	})
+	t.Run("namespace with its lists", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		n := &Namespace{ID: 1}
+		err := n.Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindNamespace, ID: 1}
+		can, err := ti.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Update(s, u)
+		assert.NoError(t, err)
+
+		_, err = getNamespaceSimpleByID(s, 1)
+		assert.NoError(t, err)
+		_, err = GetListSimpleByID(s, 1)
+		assert.NoError(t, err)
+		_, err = GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+	})
+	t.Run("parent deleted", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		l := &List{ID: 1}
+		err := l.Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 1}
+		_, err = ti.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTrashItemParentDeleted(err))
+	})
+	t.Run("not in trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 1}
+		_, err := ti.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTrashItemDoesNotExist(err))
+	})
+	t.Run("invalid kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{Kind: "label", ID: 1}
+		_, err := ti.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTrashItemKind(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 14}
+		err := task.Delete(s, &user.User{ID: 5})
+		assert.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 14}
+		can, err := ti.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTrashItem_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	task := &Task{ID: 1}
+	err := task.Delete(s, u)
+	assert.NoError(t, err)
+
+	ti := &TrashItem{Kind: TrashItemKindTask, ID: 1}
+	can, err := ti.CanDelete(s, u)
+	assert.NoError(t, err)
+	assert.True(t, can)
+	err = ti.Delete(s, u)
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "tasks", map[string]interface{}{
+		"id": 1,
+	})
+	db.AssertMissing(t, "task_comments", map[string]interface{}{
+		"task_id": 1,
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	now := time.Now()
+
+	task1, err := GetTaskByIDSimple(s, 1)
+	assert.NoError(t, err)
+	err = trashTask(s, u, &task1, now.Add(-time.Hour*24*40))
+	assert.NoError(t, err)
+	task2, err := GetTaskByIDSimple(s, 2)
+	assert.NoError(t, err)
+	err = trashTask(s, u, &task2, now)
+	assert.NoError(t, err)
+
+	purged, err := purgeTrash(s, now.Add(-time.Hour*24*30))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "tasks", map[string]interface{}{
+		"id": 1,
+	})
+	_, err = getTrashedTask(s, 2)
+	assert.NoError(t, err)
+}
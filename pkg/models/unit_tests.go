@@ -67,6 +67,22 @@ func SetupTests() {
 		"saved_filters",
 		"subscriptions",
 		"favorites",
+		"task_time_entries",
+		"task_history",
+		"task_checklist_items",
+		"list_custom_fields",
+		"task_custom_field_values",
+		"task_templates",
+		"task_template_users",
+		"task_template_teams",
+		"list_blueprints",
+		"task_previous_identifiers",
+		"saved_filter_users",
+		"saved_filter_teams",
+		"saved_filter_task_buckets",
+		"task_comment_edits",
+		"reactions",
+		"task_attachment_previews",
 	)
 	if err != nil {
 		log.Fatal(err)
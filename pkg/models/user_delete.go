@@ -182,19 +182,73 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 
 	// Delete everything not shared with anybody else
 	for _, n := range namespacesToDelete {
-		err = deleteNamespace(s, n, u, false)
+		err = purgeNamespace(s, u, n, false)
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, l := range listsToDelete {
-		err = l.Delete(s, u)
+		err = purgeList(s, u, l)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Everything the user owns which is still in the trash would otherwise only get purged after the retention period
+	err = purgeTrashOwnedByUser(s, u)
+	if err != nil {
+		return err
+	}
+
+	// Time entries on lists which are still shared with others would otherwise point to a user which does not exist
+	_, err = s.Where("user_id = ?", u.ID).Delete(&TaskTimeEntry{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("assignee_id = ?", u.ID).
+		Cols("assignee_id").
+		NoAutoTime().
+		Update(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where(builder.And(
+			builder.Eq{"value_number": u.ID},
+			builder.In("field_id", builder.
+				Select("id").
+				From("list_custom_fields").
+				Where(builder.Eq{"type": CustomFieldTypeUser})),
+		)).
+		Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	err = deleteTaskTemplates(s, builder.Eq{"owner_id": u.ID})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&TaskTemplateUser{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&ListBlueprint{})
+	if err != nil {
+		return err
+	}
+
+	err = deleteReactions(s, builder.Eq{"user_id": u.ID})
+	if err != nil {
+		return err
+	}
+
 	_, err = s.Where("id = ?", u.ID).Delete(&user.User{})
 	if err != nil {
 		return err
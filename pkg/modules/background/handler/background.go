@@ -291,6 +291,10 @@ func GetListBackground(c echo.Context) error {
 	bgFile := &files.File{
 		ID: list.BackgroundFileID,
 	}
+	if err := bgFile.LoadFileMetaByID(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
 	if err := bgFile.LoadFileByID(); err != nil {
 		_ = s.Rollback()
 		return handler.HandleHTTPError(err, c)
@@ -307,7 +311,9 @@ func GetListBackground(c echo.Context) error {
 	}
 
 	// Serve the file
-	return c.Stream(http.StatusOK, "image/jpg", bgFile.File)
+	defer bgFile.File.Close()
+	bgFile.ServeContent(c.Response(), c.Request(), files.DispositionInline)
+	return nil
 }
 
 // RemoveListBackground removes a list background, no matter the background provider
@@ -249,12 +249,21 @@ func insertFromStructure(s *xorm.Session, str []*models.NamespaceWithListsAndTas
 					log.Debugf("[creating structure] Associated task %d with label %d", t.ID, lb.ID)
 				}
 
+				// Replies need to reference the new ids of the comments they reply to
+				commentIDs := make(map[int64]int64, len(t.Comments))
 				for _, comment := range t.Comments {
+					if comment.IsDeleted {
+						continue
+					}
+					oldID := comment.ID
+					comment.ID = 0
 					comment.TaskID = t.ID
+					comment.ParentID = commentIDs[comment.ParentID]
 					err = comment.Create(s, user)
 					if err != nil {
 						return
 					}
+					commentIDs[oldID] = comment.ID
 					log.Debugf("[creating structure] Created new comment %d", comment.ID)
 				}
 			}
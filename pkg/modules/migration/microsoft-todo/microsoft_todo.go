@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -92,6 +93,91 @@ type recurrence struct {
 	Range   *taskRange `json:"range"`
 }
 
+var weekdays = map[string]string{
+	"monday":    "MO",
+	"tuesday":   "TU",
+	"wednesday": "WE",
+	"thursday":  "TH",
+	"friday":    "FR",
+	"saturday":  "SA",
+	"sunday":    "SU",
+}
+
+var weekIndexes = map[string]string{
+	"first":  "1",
+	"second": "2",
+	"third":  "3",
+	"fourth": "4",
+	"last":   "-1",
+}
+
+// Converts a microsoft todo recurrence to an RFC 5545 repeat rule
+func (r *recurrence) toRepeatRule() string {
+	p := r.Pattern
+
+	var rule []string
+	switch p.Type {
+	case "daily":
+		rule = append(rule, "FREQ=DAILY")
+	case "weekly":
+		rule = append(rule, "FREQ=WEEKLY")
+	case "absoluteMonthly", "relativeMonthly", "monthly":
+		rule = append(rule, "FREQ=MONTHLY")
+	case "absoluteYearly", "relativeYearly", "yearly":
+		rule = append(rule, "FREQ=YEARLY")
+	default:
+		return ""
+	}
+
+	if p.Interval > 1 {
+		rule = append(rule, "INTERVAL="+strconv.FormatInt(p.Interval, 10))
+	}
+
+	if r.Range != nil {
+		switch r.Range.Type {
+		case "endDate":
+			until, err := time.Parse("2006-01-02", r.Range.EndDate)
+			if err == nil {
+				rule = append(rule, "UNTIL="+until.Format("20060102")+"T235959Z")
+			}
+		case "numbered":
+			if r.Range.NumberOfOccurrences > 0 {
+				rule = append(rule, "COUNT="+strconv.Itoa(r.Range.NumberOfOccurrences))
+			}
+		}
+	}
+
+	if p.Type == "absoluteYearly" || p.Type == "relativeYearly" {
+		if p.Month > 0 {
+			rule = append(rule, "BYMONTH="+strconv.FormatInt(p.Month, 10))
+		}
+	}
+
+	if p.Type == "absoluteMonthly" || p.Type == "absoluteYearly" {
+		if p.DayOfMonth > 0 {
+			rule = append(rule, "BYMONTHDAY="+strconv.FormatInt(p.DayOfMonth, 10))
+		}
+	}
+
+	if p.Type == "weekly" || p.Type == "relativeMonthly" || p.Type == "relativeYearly" {
+		days := make([]string, 0, len(p.DaysOfWeek))
+		for _, d := range p.DaysOfWeek {
+			if day, has := weekdays[strings.ToLower(d)]; has {
+				days = append(days, day)
+			}
+		}
+		if len(days) > 0 {
+			rule = append(rule, "BYDAY="+strings.Join(days, ","))
+		}
+
+		if pos, has := weekIndexes[p.Index]; has && p.Type != "weekly" {
+			rule = append(rule, "BYSETPOS="+pos)
+		}
+	}
+
+	return strings.Join(rule, ";")
+}
+
 type tasksResponse struct {
 	OdataContext string  `json:"@odata.context"`
 	Nextlink     string  `json:"@odata.nextLink"`
@@ -346,16 +432,7 @@ func convertMicrosoftTodoData(todoData []*list) (vikunjsStructure []*models.Name
 			// Repeating
 			if t.Recurrence != nil && t.Recurrence.Pattern != nil {
 				log.Debugf("[Microsoft Todo Migration] Converting recurring pattern for task %s", t.ID)
-				switch t.Recurrence.Pattern.Type {
-				case "daily":
-					task.RepeatAfter = t.Recurrence.Pattern.Interval * 60 * 60 * 24
-				case "weekly":
-					task.RepeatAfter = t.Recurrence.Pattern.Interval * 60 * 60 * 24 * 7
-				case "monthly":
-					task.RepeatAfter = t.Recurrence.Pattern.Interval * 60 * 60 * 24 * 30
-				case "yearly":
-					task.RepeatAfter = t.Recurrence.Pattern.Interval * 60 * 60 * 24 * 365
-				}
+				task.RepeatRule = t.Recurrence.toRepeatRule()
 			}
 
 			list.Tasks = append(list.Tasks, &models.TaskWithComments{Task: *task})
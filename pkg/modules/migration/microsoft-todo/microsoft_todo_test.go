@@ -154,9 +154,9 @@ func TestConverting(t *testing.T) {
 						},
 						{
 							Task: models.Task{
-								Title:       "Task 7",
-								DueDate:     testtimeTime,
-								RepeatAfter: 60 * 60 * 24 * 7, // The amount of seconds in a week
+								Title:      "Task 7",
+								DueDate:    testtimeTime,
+								RepeatRule: "FREQ=WEEKLY",
 							},
 						},
 					},
@@ -189,3 +189,74 @@ func TestConverting(t *testing.T) {
 		t.Errorf("converted microsoft todo data = %v, want %v, diff: %v", hierachie, expectedHierachie, diff)
 	}
 }
+
+func TestRecurrenceToRepeatRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence *recurrence
+		want       string
+	}{
+		{
+			name: "every two days",
+			recurrence: &recurrence{
+				Pattern: &pattern{Type: "daily", Interval: 2},
+			},
+			want: "FREQ=DAILY;INTERVAL=2",
+		},
+		{
+			name: "weekdays",
+			recurrence: &recurrence{
+				Pattern: &pattern{
+					Type:       "weekly",
+					Interval:   1,
+					DaysOfWeek: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+				},
+			},
+			want: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		},
+		{
+			name: "last friday of the month, ten times",
+			recurrence: &recurrence{
+				Pattern: &pattern{
+					Type:       "relativeMonthly",
+					Interval:   1,
+					DaysOfWeek: []string{"friday"},
+					Index:      "last",
+				},
+				Range: &taskRange{
+					Type:                "numbered",
+					NumberOfOccurrences: 10,
+				},
+			},
+			want: "FREQ=MONTHLY;COUNT=10;BYDAY=FR;BYSETPOS=-1",
+		},
+		{
+			name: "yearly until a date",
+			recurrence: &recurrence{
+				Pattern: &pattern{
+					Type:       "absoluteYearly",
+					Interval:   1,
+					Month:      3,
+					DayOfMonth: 15,
+				},
+				Range: &taskRange{
+					Type:    "endDate",
+					EndDate: "2025-03-15",
+				},
+			},
+			want: "FREQ=YEARLY;UNTIL=20250315T235959Z;BYMONTH=3;BYMONTHDAY=15",
+		},
+		{
+			name: "unknown type",
+			recurrence: &recurrence{
+				Pattern: &pattern{Type: "hourly", Interval: 1},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.recurrence.toRepeatRule())
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package document
+
+// Field is a piece of text of a document. Matches in fields with a higher boost rank higher.
+type Field struct {
+	Text  string
+	Boost float64
+}
+
+// Document is something which can be found through the search index.
+// Every document belongs to a task, search results are always tasks.
+type Document struct {
+	// A unique id of the document, for example "task-1" or "comment-12"
+	ID     string
+	TaskID int64
+	Fields []*Field
+}
@@ -0,0 +1,466 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package embedded
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.vikunja.io/api/pkg/modules/search/document"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+/*
+ * The embedded index is an inverted index stored in a leveldb database on disk.
+ * It uses these keys:
+ *
+ * n                   -> The number of indexed documents
+ * d\x00<doc>          -> The task id of a document and the weights of all its terms, used to remove a document again
+ * w\x00<term>         -> The number of documents containing a term
+ * t\x00<term>\x00<doc> -> The task id of the document and the weight of the term in the document
+ * k\x00<task>\x00<doc> -> Marks a document as belonging to a task
+ */
+
+const (
+	sep = "\x00"
+
+	// Matches are ranked lower the less exact they are.
+	exactMatchFactor  = 1.0
+	prefixMatchFactor = 0.8
+	typoMatchFactor   = 0.6
+
+	// The maximum number of terms a single word of a query can expand to through prefix or typo matches.
+	maxExpansions = 100
+
+	// Controls how fast the weight of a term saturates when it appears multiple times in a document.
+	saturation = 1.2
+)
+
+var docCountKey = []byte("n")
+
+// Index is the embedded search index
+type Index struct {
+	db    *leveldb.DB
+	mutex sync.Mutex
+}
+
+type storedDocument struct {
+	TaskID  int64              `json:"t"`
+	Weights map[string]float64 `json:"w"`
+}
+
+// Open opens the index at the path and creates it if it does not exist yet.
+func Open(path string) (*Index, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{db: db}, nil
+}
+
+// OpenInMemory opens an index which only lives in memory.
+func OpenInMemory() (*Index, error) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Index{db: db}, nil
+}
+
+func docKey(docID string) []byte {
+	return []byte("d" + sep + docID)
+}
+
+func termKey(term string) []byte {
+	return []byte("w" + sep + term)
+}
+
+func postingKey(term, docID string) []byte {
+	return []byte("t" + sep + term + sep + docID)
+}
+
+func taskPrefix(taskID int64) string {
+	return "k" + sep + strconv.FormatInt(taskID, 10) + sep
+}
+
+func encodeUint(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func decodeUint(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func encodePosting(taskID int64, weight float64) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b, uint64(taskID))
+	binary.BigEndian.PutUint64(b[8:], math.Float64bits(weight))
+	return b
+}
+
+func decodePosting(b []byte) (taskID int64, weight float64) {
+	if len(b) != 16 {
+		return 0, 0
+	}
+	return int64(binary.BigEndian.Uint64(b)), math.Float64frombits(binary.BigEndian.Uint64(b[8:]))
+}
+
+func (i *Index) getUint(key []byte) (uint64, error) {
+	v, err := i.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	return decodeUint(v), err
+}
+
+func (i *Index) getDocument(docID string) (doc *storedDocument, err error) {
+	v, err := i.db.Get(docKey(docID), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc = &storedDocument{}
+	err = json.Unmarshal(v, doc)
+	return
+}
+
+// write collects all changes to the index and applies them at once
+type write struct {
+	index     *Index
+	batch     *leveldb.Batch
+	docCount  int64
+	termCount map[string]int64
+	docs      map[string]*storedDocument
+}
+
+func (i *Index) newWrite() (w *write, err error) {
+	count, err := i.getUint(docCountKey)
+	if err != nil {
+		return nil, err
+	}
+	return &write{
+		index:     i,
+		batch:     new(leveldb.Batch),
+		docCount:  int64(count),
+		termCount: make(map[string]int64),
+		docs:      make(map[string]*storedDocument),
+	}, nil
+}
+
+func (w *write) getDocument(docID string) (*storedDocument, error) {
+	if doc, has := w.docs[docID]; has {
+		return doc, nil
+	}
+	return w.index.getDocument(docID)
+}
+
+func (w *write) changeTermCount(term string, delta int64) error {
+	if _, has := w.termCount[term]; !has {
+		count, err := w.index.getUint(termKey(term))
+		if err != nil {
+			return err
+		}
+		w.termCount[term] = int64(count)
+	}
+	w.termCount[term] += delta
+	return nil
+}
+
+func (w *write) remove(docID string) error {
+	doc, err := w.getDocument(docID)
+	if err != nil || doc == nil {
+		return err
+	}
+
+	for term := range doc.Weights {
+		w.batch.Delete(postingKey(term, docID))
+		if err := w.changeTermCount(term, -1); err != nil {
+			return err
+		}
+	}
+	w.batch.Delete(docKey(docID))
+	w.batch.Delete([]byte(taskPrefix(doc.TaskID) + docID))
+	w.docs[docID] = nil
+	w.docCount--
+	return nil
+}
+
+func (w *write) add(doc *document.Document) error {
+	err := w.remove(doc.ID)
+	if err != nil {
+		return err
+	}
+
+	stored := &storedDocument{
+		TaskID:  doc.TaskID,
+		Weights: make(map[string]float64),
+	}
+	for _, f := range doc.Fields {
+		boost := f.Boost
+		if boost <= 0 {
+			boost = 1
+		}
+		for _, term := range tokenize(f.Text) {
+			stored.Weights[term] += boost
+		}
+	}
+
+	for term, weight := range stored.Weights {
+		w.batch.Put(postingKey(term, doc.ID), encodePosting(doc.TaskID, weight))
+		if err := w.changeTermCount(term, 1); err != nil {
+			return err
+		}
+	}
+
+	encoded, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	w.batch.Put(docKey(doc.ID), encoded)
+	w.batch.Put([]byte(taskPrefix(doc.TaskID)+doc.ID), nil)
+	w.docs[doc.ID] = stored
+	w.docCount++
+	return nil
+}
+
+func (w *write) commit() error {
+	for term, count := range w.termCount {
+		if count <= 0 {
+			w.batch.Delete(termKey(term))
+			continue
+		}
+		w.batch.Put(termKey(term), encodeUint(uint64(count)))
+	}
+	if w.docCount < 0 {
+		w.docCount = 0
+	}
+	w.batch.Put(docCountKey, encodeUint(uint64(w.docCount)))
+	return w.index.db.Write(w.batch, nil)
+}
+
+// Index adds documents to the index or replaces them if they were already indexed.
+func (i *Index) Index(docs ...*document.Document) (err error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	w, err := i.newWrite()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		err = w.add(doc)
+		if err != nil {
+			return err
+		}
+	}
+	return w.commit()
+}
+
+// Delete removes documents from the index.
+func (i *Index) Delete(docIDs ...string) (err error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	w, err := i.newWrite()
+	if err != nil {
+		return err
+	}
+	for _, docID := range docIDs {
+		err = w.remove(docID)
+		if err != nil {
+			return err
+		}
+	}
+	return w.commit()
+}
+
+// DeleteTask removes all documents belonging to a task from the index.
+func (i *Index) DeleteTask(taskID int64) (err error) {
+	prefix := taskPrefix(taskID)
+	docIDs := []string{}
+	iter := i.db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	for iter.Next() {
+		docIDs = append(docIDs, strings.TrimPrefix(string(iter.Key()), prefix))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	return i.Delete(docIDs...)
+}
+
+// Clear removes all documents from the index.
+func (i *Index) Clear() (err error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	iter := i.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+		if batch.Len() >= 1000 {
+			if err := i.db.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return i.db.Write(batch, nil)
+}
+
+// Close closes the index.
+func (i *Index) Close() error {
+	return i.db.Close()
+}
+
+// expand returns all indexed terms matching a word of a query, along with how well they match.
+func (i *Index) expand(word string) (terms map[string]float64, err error) {
+	terms = make(map[string]float64)
+	runes := []rune(word)
+	typos := maxTypos(runes)
+
+	// Typos in the first character are not tolerated, this keeps the number of terms to check small.
+	prefix := word
+	if typos > 0 {
+		prefix = string(runes[0])
+	}
+
+	iter := i.db.NewIterator(util.BytesPrefix(termKey(prefix)), nil)
+	defer iter.Release()
+
+	var expansions int
+	for iter.Next() {
+		term := strings.TrimPrefix(string(iter.Key()), "w"+sep)
+		switch {
+		case term == word:
+			terms[term] = exactMatchFactor
+		case expansions >= maxExpansions:
+			continue
+		case strings.HasPrefix(term, word):
+			terms[term] = prefixMatchFactor
+			expansions++
+		case typos > 0:
+			d := distance(runes, []rune(term), typos)
+			if d <= typos {
+				terms[term] = typoMatchFactor / float64(d)
+				expansions++
+			}
+		}
+	}
+
+	return terms, iter.Error()
+}
+
+// Search returns the ids of all tasks matching the query, ordered by relevance.
+// A task matches if every word of the query matches a term of the task itself, its comments or attachments
+// either exactly, as a prefix or with a few typos.
+func (i *Index) Search(query string, limit int) (taskIDs []int64, err error) {
+	words := tokenize(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	count, err := i.getUint(docCountKey)
+	if err != nil {
+		return nil, err
+	}
+	docCount := float64(count)
+
+	var scores map[int64]float64
+	for _, word := range words {
+		terms, err := i.expand(word)
+		if err != nil {
+			return nil, err
+		}
+
+		// The score of a task for a single word is the score of the best matching term
+		wordScores := make(map[int64]float64)
+		for term, factor := range terms {
+			termCount, err := i.getUint(termKey(term))
+			if err != nil {
+				return nil, err
+			}
+			df := float64(termCount)
+			idf := math.Log(1 + (docCount-df+0.5)/(df+0.5))
+
+			prefix := postingKey(term, "")
+			iter := i.db.NewIterator(util.BytesPrefix(prefix), nil)
+			for iter.Next() {
+				taskID, weight := decodePosting(iter.Value())
+				score := factor * idf * weight * (saturation + 1) / (weight + saturation)
+				if score > wordScores[taskID] {
+					wordScores[taskID] = score
+				}
+			}
+			iter.Release()
+			if err := iter.Error(); err != nil {
+				return nil, err
+			}
+		}
+
+		// Only tasks matching all words are results
+		if scores == nil {
+			scores = wordScores
+			continue
+		}
+		for taskID, score := range scores {
+			wordScore, has := wordScores[taskID]
+			if !has {
+				delete(scores, taskID)
+				continue
+			}
+			scores[taskID] = score + wordScore
+		}
+	}
+
+	taskIDs = make([]int64, 0, len(scores))
+	for taskID := range scores {
+		taskIDs = append(taskIDs, taskID)
+	}
+	sort.Slice(taskIDs, func(a, b int) bool {
+		if scores[taskIDs[a]] == scores[taskIDs[b]] {
+			return taskIDs[a] < taskIDs[b]
+		}
+		return scores[taskIDs[a]] > scores[taskIDs[b]]
+	})
+
+	if limit > 0 && len(taskIDs) > limit {
+		taskIDs = taskIDs[:limit]
+	}
+	return taskIDs, nil
+}
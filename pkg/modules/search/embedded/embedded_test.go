@@ -0,0 +1,200 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package embedded
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/modules/search/document"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	i, err := OpenInMemory()
+	assert.NoError(t, err)
+
+	err = i.Index(
+		&document.Document{ID: "task-1", TaskID: 1, Fields: []*document.Field{
+			{Text: "Buy groceries", Boost: 3},
+			{Text: "Milk, eggs and bread", Boost: 1},
+		}},
+		&document.Document{ID: "task-2", TaskID: 2, Fields: []*document.Field{
+			{Text: "Prepare the presentation", Boost: 3},
+			{Text: "Slides about groceries", Boost: 1},
+		}},
+		&document.Document{ID: "comment-1", TaskID: 2, Fields: []*document.Field{
+			{Text: "Don't forget the quarterly numbers", Boost: 1},
+		}},
+		&document.Document{ID: "attachment-1", TaskID: 3, Fields: []*document.Field{
+			{Text: "invoice-2022.pdf", Boost: 2},
+		}},
+	)
+	assert.NoError(t, err)
+	return i
+}
+
+func TestIndex_Search(t *testing.T) {
+	t.Run("exact", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("eggs", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("ranks title matches higher", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("groceries", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, ids)
+	})
+	t.Run("prefix", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("presen", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+	})
+	t.Run("typo", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("qaurterly", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+
+		ids, err = i.Search("grocreies", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, ids)
+	})
+	t.Run("no typos in short words", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("mlk", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+	t.Run("all words must match", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("groceries slides", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+
+		ids, err = i.Search("groceries invoice", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+	t.Run("matches comments and attachments", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("presentation numbers", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+
+		ids, err = i.Search("invoice", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("limit", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search("groceries", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("empty query", func(t *testing.T) {
+		i := newTestIndex(t)
+		ids, err := i.Search(" ,. ", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
+
+func TestIndex_Index(t *testing.T) {
+	t.Run("replaces documents", func(t *testing.T) {
+		i := newTestIndex(t)
+		err := i.Index(&document.Document{ID: "task-1", TaskID: 1, Fields: []*document.Field{
+			{Text: "Water the plants", Boost: 3},
+		}})
+		assert.NoError(t, err)
+
+		ids, err := i.Search("eggs", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+
+		ids, err = i.Search("plants", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+
+		count, err := i.getUint(docCountKey)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(4), count)
+	})
+}
+
+func TestIndex_Delete(t *testing.T) {
+	t.Run("document", func(t *testing.T) {
+		i := newTestIndex(t)
+		err := i.Delete("comment-1")
+		assert.NoError(t, err)
+
+		ids, err := i.Search("quarterly", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+
+		ids, err = i.Search("presentation", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+
+		count, err := i.getUint(termKey("quarterly"))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), count)
+	})
+	t.Run("nonexisting document", func(t *testing.T) {
+		i := newTestIndex(t)
+		err := i.Delete("task-9999")
+		assert.NoError(t, err)
+
+		count, err := i.getUint(docCountKey)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(4), count)
+	})
+	t.Run("task", func(t *testing.T) {
+		i := newTestIndex(t)
+		err := i.DeleteTask(2)
+		assert.NoError(t, err)
+
+		ids, err := i.Search("groceries", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+
+		ids, err = i.Search("quarterly", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+	t.Run("clear", func(t *testing.T) {
+		i := newTestIndex(t)
+		err := i.Clear()
+		assert.NoError(t, err)
+
+		ids, err := i.Search("groceries", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance([]rune("task"), []rune("task"), 2))
+	assert.Equal(t, 1, distance([]rune("task"), []rune("tsak"), 2))
+	assert.Equal(t, 1, distance([]rune("task"), []rune("tasks"), 2))
+	assert.Equal(t, 2, distance([]rune("kitten"), []rune("sittin"), 2))
+	assert.Equal(t, 3, distance([]rune("kitten"), []rune("sitting"), 2))
+	assert.Equal(t, 2, distance([]rune("a"), []rune("abcd"), 1))
+}
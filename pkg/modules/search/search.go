@@ -0,0 +1,118 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/modules/search/document"
+	"code.vikunja.io/api/pkg/modules/search/embedded"
+)
+
+// Index defines an interface for a full-text search index backend
+type Index interface {
+	// Index adds documents to the index or replaces them if they were already indexed.
+	Index(docs ...*document.Document) (err error)
+	// Delete removes documents from the index.
+	Delete(docIDs ...string) (err error)
+	// DeleteTask removes all documents belonging to a task from the index.
+	DeleteTask(taskID int64) (err error)
+	// Search returns the ids of all tasks matching the query, ordered by relevance.
+	Search(query string, limit int) (taskIDs []int64, err error)
+	// Clear removes all documents from the index.
+	Clear() (err error)
+	Close() (err error)
+}
+
+var index Index
+
+// InitIndex initializes the configured search index backend.
+// When searching directly in the database there is no index to initialize.
+func InitIndex() {
+	switch config.SearchType.GetString() {
+	case "database":
+		index = nil
+	case "embedded":
+		fallthrough
+	default:
+		i, err := embedded.Open(config.SearchPath.GetString())
+		if err != nil {
+			log.Fatalf("Could not open the search index at %s: %s", config.SearchPath.GetString(), err)
+		}
+		index = i
+	}
+}
+
+// SetIndex sets the index backend. Useful for tests.
+func SetIndex(i Index) {
+	index = i
+}
+
+// Enabled returns whether a search index is configured.
+// If it is not, searching should fall back to searching in the database.
+func Enabled() bool {
+	return index != nil
+}
+
+// Add adds documents to the search index or replaces them if they were already indexed.
+func Add(docs ...*document.Document) error {
+	if index == nil {
+		return nil
+	}
+
+	return index.Index(docs...)
+}
+
+// Delete removes documents from the search index.
+func Delete(docIDs ...string) error {
+	if index == nil {
+		return nil
+	}
+	return index.Delete(docIDs...)
+}
+
+// DeleteTask removes all documents of a task from the search index.
+func DeleteTask(taskID int64) error {
+	if index == nil {
+		return nil
+	}
+	return index.DeleteTask(taskID)
+}
+
+// Search returns the ids of all tasks matching the query, the most relevant first.
+func Search(query string, limit int) (taskIDs []int64, err error) {
+	if index == nil {
+		return nil, nil
+	}
+	return index.Search(query, limit)
+}
+
+// Clear removes everything from the search index.
+func Clear() error {
+	if index == nil {
+		return nil
+	}
+	return index.Clear()
+}
+
+// Close closes the search index.
+func Close() error {
+	if index == nil {
+		return nil
+	}
+	return index.Close()
+}
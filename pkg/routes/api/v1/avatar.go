@@ -29,6 +29,7 @@ import (
 	"code.vikunja.io/api/pkg/modules/avatar/marble"
 	"code.vikunja.io/api/pkg/modules/avatar/upload"
 	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/web/handler"
 
 	"bytes"
@@ -38,6 +39,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/gabriel-vasile/mimetype"
@@ -109,7 +111,21 @@ func GetAvatar(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	return c.Blob(http.StatusOK, mimeType, a)
+	// Uploaded avatars are validated with the file they are generated from, all others with their content
+	etag := `"` + utils.Sha256(string(a)) + `"`
+	if _, is := avatarProvider.(*upload.Provider); is {
+		f := &files.File{ID: u.AvatarFileID}
+		if err := f.LoadFileMetaByID(); err == nil {
+			etag = strings.TrimSuffix(f.ETag(), `"`) + "-" + strconv.FormatInt(sizeInt, 10) + `"`
+		}
+	}
+
+	files.SetCacheHeaders(c.Response(), etag)
+	if mimeType != "" {
+		c.Response().Header().Set("Content-Type", mimeType)
+	}
+	http.ServeContent(c.Response(), c.Request(), "", time.Time{}, bytes.NewReader(a))
+	return nil
 }
 
 // UploadAvatar uploads and sets a user avatar
@@ -52,6 +52,7 @@ type vikunjaInfos struct {
 	EmailRemindersEnabled      bool      `json:"email_reminders_enabled"`
 	UserDeletionEnabled        bool      `json:"user_deletion_enabled"`
 	TaskCommentsEnabled        bool      `json:"task_comments_enabled"`
+	TimeTrackingEnabled        bool      `json:"time_tracking_enabled"`
 }
 
 type authInfo struct {
@@ -95,6 +96,7 @@ func Info(c echo.Context) error {
 		EmailRemindersEnabled:  config.ServiceEnableEmailReminders.GetBool(),
 		UserDeletionEnabled:    config.ServiceEnableUserDeletion.GetBool(),
 		TaskCommentsEnabled:    config.ServiceEnableTaskComments.GetBool(),
+		TimeTrackingEnabled:    config.ServiceEnableTimeTracking.GetBool(),
 		AvailableMigrators: []string{
 			(&vikunja_file.FileMigrator{}).Name(),
 		},
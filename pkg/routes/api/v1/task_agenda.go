@@ -0,0 +1,76 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// GetTaskAgenda returns the undone tasks of the current user sorted into agenda sections
+// @Summary Get the task agenda
+// @Description Returns all undone tasks of the current user with a due date, start date or reminder, sorted into overdue, today, tomorrow, this week and later. The days are calculated in the time zone of the user and the week starts at the day configured in the user settings. Tasks show up at their due date, or at their start date or first reminder from today on if they don't have one. Future occurrences of repeating tasks are included as extra entries. The `today` view only returns overdue tasks and the ones of today, `upcoming` everything from today on and `overdue` only overdue tasks.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param view path string false "One of today, upcoming or overdue. If not provided, all sections are returned."
+// @Param days query int false "How many days from today on the agenda covers. Defaults to 30, at most 366."
+// @Success 200 {object} models.TaskAgenda "The agenda."
+// @Failure 400 {object} web.HTTPError "Invalid agenda view or number of days."
+// @Failure 403 {object} web.HTTPError "Link shares don't have an agenda."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/agenda/{view} [get]
+func GetTaskAgenda(c echo.Context) error {
+	opts := &models.TaskAgendaOptions{
+		View: c.Param("view"),
+	}
+
+	if days := c.QueryParam("days"); days != "" {
+		var err error
+		opts.Days, err = strconv.Atoi(days)
+		if err != nil || opts.Days < 1 || opts.Days > models.TaskAgendaMaxDays {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid number of days.")
+		}
+	}
+
+	auth, err := auth2.GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	agenda, err := models.GetTaskAgenda(s, auth, opts)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, agenda)
+}
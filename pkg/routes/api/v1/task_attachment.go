@@ -20,6 +20,7 @@ import (
 	"net/http"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
 
 	"code.vikunja.io/api/pkg/models"
 	auth2 "code.vikunja.io/api/pkg/modules/auth"
@@ -115,10 +116,12 @@ func UploadTaskAttachment(c echo.Context) error {
 // @Produce octet-stream
 // @Param id path int true "Task ID"
 // @Param attachmentID path int true "Attachment ID"
+// @Param size query string false "If set, returns the preview of an image attachment in this size instead of the original file. One of sm, md or lg."
 // @Security JWTKeyAuth
 // @Success 200 {} string "The attachment file."
+// @Failure 400 {object} models.Message "The preview size does not exist."
 // @Failure 403 {object} models.Message "No access to this task."
-// @Failure 404 {object} models.Message "The task does not exist."
+// @Failure 404 {object} models.Message "The task does not exist or the attachment has no preview in this size."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{id}/attachments/{attachmentID} [get]
 func GetTaskAttachment(c echo.Context) error {
@@ -153,8 +156,17 @@ func GetTaskAttachment(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	file := taskAttachment.File
+	if size := c.QueryParam("size"); size != "" {
+		file, err = taskAttachment.GetPreview(s, size)
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+	}
+
 	// Open an send the file to the client
-	err = taskAttachment.File.LoadFileByID()
+	err = file.LoadFileByID()
 	if err != nil {
 		_ = s.Rollback()
 		return handler.HandleHTTPError(err, c)
@@ -165,6 +177,7 @@ func GetTaskAttachment(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	http.ServeContent(c.Response(), c.Request(), taskAttachment.File.Name, taskAttachment.File.Created, taskAttachment.File.File)
+	defer file.File.Close()
+	file.ServeContent(c.Response(), c.Request(), files.DispositionInline)
 	return nil
 }
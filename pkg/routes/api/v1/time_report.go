@@ -0,0 +1,140 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// parseTimeReportDate accepts either a full RFC3339 timestamp or a plain date.
+// Plain dates used as the end of a range include the whole day.
+func parseTimeReportDate(value string, endOfRange bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", value, config.GetTimeZone())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func parseTimeReportID(c echo.Context, param string) (int64, error) {
+	value := c.QueryParam(param)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// GetTimeReport returns the time logged on all tasks the user has access to
+// @Summary Get a time report
+// @Description Aggregates the time logged on all tasks the current user has access to. Running timers count with the time elapsed until now.
+// @tags time tracking
+// @Produce json
+// @Produce text/csv
+// @Security JWTKeyAuth
+// @Param group_by query string false "Group the logged time by user, list, label or date. Defaults to user."
+// @Param from query string false "Only include entries which started on or after this date. Either an RFC3339 timestamp or a date like 2022-10-01."
+// @Param to query string false "Only include entries which started before this date. Either an RFC3339 timestamp or a date like 2022-10-31, which includes the whole day."
+// @Param user_id query int false "Only include entries logged by this user."
+// @Param list_id query int false "Only include entries on tasks in this list."
+// @Param label_id query int false "Only include entries on tasks with this label."
+// @Param format query string false "Set to csv to get the report as a csv file."
+// @Success 200 {object} models.TimeReport "The time report."
+// @Failure 400 {object} web.HTTPError "Invalid report options."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /time_entries/report [get]
+func GetTimeReport(c echo.Context) error {
+	opts := &models.TimeReportOptions{
+		GroupBy: c.QueryParam("group_by"),
+	}
+
+	var err error
+	opts.From, err = parseTimeReportDate(c.QueryParam("from"), false)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid from date.")
+	}
+	opts.To, err = parseTimeReportDate(c.QueryParam("to"), true)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid to date.")
+	}
+	opts.UserID, err = parseTimeReportID(c, "user_id")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user id.")
+	}
+	opts.ListID, err = parseTimeReportID(c, "list_id")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid list id.")
+	}
+	opts.LabelID, err = parseTimeReportID(c, "label_id")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid label id.")
+	}
+
+	auth, err := auth2.GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	report, err := models.GetTimeReport(s, auth, opts)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if c.QueryParam("format") != "csv" {
+		return c.JSON(http.StatusOK, report)
+	}
+
+	buf := &bytes.Buffer{}
+	err = report.WriteCSV(buf)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="time-report.csv"`)
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -136,6 +136,7 @@ func DownloadUserDataExport(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	http.ServeContent(c.Response(), c.Request(), exportFile.Name, exportFile.Created, exportFile.File)
+	defer exportFile.File.Close()
+	exportFile.ServeContent(c.Response(), c.Request(), files.DispositionAttachment)
 	return nil
 }
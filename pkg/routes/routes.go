@@ -381,6 +381,17 @@ func registerAPIRoutes(a *echo.Group) {
 	a.POST("/lists/:list/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
 	a.DELETE("/lists/:list/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
 
+	listCustomFieldHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListCustomField{}
+		},
+	}
+	a.GET("/lists/:list/custom_fields", listCustomFieldHandler.ReadAllWeb)
+	a.PUT("/lists/:list/custom_fields", listCustomFieldHandler.CreateWeb)
+	a.GET("/lists/:list/custom_fields/:customfield", listCustomFieldHandler.ReadOneWeb)
+	a.POST("/lists/:list/custom_fields/:customfield", listCustomFieldHandler.UpdateWeb)
+	a.DELETE("/lists/:list/custom_fields/:customfield", listCustomFieldHandler.DeleteWeb)
+
 	listDuplicateHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.ListDuplicate{}
@@ -396,6 +407,8 @@ func registerAPIRoutes(a *echo.Group) {
 	a.PUT("/lists/:list", taskHandler.CreateWeb)
 	a.GET("/tasks/:listtask", taskHandler.ReadOneWeb)
 	a.GET("/tasks/all", taskCollectionHandler.ReadAllWeb)
+	a.GET("/tasks/agenda", apiv1.GetTaskAgenda)
+	a.GET("/tasks/agenda/:view", apiv1.GetTaskAgenda)
 	a.DELETE("/tasks/:listtask", taskHandler.DeleteWeb)
 	a.POST("/tasks/:listtask", taskHandler.UpdateWeb)
 
@@ -406,6 +419,22 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.POST("/tasks/bulk", bulkTaskHandler.UpdateWeb)
 
+	taskMoveHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskMove{}
+		},
+	}
+	a.POST("/tasks/:listtask/move", taskMoveHandler.UpdateWeb)
+	a.POST("/tasks/bulk/move", taskMoveHandler.UpdateWeb)
+
+	taskByIdentifierHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskByIdentifier{}
+		},
+	}
+	a.GET("/tasks/by-identifier/:identifier", taskByIdentifierHandler.ReadOneWeb)
+	a.GET("/lists/:list/tasks/by-index/:index", taskByIdentifierHandler.ReadOneWeb)
+
 	assigneeTaskHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.TaskAssginee{}
@@ -469,6 +498,89 @@ func registerAPIRoutes(a *echo.Group) {
 		a.DELETE("/tasks/:task/comments/:commentid", taskCommentHandler.DeleteWeb)
 		a.POST("/tasks/:task/comments/:commentid", taskCommentHandler.UpdateWeb)
 		a.GET("/tasks/:task/comments/:commentid", taskCommentHandler.ReadOneWeb)
+
+		taskCommentEditHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TaskCommentEdit{}
+			},
+		}
+		a.GET("/tasks/:task/comments/:commentid/history", taskCommentEditHandler.ReadAllWeb)
+
+		commentReactionHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.Reaction{}
+			},
+		}
+		a.GET("/tasks/:task/comments/:commentid/reactions", commentReactionHandler.ReadAllWeb)
+		a.PUT("/tasks/:task/comments/:commentid/reactions", commentReactionHandler.CreateWeb)
+		a.POST("/tasks/:task/comments/:commentid/reactions/delete", commentReactionHandler.DeleteWeb)
+	}
+
+	taskReactionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Reaction{}
+		},
+	}
+	a.GET("/tasks/:task/reactions", taskReactionHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/reactions", taskReactionHandler.CreateWeb)
+	a.POST("/tasks/:task/reactions/delete", taskReactionHandler.DeleteWeb)
+
+	taskHistoryHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskHistoryEntry{}
+		},
+	}
+	a.GET("/tasks/:task/history", taskHistoryHandler.ReadAllWeb)
+
+	taskChecklistHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskChecklistItem{}
+		},
+	}
+	a.GET("/tasks/:task/checklist", taskChecklistHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/checklist", taskChecklistHandler.CreateWeb)
+	a.GET("/tasks/:task/checklist/:checklistitem", taskChecklistHandler.ReadOneWeb)
+	a.POST("/tasks/:task/checklist/:checklistitem", taskChecklistHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/checklist/:checklistitem", taskChecklistHandler.DeleteWeb)
+
+	taskChecklistOrderHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskChecklistOrder{}
+		},
+	}
+	a.POST("/tasks/:task/checklist/order", taskChecklistOrderHandler.UpdateWeb)
+
+	trashHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TrashItem{}
+		},
+	}
+	a.GET("/trash", trashHandler.ReadAllWeb)
+	a.POST("/trash/:kind/:id", trashHandler.UpdateWeb)
+	a.DELETE("/trash/:kind/:id", trashHandler.DeleteWeb)
+
+	if config.ServiceEnableTimeTracking.GetBool() {
+		taskTimeEntryHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TaskTimeEntry{}
+			},
+		}
+		a.GET("/tasks/:task/time_entries", taskTimeEntryHandler.ReadAllWeb)
+		a.PUT("/tasks/:task/time_entries", taskTimeEntryHandler.CreateWeb)
+		a.GET("/tasks/:task/time_entries/:timeentry", taskTimeEntryHandler.ReadOneWeb)
+		a.POST("/tasks/:task/time_entries/:timeentry", taskTimeEntryHandler.UpdateWeb)
+		a.DELETE("/tasks/:task/time_entries/:timeentry", taskTimeEntryHandler.DeleteWeb)
+
+		taskTimerHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TaskTimer{}
+			},
+		}
+		a.GET("/tasks/:task/timer", taskTimerHandler.ReadOneWeb)
+		a.PUT("/tasks/:task/timer", taskTimerHandler.CreateWeb)
+		a.POST("/tasks/:task/timer", taskTimerHandler.UpdateWeb)
+
+		a.GET("/time_entries/report", apiv1.GetTimeReport)
 	}
 
 	labelHandler := &handler.WebHandler{
@@ -502,6 +614,62 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/lists/:list/users/:user", listUserHandler.DeleteWeb)
 	a.POST("/lists/:list/users/:user", listUserHandler.UpdateWeb)
 
+	taskTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTemplate{}
+		},
+	}
+	a.GET("/tasktemplates", taskTemplateHandler.ReadAllWeb)
+	a.PUT("/tasktemplates", taskTemplateHandler.CreateWeb)
+	a.GET("/tasktemplates/:tasktemplate", taskTemplateHandler.ReadOneWeb)
+	a.POST("/tasktemplates/:tasktemplate", taskTemplateHandler.UpdateWeb)
+	a.DELETE("/tasktemplates/:tasktemplate", taskTemplateHandler.DeleteWeb)
+
+	taskFromTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskFromTemplate{}
+		},
+	}
+	a.PUT("/tasktemplates/:tasktemplate/tasks", taskFromTemplateHandler.CreateWeb)
+
+	taskTemplateTeamHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTemplateTeam{}
+		},
+	}
+	a.GET("/tasktemplates/:tasktemplate/teams", taskTemplateTeamHandler.ReadAllWeb)
+	a.PUT("/tasktemplates/:tasktemplate/teams", taskTemplateTeamHandler.CreateWeb)
+	a.DELETE("/tasktemplates/:tasktemplate/teams/:team", taskTemplateTeamHandler.DeleteWeb)
+	a.POST("/tasktemplates/:tasktemplate/teams/:team", taskTemplateTeamHandler.UpdateWeb)
+
+	taskTemplateUserHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTemplateUser{}
+		},
+	}
+	a.GET("/tasktemplates/:tasktemplate/users", taskTemplateUserHandler.ReadAllWeb)
+	a.PUT("/tasktemplates/:tasktemplate/users", taskTemplateUserHandler.CreateWeb)
+	a.DELETE("/tasktemplates/:tasktemplate/users/:user", taskTemplateUserHandler.DeleteWeb)
+	a.POST("/tasktemplates/:tasktemplate/users/:user", taskTemplateUserHandler.UpdateWeb)
+
+	listBlueprintHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListBlueprint{}
+		},
+	}
+	a.GET("/listblueprints", listBlueprintHandler.ReadAllWeb)
+	a.PUT("/listblueprints", listBlueprintHandler.CreateWeb)
+	a.GET("/listblueprints/:listblueprint", listBlueprintHandler.ReadOneWeb)
+	a.POST("/listblueprints/:listblueprint", listBlueprintHandler.UpdateWeb)
+	a.DELETE("/listblueprints/:listblueprint", listBlueprintHandler.DeleteWeb)
+
+	listFromBlueprintHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListFromBlueprint{}
+		},
+	}
+	a.PUT("/listblueprints/:listblueprint/lists", listFromBlueprintHandler.CreateWeb)
+
 	savedFiltersHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.SavedFilter{}
@@ -512,6 +680,33 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/filters/:filter", savedFiltersHandler.DeleteWeb)
 	a.POST("/filters/:filter", savedFiltersHandler.UpdateWeb)
 
+	savedFilterTeamHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.SavedFilterTeam{}
+		},
+	}
+	a.GET("/filters/:filter/teams", savedFilterTeamHandler.ReadAllWeb)
+	a.PUT("/filters/:filter/teams", savedFilterTeamHandler.CreateWeb)
+	a.DELETE("/filters/:filter/teams/:team", savedFilterTeamHandler.DeleteWeb)
+	a.POST("/filters/:filter/teams/:team", savedFilterTeamHandler.UpdateWeb)
+
+	savedFilterUserHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.SavedFilterUser{}
+		},
+	}
+	a.GET("/filters/:filter/users", savedFilterUserHandler.ReadAllWeb)
+	a.PUT("/filters/:filter/users", savedFilterUserHandler.CreateWeb)
+	a.DELETE("/filters/:filter/users/:user", savedFilterUserHandler.DeleteWeb)
+	a.POST("/filters/:filter/users/:user", savedFilterUserHandler.UpdateWeb)
+
+	savedFilterTaskBucketHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.SavedFilterTaskBucket{}
+		},
+	}
+	a.POST("/filters/:filter/buckets/:bucket/tasks", savedFilterTaskBucketHandler.UpdateWeb)
+
 	namespaceHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Namespace{}